@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"argus-collector/internal/console"
+	"argus-collector/internal/processor"
+
+	"github.com/spf13/cobra"
+)
+
+var benchRuns int // Times the dataset is processed
+
+// benchCmd times the processing of a fixed dataset
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Time the load, correlation and solve of a fixed dataset",
+	Long: `Bench processes the same input files several times and reports how long
+each processing step took and the sample throughput, giving repeatable numbers
+for tuning correlation parameters and catching performance regressions.
+
+No output files are written. Processing progress is hidden unless --verbose
+is given.
+
+Example usage:
+  argus-processor bench --input "data/argus-?_1754061697.dat"
+  argus-processor bench --input data/ --runs 5 --parallel 4 --corr-duration 0.5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runBench(); err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().StringVarP(&inputPattern, "input", "i", "", "input file pattern (e.g., 'argus-?_*.dat'), directory to search recursively, or - to read file paths from stdin")
+	benchCmd.Flags().IntVar(&benchRuns, "runs", 3, "number of times to process the dataset")
+	benchCmd.Flags().Float64VarP(&confidence, "confidence", "c", 0.5, "minimum confidence threshold (0.0-1.0)")
+	benchCmd.Flags().IntVar(&parallelWorkers, "parallel", 0, "number of parallel workers (0 = auto-detect based on CPU cores)")
+	benchCmd.Flags().StringVar(&reference, "reference", "", "reference receiver ID (e.g. R2) or 1-based file index (default: highest SNR)")
+	benchCmd.Flags().Float64Var(&corrStart, "corr-start", 0, "correlate only from this many seconds into each capture")
+	benchCmd.Flags().Float64Var(&corrDuration, "corr-duration", 0, "correlate only this many seconds from --corr-start (0 = to the end of the capture)")
+	benchCmd.Flags().BoolVar(&envelope, "envelope", false, "correlate sample magnitudes instead of complex samples")
+	benchCmd.Flags().BoolVar(&freqCorrect, "freq-correct", false, "estimate each receiver pair's carrier frequency offset and remove it before correlating")
+	benchCmd.Flags().BoolVar(&lowMemory, "low-memory", false, "load only the samples correlation uses instead of whole captures")
+	benchCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show processing progress")
+	benchCmd.MarkFlagRequired("input")
+}
+
+// benchRun holds the timings of one pass over the dataset
+type benchRun struct {
+	steps   []processor.StepTiming
+	total   time.Duration
+	samples int // Samples loaded across all receivers
+}
+
+// runBench processes the input files benchRuns times and reports the time
+// each step took
+func runBench() error {
+	if benchRuns < 1 {
+		return fmt.Errorf("invalid --runs %d: must be at least 1", benchRuns)
+	}
+
+	files, err := findMatchingFiles(inputPattern)
+	if err != nil {
+		return fmt.Errorf("failed to find input files: %w", err)
+	}
+	if len(files) < 3 {
+		return fmt.Errorf("benchmark requires at least 3 input files, found %d matching '%s'", len(files), inputPattern)
+	}
+
+	// The processor's progress is hidden unless verbose
+	progress := out
+	if !verbose {
+		progress = io.Discard
+	}
+	proc, err := processor.NewProcessor(&processor.Config{
+		Output:          progress,
+		Algorithm:       "basic",
+		Confidence:      confidence,
+		MaxDistance:     maxDistance,
+		ParallelWorkers: parallelWorkers,
+		Reference:       reference,
+		CorrStart:       time.Duration(corrStart * float64(time.Second)),
+		CorrDuration:    time.Duration(corrDuration * float64(time.Second)),
+		Envelope:        envelope,
+		FreqCorrection:  freqCorrect,
+		LowMemory:       lowMemory,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize processor: %w", err)
+	}
+
+	fmt.Fprintf(out, "⏱️  Benchmarking %d files, %d run(s):\n", len(files), benchRuns)
+	for i, file := range files {
+		fmt.Fprintf(out, "   %d. %s\n", i+1, filepath.Base(file))
+	}
+	fmt.Fprintln(out)
+
+	runs := make([]benchRun, 0, benchRuns)
+	for i := 1; i <= benchRuns; i++ {
+		run, err := benchOnce(proc, files)
+		if err != nil {
+			return fmt.Errorf("run %d failed: %w", i, err)
+		}
+		fmt.Fprintf(out, "Run %d: %v total, %s\n", i, run.total.Round(time.Microsecond), formatThroughput(run.samples, run.total))
+		for _, step := range run.steps {
+			fmt.Fprintf(out, "   %-40s %10v  %s\n", step.Name, step.Duration.Round(time.Microsecond), stepThroughput(step.Name, run.samples, step.Duration))
+		}
+		runs = append(runs, run)
+	}
+
+	displayBenchSummary(runs)
+	return nil
+}
+
+// benchOnce processes files once
+func benchOnce(proc *processor.Processor, files []string) (benchRun, error) {
+	start := time.Now()
+	result, err := proc.ProcessFiles(files)
+	if err != nil {
+		return benchRun{}, err
+	}
+	run := benchRun{steps: result.StepTimings, total: time.Since(start)}
+	for _, r := range result.ReceiverLocations {
+		run.samples += len(r.Samples)
+	}
+	return run, nil
+}
+
+// displayBenchSummary prints the fastest and mean time of each step across
+// runs. The fastest is the most repeatable figure, the least disturbed by
+// other load and a cold disk cache.
+func displayBenchSummary(runs []benchRun) {
+	fmt.Fprintf(out, "\n📊 Benchmark Summary (%d run(s)):\n", len(runs))
+	fmt.Fprintf(out, "   %-40s %10s %10s  %s\n", "Step", "Fastest", "Mean", "Throughput (fastest)")
+	for i, step := range runs[0].steps {
+		fastest, sum := step.Duration, time.Duration(0)
+		for _, run := range runs {
+			if i >= len(run.steps) {
+				continue
+			}
+			d := run.steps[i].Duration
+			sum += d
+			if d < fastest {
+				fastest = d
+			}
+		}
+		mean := sum / time.Duration(len(runs))
+		fmt.Fprintf(out, "   %-40s %10v %10v  %s\n", step.Name, fastest.Round(time.Microsecond), mean.Round(time.Microsecond),
+			stepThroughput(step.Name, runs[0].samples, fastest))
+	}
+
+	fastest, sum := runs[0].total, time.Duration(0)
+	for _, run := range runs {
+		sum += run.total
+		if run.total < fastest {
+			fastest = run.total
+		}
+	}
+	fmt.Fprintf(out, "   %-40s %10v %10v  %s\n", "Total", fastest.Round(time.Microsecond), (sum / time.Duration(len(runs))).Round(time.Microsecond),
+		formatThroughput(runs[0].samples, fastest))
+	fmt.Fprintf(out, "   Samples per run: %d\n", runs[0].samples)
+}
+
+// stepThroughput formats the sample throughput of a step that handles every
+// loaded sample; the solve works on the measurements alone, so has none
+func stepThroughput(name string, samples int, d time.Duration) string {
+	if name != processor.StepLoad && name != processor.StepCorrelate {
+		return ""
+	}
+	return formatThroughput(samples, d)
+}
+
+// formatThroughput formats the rate samples were handled at over d
+func formatThroughput(samples int, d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	rate := float64(samples) / d.Seconds()
+	if rate >= 1e6 {
+		return fmt.Sprintf("%.2f Msamples/s", rate/1e6)
+	}
+	return fmt.Sprintf("%.0f samples/s", rate)
+}
@@ -4,11 +4,19 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"argus-collector/internal/console"
 	"argus-collector/internal/processor"
 	"argus-collector/internal/version"
 
@@ -16,17 +24,49 @@ import (
 )
 
 var (
-	inputPattern    string   // File pattern for input files (e.g., "argus-?_*.dat")
-	outputFormat    string   // Output format: geojson, kml, csv
-	outputDir       string   // Output directory
-	algorithm       string   // TDOA algorithm: basic, weighted, kalman
-	confidence      float64  // Minimum confidence threshold
-	maxDistance     float64  // Maximum expected transmitter distance (km)
-	frequencyRange  []string // Frequency range to analyze
-	parallelWorkers int      // Number of parallel workers (0 = auto-detect)
-	verbose         bool     // Enable verbose logging
-	showVersion     bool     // Show version information
-	dryRun          bool     // Show what would be processed without doing it
+	inputPattern     string        // File pattern for input files (e.g., "argus-?_*.dat")
+	outputFormat     string        // Output format: geojson, kml, csv
+	outputDir        string        // Output directory
+	algorithm        string        // TDOA algorithm: basic, weighted, kalman
+	confidence       float64       // Minimum confidence threshold
+	maxDistance      float64       // Maximum expected transmitter distance (km)
+	frequencyRange   []string      // Frequency range to analyze
+	parallelWorkers  int           // Number of parallel workers (0 = auto-detect)
+	reference        string        // Reference receiver ID or index (empty = highest SNR)
+	propagationSpeed float64       // Signal propagation speed in m/s
+	calibrationFile  string        // Per-receiver calibration delay file
+	manifestFile     string        // Receiver manifest assigning IDs, positions and delays to files
+	importCSV        string        // CSV of receiver positions and time differences to solve directly
+	corrStart        float64       // Start of the correlated segment in seconds
+	corrDuration     float64       // Length of the correlated segment in seconds (0 = to the end)
+	orderByTime      bool          // Group files into sessions by filename timestamp
+	sessionTolerance time.Duration // Maximum timestamp spread within one session
+	syncCheck        bool          // Run the two-station time alignment self-check
+	syncTolerance    time.Duration // Maximum acceptable residual offset for the self-check
+	verbose          bool          // Enable verbose logging
+	showVersion      bool          // Show version information
+	dryRun           bool          // Show what would be processed without doing it
+	summaryJSON      bool          // Write a JSON result summary to stdout
+	showResiduals    bool          // Print per-measurement residuals after solving
+	hyperbolas       bool          // Add each pair's hyperbola to GeoJSON output
+	errorEllipse     bool          // Map a confidence ellipse instead of the error circle
+	heatmapGrid      int           // Heatmap points along each side of the grid
+	heatmapExtent    float64       // Heatmap grid width in meters (0 = heatmapRadii times the error radius)
+	heatmapRadii     float64       // Heatmap grid width as a multiple of the error radius
+	serveAddr        string        // Address to serve the web viewer on after processing
+	quiet            bool          // Plain output without banners or emoji
+	envelope         bool          // Correlate sample magnitudes instead of complex samples
+	freqCorrect      bool          // Estimate and remove each pair's carrier frequency offset
+	lowMemory        bool          // Load only the correlation window of each capture
+	minPeakToNoise   float64       // Skip captures peaking less than this many dB above their noise floor
+	swapIQ           []string      // Receiver IDs or station names whose I and Q are swapped
+
+	// out receives progress and results for people, errOut errors, and
+	// summaryOut the JSON summaries; with --summary-json out is stderr so
+	// stdout stays machine readable. All are set once the output is set up.
+	out        io.Writer
+	errOut     io.Writer
+	summaryOut io.Writer
 )
 
 // rootCmd represents the base command
@@ -48,27 +88,36 @@ Supported output formats:
 Example usage:
   argus-processor --input "data/argus-?_1754061697.dat"
   argus-processor --input "/path/to/station*.dat" --algorithm weighted --confidence 0.8 --output-format geojson
+  argus-processor --input data/ --dry-run
   argus-processor --input "*.dat" --dry-run --verbose`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Handle version flag
 		if showVersion {
-			fmt.Println(version.GetVersionInfo("Argus Processor"))
+			fmt.Fprintln(out, version.GetVersionInfo("Argus Processor"))
 			return
 		}
 
 		if err := runProcessor(cmd); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
+		}
+		if serveListener != nil {
+			if err := serveViewer(); err != nil {
+				fmt.Fprintf(errOut, "Error: %v\n", err)
+				console.Exit(1)
+			}
 		}
 	},
 }
 
 func init() {
+	cobra.OnInitialize(initOutput)
+
 	// Version flag
 	rootCmd.Flags().BoolVar(&showVersion, "version", false, "show version information")
 
 	// Input/Output flags
-	rootCmd.Flags().StringVarP(&inputPattern, "input", "i", "", "input file pattern (e.g., 'argus-?_*.dat')")
+	rootCmd.Flags().StringVarP(&inputPattern, "input", "i", "", "input file pattern (e.g., 'argus-?_*.dat'), directory to search recursively, or - to read file paths from stdin")
 	rootCmd.Flags().StringVarP(&outputFormat, "output-format", "f", "kml", "output format (geojson, kml, csv)")
 	rootCmd.Flags().StringVarP(&outputDir, "output", "o", "./tdoa-results", "output directory")
 
@@ -78,34 +127,105 @@ func init() {
 	rootCmd.Flags().Float64VarP(&maxDistance, "max-distance", "d", 50.0, "maximum expected transmitter distance (km)")
 	rootCmd.Flags().StringSliceVar(&frequencyRange, "frequency-range", []string{}, "frequency range to analyze (e.g., '433.9-434.0')")
 	rootCmd.Flags().IntVar(&parallelWorkers, "parallel", 0, "number of parallel workers (0 = auto-detect based on CPU cores)")
+	rootCmd.Flags().BoolVar(&orderByTime, "order-by-time", false, "group files into collection sessions by filename timestamp and process each separately")
+	rootCmd.Flags().DurationVar(&sessionTolerance, "session-tolerance", 10*time.Second, "maximum timestamp difference between files of the same session")
+	rootCmd.Flags().BoolVar(&syncCheck, "sync-check", false, "correlate two captures of a common reference signal and report their residual timing offset")
+	rootCmd.Flags().DurationVar(&syncTolerance, "sync-tolerance", time.Millisecond, "maximum acceptable timing offset for --sync-check")
+	rootCmd.Flags().StringVar(&reference, "reference", "", "reference receiver ID (e.g. R2) or 1-based file index (default: highest SNR)")
+	rootCmd.Flags().StringVar(&calibrationFile, "calibration", "", "file of per-station calibration delays in ns (\"<station> <delay_ns>\" per line)")
+	rootCmd.Flags().StringVar(&manifestFile, "receivers-from-file", "", "CSV manifest assigning each data file or station a receiver ID and optional position and calibration delay")
+	rootCmd.Flags().StringVar(&importCSV, "import-csv", "", "solve from receiver positions and time differences in this CSV file instead of data files")
+	rootCmd.Flags().Float64Var(&corrStart, "corr-start", 0, "correlate only from this many seconds into each capture (e.g. where a known burst begins)")
+	rootCmd.Flags().Float64Var(&corrDuration, "corr-duration", 0, "correlate only this many seconds from --corr-start (0 = to the end of the capture)")
+	rootCmd.Flags().BoolVar(&envelope, "envelope", false, "correlate sample magnitudes instead of complex samples; tolerates frequency offsets between receivers at some cost in timing resolution")
+	rootCmd.Flags().BoolVar(&freqCorrect, "freq-correct", false, "estimate each receiver pair's carrier frequency offset and remove it before correlating")
+	rootCmd.Flags().BoolVar(&lowMemory, "low-memory", false, "load only the samples correlation uses (the first 50000, or the --corr-start/--corr-duration segment) instead of whole captures; SNR is estimated from the same samples")
+	rootCmd.Flags().Float64Var(&minPeakToNoise, "min-peak-to-noise", 0, "skip receivers whose capture peaks less than this many dB above its noise floor, as recorded by the collector (e.g. 6; 0 = keep all)")
+	rootCmd.Flags().StringSliceVar(&swapIQ, "swap-iq", nil, "exchange I and Q of these receivers' samples on read, by receiver ID (e.g. R2) or station name, or \"all\"; corrects captures from hardware that delivered Q first")
+	rootCmd.Flags().Float64Var(&propagationSpeed, "propagation-speed", processor.SpeedOfLight, "signal propagation speed in m/s used to convert delays to distances")
+	rootCmd.Flags().IntVar(&heatmapGrid, "heatmap-grid", processor.DefaultHeatmapGridSize, "heatmap points along each side of the square grid")
+	rootCmd.Flags().Float64Var(&heatmapExtent, "heatmap-extent", 0, "heatmap grid width in meters (default: --heatmap-extent-radii times the error radius)")
+	rootCmd.Flags().Float64Var(&heatmapRadii, "heatmap-extent-radii", processor.DefaultHeatmapRadii, "heatmap grid width as a multiple of the error radius")
 
 	// Control flags
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose logging")
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would be processed without doing it")
-
-	// Mark required flags, but version should be handled first
-	rootCmd.MarkFlagRequired("input")
+	rootCmd.Flags().BoolVar(&showResiduals, "residuals", false, "print each measurement's residual against the solved location")
+	rootCmd.Flags().BoolVar(&hyperbolas, "hyperbolas", false, "add each receiver pair's hyperbola, sampled out to --max-distance, to GeoJSON output")
+	rootCmd.Flags().StringVar(&serveAddr, "serve", "", "after processing, serve a web page mapping the results on this address (e.g. :8090) until interrupted")
+	rootCmd.Flags().BoolVar(&errorEllipse, "error-ellipse", false, "compute a 95% confidence ellipse from the station geometry and residuals, and map it instead of the error circle")
+	rootCmd.Flags().BoolVar(&quiet, "quiet", false, "plain output without banners, emoji or symbols, for logs and scripts")
+	rootCmd.Flags().BoolVar(&summaryJSON, "summary-json", false, "write a JSON result summary to stdout (other output goes to stderr)")
 
 	// Handle version flag early
 	rootCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		if showVersion {
-			fmt.Println(version.GetVersionInfo("Argus Processor"))
-			os.Exit(0)
+			fmt.Fprintln(out, version.GetVersionInfo("Argus Processor"))
+			console.Exit(0)
 		}
 		return nil
 	}
 }
 
+// initOutput strips decoration from the output when --quiet is given, and
+// sends everything but the JSON summaries to stderr with --summary-json
+func initOutput() {
+	out, errOut = os.Stdout, os.Stderr
+	if quiet {
+		out, errOut = console.NewWriter(os.Stdout), console.NewWriter(os.Stderr)
+	}
+
+	// The summaries are data, passed on unfiltered
+	summaryOut = os.Stdout
+	if summaryJSON {
+		out = errOut
+	}
+}
+
 // runProcessor is the main application logic
 func runProcessor(cmd *cobra.Command) error {
+	if hyperbolas && outputFormat != "geojson" {
+		fmt.Fprintf(out, "⚠️  --hyperbolas only applies to GeoJSON output (--output-format geojson)\n")
+	}
+	if err := validateHeatmapFlags(cmd); err != nil {
+		return err
+	}
+	if serveAddr != "" && !dryRun {
+		if err := listenForViewer(serveAddr); err != nil {
+			return err
+		}
+	}
+
+	if importCSV != "" {
+		return runImport(importCSV)
+	}
+	if inputPattern == "" {
+		return fmt.Errorf("required flag \"input\" not set (or use --import-csv to solve from measurements)")
+	}
+
 	// Find matching files first to validate before printing header
 	files, err := findMatchingFiles(inputPattern)
 	if err != nil {
 		return fmt.Errorf("failed to find input files: %w", err)
 	}
 
+	if len(files) == 0 && inputPattern == stdinInput {
+		return fmt.Errorf("no file paths read from stdin (--input -): pipe in one path per line, e.g. find data -name '*.dat' | argus-processor --input -")
+	}
 	if len(files) == 0 {
-		return fmt.Errorf("no files found matching pattern '%s'. Make sure:\n  - Pattern includes correct path (e.g., 'data/argus-*.dat')\n  - Files exist and have .dat extension\n  - Pattern is quoted to prevent shell expansion", inputPattern)
+		return fmt.Errorf("no files found matching pattern '%s'. Make sure:\n  - Pattern includes correct path (e.g., 'data/argus-*.dat') or names a data directory\n  - Files exist and have .dat extension\n  - Pattern is quoted to prevent shell expansion", inputPattern)
+	}
+
+	var manifest []processor.ManifestEntry
+	if manifestFile != "" {
+		manifest, err = processor.LoadManifest(manifestFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if syncCheck {
+		return runSyncCheck(files, manifest)
 	}
 
 	if len(files) < 3 {
@@ -113,42 +233,120 @@ func runProcessor(cmd *cobra.Command) error {
 	}
 
 	// Display simple header
-	fmt.Printf("ARGUS TDOA PROCESSOR %s\n\n", version.GetFullVersion())
+	fmt.Fprintf(out, "ARGUS TDOA PROCESSOR %s\n\n", version.GetFullVersion())
 
 	if verbose {
-		fmt.Printf("🔧 Configuration:\n")
-		fmt.Printf("   Input Pattern: %s\n", inputPattern)
-		fmt.Printf("   Output Format: %s\n", outputFormat)
-		fmt.Printf("   Output Directory: %s\n", outputDir)
-		fmt.Printf("   Algorithm: %s\n", algorithm)
-		fmt.Printf("   Confidence Threshold: %.2f\n", confidence)
-		fmt.Printf("   Max Distance: %.1f km\n", maxDistance)
+		fmt.Fprintf(out, "🔧 Configuration:\n")
+		fmt.Fprintf(out, "   Input Pattern: %s\n", inputPattern)
+		fmt.Fprintf(out, "   Output Format: %s\n", outputFormat)
+		fmt.Fprintf(out, "   Output Directory: %s\n", outputDir)
+		fmt.Fprintf(out, "   Algorithm: %s\n", algorithm)
+		fmt.Fprintf(out, "   Confidence Threshold: %.2f\n", confidence)
+		fmt.Fprintf(out, "   Max Distance: %.1f km\n", maxDistance)
+		fmt.Fprintf(out, "   Propagation Speed: %.0f m/s\n", propagationSpeed)
+		if heatmapExtent > 0 {
+			fmt.Fprintf(out, "   Heatmap Grid: %dx%d over %.0f m\n", heatmapGrid, heatmapGrid, heatmapExtent)
+		} else {
+			fmt.Fprintf(out, "   Heatmap Grid: %dx%d over %.1fx the error radius\n", heatmapGrid, heatmapGrid, heatmapRadii)
+		}
+		if calibrationFile != "" {
+			fmt.Fprintf(out, "   Calibration File: %s\n", calibrationFile)
+		}
+		if manifestFile != "" {
+			fmt.Fprintf(out, "   Receiver Manifest: %s (%d receivers)\n", manifestFile, len(manifest))
+		}
+		if corrStart > 0 || corrDuration > 0 {
+			if corrDuration > 0 {
+				fmt.Fprintf(out, "   Correlation Segment: %.3fs to %.3fs\n", corrStart, corrStart+corrDuration)
+			} else {
+				fmt.Fprintf(out, "   Correlation Segment: %.3fs to end\n", corrStart)
+			}
+		}
+		if lowMemory {
+			fmt.Fprintf(out, "   Low Memory: loading only the correlation window\n")
+		}
+		if minPeakToNoise > 0 {
+			fmt.Fprintf(out, "   Min Peak-to-Noise: %.1f dB\n", minPeakToNoise)
+		}
+		if len(swapIQ) > 0 {
+			fmt.Fprintf(out, "   Swap IQ: %s\n", strings.Join(swapIQ, ", "))
+		}
+		if reference != "" {
+			fmt.Fprintf(out, "   Reference Receiver: %s\n", reference)
+		} else {
+			fmt.Fprintf(out, "   Reference Receiver: auto (highest SNR)\n")
+		}
 		if len(frequencyRange) > 0 {
-			fmt.Printf("   Frequency Range: %s\n", strings.Join(frequencyRange, ", "))
+			fmt.Fprintf(out, "   Frequency Range: %s\n", strings.Join(frequencyRange, ", "))
 		}
-		fmt.Printf("   Dry Run: %t\n\n", dryRun)
+		fmt.Fprintf(out, "   Dry Run: %t\n\n", dryRun)
 	}
 
-	fmt.Printf("📁 Found %d input files:\n", len(files))
+	fmt.Fprintf(out, "📁 Found %d input files:\n", len(files))
 	for i, file := range files {
-		fmt.Printf("   %d. %s\n", i+1, filepath.Base(file))
+		fmt.Fprintf(out, "   %d. %s\n", i+1, filepath.Base(file))
+	}
+	fmt.Fprintln(out)
+
+	// Split the input into collection sessions if requested
+	var sessions []session
+	if orderByTime {
+		var undated []string
+		sessions, undated = groupSessions(files, sessionTolerance)
+		for _, file := range undated {
+			fmt.Fprintf(out, "⚠️  Skipping %s: no timestamp in filename\n", filepath.Base(file))
+		}
+		fmt.Fprintf(out, "🗂️  Grouped into %d session(s):\n", len(sessions))
+		for i, s := range sessions {
+			fmt.Fprintf(out, "   %d. %s: %d files\n", i+1, s.Start.UTC().Format("2006-01-02 15:04:05 UTC"), len(s.Files))
+		}
+		fmt.Fprintln(out)
 	}
-	fmt.Println()
 
 	if dryRun {
-		fmt.Printf("🔍 DRY RUN: Would process %d files with %s algorithm\n", len(files), algorithm)
-		fmt.Printf("📤 Would generate output in %s format to: %s\n", outputFormat, outputDir)
+		if orderByTime {
+			fmt.Fprintf(out, "🔍 DRY RUN: Would process %d session(s) with %s algorithm\n", len(sessions), algorithm)
+			fmt.Fprintf(out, "📤 Would generate output in %s format to: %s\n", outputFormat, outputDir)
+			return nil
+		}
+		fmt.Fprintf(out, "🔍 DRY RUN: Would process %d files with %s algorithm\n", len(files), algorithm)
+		fmt.Fprintf(out, "📤 Would generate output in %s format to: %s\n", outputFormat, outputDir)
 		return nil
 	}
 
+	var calibration map[string]float64
+	if calibrationFile != "" {
+		calibration, err = processor.LoadCalibration(calibrationFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create processor configuration
 	config := &processor.Config{
-		Algorithm:       algorithm,
-		Confidence:      confidence,
-		MaxDistance:     maxDistance,
-		FrequencyRange:  frequencyRange,
-		Verbose:         verbose,
-		ParallelWorkers: parallelWorkers,
+		Output:           out,
+		Algorithm:        algorithm,
+		Confidence:       confidence,
+		MaxDistance:      maxDistance,
+		FrequencyRange:   frequencyRange,
+		Verbose:          verbose,
+		ParallelWorkers:  parallelWorkers,
+		Reference:        reference,
+		PropagationSpeed: propagationSpeed,
+		Calibration:      calibration,
+		CorrStart:        time.Duration(corrStart * float64(time.Second)),
+		CorrDuration:     time.Duration(corrDuration * float64(time.Second)),
+		Hyperbolas:       hyperbolas,
+		ErrorEllipse:     errorEllipse,
+		HeatmapGridSize:  heatmapGrid,
+		HeatmapExtent:    heatmapExtent,
+		HeatmapRadii:     heatmapRadii,
+		Envelope:         envelope,
+		FreqCorrection:   freqCorrect,
+		Manifest:         manifest,
+		LowMemory:        lowMemory,
+		SwapIQ:           swapIQ,
+		MinPeakToNoise:   minPeakToNoise,
 	}
 
 	// Initialize processor
@@ -157,12 +355,60 @@ func runProcessor(cmd *cobra.Command) error {
 		return fmt.Errorf("failed to initialize processor: %w", err)
 	}
 
+	if !orderByTime {
+		return processFileSet(proc, files, "")
+	}
+
+	// Process each session on its own, continuing past failures
+	processed := 0
+	for i, s := range sessions {
+		fmt.Fprintf(out, "📂 Session %d/%d (%s, %d files)\n", i+1, len(sessions), s.Label(), len(s.Files))
+		if len(s.Files) < 3 {
+			fmt.Fprintf(out, "⚠️  Skipping session: TDOA requires at least 3 files\n\n")
+			continue
+		}
+		if err := processFileSet(proc, s.Files, s.Label()); err != nil {
+			fmt.Fprintf(errOut, "⚠️  Session %s failed: %v\n\n", s.Label(), err)
+			continue
+		}
+		processed++
+	}
+
+	if processed == 0 {
+		return fmt.Errorf("no session could be processed")
+	}
+	fmt.Fprintf(out, "🏁 Processed %d of %d sessions\n", processed, len(sessions))
+
+	return nil
+}
+
+// validateHeatmapFlags checks that the heatmap grid flags given are positive
+// and that only one way of setting the grid extent is used
+func validateHeatmapFlags(cmd *cobra.Command) error {
+	if heatmapGrid <= 0 {
+		return fmt.Errorf("invalid --heatmap-grid %d: must be positive", heatmapGrid)
+	}
+	if cmd.Flags().Changed("heatmap-extent") && heatmapExtent <= 0 {
+		return fmt.Errorf("invalid --heatmap-extent %g: must be a positive width in meters", heatmapExtent)
+	}
+	if heatmapRadii <= 0 {
+		return fmt.Errorf("invalid --heatmap-extent-radii %g: must be positive", heatmapRadii)
+	}
+	if cmd.Flags().Changed("heatmap-extent") && cmd.Flags().Changed("heatmap-extent-radii") {
+		return fmt.Errorf("--heatmap-extent and --heatmap-extent-radii both set the heatmap width: give only one")
+	}
+	return nil
+}
+
+// processFileSet runs TDOA processing on one set of files and exports the result.
+// A non-empty label is included in the output filename to keep sessions apart.
+func processFileSet(proc *processor.Processor, files []string, label string) error {
 	// Process the files
-	fmt.Printf("⚙️  Processing %d files with %s algorithm...\n", len(files), algorithm)
+	fmt.Fprintf(out, "⚙️  Processing %d files with %s algorithm...\n", len(files), algorithm)
 
 	// Estimate processing time based on file count and parallel workers
-	baseTimePerPair := 10 // Base time per pair in seconds (after optimizations)
-	totalPairs := len(files) * (len(files) - 1) / 2
+	baseTimePerPair := 10        // Base time per pair in seconds (after optimizations)
+	totalPairs := len(files) - 1 // Each receiver is correlated against the reference
 	workers := parallelWorkers
 	if workers <= 0 {
 		workers = len(files) // Use runtime.NumCPU() equivalent estimate
@@ -174,10 +420,10 @@ func runProcessor(cmd *cobra.Command) error {
 	estimatedTime := (totalPairs * baseTimePerPair) / workers
 	if totalPairs > 0 {
 		if estimatedTime > 60 {
-			fmt.Printf("⏱️  Estimated processing time: ~%d minutes (%d pairs, %d workers)\n", 
+			fmt.Fprintf(out, "⏱️  Estimated processing time: ~%d minutes (%d pairs, %d workers)\n", 
 				estimatedTime/60, totalPairs, workers)
 		} else {
-			fmt.Printf("⏱️  Estimated processing time: ~%d seconds (%d pairs, %d workers)\n", 
+			fmt.Fprintf(out, "⏱️  Estimated processing time: ~%d seconds (%d pairs, %d workers)\n", 
 				estimatedTime, totalPairs, workers)
 		}
 	}
@@ -187,16 +433,63 @@ func runProcessor(cmd *cobra.Command) error {
 		return fmt.Errorf("TDOA processing failed: %w", err)
 	}
 
+	return exportResult(result, label)
+}
+
+// runImport solves the geometry for the receiver positions and time
+// differences in a CSV file, skipping file loading and correlation
+func runImport(filename string) error {
+	if calibrationFile != "" {
+		return fmt.Errorf("--calibration cannot be combined with --import-csv: imported time differences must already have station delays removed")
+	}
+
+	fmt.Fprintf(out, "ARGUS TDOA PROCESSOR %s\n\n", version.GetFullVersion())
+	fmt.Fprintf(out, "📄 Solving from measurements in %s\n", filepath.Base(filename))
+
+	if dryRun {
+		fmt.Fprintf(out, "🔍 DRY RUN: Would solve %s with %s algorithm\n", filename, algorithm)
+		fmt.Fprintf(out, "📤 Would generate output in %s format to: %s\n", outputFormat, outputDir)
+		return nil
+	}
+
+	proc, err := processor.NewProcessor(&processor.Config{
+		Output:           out,
+		Algorithm:        algorithm,
+		Confidence:       confidence,
+		MaxDistance:      maxDistance,
+		Verbose:          verbose,
+		PropagationSpeed: propagationSpeed,
+		Hyperbolas:       hyperbolas,
+		ErrorEllipse:     errorEllipse,
+		HeatmapGridSize:  heatmapGrid,
+		HeatmapExtent:    heatmapExtent,
+		HeatmapRadii:     heatmapRadii,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize processor: %w", err)
+	}
+
+	result, err := proc.ProcessCSV(filename)
+	if err != nil {
+		return fmt.Errorf("TDOA solve failed: %w", err)
+	}
+
+	return exportResult(result, "")
+}
+
+// exportResult writes a processing result in the selected format and prints
+// its summary. A non-empty label is included in the output filename.
+func exportResult(result *processor.Result, label string) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Generate output filename
-	outputFile := generateOutputFilename(result, outputFormat, outputDir)
+	outputFile := generateOutputFilename(result, outputFormat, outputDir, label)
 
 	// Export results
-	fmt.Printf("📤 Exporting results to %s...\n", outputFile)
+	fmt.Fprintf(out, "📤 Exporting results to %s...\n", outputFile)
 
 	if err := exportResults(result, outputFormat, outputFile); err != nil {
 		return fmt.Errorf("failed to export results: %w", err)
@@ -205,9 +498,84 @@ func runProcessor(cmd *cobra.Command) error {
 	// Display summary
 	displaySummary(result, outputFile)
 
+	if serveAddr != "" {
+		servedResults = append(servedResults, servedResult{Label: label, Result: result})
+	}
+
+	if summaryJSON {
+		summary := result.Summary()
+		summary.Session = label
+		summary.OutputFile = outputFile
+		if err := json.NewEncoder(summaryOut).Encode(summary); err != nil {
+			return fmt.Errorf("failed to write JSON summary: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// runSyncCheck verifies that two stations are time-synchronized by correlating
+// their captures of a common reference signal
+func runSyncCheck(files []string, manifest []processor.ManifestEntry) error {
+	if len(files) != 2 {
+		return fmt.Errorf("--sync-check requires exactly 2 input files, found %d:\n%s", len(files), formatFileList(files))
+	}
+
+	fmt.Fprintf(out, "ARGUS TDOA PROCESSOR %s\n\n", version.GetFullVersion())
+	fmt.Fprintf(out, "⏱️  Time alignment check: %s ↔ %s\n", filepath.Base(files[0]), filepath.Base(files[1]))
+
+	proc, err := processor.NewProcessor(&processor.Config{
+		Output:          out,
+		Confidence:      confidence,
+		MaxDistance:     maxDistance,
+		Verbose:         verbose,
+		ParallelWorkers: parallelWorkers,
+		Envelope:        envelope,
+		FreqCorrection:  freqCorrect,
+		Manifest:        manifest,
+		LowMemory:       lowMemory,
+		SwapIQ:          swapIQ,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize processor: %w", err)
+	}
+
+	result, err := proc.CheckTimeAlignment(files, syncTolerance)
+	if err != nil {
+		return fmt.Errorf("time alignment check failed: %w", err)
+	}
+
+	fmt.Fprintf(out, "\n📊 Alignment Results:\n")
+	fmt.Fprintf(out, "Residual Offset: %.1f ns (%d samples, resolution %.1f ns)\n",
+		result.OffsetNs, result.OffsetSamples, result.ResolutionNs)
+	fmt.Fprintf(out, "Recorded Start Difference: %.1f ns\n", result.StartDiffNs)
+	fmt.Fprintf(out, "Confidence: %.3f (peak-to-sidelobe %.2f)\n", result.Confidence, result.PeakToSidelobe)
+	if freqCorrect {
+		fmt.Fprintf(out, "Frequency Offset: %+.1f Hz\n", result.FrequencyOffset)
+	}
+	fmt.Fprintf(out, "Tolerance: %.1f ns\n\n", result.ToleranceNs)
+
+	verdict, err := syncVerdict(result, confidence, syncTolerance)
+	fmt.Fprintln(out, verdict)
+	return err
+}
+
+// syncVerdict judges a time alignment result, returning the line reporting
+// it and an error unless the stations are shown to be synchronized. Below
+// minConfidence the correlation peak may not be the reference signal, so the
+// offset proves nothing either way.
+func syncVerdict(result *processor.AlignmentResult, minConfidence float64, tolerance time.Duration) (string, error) {
+	if result.Confidence < minConfidence {
+		return "❓ Synchronization indeterminate: low correlation confidence - make sure both captures contain the same reference signal",
+			fmt.Errorf("correlation confidence %.3f is below %.3f, timing offset not established", result.Confidence, minConfidence)
+	}
+	if !result.WithinTolerance {
+		return fmt.Sprintf("❌ Stations are NOT synchronized: offset exceeds %v", tolerance),
+			fmt.Errorf("timing offset %.1f ns exceeds tolerance %.1f ns", result.OffsetNs, result.ToleranceNs)
+	}
+	return fmt.Sprintf("✅ Stations are synchronized within %v", tolerance), nil
+}
+
 // formatFileList formats a list of files for error messages
 func formatFileList(files []string) string {
 	if len(files) == 0 {
@@ -221,29 +589,80 @@ func formatFileList(files []string) string {
 	return result
 }
 
-// findMatchingFiles finds files matching the input pattern
+// stdinInput is the input pattern that reads the file list from stdin
+const stdinInput = "-"
+
+// findMatchingFiles finds .dat files matching the input pattern. If the input
+// is a directory it is searched recursively. Results are sorted by path. An
+// input of "-" reads the file list from stdin instead.
 func findMatchingFiles(pattern string) ([]string, error) {
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, fmt.Errorf("invalid pattern: %w", err)
+	if pattern == stdinInput {
+		return readFileList(os.Stdin)
 	}
 
-	// Filter for .dat files only
+	var matches []string
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		err := filepath.WalkDir(pattern, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				matches = append(matches, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search directory: %w", err)
+		}
+	} else {
+		matches, err = filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	// Filter for .dat files only, compressed or not
 	var datFiles []string
 	for _, match := range matches {
-		if strings.HasSuffix(strings.ToLower(match), ".dat") {
+		if name := strings.ToLower(match); strings.HasSuffix(name, ".dat") || strings.HasSuffix(name, ".dat.gz") {
 			datFiles = append(datFiles, match)
 		}
 	}
+	sort.Strings(datFiles)
 
 	return datFiles, nil
 }
 
+// readFileList reads newline-separated file paths, as printed by find or ls,
+// skipping blank lines. The paths are used as given, in the order given, so
+// they are not globbed or filtered by extension; each must exist.
+func readFileList(r io.Reader) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("input file from stdin: %w", err)
+		}
+		files = append(files, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file list from stdin: %w", err)
+	}
+	return files, nil
+}
+
 // generateOutputFilename creates an output filename based on processing results
-func generateOutputFilename(result *processor.Result, format, outputDir string) string {
+func generateOutputFilename(result *processor.Result, format, outputDir, label string) string {
 	// Format: tdoa_YYYYMMDD_HHMMSS_433920000Hz_heatmap.geojson
 	timestamp := result.ProcessingTime.Format("20060102_150405")
 	frequency := fmt.Sprintf("%.0fHz", result.Frequency)
+	if label != "" {
+		frequency += "_" + label
+	}
 
 	var suffix string
 	switch format {
@@ -277,24 +696,83 @@ func exportResults(result *processor.Result, format, filename string) error {
 
 // displaySummary shows a summary of the processing results
 func displaySummary(result *processor.Result, outputFile string) {
-	fmt.Printf("\n✅ TDOA Processing Complete!\n\n")
-
-	fmt.Printf("📊 Results Summary:\n")
-	fmt.Printf("Estimated Location: %.6f°, %.6f°\n", result.Location.Latitude, result.Location.Longitude)
-	fmt.Printf("Confidence: %.2f\n", result.Confidence)
-	fmt.Printf("Error Radius: %.1f meters\n", result.ErrorRadius)
-	fmt.Printf("Processing Time: %s\n", result.ProcessingTime.Format("2006-01-02 15:04:05"))
-	fmt.Printf("Files Processed: %d\n", len(result.ReceiverLocations))
-	fmt.Printf("Frequency: %.3f MHz\n", result.Frequency/1e6)
-	fmt.Printf("Algorithm: %s\n", result.Algorithm)
-	fmt.Printf("\n📁 Output File: %s\n", outputFile)
-	fmt.Printf("🗺️  Open the output file in mapping software or web applications\n")
-	fmt.Printf("   for visualization of the transmitter location and confidence area.\n\n")
+	fmt.Fprintf(out, "\n✅ TDOA Processing Complete!\n\n")
+
+	fmt.Fprintf(out, "📊 Results Summary:\n")
+	fmt.Fprintf(out, "Estimated Location: %.6f°, %.6f°\n", result.Location.Latitude, result.Location.Longitude)
+	fmt.Fprintf(out, "Confidence: %.2f\n", result.Confidence)
+	fmt.Fprintf(out, "Error Radius: %.1f meters\n", result.ErrorRadius)
+	if e := result.ErrorEllipse; e != nil {
+		fmt.Fprintf(out, "Error Ellipse (%.0f%%): %.1f × %.1f meters semi-axes, major axis bearing %.0f°\n",
+			e.ConfidenceLevel*100, e.SemiMajor, e.SemiMinor, e.Orientation)
+	}
+	fmt.Fprintf(out, "Processing Time: %s\n", result.ProcessingTime.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(out, "Files Processed: %d\n", len(result.ReceiverLocations))
+	fmt.Fprintf(out, "Frequency: %.3f MHz\n", result.Frequency/1e6)
+	fmt.Fprintf(out, "Algorithm: %s\n", result.Algorithm)
+	fmt.Fprintf(out, "Reference Receiver: %s\n", result.ReferenceReceiver)
+	fmt.Fprintf(out, "RMS Residual: %.1f meters\n", result.RMSResidual)
+	if showResiduals {
+		displayResiduals(result)
+	}
+	if freqCorrect {
+		displayFrequencyOffsets(result)
+	}
+	if len(result.FailedPairs) > 0 {
+		displayFailedPairs(result)
+	}
+	if result.Algorithm == processor.CentroidFallbackAlgorithm {
+		fmt.Fprintf(out, "\n⚠️  WARNING: The location is a confidence-weighted centroid of the receivers,\n")
+		fmt.Fprintf(out, "   not a true TDOA fix. Do not rely on it beyond the receiver area.\n")
+	}
+	fmt.Fprintf(out, "\n📁 Output File: %s\n", outputFile)
+	fmt.Fprintf(out, "🗺️  Open the output file in mapping software or web applications\n")
+	fmt.Fprintf(out, "   for visualization of the transmitter location and confidence area.\n\n")
+}
+
+// residualWarnFactor marks measurements whose residual exceeds this multiple
+// of the RMS residual as likely outliers
+const residualWarnFactor = 2.0
+
+// displayResiduals lists each measurement's residual against the solution so
+// a misbehaving station or multipath baseline stands out
+func displayResiduals(result *processor.Result) {
+	fmt.Fprintf(out, "\n📏 Measurement Residuals (observed - predicted distance difference):\n")
+	for _, m := range result.TDOAMeasurements {
+		flag := ""
+		if result.RMSResidual > 0 && math.Abs(m.Residual) > residualWarnFactor*result.RMSResidual {
+			flag = "  ⚠️  outlier"
+		}
+		fmt.Fprintf(out, "   %s-%s: %10.1f m (confidence %.2f)%s\n", m.Receiver1ID, m.Receiver2ID, m.Residual, m.Confidence, flag)
+	}
+}
+
+// displayFrequencyOffsets lists the carrier frequency offset removed from each
+// pair before correlating; a pair without a clear carrier was left uncorrected
+func displayFrequencyOffsets(result *processor.Result) {
+	fmt.Fprintf(out, "\n📻 Frequency Offsets (receiver 2 - receiver 1):\n")
+	for _, m := range result.TDOAMeasurements {
+		if m.FrequencyOffset == 0 {
+			fmt.Fprintf(out, "   %s-%s: not estimated (no clear carrier)\n", m.Receiver1ID, m.Receiver2ID)
+			continue
+		}
+		fmt.Fprintf(out, "   %s-%s: %+10.1f Hz (%+.2f ppm)\n", m.Receiver1ID, m.Receiver2ID, m.FrequencyOffset, m.FrequencyOffset/result.Frequency*1e6)
+	}
+}
+
+// displayFailedPairs lists the receiver pairs left out of the solve because
+// they could not be correlated, with the reason for each
+func displayFailedPairs(result *processor.Result) {
+	fmt.Fprintf(out, "\n⚠️  Baselines Not Measured (%d, left out of the solve):\n", len(result.FailedPairs))
+	for _, failure := range result.FailedPairs {
+		fmt.Fprintf(out, "   %s-%s: %s\n", failure.Receiver1ID, failure.Receiver2ID, failure.Reason)
+	}
 }
 
 // main is the entry point of the application
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		console.Exit(1)
 	}
+	console.Flush()
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"argus-collector/internal/processor"
+)
+
+func TestSyncVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  processor.AlignmentResult
+		verdict string
+		err     string // Expected error text; "" for synchronized
+	}{
+		{"synchronized", processor.AlignmentResult{Confidence: 0.9, OffsetNs: 120, ToleranceNs: 1000, WithinTolerance: true}, "synchronized within", ""},
+		{"offset too large", processor.AlignmentResult{Confidence: 0.9, OffsetNs: 5000, ToleranceNs: 1000}, "NOT synchronized", "exceeds tolerance"},
+		{"low confidence within tolerance", processor.AlignmentResult{Confidence: 0.2, OffsetNs: 120, ToleranceNs: 1000, WithinTolerance: true}, "indeterminate", "below 0.500"},
+		{"low confidence outside tolerance", processor.AlignmentResult{Confidence: 0.2, OffsetNs: 5000, ToleranceNs: 1000}, "indeterminate", "below 0.500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := syncVerdict(&tt.result, 0.5, time.Microsecond)
+			if !strings.Contains(verdict, tt.verdict) {
+				t.Errorf("verdict = %q, want one containing %q", verdict, tt.verdict)
+			}
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
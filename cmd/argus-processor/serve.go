@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"argus-collector/internal/processor"
+)
+
+// viewerPage is the Leaflet page that draws served results
+//
+//go:embed viewer.html
+var viewerPage []byte
+
+// servedResult is a processing result kept for the web viewer
+type servedResult struct {
+	Label  string
+	Result *processor.Result
+}
+
+var (
+	serveListener net.Listener   // Opened before processing so a busy address fails fast
+	servedResults []servedResult // Results of this run, in the order processed
+)
+
+// listenForViewer opens the web viewer's address ahead of processing
+func listenForViewer(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot serve the web viewer on %s: %w", addr, err)
+	}
+	serveListener = listener
+	return nil
+}
+
+// serveViewer serves a web page mapping the results of this run until
+// interrupted. The page draws each result's GeoJSON, built from the result in
+// memory as the exporter builds it. Leaflet and the map tiles are loaded from
+// the internet by the browser.
+func serveViewer() error {
+	defer serveListener.Close()
+	if len(servedResults) == 0 {
+		fmt.Fprintf(out, "⚠️  No result to show in the web viewer\n")
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(viewerPage)
+	})
+	mux.HandleFunc("GET /results", func(w http.ResponseWriter, r *http.Request) {
+		type entry struct {
+			Label    string             `json:"label"`
+			Location processor.Location `json:"location"`
+		}
+		entries := make([]entry, len(servedResults))
+		for i, s := range servedResults {
+			entries[i] = entry{Label: s.Label, Location: s.Result.Location}
+		}
+		writeJSON(w, entries)
+	})
+	mux.HandleFunc("GET /result/{file}", func(w http.ResponseWriter, r *http.Request) {
+		var index int
+		if _, err := fmt.Sscanf(r.PathValue("file"), "%d.geojson", &index); err != nil || index < 0 || index >= len(servedResults) {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, servedResults[index].Result.GeoJSON())
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(out, "\n🌐 Web viewer: %s (Ctrl-C to stop)\n", viewerURL(serveListener.Addr()))
+	if err := server.Serve(serveListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("web viewer failed: %w", err)
+	}
+	fmt.Fprintf(out, "🌐 Web viewer stopped\n")
+	return nil
+}
+
+// writeJSON writes v as the JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// viewerURL returns the URL to open the viewer at, naming localhost when
+// listening on all interfaces
+func viewerURL(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return "http://" + addr.String() + "/"
+	}
+	host := tcp.IP.String()
+	if tcp.IP.IsUnspecified() {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(tcp.Port)) + "/"
+}
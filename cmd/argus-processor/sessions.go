@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"argus-collector/internal/processor"
+)
+
+// session is a set of files collected together, identified by the collection
+// time embedded in their filenames (e.g. argus-0_1754061697.dat)
+type session struct {
+	Start time.Time // Collection time of the earliest file
+	Files []string
+}
+
+// Label returns a short identifier for the session used in output filenames
+func (s session) Label() string {
+	return fmt.Sprintf("session%d", s.Start.Unix())
+}
+
+// groupSessions groups files whose filename timestamps lie within tolerance of
+// the first file in the group. Files without a timestamp are returned separately.
+func groupSessions(files []string, tolerance time.Duration) ([]session, []string) {
+	type stamped struct {
+		file string
+		time time.Time
+	}
+
+	var dated []stamped
+	var undated []string
+	for _, file := range files {
+		t, ok := processor.FilenameTime(file)
+		if !ok {
+			undated = append(undated, file)
+			continue
+		}
+		dated = append(dated, stamped{file: file, time: t})
+	}
+
+	sort.SliceStable(dated, func(i, j int) bool {
+		if !dated[i].time.Equal(dated[j].time) {
+			return dated[i].time.Before(dated[j].time)
+		}
+		return dated[i].file < dated[j].file
+	})
+
+	var sessions []session
+	for _, f := range dated {
+		if n := len(sessions); n > 0 && f.time.Sub(sessions[n-1].Start) <= tolerance {
+			sessions[n-1].Files = append(sessions[n-1].Files, f.file)
+			continue
+		}
+		sessions = append(sessions, session{Start: f.time, Files: []string{f.file}})
+	}
+
+	return sessions, undated
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGroupSessions(t *testing.T) {
+	files := []string{
+		"data/argus-2_1698765432.dat",
+		"data/argus-0_1698765432.250000000.dat", // Nanosecond filename
+		"data/argus-1_1698765432_2.dat",         // Overwrite suffix of an existing file
+		"data/argus-0_1698765500.dat",
+		"data/argus-1_1698765500.750000000_1.dat",
+		"data/capture.dat",
+		"data/argus-3_42.dat", // Too short to be a collection time
+	}
+
+	sessions, undated := groupSessions(files, 5*time.Second)
+
+	want := []session{
+		{Start: time.Unix(1698765432, 0), Files: []string{
+			"data/argus-1_1698765432_2.dat",
+			"data/argus-2_1698765432.dat",
+			"data/argus-0_1698765432.250000000.dat",
+		}},
+		{Start: time.Unix(1698765500, 0), Files: []string{
+			"data/argus-0_1698765500.dat",
+			"data/argus-1_1698765500.750000000_1.dat",
+		}},
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("grouped into %d sessions, want %d: %v", len(sessions), len(want), sessions)
+	}
+	for i, w := range want {
+		if !sessions[i].Start.Equal(w.Start) || !slices.Equal(sessions[i].Files, w.Files) {
+			t.Errorf("session %d = %v %v, want %v %v", i, sessions[i].Start, sessions[i].Files, w.Start, w.Files)
+		}
+	}
+	if label := sessions[0].Label(); label != "session1698765432" {
+		t.Errorf("label = %s, want session1698765432", label)
+	}
+	if wantUndated := []string{"data/capture.dat", "data/argus-3_42.dat"}; !slices.Equal(undated, wantUndated) {
+		t.Errorf("undated = %v, want %v", undated, wantUndated)
+	}
+}
+
+func TestGroupSessionsSubSecondTolerance(t *testing.T) {
+	files := []string{
+		"argus-0_1698765432.100000000.dat",
+		"argus-1_1698765432.300000000.dat",
+		"argus-2_1698765432.900000000.dat",
+	}
+
+	sessions, _ := groupSessions(files, 500*time.Millisecond)
+	if len(sessions) != 2 || len(sessions[0].Files) != 2 || len(sessions[1].Files) != 1 {
+		t.Fatalf("sessions = %v, want the first two files together and the third apart", sessions)
+	}
+	if want := time.Unix(1698765432, 900000000); !sessions[1].Start.Equal(want) {
+		t.Errorf("second session starts %v, want %v", sessions[1].Start, want)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// constellationShades maps increasing point density to characters
+const constellationShades = ".:-=+*#%@"
+
+// displayConstellation renders an ASCII density plot of I (x) against Q (y).
+// Both axes share one symmetric scale taken from the data so the origin is
+// centred and circular constellations stay circular.
+func displayConstellation(samples []complex64) {
+	if len(samples) == 0 {
+		fmt.Fprintf(out, "🔵 IQ Constellation: No samples to display\n\n")
+		return
+	}
+
+	limit := 0.0
+	for _, sample := range samples {
+		limit = math.Max(limit, math.Abs(float64(real(sample))))
+		limit = math.Max(limit, math.Abs(float64(imag(sample))))
+	}
+	if limit == 0 {
+		limit = 1e-6
+	}
+
+	// Bin samples into the grid, counting points per cell
+	counts := make([][]int, graphHeight)
+	for i := range counts {
+		counts[i] = make([]int, graphWidth)
+	}
+	maxCount := 0
+	for _, sample := range samples {
+		x := int(math.Round((float64(real(sample)) + limit) / (2 * limit) * float64(graphWidth-1)))
+		y := int(math.Round((limit - float64(imag(sample))) / (2 * limit) * float64(graphHeight-1)))
+		x = min(max(x, 0), graphWidth-1)
+		y = min(max(y, 0), graphHeight-1)
+		counts[y][x]++
+		maxCount = max(maxCount, counts[y][x])
+	}
+
+	fmt.Fprintf(out, "🔵 IQ Constellation (I → x, Q → y):\n")
+	fmt.Fprintf(out, "Samples: %d | Scale: ±%.6f\n\n", len(samples), limit)
+
+	centerX := (graphWidth - 1) / 2
+	centerY := (graphHeight - 1) / 2
+	for i, row := range counts {
+		// Label the top, centre and bottom rows with their Q value
+		if i == 0 || i == centerY || i == graphHeight-1 {
+			fmt.Fprintf(out, "%9.4f |", limit-float64(i)*2*limit/float64(graphHeight-1))
+		} else {
+			fmt.Fprintf(out, "          |")
+		}
+
+		for j, count := range row {
+			switch {
+			case count > 0:
+				// Log scaling keeps sparse outliers visible next to dense clusters
+				level := int(math.Log1p(float64(count)) / math.Log1p(float64(maxCount)) * float64(len(constellationShades)-1))
+				fmt.Fprint(out, string(constellationShades[level]))
+			case i == centerY && j == centerX:
+				fmt.Fprint(out, "+")
+			case i == centerY:
+				fmt.Fprint(out, "-")
+			case j == centerX:
+				fmt.Fprint(out, "|")
+			default:
+				fmt.Fprint(out, " ")
+			}
+		}
+		fmt.Fprintln(out, "|")
+	}
+
+	// Print x-axis with I labels at the edges and centre
+	fmt.Fprintf(out, "          +%s+\n", strings.Repeat("-", graphWidth))
+	leftLabel := fmt.Sprintf("%.4f", -limit)
+	midLabel := "0"
+	rightLabel := fmt.Sprintf("%.4f", limit)
+	fmt.Fprintf(out, "          %s", leftLabel)
+	fmt.Fprint(out, strings.Repeat(" ", max(centerX+1-len(leftLabel), 1)))
+	fmt.Fprint(out, midLabel)
+	fmt.Fprint(out, strings.Repeat(" ", max(graphWidth-centerX-len(midLabel)-len(rightLabel)+1, 1)))
+	fmt.Fprintln(out, rightLabel)
+
+	fmt.Fprintf(out, "\nLegend: density %s (low → high, log scale), axes cross at 0\n\n", constellationShades)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"argus-collector/internal/console"
+	"argus-collector/internal/filewriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Convert command flag variables
+var (
+	convertFormat    string // Output sample encoding
+	convertNormalize bool   // Rescale samples so the peak I or Q value is full scale
+)
+
+// convertChunk is the number of samples decoded and encoded at a time
+const convertChunk = 65536
+
+// rawFormat describes one raw IQ output encoding
+type rawFormat struct {
+	Size       int    // Bytes per complex sample
+	GNURadio   string // Matching GNU Radio File Source output type
+	Conversion string // How samples are scaled
+	encode     func(dst []byte, s complex64)
+}
+
+// rawFormats lists the supported raw IQ output encodings by name
+var rawFormats = map[string]rawFormat{
+	"complex-f32": {8, "complex (gr_complex)", "unscaled", func(dst []byte, s complex64) {
+		binary.LittleEndian.PutUint32(dst, math.Float32bits(real(s)))
+		binary.LittleEndian.PutUint32(dst[4:], math.Float32bits(imag(s)))
+	}},
+	"complex-f64": {16, "none (use a float64 reader)", "unscaled", func(dst []byte, s complex64) {
+		binary.LittleEndian.PutUint64(dst, math.Float64bits(float64(real(s))))
+		binary.LittleEndian.PutUint64(dst[8:], math.Float64bits(float64(imag(s))))
+	}},
+	"complex-i16": {4, "short, vector length 2 (or ishort)", "1.0 = 32767", func(dst []byte, s complex64) {
+		binary.LittleEndian.PutUint16(dst, uint16(scaleInt16(real(s))))
+		binary.LittleEndian.PutUint16(dst[2:], uint16(scaleInt16(imag(s))))
+	}},
+	"complex-u8": {2, "byte, vector length 2 (RTL-SDR style, offset 127.5)", "1.0 = 255, 0.0 = 127.5", func(dst []byte, s complex64) {
+		dst[0] = scaleUint8(real(s))
+		dst[1] = scaleUint8(imag(s))
+	}},
+}
+
+// convertCmd converts the samples of a data file to a headerless raw IQ file
+var convertCmd = &cobra.Command{
+	Use:   "convert <file.dat> <output>",
+	Short: "Write the samples of a data file as raw interleaved IQ",
+	Long: `Convert writes the samples of an Argus data file as raw interleaved I/Q
+without the .dat header, in the encoding chosen with --format:
+
+  complex-f32  little-endian float32 I, Q (GNU Radio gr_complex)
+  complex-f64  little-endian float64 I, Q
+  complex-i16  little-endian int16 I, Q, full scale 32767
+  complex-u8   unsigned 8-bit I, Q, offset 127.5 as produced by rtl_sdr
+
+Integer encodings clip samples outside [-1, 1].
+
+--normalize scans the capture for its largest I or Q value and scales every
+sample so that value is full scale (1.0), which makes weak, low-gain captures
+easier to inspect. The scale factor is written with the source metadata to
+<output>.json; divide samples by it to recover the captured levels.`,
+	Example: `  argus-reader convert capture.dat capture.cf32
+  argus-reader convert capture.dat capture.cu8 --format complex-u8
+  argus-reader convert capture.dat capture.cs16 --format complex-i16 --normalize`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := convertFile(args[0], args[1], convertFormat, convertNormalize); err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
+		}
+	},
+}
+
+func init() {
+	convertCmd.Flags().StringVarP(&convertFormat, "format", "f", "complex-f32", "output sample encoding (complex-f32, complex-f64, complex-i16, complex-u8)")
+	convertCmd.Flags().BoolVar(&convertNormalize, "normalize", false, "scale samples so the largest I or Q value is full scale, recording the factor in <output>.json")
+	rootCmd.AddCommand(convertCmd)
+}
+
+// normalizedInfo is written next to a normalized output file so the captured
+// levels can be recovered
+type normalizedInfo struct {
+	DataFile    string               `json:"data_file"`
+	Format      string               `json:"format"`
+	SampleCount int                  `json:"sample_count"`
+	ScaleFactor float64              `json:"scale_factor"` // Samples were multiplied by this; divide to recover captured levels
+	Source      *filewriter.Metadata `json:"source"`
+}
+
+// convertFile streams the samples of filename to output in the named raw
+// format, scaling them to full range first if normalize is set
+func convertFile(filename, output, formatName string, normalize bool) error {
+	format, ok := rawFormats[formatName]
+	if !ok {
+		return fmt.Errorf("unknown format %q (use complex-f32, complex-f64, complex-i16 or complex-u8)", formatName)
+	}
+
+	reader, err := openSampleReader(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	scale := float32(1)
+	if normalize {
+		peak, err := peakComponent(reader)
+		if err != nil {
+			return err
+		}
+		if peak == 0 {
+			return fmt.Errorf("cannot normalize: every sample is zero")
+		}
+		scale = float32(1 / peak)
+		if err := reader.SeekSample(0); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+
+	samples := make([]complex64, convertChunk)
+	encoded := make([]byte, convertChunk*format.Size)
+	written := 0
+	for {
+		n, err := reader.Read(samples)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, s := range samples[:n] {
+			format.encode(encoded[i*format.Size:], s*complex(scale, 0))
+		}
+		if _, err := writer.Write(encoded[:n*format.Size]); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		written += n
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+
+	metadata := reader.Metadata()
+	fmt.Fprintf(out, "✅ Wrote %d samples as %s to %s\n", written, formatName, output)
+	fmt.Fprintf(out, "   Sample rate: %d Hz, center frequency: %d Hz\n", metadata.SampleRate, metadata.Frequency)
+	fmt.Fprintf(out, "   Scaling: %s; GNU Radio File Source type: %s\n", format.Conversion, format.GNURadio)
+	if normalize {
+		infoFile, err := writeNormalizedInfo(output, formatName, written, float64(scale), metadata)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "   Normalized: samples scaled by %.6g (%+.1f dB); factor recorded in %s\n",
+			scale, 20*math.Log10(float64(scale)), infoFile)
+	}
+	return nil
+}
+
+// peakComponent returns the largest absolute I or Q value in the samples
+// remaining in reader
+func peakComponent(reader *filewriter.SampleReader) (float64, error) {
+	var peak float64
+	samples := make([]complex64, convertChunk)
+	for {
+		n, err := reader.Read(samples)
+		if err == io.EOF {
+			return peak, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, s := range samples[:n] {
+			peak = math.Max(peak, math.Max(math.Abs(float64(real(s))), math.Abs(float64(imag(s)))))
+		}
+	}
+}
+
+// writeNormalizedInfo records the scale factor applied to output, with the
+// source metadata, in output.json and returns its path
+func writeNormalizedInfo(output, formatName string, sampleCount int, scale float64, metadata *filewriter.Metadata) (string, error) {
+	data, err := json.MarshalIndent(normalizedInfo{
+		DataFile:    filepath.Base(output),
+		Format:      formatName,
+		SampleCount: sampleCount,
+		ScaleFactor: scale,
+		Source:      metadata,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode scale factor: %w", err)
+	}
+	filename := output + ".json"
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write scale factor: %w", err)
+	}
+	return filename, nil
+}
+
+// scaleInt16 scales a normalized sample component to int16, clipping at full scale
+func scaleInt16(v float32) int16 {
+	return int16(math.Round(math.Max(-1, math.Min(1, float64(v))) * math.MaxInt16))
+}
+
+// scaleUint8 scales a normalized sample component to an offset-binary byte,
+// clipping at full scale
+func scaleUint8(v float32) uint8 {
+	return uint8(math.Round(127.5 + 127.5*math.Max(-1, math.Min(1, float64(v)))))
+}
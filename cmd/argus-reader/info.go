@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"argus-collector/internal/filewriter"
+)
+
+// fileInfo is the document printed by --info-json: the sidecar fields plus
+// values derived from the header and the file size
+type fileInfo struct {
+	filewriter.Sidecar
+	DurationSeconds float64 `json:"duration_seconds"`
+	FileSize        int64   `json:"file_size_bytes"`
+	Compressed      bool    `json:"compressed"` // File is gzip-compressed
+	Truncated       bool    `json:"truncated"`  // File holds fewer samples than the header claims; not checked for compressed files
+}
+
+// printInfoJSON prints the metadata of a data file as JSON. Only the header is
+// read, so it takes the same time for any file size.
+func printInfoJSON(filename string) error {
+	metadata, sampleCount, err := filewriter.ReadMetadata(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+	stat, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	compressed, err := filewriter.IsCompressedFile(filename)
+	if err != nil {
+		return err
+	}
+
+	info := fileInfo{
+		Sidecar: filewriter.Sidecar{
+			Metadata:    *metadata,
+			SampleCount: sampleCount,
+			DataFile:    filename,
+		},
+		FileSize:   stat.Size(),
+		Compressed: compressed,
+		Truncated:  !compressed && filewriter.DataOffset(metadata, int64(sampleCount)) > stat.Size(),
+	}
+	if metadata.SampleRate > 0 {
+		info.DurationSeconds = float64(sampleCount) / float64(metadata.SampleRate)
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
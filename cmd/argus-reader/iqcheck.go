@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"argus-collector/internal/filewriter"
+)
+
+// Swapped I/Q detection thresholds
+const (
+	iqMinSignalDb   = 6.0 // A peak must stand this far above the noise floor to be judged
+	iqVerdictDb     = 6.0 // Excess power one side of the mirror must exceed the other by for a verdict
+	iqLopsidedDb    = 3.0 // Asymmetry beyond which the spectrum is reported as lopsided
+	iqDCExcludeBins = 2   // Bins each side of 0 Hz ignored, as the RTL-SDR DC spike sits there
+	iqSignalBins    = 3   // Bins each side of the expected signal offset summed as its power
+)
+
+// iqAsymmetry describes how the power above the noise floor is split
+// between frequencies above and below the tuned frequency
+type iqAsymmetry struct {
+	UpperPower  float64 // Excess power above the tuned frequency, linear
+	LowerPower  float64 // Excess power below the tuned frequency, linear
+	PeakOffset  float64 // Offset of the strongest bin from the tuned frequency in Hz
+	PeakDb      float64 // Strongest bin above the noise floor in dB
+	floor       float64 // Median bin power, linear
+	power       []float64
+	center      int
+	binWidth    float64
+	excludeBins int
+}
+
+// AsymmetryDb returns how much stronger the upper half of the spectrum is
+// than the lower half, in dB
+func (a *iqAsymmetry) AsymmetryDb() float64 {
+	return 10 * math.Log10((a.UpperPower+1e-30)/(a.LowerPower+1e-30))
+}
+
+// bandPower returns the excess power in the bins within iqSignalBins of
+// offset Hz from the tuned frequency
+func (a *iqAsymmetry) bandPower(offset float64) float64 {
+	k := a.center + int(math.Round(offset/a.binWidth))
+	total := 0.0
+	for i := k - iqSignalBins; i <= k+iqSignalBins; i++ {
+		if i < 0 || i >= len(a.power) || absInt(i-a.center) <= a.excludeBins {
+			continue
+		}
+		total += math.Max(a.power[i]-a.floor, 0)
+	}
+	return total
+}
+
+// absInt returns the absolute value of an integer
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// measureIQAsymmetry splits the excess power of psd about its center bin,
+// the tuned frequency. Swapped I and Q mirror the spectrum about that bin.
+func measureIQAsymmetry(psd *psdEstimate) *iqAsymmetry {
+	n := len(psd.PowerDb)
+	a := &iqAsymmetry{
+		power:       make([]float64, n),
+		center:      n / 2,
+		binWidth:    psd.BinWidth,
+		excludeBins: iqDCExcludeBins,
+	}
+	for i, db := range psd.PowerDb {
+		a.power[i] = math.Pow(10, db/10)
+	}
+	sorted := append([]float64(nil), a.power...)
+	sort.Float64s(sorted)
+	a.floor = sorted[n/2]
+
+	peak := -1
+	for i, p := range a.power {
+		offset := i - a.center
+		if absInt(offset) <= a.excludeBins {
+			continue
+		}
+		if offset > 0 {
+			a.UpperPower += math.Max(p-a.floor, 0)
+		} else {
+			a.LowerPower += math.Max(p-a.floor, 0)
+		}
+		if peak < 0 || p > a.power[peak] {
+			peak = i
+		}
+	}
+	a.PeakOffset = float64(peak-a.center) * a.binWidth
+	a.PeakDb = 10 * math.Log10(a.power[peak]/(a.floor+1e-30))
+	return a
+}
+
+// checkIQSwap looks for the mirrored spectrum that swapped I and Q produce.
+// Given the transmitter frequency it compares the power at the expected
+// offset from the tuned frequency with the power at its mirror image;
+// without it, it can only report where the strongest signal sits and how
+// lopsided the spectrum is.
+func checkIQSwap(filename string, metadata *filewriter.Metadata, totalSamples, fftSize int, signalFreq float64) error {
+	psd, err := computePSD(filename, metadata, totalSamples, fftSize)
+	if err != nil {
+		return err
+	}
+	a := measureIQAsymmetry(psd)
+	tuned := float64(metadata.Frequency)
+
+	fmt.Fprintf(out, "🔀 I/Q Order Check (%d-bin spectrum, %.1f Hz resolution):\n", fftSize, psd.BinWidth)
+	fmt.Fprintf(out, "Strongest signal: %.0f Hz (%+.1f kHz from the tuned frequency), %.1f dB above the noise floor\n",
+		tuned+a.PeakOffset, a.PeakOffset/1000, a.PeakDb)
+	fmt.Fprintf(out, "Spectrum asymmetry: upper half %+.1f dB relative to lower half\n", a.AsymmetryDb())
+	if swapIQ {
+		fmt.Fprintf(out, "Samples read with --swap-iq\n")
+	}
+
+	if a.PeakDb < iqMinSignalDb {
+		fmt.Fprintf(out, "No signal stands above the noise floor; I/Q order cannot be checked\n\n")
+		return nil
+	}
+
+	if signalFreq == 0 {
+		if math.Abs(a.AsymmetryDb()) >= iqLopsidedDb {
+			fmt.Fprintf(out, "⚠️  Spectrum is lopsided: if the transmitter is at %.0f Hz rather than %.0f Hz, I and Q are swapped\n",
+				tuned-a.PeakOffset, tuned+a.PeakOffset)
+		} else {
+			fmt.Fprintf(out, "Spectrum is roughly symmetric about the tuned frequency\n")
+		}
+		fmt.Fprintf(out, "   Give --signal-freq with the transmitter frequency for a verdict\n\n")
+		return nil
+	}
+
+	offset := signalFreq - tuned
+	if math.Abs(offset) <= float64(iqDCExcludeBins+iqSignalBins)*psd.BinWidth {
+		fmt.Fprintf(out, "Transmitter is at the tuned frequency; swapped I/Q cannot be told apart there\n")
+		fmt.Fprintf(out, "   Tune a few kHz off the transmitter frequency to check I/Q order\n\n")
+		return nil
+	}
+	if math.Abs(offset) >= float64(metadata.SampleRate)/2 {
+		fmt.Fprintf(out, "Transmitter at %.0f Hz is outside the captured band (%.0f Hz wide)\n\n", signalFreq, float64(metadata.SampleRate))
+		return nil
+	}
+
+	expected := a.bandPower(offset)
+	mirrored := a.bandPower(-offset)
+	ratioDb := 10 * math.Log10((expected+1e-30)/(mirrored+1e-30))
+	fmt.Fprintf(out, "Power at %.0f Hz (expected) vs %.0f Hz (mirror image): %+.1f dB\n", signalFreq, tuned-offset, ratioDb)
+	switch {
+	case ratioDb <= -iqVerdictDb:
+		fmt.Fprintf(out, "❌ I and Q are likely swapped: the signal appears mirrored about the tuned frequency\n")
+		fmt.Fprintf(out, "   Read with --swap-iq, process with argus-processor --swap-iq, or capture with argus-collector --swap-iq\n\n")
+	case ratioDb >= iqVerdictDb:
+		fmt.Fprintf(out, "✅ I/Q order looks correct\n\n")
+	default:
+		fmt.Fprintf(out, "⚠️  Inconclusive: no clear signal at %.0f Hz or its mirror image\n\n", signalFreq)
+	}
+	return nil
+}
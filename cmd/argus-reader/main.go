@@ -4,14 +4,18 @@ package main
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	"argus-collector/internal/console"
+	"argus-collector/internal/datareader"
 	"argus-collector/internal/filewriter"
 	"argus-collector/internal/version"
 
@@ -30,14 +34,34 @@ var (
 	graphScale         string
 	showVersion        bool
 	showDeviceAnalysis bool
+	writeSidecar       bool
+	infoJSON           bool
+	psdCSVFile         string
+	psdFFTSize         int
+	detectBurstsFlag   bool
+	burstThresholdDb   float64
+	showConstellation  bool
+	constellationCount int
+	plotFile           string
+	plotSpectrum       bool
+	quiet              bool
+	swapIQ             bool
+	checkIQ            bool
+	signalFreq         float64
+	npyFile            string
 )
 
 // DeviceSettings contains parsed device configuration information
 type DeviceSettings struct {
-	Name     string
-	Gain     string
-	GainMode string
-	BiasTee  string
+	Name       string
+	TunerType  string
+	Gain       string
+	GainMode   string
+	BiasTee    string
+	GainStep   string // Position of the gain on the tuner's gain ladder, from the metadata extension block
+	GainLadder string // Tuner's supported gains, from the metadata extension block
+	LNAGain    string // External LNA gain, from the metadata extension block
+	Antenna    string // Antenna description, from the metadata extension block
 }
 
 // rootCmd represents the base command
@@ -50,34 +74,51 @@ Useful for analyzing collected RF data and verifying collection parameters.
 Display modes:
   --samples    Show all decoded IQ sample values (magnitude, phase)
   --hex        Show complete raw hexadecimal dump of sample data bytes
-  --stats      Show statistical analysis of sample data
-  --graph      Generate ASCII graph of signal over time (use --graph-scale for units)`,
+  --stats      Show statistical analysis of sample data and check for stuck samples
+  --graph      Generate ASCII graph of signal over time (use --graph-scale for units)
+  --constellation  Plot I vs Q as an ASCII density scatter
+  --plot-png   Save the signal over time (and --plot-spectrum) as a PNG or SVG image
+  --detect-bursts  List bursts above the noise floor with start time, duration, and peak power
+  --check-iq   Check the spectrum for signs of swapped I and Q (add --signal-freq for a verdict)
+  --info-json  Print only the header metadata as JSON, for scripts
+  --export-npy Write the samples as a NumPy .npy array for Python analysis`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		// Handle version flag
 		if showVersion {
-			fmt.Println(version.GetVersionInfo("Argus Reader"))
+			fmt.Fprintln(out, version.GetVersionInfo("Argus Reader"))
 			return
 		}
 
 		// Require filename if not showing version
 		if len(args) == 0 {
-			fmt.Fprintf(os.Stderr, "Error: filename required\n")
+			fmt.Fprintf(errOut, "Error: filename required\n")
 			cmd.Usage()
-			os.Exit(1)
+			console.Exit(1)
+		}
+
+		if infoJSON {
+			if err := printInfoJSON(args[0]); err != nil {
+				fmt.Fprintf(errOut, "Error: %v\n", err)
+				console.Exit(1)
+			}
+			return
 		}
 
 		if err := displayFile(args[0], cmd); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
 		}
 	},
 }
 
 func init() {
+	cobra.OnInitialize(initOutput)
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "plain output without banners, emoji or symbols, for logs and scripts")
+	rootCmd.PersistentFlags().BoolVar(&swapIQ, "swap-iq", false, "exchange I and Q of each sample as it is read, correcting a capture from hardware that delivered Q first")
 	rootCmd.Flags().BoolVar(&showVersion, "version", false, "show version information")
 	rootCmd.Flags().BoolVarP(&showSamples, "samples", "s", false, "display all IQ sample data")
-	rootCmd.Flags().BoolVar(&showStats, "stats", false, "show statistical analysis of samples")
+	rootCmd.Flags().BoolVar(&showStats, "stats", false, "show statistical analysis of samples and check for stuck samples")
 	rootCmd.Flags().StringVarP(&outputFormat, "format", "f", "table", "output format (table, json, csv)")
 	rootCmd.Flags().BoolVar(&showHex, "hex", false, "display all raw sample data as hexadecimal dump")
 	rootCmd.Flags().BoolVarP(&showGraph, "graph", "g", false, "generate ASCII graph of signal magnitude over time")
@@ -85,9 +126,48 @@ func init() {
 	rootCmd.Flags().IntVar(&graphHeight, "graph-height", 20, "height of the ASCII graph in lines")
 	rootCmd.Flags().IntVar(&graphSamples, "graph-samples", 1000, "number of samples to include in graph")
 	rootCmd.Flags().StringVar(&graphScale, "graph-scale", "magnitude", "graph scale: magnitude, db, or power")
+	rootCmd.Flags().BoolVar(&showConstellation, "constellation", false, "plot I vs Q as an ASCII density scatter (uses --graph-width/--graph-height)")
+	rootCmd.Flags().IntVar(&constellationCount, "constellation-samples", 10000, "number of consecutive samples from the start of the capture to plot")
+
+	// Image export of the graph for reports
+	rootCmd.Flags().StringVar(&plotFile, "plot-png", "", "save the signal over time as an image to this file (SVG if it ends in .svg, otherwise PNG; uses --graph-samples/--graph-scale)")
+	rootCmd.Flags().BoolVar(&plotSpectrum, "plot-spectrum", false, "add a power spectral density panel to the --plot-png image (uses --psd-fft-size)")
 
 	// Add a device info analysis flag
 	rootCmd.Flags().BoolVar(&showDeviceAnalysis, "device-analysis", false, "show detailed device configuration analysis")
+
+	// Export metadata for tools that cannot parse the binary header
+	rootCmd.Flags().BoolVar(&writeSidecar, "sidecar", false, "write metadata as a JSON sidecar file next to the .dat file")
+	rootCmd.Flags().BoolVar(&infoJSON, "info-json", false, "print only the header metadata and duration as JSON, without reading samples")
+
+	// Power spectral density export
+	rootCmd.Flags().StringVar(&psdCSVFile, "psd-csv", "", "compute a Welch PSD over the capture and write frequency vs power (dB) to this CSV file")
+	rootCmd.Flags().IntVar(&psdFFTSize, "psd-fft-size", 1024, "FFT size (power of two) for --psd-csv")
+
+	// Sample export for Python analysis
+	rootCmd.Flags().StringVar(&npyFile, "export-npy", "", "write the samples as a NumPy .npy array of complex64 to this file, for numpy.load")
+
+	// Burst/event detection
+	rootCmd.Flags().BoolVar(&detectBurstsFlag, "detect-bursts", false, "scan the capture for bursts above the noise floor")
+	rootCmd.Flags().Float64Var(&burstThresholdDb, "burst-threshold", 10.0, "burst detection threshold in dB above the noise floor")
+
+	// Swapped I/Q detection
+	rootCmd.Flags().BoolVar(&checkIQ, "check-iq", false, "check the spectrum for signs that I and Q are swapped (uses --psd-fft-size)")
+	rootCmd.Flags().Float64Var(&signalFreq, "signal-freq", 0, "known transmitter frequency in Hz, so --check-iq can tell a swapped capture from a correct one")
+}
+
+// Output streams, with decoration stripped when --quiet is given
+var (
+	out    io.Writer = os.Stdout
+	errOut io.Writer = os.Stderr
+)
+
+// initOutput strips decoration from the output when --quiet is given
+func initOutput() {
+	if quiet {
+		out = console.NewWriter(os.Stdout)
+		errOut = console.NewWriter(os.Stderr)
+	}
 }
 
 // displayFile reads and displays the contents of an Argus data file
@@ -107,7 +187,7 @@ func displayFile(filename string, cmd *cobra.Command) error {
 	// Actual samples will be loaded only if requested
 
 	// Display file information
-	fmt.Printf("ARGUS DATA FILE READER %s\n\n", version.GetFullVersion())
+	fmt.Fprintf(out, "ARGUS DATA FILE READER %s\n\n", version.GetFullVersion())
 
 	// Display file info
 	fileInfo, err := os.Stat(filename)
@@ -115,28 +195,76 @@ func displayFile(filename string, cmd *cobra.Command) error {
 		return err
 	}
 
-	fmt.Printf("📁 File Information:\n")
-	fmt.Printf("Name: %s\n", filepath.Base(filename))
-	fmt.Printf("Size: %.2f MB (%d bytes)\n", float64(fileInfo.Size())/(1024*1024), fileInfo.Size())
-	fmt.Printf("Modified: %s\n\n", fileInfo.ModTime().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(out, "📁 File Information:\n")
+	fmt.Fprintf(out, "Name: %s\n", filepath.Base(filename))
+	fmt.Fprintf(out, "Size: %.2f MB (%d bytes)\n", float64(fileInfo.Size())/(1024*1024), fileInfo.Size())
+	if compressed, _ := filewriter.IsCompressedFile(filename); compressed {
+		fmt.Fprintf(out, "Compression: gzip\n")
+	}
+	fmt.Fprintf(out, "Modified: %s\n\n", fileInfo.ModTime().Format("2006-01-02 15:04:05"))
 
 	// Display metadata
 	displayMetadata(metadata)
+	if metadata.SampleRate == 0 {
+		fmt.Fprintf(out, "⚠️  Invalid zero sample rate in file: the header may be corrupt or partially written; durations and time axes are unavailable\n\n")
+	}
 
 	// Display device analysis if requested
 	if showDeviceAnalysis {
-		deviceSettings := parseDeviceInfo(metadata.DeviceInfo)
+		deviceSettings := deviceSettingsFromMetadata(metadata)
 		displayDeviceAnalysis(deviceSettings)
 	}
 
 	// Display sample information (using count only)
-	displaySampleInfo(int(sampleCount), metadata.SampleRate)
+	displaySampleInfo(int(sampleCount), metadata.SampleRate, metadata.SampleFormat)
+
+	if writeSidecar {
+		sidecarFile, err := filewriter.WriteSidecar(filename, metadata, sampleCount)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "📝 Metadata sidecar written: %s\n\n", sidecarFile)
+	}
+
+	if psdCSVFile != "" {
+		if err := writePSDCSV(filename, metadata, int(sampleCount), psdFFTSize, psdCSVFile); err != nil {
+			return fmt.Errorf("failed to compute PSD: %w", err)
+		}
+	}
+
+	if npyFile != "" {
+		if err := writeNPY(filename, npyFile); err != nil {
+			return fmt.Errorf("failed to export NumPy array: %w", err)
+		}
+	}
+
+	if detectBurstsFlag {
+		if err := detectBursts(filename, metadata, int(sampleCount), burstThresholdDb); err != nil {
+			return fmt.Errorf("failed to detect bursts: %w", err)
+		}
+	}
+
+	if checkIQ {
+		if err := checkIQSwap(filename, metadata, int(sampleCount), psdFFTSize, signalFreq); err != nil {
+			return fmt.Errorf("failed to check I/Q order: %w", err)
+		}
+	}
+
+	if plotFile != "" {
+		plotSamples := graphSamples
+		if !cmd.Flags().Changed("graph-samples") {
+			plotSamples = min(int(sampleCount), 10000)
+		}
+		if err := writePlotImage(filename, metadata, int(sampleCount), plotSamples, graphScale, plotSpectrum, plotFile); err != nil {
+			return fmt.Errorf("failed to write plot: %w", err)
+		}
+	}
 
 	// Handle sample data display if requested
-	if showSamples || showStats || showHex || showGraph {
+	if showSamples || showStats || showHex || showGraph || showConstellation {
 		// For samples and hex, use streaming display
 		if showSamples {
-			if err := displaySamplesStreaming(filename, metadata, int(sampleCount)); err != nil {
+			if err := displaySamplesStreaming(filename, int(sampleCount)); err != nil {
 				return fmt.Errorf("failed to display samples: %w", err)
 			}
 		}
@@ -147,55 +275,48 @@ func displayFile(filename string, cmd *cobra.Command) error {
 			}
 		}
 
-		// For graph and stats, load samples into memory (these need all data for analysis)
-		if showGraph || showStats {
-			maxSamplesNeeded := 100000 // Default for stats
-			if showGraph {
-				actualGraphSamples := graphSamples
-				if !cmd.Flags().Changed("graph-samples") {
-					actualGraphSamples = min(int(sampleCount), 10000)
-				}
-				maxSamplesNeeded = max(maxSamplesNeeded, actualGraphSamples)
+		// The graph strides across the whole file so it shows the entire capture
+		if showGraph {
+			if metadata.SampleRate == 0 {
+				return fmt.Errorf("cannot graph: invalid zero sample rate in file")
+			}
+			actualGraphSamples := graphSamples
+			if !cmd.Flags().Changed("graph-samples") {
+				actualGraphSamples = min(int(sampleCount), 10000)
 			}
 
-			fmt.Printf("⏳ Loading %d samples for analysis...\n", maxSamplesNeeded)
-			samples, err := readLimitedSamples(filename, maxSamplesNeeded)
+			fmt.Fprintf(out, "⏳ Sampling %d points across the entire capture for graph...\n", actualGraphSamples)
+			graphSampleData, err := readStridedSamples(filename, int(sampleCount), actualGraphSamples)
 			if err != nil {
-				return fmt.Errorf("failed to read samples: %w", err)
+				return fmt.Errorf("failed to read graph samples: %w", err)
 			}
+			totalTime := float64(sampleCount) / float64(metadata.SampleRate)
+			displayGraph(graphSampleData, totalTime, metadata.SampleRate, graphScale)
+		}
 
-			if showGraph {
-				actualGraphSamples := graphSamples
-				if !cmd.Flags().Changed("graph-samples") {
-					actualGraphSamples = min(int(sampleCount), 10000)
-				}
+		// The constellation uses a contiguous window so symbol structure is preserved
+		if showConstellation {
+			samples, err := readLimitedSamples(filename, constellationCount)
+			if err != nil {
+				return fmt.Errorf("failed to read constellation samples: %w", err)
+			}
+			displayConstellation(samples)
+		}
 
-				graphSampleData := samples
-				if len(samples) > actualGraphSamples {
-					// Use evenly spaced samples
-					step := len(samples) / actualGraphSamples
-					graphSampleData = make([]complex64, 0, actualGraphSamples)
-					for i := 0; i < len(samples); i += step {
-						if len(graphSampleData) < actualGraphSamples {
-							graphSampleData = append(graphSampleData, samples[i])
-						}
-					}
-				}
-				displayGraph(graphSampleData, metadata.SampleRate, graphScale)
+		// For stats, load samples into memory (these need contiguous data for analysis)
+		if showStats {
+			maxSamplesNeeded := 100000
+
+			fmt.Fprintf(out, "⏳ Loading %d samples for analysis...\n", maxSamplesNeeded)
+			samples, err := readLimitedSamples(filename, maxSamplesNeeded)
+			if err != nil {
+				return fmt.Errorf("failed to read samples: %w", err)
 			}
 
-			if showStats {
-				statsSamples := samples
-				if len(samples) > 100000 {
-					// Use every Nth sample for large datasets
-					step := len(samples) / 50000
-					statsSamples = make([]complex64, 0, 50000)
-					for i := 0; i < len(samples); i += step {
-						statsSamples = append(statsSamples, samples[i])
-					}
-					fmt.Printf("📊 Statistics calculated from %d representative samples\n", len(statsSamples))
-				}
-				displayStatistics(statsSamples)
+			displayStatistics(samples)
+
+			if err := checkStuckSamples(filename, metadata); err != nil {
+				return fmt.Errorf("failed to check for stuck samples: %w", err)
 			}
 		}
 	}
@@ -211,8 +332,11 @@ func readSamplesRobust(filename string) (*filewriter.Metadata, []complex64, erro
 		return metadata, samples, nil
 	}
 
-	// If we get EOF, the file might be truncated. Try to read what we can.
-	fmt.Printf("⚠️  File appears truncated, attempting partial read...\n")
+	// Only a truncated file can be partially recovered
+	if !errors.Is(err, filewriter.ErrTruncated) {
+		return nil, nil, err
+	}
+	fmt.Fprintf(out, "⚠️  File appears truncated, attempting partial read...\n")
 
 	// Read metadata to get the header info
 	metadataOnly, sampleCountFromHeader, err := filewriter.ReadMetadata(filename)
@@ -220,33 +344,46 @@ func readSamplesRobust(filename string) (*filewriter.Metadata, []complex64, erro
 		return nil, nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
 
+	// The size of compressed data says nothing about the samples it holds, so
+	// read until the data ends
+	compressed, err := filewriter.IsCompressedFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if compressed {
+		fmt.Fprintf(out, "📊 Compressed file, reading up to the %d samples the header claims\n", sampleCountFromHeader)
+		samples, err = readLimitedSamples(filename, int(sampleCountFromHeader))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read available samples: %w", err)
+		}
+		fmt.Fprintf(out, "   Actual readable samples: %d\n", len(samples))
+		return metadataOnly, samples, nil
+	}
+
 	// Calculate actual available samples based on file size
 	fileInfo, err := os.Stat(filename)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	// Estimate header size (this is approximate but safer than trusting header count)
-	// Magic(5) + FileFormatVersion(2) + Frequency(8) + SampleRate(4) + CollectionTime(12) +
-	// GPS(24) + GPSTime(12) + DeviceInfoLen(1) + DeviceInfo + CollectionIDLen(1) + CollectionID + SampleCount(4)
-	estimatedHeaderSize := int64(5 + 2 + 8 + 4 + 12 + 24 + 12 + 1 + len(metadataOnly.DeviceInfo) + 1 + len(metadataOnly.CollectionID) + 4)
-
-	availableDataBytes := fileInfo.Size() - estimatedHeaderSize
-	availableSamples := availableDataBytes / 8 // Each complex64 is 8 bytes (4 bytes real + 4 bytes imag)
+	// Work out how many samples are actually present rather than trusting the header count
+	headerSize := filewriter.HeaderSize(metadataOnly)
+	availableDataBytes := fileInfo.Size() - headerSize
+	availableSamples := availableDataBytes / int64(metadataOnly.SampleFormat.Size())
 
-	fmt.Printf("📊 File analysis:\n")
-	fmt.Printf("   Header claims: %d samples (%.2f MB)\n", sampleCountFromHeader, float64(sampleCountFromHeader*8)/(1024*1024))
-	fmt.Printf("   File size: %d bytes (%.2f MB)\n", fileInfo.Size(), float64(fileInfo.Size())/(1024*1024))
-	fmt.Printf("   Estimated header size: %d bytes\n", estimatedHeaderSize)
-	fmt.Printf("   Available for samples: %d bytes\n", availableDataBytes)
-	fmt.Printf("   Actual readable samples: %d\n", availableSamples)
+	fmt.Fprintf(out, "📊 File analysis:\n")
+	fmt.Fprintf(out, "   Header claims: %d samples (%.2f MB)\n", sampleCountFromHeader, float64(int64(sampleCountFromHeader)*int64(metadataOnly.SampleFormat.Size()))/(1024*1024))
+	fmt.Fprintf(out, "   File size: %d bytes (%.2f MB)\n", fileInfo.Size(), float64(fileInfo.Size())/(1024*1024))
+	fmt.Fprintf(out, "   Header size: %d bytes\n", headerSize)
+	fmt.Fprintf(out, "   Available for samples: %d bytes\n", availableDataBytes)
+	fmt.Fprintf(out, "   Actual readable samples: %d\n", availableSamples)
 
 	if availableSamples <= 0 {
 		return metadataOnly, []complex64{}, nil
 	}
 
 	// Read only what's actually available
-	samples, err = readSamplesFromFile(filename, int(availableSamples))
+	samples, err = readLimitedSamples(filename, int(availableSamples))
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to read available samples: %w", err)
 	}
@@ -254,112 +391,60 @@ func readSamplesRobust(filename string) (*filewriter.Metadata, []complex64, erro
 	return metadataOnly, samples, nil
 }
 
-// readSamplesFromFile reads a specific number of samples from the file
-func readSamplesFromFile(filename string, maxSamples int) ([]complex64, error) {
-	file, err := os.Open(filename)
+// openSampleReader opens filename for streaming, exchanging I and Q of each
+// sample when --swap-iq is given
+func openSampleReader(filename string) (*filewriter.SampleReader, error) {
+	reader, err := filewriter.NewSampleReader(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	reader.SetSwapIQ(swapIQ)
+	return reader, nil
+}
 
-	// Skip to after the header by reading metadata first
-	_, _, err = filewriter.ReadMetadata(filename)
+// readLimitedSamples reads only a limited number of samples from the beginning
+// of the file. Large files are memory mapped and decoded in bulk.
+func readLimitedSamples(filename string, maxSamples int) ([]complex64, error) {
+	reader, err := datareader.NewReader(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
-
-	// We need to reopen and seek past the header manually
-	// This is a simplified approach - read until we get to sample data
-	file.Seek(0, 0) // Start from beginning
-
-	// Skip magic
-	file.Seek(5, 0)
-
-	// Skip metadata fields in the same order as ReadFile
-	var skipBuffer [8]byte
-
-	// Skip FileFormatVersion (2 bytes)
-	file.Read(skipBuffer[:2])
-	// Skip Frequency (8 bytes)
-	file.Read(skipBuffer[:8])
-	// Skip SampleRate (4 bytes)
-	file.Read(skipBuffer[:4])
-	// Skip CollectionTime (12 bytes)
-	file.Read(skipBuffer[:8])
-	file.Read(skipBuffer[:4])
-	// Skip GPS Location (24 bytes)
-	file.Read(skipBuffer[:8])
-	file.Read(skipBuffer[:8])
-	file.Read(skipBuffer[:8])
-	// Skip GPS timestamp (12 bytes)
-	file.Read(skipBuffer[:8])
-	file.Read(skipBuffer[:4])
-
-	// Skip device info
-	var deviceInfoLen uint8
-	binary.Read(file, binary.LittleEndian, &deviceInfoLen)
-	file.Seek(int64(deviceInfoLen), 1)
-
-	// Skip collection ID
-	var collectionIDLen uint8
-	binary.Read(file, binary.LittleEndian, &collectionIDLen)
-	file.Seek(int64(collectionIDLen), 1)
-
-	// Skip sample count field
-	file.Seek(4, 1)
-
-	// Now read samples until EOF or maxSamples
-	samples := make([]complex64, 0, maxSamples)
-
-	for len(samples) < maxSamples {
-		var real, imag float32
-		if err := binary.Read(file, binary.LittleEndian, &real); err != nil {
-			break // EOF or error
-		}
-		if err := binary.Read(file, binary.LittleEndian, &imag); err != nil {
-			break // EOF or error
-		}
-		samples = append(samples, complex(real, imag))
+	defer reader.Close()
+	reader.SetSwapIQ(swapIQ)
+
+	// Read samples until EOF or maxSamples
+	samples := make([]complex64, maxSamples)
+	count, err := reader.ReadSamples(0, samples)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
 	}
 
-	return samples, nil
+	return samples[:count], nil
 }
 
-// readLimitedSamples reads only a limited number of samples from the beginning of the file
-func readLimitedSamples(filename string, maxSamples int) ([]complex64, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+// readStridedSamples reads count samples evenly spaced across the whole file,
+// seeking between them so large captures are never read in full
+func readStridedSamples(filename string, totalSamples, count int) ([]complex64, error) {
+	if count <= 0 || totalSamples <= 0 {
+		return []complex64{}, nil
 	}
-	defer file.Close()
-
-	// Skip to after the header by reading metadata first to get header size
-	metadata, _, err := filewriter.ReadMetadata(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	if count > totalSamples {
+		count = totalSamples
 	}
 
-	// Seek to start of sample data (after header)
-	// Magic(5) + FileFormatVersion(2) + Frequency(8) + SampleRate(4) + CollectionTime(12) +
-	// GPS(24) + GPSTime(12) + DeviceInfoLen(1) + DeviceInfo + CollectionIDLen(1) + CollectionID + SampleCount(4)
-	headerSize := int64(5 + 2 + 8 + 4 + 12 + 24 + 12 + 1 + len(metadata.DeviceInfo) + 1 + len(metadata.CollectionID) + 4)
-
-	_, err = file.Seek(headerSize, 0)
+	reader, err := datareader.NewReader(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to seek to sample data: %w", err)
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
-
-	// Read samples directly
-	samples := make([]complex64, 0, maxSamples)
-	for len(samples) < maxSamples {
-		var real, imag float32
-		if err := binary.Read(file, binary.LittleEndian, &real); err != nil {
-			break // EOF or error
+	defer reader.Close()
+	reader.SetSwapIQ(swapIQ)
+
+	samples := make([]complex64, count)
+	for i := 0; i < count; i++ {
+		index := int64(i) * int64(totalSamples) / int64(count)
+		if _, err := reader.ReadSamples(index, samples[i:i+1]); err != nil {
+			return samples[:i], nil // Truncated file, plot what we have
 		}
-		if err := binary.Read(file, binary.LittleEndian, &imag); err != nil {
-			break // EOF or error
-		}
-		samples = append(samples, complex(real, imag))
 	}
 
 	return samples, nil
@@ -384,10 +469,11 @@ func max(a, b int) int {
 // parseDeviceInfo extracts device settings from the device info string
 func parseDeviceInfo(deviceInfo string) DeviceSettings {
 	settings := DeviceSettings{
-		Name:     deviceInfo, // Fallback to full string
-		Gain:     "Unknown",
-		GainMode: "Unknown",
-		BiasTee:  "Unknown",
+		Name:      deviceInfo, // Fallback to full string
+		TunerType: "Unknown", // Files written before tuner reporting
+		Gain:      "Unknown",
+		GainMode:  "Unknown",
+		BiasTee:   "Unknown",
 	}
 
 	// Extract device name (everything before the first parenthesis)
@@ -395,6 +481,12 @@ func parseDeviceInfo(deviceInfo string) DeviceSettings {
 		settings.Name = strings.TrimSpace(nameMatch[1])
 	}
 
+	// Extract tuner type: "tuner: R820T"
+	tunerRegex := regexp.MustCompile(`tuner:\s*([^,)]+)`)
+	if tunerMatch := tunerRegex.FindStringSubmatch(deviceInfo); tunerMatch != nil {
+		settings.TunerType = strings.TrimSpace(tunerMatch[1])
+	}
+
 	// Extract gain information: "gain: 20.7 dB (manual)"
 	gainRegex := regexp.MustCompile(`gain:\s*([0-9.]+)\s*dB\s*\(([^)]+)\)`)
 	if gainMatch := gainRegex.FindStringSubmatch(deviceInfo); gainMatch != nil {
@@ -411,36 +503,97 @@ func parseDeviceInfo(deviceInfo string) DeviceSettings {
 	return settings
 }
 
+// deviceSettingsFromMetadata combines the settings parsed from the device info
+// string with the descriptive gain chain fields recorded in the metadata
+func deviceSettingsFromMetadata(metadata *filewriter.Metadata) DeviceSettings {
+	settings := parseDeviceInfo(metadata.DeviceInfo)
+	if metadata.AGCUsed {
+		// The recorded converged gain is authoritative over the device info
+		settings.Gain = fmt.Sprintf("%.1f dB (AGC final)", metadata.AGCFinalGain)
+		settings.GainMode = "auto"
+	}
+	settings.GainStep = "Not recorded"
+	settings.GainLadder = "Not recorded"
+	if metadata.GainStep > 0 && metadata.GainStep <= len(metadata.GainLadder) {
+		settings.GainStep = fmt.Sprintf("%d of %d (%.1f dB)", metadata.GainStep, len(metadata.GainLadder),
+			float64(metadata.GainLadder[metadata.GainStep-1])/10)
+		gains := make([]string, len(metadata.GainLadder))
+		for i, g := range metadata.GainLadder {
+			gains[i] = fmt.Sprintf("%.1f", float64(g)/10)
+		}
+		settings.GainLadder = strings.Join(gains, ", ") + " dB"
+	}
+	settings.LNAGain = "Not recorded"
+	if metadata.LNAGain != 0 {
+		settings.LNAGain = fmt.Sprintf("%.1f dB", metadata.LNAGain)
+	}
+	settings.Antenna = "Not recorded"
+	if metadata.Antenna != "" {
+		settings.Antenna = metadata.Antenna
+	}
+	return settings
+}
+
 // displayMetadata shows the file metadata in a formatted table
 func displayMetadata(metadata *filewriter.Metadata) {
 	// Parse device information to extract gain control settings
-	deviceSettings := parseDeviceInfo(metadata.DeviceInfo)
-
-	fmt.Printf("📊 Collection Metadata:\n")
-	fmt.Printf("File Format Version: %d\n", metadata.FileFormatVersion)
-	fmt.Printf("Collection ID: %s\n", metadata.CollectionID)
-	fmt.Printf("Frequency: %.3f MHz\n", float64(metadata.Frequency)/1e6)
-	fmt.Printf("Sample Rate: %.3f MSps\n", float64(metadata.SampleRate)/1e6)
-	fmt.Printf("Collection Time: %s\n", metadata.CollectionTime.Format("2006-01-02 15:04:05.000"))
-	fmt.Printf("GPS Timestamp: %s\n", metadata.GPSTimestamp.Format("2006-01-02 15:04:05.000"))
-	fmt.Printf("GPS Latitude: %14.8f°\n", metadata.GPSLocation.Latitude)
-	fmt.Printf("GPS Longitude: %14.8f°\n", metadata.GPSLocation.Longitude)
-	fmt.Printf("GPS Altitude: %14.2f m\n\n", metadata.GPSLocation.Altitude)
+	deviceSettings := deviceSettingsFromMetadata(metadata)
+
+	fmt.Fprintf(out, "📊 Collection Metadata:\n")
+	fmt.Fprintf(out, "File Format Version: %d\n", metadata.FileFormatVersion)
+	fmt.Fprintf(out, "Collection ID: %s\n", metadata.CollectionID)
+	if metadata.SoftwareVersion != "" {
+		fmt.Fprintf(out, "Software Version: %s\n", metadata.SoftwareVersion)
+	}
+	if metadata.ConfigHash != "" {
+		fmt.Fprintf(out, "Config Hash: %s\n", metadata.ConfigHash)
+	}
+	fmt.Fprintf(out, "Frequency: %.3f MHz\n", float64(metadata.Frequency)/1e6)
+	fmt.Fprintf(out, "Sample Rate: %.3f MSps\n", float64(metadata.SampleRate)/1e6)
+	fmt.Fprintf(out, "Collection Time: %s\n", metadata.CollectionTime.Format("2006-01-02 15:04:05.000"))
+	if metadata.DeviceLost {
+		fmt.Fprintf(out, "⚠️  Capture ended early: device disconnected during collection\n")
+	}
+	for _, gap := range metadata.Gaps {
+		fmt.Fprintf(out, "⚠️  Sample gap: about %v dropped before sample %d\n", gap.Duration, gap.SampleIndex)
+	}
+	fmt.Fprintf(out, "GPS Timestamp: %s UTC\n", metadata.GPSTimestamp.UTC().Format("2006-01-02 15:04:05.000"))
+	if metadata.ClockOffsetMeasured {
+		fmt.Fprintf(out, "Clock Offset: %v (system - GPS)\n", metadata.ClockOffset)
+	} else if metadata.FileFormatVersion >= filewriter.FormatVersion2 {
+		fmt.Fprintf(out, "Clock Offset: not measured\n")
+	}
+	if metadata.SignalMeasured {
+		if metadata.PeakToNoise < filewriter.SignalPresentDB {
+			fmt.Fprintf(out, "⚠️  Signal: none stands out, peaks %.1f dB above the noise floor\n", metadata.PeakToNoise)
+		} else {
+			fmt.Fprintf(out, "Signal: peaks %.1f dB above the noise floor\n", metadata.PeakToNoise)
+		}
+	}
+	if metadata.NoPosition {
+		fmt.Fprintf(out, "GPS Location: none (collected without GPS)\n\n")
+	} else {
+		fmt.Fprintf(out, "GPS Latitude: %14.8f°\n", metadata.GPSLocation.Latitude)
+		fmt.Fprintf(out, "GPS Longitude: %14.8f°\n", metadata.GPSLocation.Longitude)
+		fmt.Fprintf(out, "GPS Altitude: %14.2f m\n\n", metadata.GPSLocation.Altitude)
+	}
 
 	// Display device configuration prominently
-	fmt.Printf("📻 Device Configuration:\n")
-	fmt.Printf("Device Name: %s\n", deviceSettings.Name)
-	fmt.Printf("Gain Setting: %s\n", deviceSettings.Gain)
-	fmt.Printf("Gain Mode: %s\n", deviceSettings.GainMode)
-	fmt.Printf("Bias Tee: %s\n\n", deviceSettings.BiasTee)
+	fmt.Fprintf(out, "📻 Device Configuration:\n")
+	fmt.Fprintf(out, "Device Name: %s\n", deviceSettings.Name)
+	fmt.Fprintf(out, "Tuner: %s\n", deviceSettings.TunerType)
+	fmt.Fprintf(out, "Gain Setting: %s\n", deviceSettings.Gain)
+	fmt.Fprintf(out, "Gain Step: %s\n", deviceSettings.GainStep)
+	fmt.Fprintf(out, "Gain Mode: %s\n", deviceSettings.GainMode)
+	fmt.Fprintf(out, "Bias Tee: %s\n\n", deviceSettings.BiasTee)
 }
 
 // displayDeviceAnalysis shows detailed analysis of device configuration
 func displayDeviceAnalysis(deviceSettings DeviceSettings) {
-	fmt.Printf("🔧 Device Configuration Analysis:\n")
-	fmt.Printf("┌─────────────────────────┬─────────────────────────────────────────┐\n")
-	fmt.Printf("│ Analysis                │ Information                             │\n")
-	fmt.Printf("├─────────────────────────┼─────────────────────────────────────────┤\n")
+	fmt.Fprintf(out, "🔧 Device Configuration Analysis:\n")
+	fmt.Fprintf(out, "┌─────────────────────────┬─────────────────────────────────────────┐\n")
+	fmt.Fprintf(out, "│ Analysis                │ Information                             │\n")
+	fmt.Fprintf(out, "├─────────────────────────┼─────────────────────────────────────────┤\n")
 
 	// Analyze gain mode
 	var gainAnalysis string
@@ -452,11 +605,11 @@ func displayDeviceAnalysis(deviceSettings DeviceSettings) {
 	default:
 		gainAnalysis = "Unknown gain mode"
 	}
-	fmt.Printf("Gain Control: %s\n", gainAnalysis)
+	fmt.Fprintf(out, "Gain Control: %s\n", gainAnalysis)
 
 	// Analyze gain setting if available
 	if deviceSettings.Gain != "Unknown" && deviceSettings.GainMode == "manual" {
-		fmt.Printf("Gain Impact: Higher values increase sensitivity but may introduce noise\n")
+		fmt.Fprintf(out, "Gain Impact: Higher values increase sensitivity but may introduce noise\n")
 	}
 
 	// Analyze bias tee
@@ -469,60 +622,69 @@ func displayDeviceAnalysis(deviceSettings DeviceSettings) {
 	default:
 		biasAnalysis = "Bias tee status unknown"
 	}
-	fmt.Printf("Bias Tee Status: %s\n", biasAnalysis)
+	fmt.Fprintf(out, "Bias Tee Status: %s\n", biasAnalysis)
+	fmt.Fprintf(out, "Supported Gains: %s\n", deviceSettings.GainLadder)
+	fmt.Fprintf(out, "External LNA Gain: %s\n", deviceSettings.LNAGain)
+	fmt.Fprintf(out, "Antenna: %s\n", deviceSettings.Antenna)
 
 	// Recommendations
-	fmt.Printf("\nRecommendations:\n")
+	fmt.Fprintf(out, "\nRecommendations:\n")
 	if deviceSettings.GainMode == "auto" {
-		fmt.Printf("• AGC may cause gain variations\n")
-		fmt.Printf("• Consider manual gain for consistency\n")
+		fmt.Fprintf(out, "• AGC may cause gain variations\n")
+		fmt.Fprintf(out, "• Consider manual gain for consistency\n")
 	} else if deviceSettings.GainMode == "manual" {
-		fmt.Printf("• Manual gain provides consistency\n")
-		fmt.Printf("• Monitor for clipping or noise\n")
+		fmt.Fprintf(out, "• Manual gain provides consistency\n")
+		fmt.Fprintf(out, "• Monitor for clipping or noise\n")
 	}
 
 	if deviceSettings.BiasTee == "on" {
-		fmt.Printf("• Bias tee active - check LNA power\n")
+		fmt.Fprintf(out, "• Bias tee active - check LNA power\n")
+	}
+	if deviceSettings.LNAGain != "Not recorded" {
+		fmt.Fprintf(out, "• Subtract the LNA gain when comparing absolute levels across stations\n")
 	}
 
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	// Show typical RTL-SDR gain values for reference
-	fmt.Printf("📊 RTL-SDR Gain Reference:\n")
-	fmt.Printf("0.0 - 10.0 dB: Strong signals, prevent overload\n")
-	fmt.Printf("10.0 - 30.0 dB: Medium signals, general purpose\n")
-	fmt.Printf("30.0 - 50.0 dB: Weak signals, maximum sensitivity\n")
-	fmt.Printf("AUTO (AGC): Automatic adjustment based on signal\n\n")
+	fmt.Fprintf(out, "📊 RTL-SDR Gain Reference:\n")
+	fmt.Fprintf(out, "0.0 - 10.0 dB: Strong signals, prevent overload\n")
+	fmt.Fprintf(out, "10.0 - 30.0 dB: Medium signals, general purpose\n")
+	fmt.Fprintf(out, "30.0 - 50.0 dB: Weak signals, maximum sensitivity\n")
+	fmt.Fprintf(out, "AUTO (AGC): Automatic adjustment based on signal\n\n")
 }
 
 // displaySampleInfo shows information about the IQ samples
-func displaySampleInfo(sampleCount int, sampleRate uint32) {
-	duration := float64(sampleCount) / float64(sampleRate)
-
-	fmt.Printf("📡 Sample Information:\n")
-	fmt.Printf("Total Samples: %d\n", sampleCount)
-	fmt.Printf("Sample Type: Complex64 (32-bit I + 32-bit Q)\n")
-	fmt.Printf("Data Size: %.2f MB\n", float64(sampleCount*8)/(1024*1024))
-	fmt.Printf("Collection Duration: %.3f seconds\n\n", duration)
+func displaySampleInfo(sampleCount int, sampleRate uint32, format filewriter.SampleFormat) {
+	fmt.Fprintf(out, "📡 Sample Information:\n")
+	fmt.Fprintf(out, "Total Samples: %d\n", sampleCount)
+	switch format {
+	case filewriter.SampleFormatInt16:
+		fmt.Fprintf(out, "Sample Type: Int16 (16-bit I + 16-bit Q)\n")
+	case filewriter.SampleFormatUint8:
+		fmt.Fprintf(out, "Sample Type: Uint8 (raw RTL-SDR 8-bit I + 8-bit Q)\n")
+	default:
+		fmt.Fprintf(out, "Sample Type: Complex64 (32-bit I + 32-bit Q)\n")
+	}
+	fmt.Fprintf(out, "Data Size: %.2f MB\n", float64(sampleCount*format.Size())/(1024*1024))
+	if sampleRate == 0 {
+		// A corrupt or partially written header; dividing would print NaN/+Inf
+		fmt.Fprintf(out, "Collection Duration: unknown (invalid zero sample rate in file)\n\n")
+		return
+	}
+	fmt.Fprintf(out, "Collection Duration: %.3f seconds\n\n", float64(sampleCount)/float64(sampleRate))
 }
 
 // displaySamplesStreaming reads and displays samples as they're read from file
-func displaySamplesStreaming(filename string, metadata *filewriter.Metadata, totalSamples int) error {
-	fmt.Printf("📈 IQ Sample Data (streaming all %d samples):\n", totalSamples)
-	fmt.Printf("%-8s %-14s %-14s %-14s %-12s\n", "#", "I (Real)", "Q (Imag)", "Magnitude", "Phase (°)")
+func displaySamplesStreaming(filename string, totalSamples int) error {
+	fmt.Fprintf(out, "📈 IQ Sample Data (streaming all %d samples):\n", totalSamples)
+	fmt.Fprintf(out, "%-8s %-14s %-14s %-14s %-12s\n", "#", "I (Real)", "Q (Imag)", "Magnitude", "Phase (°)")
 
-	file, err := os.Open(filename)
+	reader, err := openSampleReader(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
 	}
-	defer file.Close()
-
-	// Seek to start of sample data
-	headerSize := int64(5 + 2 + 8 + 4 + 12 + 24 + 12 + 1 + len(metadata.DeviceInfo) + 1 + len(metadata.CollectionID) + 4)
-	_, err = file.Seek(headerSize, 0)
-	if err != nil {
-		return fmt.Errorf("failed to seek to sample data: %w", err)
-	}
+	defer reader.Close()
 
 	// Stream samples and display them
 	const rad2deg = 180.0 / math.Pi
@@ -530,55 +692,56 @@ func displaySamplesStreaming(filename string, metadata *filewriter.Metadata, tot
 	var batch strings.Builder
 	batch.Grow(batchSize * 80) // Estimate 80 chars per row
 
+	buf := make([]complex64, batchSize)
 	index := 0
 	for {
-		var real, imag float32
-		if err := binary.Read(file, binary.LittleEndian, &real); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to read real part: %w", err)
+		n, err := reader.Read(buf)
+		if err == io.EOF {
+			break
 		}
-		if err := binary.Read(file, binary.LittleEndian, &imag); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to read imaginary part: %w", err)
+		if err != nil {
+			return fmt.Errorf("failed to read sample: %w", err)
 		}
 
-		realPart := float64(real)
-		imagPart := float64(imag)
-		magnitude := math.Sqrt(realPart*realPart + imagPart*imagPart)
-		phase := math.Atan2(imagPart, realPart) * rad2deg
-
-		batch.WriteString(fmt.Sprintf("%-8d %-14.6f %-14.6f %-14.6f %-12.2f\n",
-			index, realPart, imagPart, magnitude, phase))
+		for _, sample := range buf[:n] {
+			realPart := float64(real(sample))
+			imagPart := float64(imag(sample))
+			magnitude := math.Sqrt(realPart*realPart + imagPart*imagPart)
+			phase := math.Atan2(imagPart, realPart) * rad2deg
 
-		index++
-
-		// Output batch when it's full
-		if index%batchSize == 0 {
-			fmt.Print(batch.String())
-			batch.Reset()
+			batch.WriteString(fmt.Sprintf("%-8d %-14.6f %-14.6f %-14.6f %-12.2f\n",
+				index, realPart, imagPart, magnitude, phase))
+			index++
 		}
+
+		// Output each full batch
+		fmt.Fprint(out, batch.String())
+		batch.Reset()
 	}
 
 	// Output remaining batch
 	if batch.Len() > 0 {
-		fmt.Print(batch.String())
+		fmt.Fprint(out, batch.String())
 	}
 
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	return nil
 }
 
 // displayHexStreaming reads and displays hex dump as samples are read from file
 func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSamples int) error {
-	totalBytes := totalSamples * 8
-	fmt.Printf("🔍 Hex Dump of Raw Sample Data (streaming all %d bytes):\n", totalBytes)
-	fmt.Printf("Each complex64 sample = 8 bytes (4-byte float I + 4-byte float Q)\n")
-	fmt.Printf("%-9s %-48s %s\n", "Address", "00 01 02 03 04 05 06 07 08 09 0A 0B 0C 0D 0E 0F", "ASCII")
+	totalBytes := totalSamples * metadata.SampleFormat.Size()
+	fmt.Fprintf(out, "🔍 Hex Dump of Raw Sample Data (streaming all %d bytes):\n", totalBytes)
+	switch metadata.SampleFormat {
+	case filewriter.SampleFormatInt16:
+		fmt.Fprintf(out, "Each int16 sample = 4 bytes (2-byte int I + 2-byte int Q)\n")
+	case filewriter.SampleFormatUint8:
+		fmt.Fprintf(out, "Each uint8 sample = 2 bytes (1-byte I + 1-byte Q, 127.5 = zero)\n")
+	default:
+		fmt.Fprintf(out, "Each complex64 sample = 8 bytes (4-byte float I + 4-byte float Q)\n")
+	}
+	fmt.Fprintf(out, "%-9s %-48s %s\n", "Address", "00 01 02 03 04 05 06 07 08 09 0A 0B 0C 0D 0E 0F", "ASCII")
 
 	file, err := os.Open(filename)
 	if err != nil {
@@ -587,8 +750,7 @@ func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSa
 	defer file.Close()
 
 	// Seek to start of sample data
-	headerSize := int64(5 + 2 + 8 + 4 + 12 + 24 + 12 + 1 + len(metadata.DeviceInfo) + 1 + len(metadata.CollectionID) + 4)
-	_, err = file.Seek(headerSize, 0)
+	_, err = file.Seek(filewriter.HeaderSize(metadata), 0)
 	if err != nil {
 		return fmt.Errorf("failed to seek to sample data: %w", err)
 	}
@@ -596,7 +758,7 @@ func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSa
 	// Read and display in 16-byte rows
 	var buffer [16]byte
 	offset := 0
-	showInterpretation := true
+	showInterpretation := metadata.SampleFormat == filewriter.SampleFormatComplex64
 	interpretCount := 0
 
 	for {
@@ -629,7 +791,7 @@ func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSa
 			}
 		}
 
-		fmt.Printf("%08x %-48s %s\n", offset, hexPart.String(), asciiPart.String())
+		fmt.Fprintf(out, "%08x %-48s %s\n", offset, hexPart.String(), asciiPart.String())
 
 		// Show sample interpretation for first few complete samples
 		if showInterpretation && offset%8 == 0 && n >= 8 && interpretCount < 4 {
@@ -644,10 +806,10 @@ func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSa
 
 				sampleNum := (offset + i) / 8
 				if interpretCount == 0 {
-					fmt.Printf("\nSample Interpretation (first few samples):\n")
+					fmt.Fprintf(out, "\nSample Interpretation (first few samples):\n")
 				}
 
-				fmt.Printf("Sample %d: I=%f Q=%f | I bytes: %02x %02x %02x %02x | Q bytes: %02x %02x %02x %02x\n",
+				fmt.Fprintf(out, "Sample %d: I=%f Q=%f | I bytes: %02x %02x %02x %02x | Q bytes: %02x %02x %02x %02x\n",
 					sampleNum, realVal, imagVal,
 					buffer[i], buffer[i+1], buffer[i+2], buffer[i+3],
 					buffer[i+4], buffer[i+5], buffer[i+6], buffer[i+7])
@@ -657,7 +819,7 @@ func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSa
 
 			if interpretCount >= 4 {
 				showInterpretation = false
-				fmt.Println()
+				fmt.Fprintln(out)
 			}
 		}
 
@@ -668,20 +830,14 @@ func displayHexStreaming(filename string, metadata *filewriter.Metadata, totalSa
 		}
 	}
 
-	fmt.Println()
+	fmt.Fprintln(out)
 	return nil
 }
 
-// displayGraph creates an ASCII graph of signal magnitude over time
-func displayGraph(samples []complex64, sampleRate uint32, scale string) {
-	if len(samples) == 0 {
-		fmt.Printf("📈 Signal Graph: No samples to display\n\n")
-		return
-	}
-
-	// Calculate values based on scale type
+// scaleSamples converts samples to the values plotted for a graph scale
+// (magnitude, db, or power), returning them with the axis and unit labels
+func scaleSamples(samples []complex64, scale string) ([]float64, string, string) {
 	values := make([]float64, len(samples))
-	minVal, maxVal := math.Inf(1), math.Inf(-1)
 	scaleLabel := ""
 	unitLabel := ""
 
@@ -690,7 +846,7 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 		imagPart := float64(imag(sample))
 		magnitude := math.Sqrt(realPart*realPart + imagPart*imagPart)
 		power := realPart*realPart + imagPart*imagPart
-		
+
 		var val float64
 		switch scale {
 		case "db", "dB":
@@ -713,8 +869,22 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 			scaleLabel = "Signal Magnitude"
 			unitLabel = ""
 		}
-		
 		values[i] = val
+	}
+	return values, scaleLabel, unitLabel
+}
+
+// displayGraph creates an ASCII graph of signal magnitude over time
+// totalTime is the capture duration the samples span, which may be strided
+func displayGraph(samples []complex64, totalTime float64, sampleRate uint32, scale string) {
+	if len(samples) == 0 {
+		fmt.Fprintf(out, "📈 Signal Graph: No samples to display\n\n")
+		return
+	}
+
+	values, scaleLabel, unitLabel := scaleSamples(samples, scale)
+	minVal, maxVal := math.Inf(1), math.Inf(-1)
+	for _, val := range values {
 		if val < minVal {
 			minVal = val
 		}
@@ -728,19 +898,16 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 		maxVal = minVal + 1e-6
 	}
 
-	// Calculate time span
-	totalTime := float64(len(samples)) / float64(sampleRate)
-
-	fmt.Printf("📈 %s Over Time:\n", scaleLabel)
-	fmt.Printf("Samples: %d | Duration: %.3f seconds | Sample Rate: %.3f MSps\n",
+	fmt.Fprintf(out, "📈 %s Over Time:\n", scaleLabel)
+	fmt.Fprintf(out, "Samples: %d | Duration: %.3f seconds | Sample Rate: %.3f MSps\n",
 		len(samples), totalTime, float64(sampleRate)/1e6)
 	
 	if unitLabel != "" {
-		fmt.Printf("%s Range: %.2f to %.2f %s\n", scaleLabel, minVal, maxVal, unitLabel)
+		fmt.Fprintf(out, "%s Range: %.2f to %.2f %s\n", scaleLabel, minVal, maxVal, unitLabel)
 	} else {
-		fmt.Printf("%s Range: %.6f to %.6f\n", scaleLabel, minVal, maxVal)
+		fmt.Fprintf(out, "%s Range: %.6f to %.6f\n", scaleLabel, minVal, maxVal)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	// Create graph grid
 	graph := make([][]rune, graphHeight)
@@ -778,7 +945,7 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 	}
 
 	// Display the graph with y-axis labels
-	fmt.Printf("%s\n", scaleLabel)
+	fmt.Fprintf(out, "%s\n", scaleLabel)
 	for i, row := range graph {
 		// Calculate the value for this row
 		normalizedY := float64(graphHeight-1-i) / float64(graphHeight-1)
@@ -786,23 +953,23 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 
 		// Print y-axis label and graph row
 		if unitLabel != "" {
-			fmt.Printf("%8.2f |", yValue)
+			fmt.Fprintf(out, "%8.2f |", yValue)
 		} else {
-			fmt.Printf("%8.4f |", yValue)
+			fmt.Fprintf(out, "%8.4f |", yValue)
 		}
 		for _, char := range row {
-			fmt.Print(string(char))
+			fmt.Fprint(out, string(char))
 		}
-		fmt.Println("|")
+		fmt.Fprintln(out, "|")
 	}
 
 	// Print x-axis
-	fmt.Printf("         +")
-	fmt.Print(strings.Repeat("-", graphWidth))
-	fmt.Println("+")
+	fmt.Fprintf(out, "         +")
+	fmt.Fprint(out, strings.Repeat("-", graphWidth))
+	fmt.Fprintln(out, "+")
 
 	// Print time labels
-	fmt.Printf("         0")
+	fmt.Fprintf(out, "         0")
 	midTime := totalTime / 2
 	endTime := totalTime
 
@@ -812,19 +979,19 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 
 	// Print middle time label
 	midLabel := fmt.Sprintf("%.3fs", midTime)
-	fmt.Print(strings.Repeat(" ", midPos-len(midLabel)/2))
-	fmt.Print(midLabel)
+	fmt.Fprint(out, strings.Repeat(" ", midPos-len(midLabel)/2))
+	fmt.Fprint(out, midLabel)
 
 	// Print end time label
 	endLabel := fmt.Sprintf("%.3fs", endTime)
-	fmt.Print(strings.Repeat(" ", endPos-midPos-len(endLabel)))
-	fmt.Print(endLabel)
-	fmt.Println()
+	fmt.Fprint(out, strings.Repeat(" ", endPos-midPos-len(endLabel)))
+	fmt.Fprint(out, endLabel)
+	fmt.Fprintln(out)
 
-	fmt.Printf("\nLegend: * = data point, # = multiple points, Time →\n\n")
+	fmt.Fprintf(out, "\nLegend: * = data point, # = multiple points, Time →\n\n")
 
 	// Additional analysis
-	fmt.Printf("📊 Signal Analysis:\n")
+	fmt.Fprintf(out, "📊 Signal Analysis:\n")
 	avgVal := 0.0
 	for _, val := range values {
 		avgVal += val
@@ -833,19 +1000,19 @@ func displayGraph(samples []complex64, sampleRate uint32, scale string) {
 
 	switch scale {
 	case "db", "dB":
-		fmt.Printf("   Average Signal: %.2f dB\n", avgVal)
-		fmt.Printf("   Peak Signal: %.2f dB\n", maxVal)
-		fmt.Printf("   Dynamic Range: %.2f dB\n", maxVal-minVal)
+		fmt.Fprintf(out, "   Average Signal: %.2f dB\n", avgVal)
+		fmt.Fprintf(out, "   Peak Signal: %.2f dB\n", maxVal)
+		fmt.Fprintf(out, "   Dynamic Range: %.2f dB\n", maxVal-minVal)
 	case "power":
-		fmt.Printf("   Average Power: %.6f\n", avgVal)
-		fmt.Printf("   Peak Power: %.6f\n", maxVal)
-		fmt.Printf("   Dynamic Range: %.2f dB\n", 10*math.Log10(maxVal/minVal))
+		fmt.Fprintf(out, "   Average Power: %.6f\n", avgVal)
+		fmt.Fprintf(out, "   Peak Power: %.6f\n", maxVal)
+		fmt.Fprintf(out, "   Dynamic Range: %.2f dB\n", 10*math.Log10(maxVal/minVal))
 	default: // magnitude
-		fmt.Printf("   Average Magnitude: %.6f\n", avgVal)
-		fmt.Printf("   Peak Magnitude: %.6f\n", maxVal)
-		fmt.Printf("   Dynamic Range: %.2f dB\n", 20*math.Log10(maxVal/minVal))
+		fmt.Fprintf(out, "   Average Magnitude: %.6f\n", avgVal)
+		fmt.Fprintf(out, "   Peak Magnitude: %.6f\n", maxVal)
+		fmt.Fprintf(out, "   Dynamic Range: %.2f dB\n", 20*math.Log10(maxVal/minVal))
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 }
 
 // assessSignalQuality provides a simple quality assessment based on signal metrics
@@ -913,7 +1080,7 @@ func assessSignalQuality(snrDb, signalPowerDb, meanMag, dynamicRange float64) st
 // displayStatistics shows statistical analysis of the samples
 func displayStatistics(samples []complex64) {
 	if len(samples) == 0 {
-		fmt.Printf("📊 Statistics: No samples to analyze\n\n")
+		fmt.Fprintf(out, "📊 Statistics: No samples to analyze\n\n")
 		return
 	}
 
@@ -965,20 +1132,44 @@ func displayStatistics(samples []complex64) {
 	signalStrengthDbm := 10*math.Log10(meanPower) - 30 // Convert to dBm (assuming 50-ohm impedance)
 
 	// Calculate noise floor estimation using lowest 10% of magnitude samples
-	// Sort magnitudes to find noise floor
-	sortedMags := make([]float64, len(magnitudes))
-	copy(sortedMags, magnitudes)
+	noiseFloorPower := estimateNoiseFloorPower(magnitudes)
+	noiseFloorDb := 10 * math.Log10(noiseFloorPower)
 
-	// Simple bubble sort for the bottom 10%
-	n := len(sortedMags)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if sortedMags[j] > sortedMags[j+1] {
-				sortedMags[j], sortedMags[j+1] = sortedMags[j+1], sortedMags[j]
-			}
-		}
+	// Calculate Signal-to-Noise Ratio
+	signalPowerDb := 10 * math.Log10(meanPower)
+	snrDb := signalPowerDb - noiseFloorDb
+
+	fmt.Fprintf(out, "📊 Statistical Analysis:\n")
+	fmt.Fprintf(out, "Mean I (Real): %12.6f\n", meanI)
+	fmt.Fprintf(out, "Mean Q (Imaginary): %12.6f\n", meanQ)
+	fmt.Fprintf(out, "I Variance: %12.6f\n", varI)
+	fmt.Fprintf(out, "Q Variance: %12.6f\n", varQ)
+	fmt.Fprintf(out, "Mean Magnitude: %12.6f\n", meanMag)
+	fmt.Fprintf(out, "Min Magnitude: %12.6f\n", minMag)
+	fmt.Fprintf(out, "Max Magnitude: %12.6f\n", maxMag)
+	fmt.Fprintf(out, "RMS Amplitude: %12.6f\n", rmsAmplitude)
+	fmt.Fprintf(out, "Mean Power: %12.6f\n", meanPower)
+	fmt.Fprintf(out, "Signal Power (dB): %12.2f dB\n", signalPowerDb)
+	fmt.Fprintf(out, "Signal Strength (dBm): %12.2f dBm\n", signalStrengthDbm)
+	fmt.Fprintf(out, "Noise Floor (dB): %12.2f dB\n", noiseFloorDb)
+	fmt.Fprintf(out, "Signal-to-Noise Ratio: %12.2f dB\n", snrDb)
+	
+	// Calculate and display overall signal quality
+	quality := assessSignalQuality(snrDb, signalPowerDb, meanMag, maxMag-minMag)
+	fmt.Fprintf(out, "Overall Signal Quality: %s\n\n", quality)
+}
+
+// estimateNoiseFloorPower returns the mean power of the weakest 10% of the given
+// magnitudes (at least 10 values), which approximates the receiver noise floor
+func estimateNoiseFloorPower(magnitudes []float64) float64 {
+	if len(magnitudes) == 0 {
+		return 0
 	}
 
+	sortedMags := make([]float64, len(magnitudes))
+	copy(sortedMags, magnitudes)
+	sort.Float64s(sortedMags)
+
 	// Use bottom 10% for noise floor estimation
 	noiseFloorSamples := int(float64(len(sortedMags)) * 0.1)
 	if noiseFloorSamples < 10 {
@@ -992,36 +1183,13 @@ func displayStatistics(samples []complex64) {
 	for i := 0; i < noiseFloorSamples; i++ {
 		noiseFloorSum += sortedMags[i] * sortedMags[i] // Convert to power
 	}
-	noiseFloorPower := noiseFloorSum / float64(noiseFloorSamples)
-	noiseFloorDb := 10 * math.Log10(noiseFloorPower)
-
-	// Calculate Signal-to-Noise Ratio
-	signalPowerDb := 10 * math.Log10(meanPower)
-	snrDb := signalPowerDb - noiseFloorDb
-
-	fmt.Printf("📊 Statistical Analysis:\n")
-	fmt.Printf("Mean I (Real): %12.6f\n", meanI)
-	fmt.Printf("Mean Q (Imaginary): %12.6f\n", meanQ)
-	fmt.Printf("I Variance: %12.6f\n", varI)
-	fmt.Printf("Q Variance: %12.6f\n", varQ)
-	fmt.Printf("Mean Magnitude: %12.6f\n", meanMag)
-	fmt.Printf("Min Magnitude: %12.6f\n", minMag)
-	fmt.Printf("Max Magnitude: %12.6f\n", maxMag)
-	fmt.Printf("RMS Amplitude: %12.6f\n", rmsAmplitude)
-	fmt.Printf("Mean Power: %12.6f\n", meanPower)
-	fmt.Printf("Signal Power (dB): %12.2f dB\n", signalPowerDb)
-	fmt.Printf("Signal Strength (dBm): %12.2f dBm\n", signalStrengthDbm)
-	fmt.Printf("Noise Floor (dB): %12.2f dB\n", noiseFloorDb)
-	fmt.Printf("Signal-to-Noise Ratio: %12.2f dB\n", snrDb)
-	
-	// Calculate and display overall signal quality
-	quality := assessSignalQuality(snrDb, signalPowerDb, meanMag, maxMag-minMag)
-	fmt.Printf("Overall Signal Quality: %s\n\n", quality)
+	return noiseFloorSum / float64(noiseFloorSamples)
 }
 
 // main is the entry point of the application
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		console.Exit(1)
 	}
+	console.Flush()
 }
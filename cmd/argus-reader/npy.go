@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// npyMagic starts every NumPy .npy file, followed by the format version 1.0
+const npyMagic = "\x93NUMPY\x01\x00"
+
+// npyHeader returns the .npy preamble and header describing a one-dimensional
+// little-endian complex64 array of count elements. The header is padded to
+// the length the largest possible count needs, so the count can be rewritten
+// in place once the samples actually present are known.
+func npyHeader(count uint64) []byte {
+	dict := fmt.Sprintf("{'descr': '<c8', 'fortran_order': False, 'shape': (%d,), }", count)
+	maxDict := len(fmt.Sprintf("{'descr': '<c8', 'fortran_order': False, 'shape': (%d,), }", uint64(math.MaxUint64)))
+
+	// Preamble, 2-byte header length and header end on a 64-byte boundary
+	total := (len(npyMagic) + 2 + maxDict + 1 + 63) / 64 * 64
+	headerLen := total - len(npyMagic) - 2
+
+	header := make([]byte, 0, total)
+	header = append(header, npyMagic...)
+	header = binary.LittleEndian.AppendUint16(header, uint16(headerLen))
+	header = append(header, dict...)
+	header = append(header, strings.Repeat(" ", headerLen-len(dict)-1)...)
+	return append(header, '\n')
+}
+
+// writeNPY streams the samples of filename to outputFile as a NumPy .npy
+// array of complex64, which numpy.load reads directly
+func writeNPY(filename, outputFile string) error {
+	reader, err := openSampleReader(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create NumPy file: %w", err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+
+	// Written with the header sample count, then corrected below if the file
+	// holds a different number of samples
+	if _, err := writer.Write(npyHeader(uint64(reader.SampleCount()))); err != nil {
+		return fmt.Errorf("failed to write NumPy file: %w", err)
+	}
+
+	samples := make([]complex64, convertChunk)
+	encoded := make([]byte, convertChunk*8)
+	written := 0
+	for {
+		n, err := reader.Read(samples)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, s := range samples[:n] {
+			binary.LittleEndian.PutUint32(encoded[i*8:], math.Float32bits(real(s)))
+			binary.LittleEndian.PutUint32(encoded[i*8+4:], math.Float32bits(imag(s)))
+		}
+		if _, err := writer.Write(encoded[:n*8]); err != nil {
+			return fmt.Errorf("failed to write NumPy file: %w", err)
+		}
+		written += n
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write NumPy file: %w", err)
+	}
+
+	if written != int(reader.SampleCount()) {
+		fmt.Fprintf(out, "⚠️  Header records %d samples but the file holds %d; the array has the samples present\n", reader.SampleCount(), written)
+		if _, err := file.WriteAt(npyHeader(uint64(written)), 0); err != nil {
+			return fmt.Errorf("failed to write NumPy file: %w", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close NumPy file: %w", err)
+	}
+
+	fmt.Fprintf(out, "🐍 NumPy array written to %s (%d complex64 samples; load with numpy.load)\n\n", outputFile, written)
+	return nil
+}
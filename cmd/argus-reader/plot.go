@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"argus-collector/internal/filewriter"
+)
+
+// Plot image layout in pixels; each panel is one chart stacked vertically
+const (
+	plotWidth        = 1000
+	plotPanelHeight  = 360
+	plotMarginLeft   = 90
+	plotMarginRight  = 24
+	plotMarginTop    = 36
+	plotMarginBottom = 48
+	plotTicks        = 6
+)
+
+var (
+	plotBackground = color.RGBA{255, 255, 255, 255}
+	plotAxis       = color.RGBA{40, 40, 40, 255}
+	plotGrid       = color.RGBA{225, 225, 225, 255}
+	plotLine       = color.RGBA{31, 119, 180, 255}
+)
+
+// plotPanel is one chart of a plot image: a line of Y against X
+type plotPanel struct {
+	Title  string
+	XLabel string
+	X, Y   []float64
+}
+
+// writePlotImage renders the signal over time, and optionally its power
+// spectral density, to outputFile. The time panel uses the same strided
+// samples as the ASCII graph. A .svg file is written as SVG, anything else
+// as PNG.
+func writePlotImage(filename string, metadata *filewriter.Metadata, totalSamples int, count int, scale string, spectrum bool, outputFile string) error {
+	if metadata.SampleRate == 0 {
+		return fmt.Errorf("cannot plot: invalid zero sample rate in file")
+	}
+	count = min(count, totalSamples)
+
+	fmt.Fprintf(out, "⏳ Sampling %d points across the entire capture for plot...\n", count)
+	samples, err := readStridedSamples(filename, totalSamples, count)
+	if err != nil {
+		return fmt.Errorf("failed to read plot samples: %w", err)
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("no samples to plot")
+	}
+
+	// Strided sample i was read from index i*totalSamples/count
+	totalTime := float64(totalSamples) / float64(metadata.SampleRate)
+	values, scaleLabel, _ := scaleSamples(samples, scale)
+	times := make([]float64, len(values))
+	for i := range times {
+		times[i] = float64(i) * totalTime / float64(count)
+	}
+	panels := []plotPanel{{
+		Title:  fmt.Sprintf("%s over time - %.3f MHz", scaleLabel, float64(metadata.Frequency)/1e6),
+		XLabel: "Time (s)",
+		X:      times,
+		Y:      values,
+	}}
+
+	if spectrum {
+		psd, err := computePSD(filename, metadata, totalSamples, psdFFTSize)
+		if err != nil {
+			return fmt.Errorf("failed to compute spectrum: %w", err)
+		}
+		freqs := make([]float64, len(psd.Frequencies))
+		for i, freq := range psd.Frequencies {
+			freqs[i] = freq / 1e6
+		}
+		panels = append(panels, plotPanel{
+			Title:  fmt.Sprintf("Power spectral density (dB/Hz) - %d segments averaged", psd.Segments),
+			XLabel: "Frequency (MHz)",
+			X:      freqs,
+			Y:      psd.PowerDb,
+		})
+	}
+
+	if strings.EqualFold(filepath.Ext(outputFile), ".svg") {
+		err = writePlotSVG(outputFile, panels)
+	} else {
+		err = writePlotPNG(outputFile, panels)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "🖼️  Plot written to %s\n\n", outputFile)
+	return nil
+}
+
+// plotAxes holds the data range and tick marks of one panel
+type plotAxes struct {
+	xMin, xMax, yMin, yMax float64
+	xTicks, yTicks         []float64
+	xStep, yStep           float64
+}
+
+// newPlotAxes chooses rounded axis ranges and ticks that cover the panel data
+func newPlotAxes(panel plotPanel) plotAxes {
+	var axes plotAxes
+	axes.xMin, axes.xMax = valueRange(panel.X)
+	axes.yMin, axes.yMax = valueRange(panel.Y)
+	axes.xTicks, axes.xStep = niceTicks(axes.xMin, axes.xMax)
+	axes.yTicks, axes.yStep = niceTicks(axes.yMin, axes.yMax)
+
+	// Widen the y range to whole ticks so the line does not touch the frame
+	axes.yMin = math.Min(axes.yMin, axes.yTicks[0])
+	axes.yMax = math.Max(axes.yMax, axes.yTicks[len(axes.yTicks)-1])
+	return axes
+}
+
+// valueRange returns the smallest and largest value, widened when they are equal
+func valueRange(values []float64) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if hi == lo {
+		pad := math.Max(math.Abs(lo)*0.01, 1e-6)
+		lo, hi = lo-pad, hi+pad
+	}
+	return lo, hi
+}
+
+// niceTicks returns about plotTicks tick values spaced 1, 2, or 5 times a
+// power of ten, from the tick at or below lo to the tick at or above hi
+func niceTicks(lo, hi float64) ([]float64, float64) {
+	raw := (hi - lo) / plotTicks
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude * 10
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*magnitude {
+			step = m * magnitude
+			break
+		}
+	}
+
+	var ticks []float64
+	for tick := math.Floor(lo/step) * step; tick <= hi+step/2; tick += step {
+		ticks = append(ticks, tick)
+	}
+	return ticks, step
+}
+
+// formatTick formats a tick value with as many decimals as the step needs
+func formatTick(value, step float64) string {
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	if math.Abs(value) < step/2 {
+		value = 0 // Avoid "-0.0" from accumulated rounding
+	}
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
+
+// plotArea returns the pixel rectangle of panel index's chart area
+func plotArea(index int) image.Rectangle {
+	top := index * plotPanelHeight
+	return image.Rect(plotMarginLeft, top+plotMarginTop, plotWidth-plotMarginRight, top+plotPanelHeight-plotMarginBottom)
+}
+
+// toPixel maps a data point to pixel coordinates within area
+func (a plotAxes) toPixel(area image.Rectangle, x, y float64) (float64, float64) {
+	px := float64(area.Min.X) + (x-a.xMin)/(a.xMax-a.xMin)*float64(area.Dx())
+	py := float64(area.Max.Y) - (y-a.yMin)/(a.yMax-a.yMin)*float64(area.Dy())
+	return px, py
+}
+
+// writePlotSVG renders panels as an SVG document
+func writePlotSVG(outputFile string, panels []plotPanel) error {
+	out, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create plot file: %w", err)
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	height := plotPanelHeight * len(panels)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		plotWidth, height, plotWidth, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	for i, panel := range panels {
+		axes := newPlotAxes(panel)
+		area := plotArea(i)
+
+		for _, tick := range axes.xTicks {
+			if tick < axes.xMin || tick > axes.xMax {
+				continue
+			}
+			x, _ := axes.toPixel(area, tick, axes.yMin)
+			fmt.Fprintf(w, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#e1e1e1"/>`+"\n", x, area.Min.Y, x, area.Max.Y)
+			fmt.Fprintf(w, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x, area.Max.Y+16, formatTick(tick, axes.xStep))
+		}
+		for _, tick := range axes.yTicks {
+			_, y := axes.toPixel(area, axes.xMin, tick)
+			fmt.Fprintf(w, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e1e1e1"/>`+"\n", area.Min.X, y, area.Max.X, y)
+			fmt.Fprintf(w, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`+"\n", area.Min.X-6, y+4, formatTick(tick, axes.yStep))
+		}
+
+		fmt.Fprintf(w, `<polyline fill="none" stroke="#1f77b4" stroke-width="1" points="`)
+		for j := range panel.X {
+			x, y := axes.toPixel(area, panel.X[j], panel.Y[j])
+			fmt.Fprintf(w, "%.1f,%.1f ", x, y)
+		}
+		fmt.Fprintf(w, `"/>`+"\n")
+
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="#282828"/>`+"\n",
+			area.Min.X, area.Min.Y, area.Dx(), area.Dy())
+		fmt.Fprintf(w, `<text x="%d" y="%d" font-size="14">%s</text>`+"\n", area.Min.X, area.Min.Y-12, html.EscapeString(panel.Title))
+		fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+			(area.Min.X+area.Max.X)/2, area.Max.Y+36, html.EscapeString(panel.XLabel))
+	}
+
+	fmt.Fprintln(w, "</svg>")
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write plot file: %w", err)
+	}
+	return nil
+}
+
+// writePlotPNG renders panels as a PNG image
+func writePlotPNG(outputFile string, panels []plotPanel) error {
+	img := image.NewRGBA(image.Rect(0, 0, plotWidth, plotPanelHeight*len(panels)))
+	draw.Draw(img, img.Bounds(), &image.Uniform{plotBackground}, image.Point{}, draw.Src)
+
+	for i, panel := range panels {
+		axes := newPlotAxes(panel)
+		area := plotArea(i)
+
+		for _, tick := range axes.xTicks {
+			if tick < axes.xMin || tick > axes.xMax {
+				continue
+			}
+			x, _ := axes.toPixel(area, tick, axes.yMin)
+			drawLine(img, x, float64(area.Min.Y), x, float64(area.Max.Y), plotGrid)
+			label := formatTick(tick, axes.xStep)
+			drawText(img, int(x)-textWidth(label)/2, area.Max.Y+8, label, plotAxis)
+		}
+		for _, tick := range axes.yTicks {
+			_, y := axes.toPixel(area, axes.xMin, tick)
+			drawLine(img, float64(area.Min.X), y, float64(area.Max.X), y, plotGrid)
+			label := formatTick(tick, axes.yStep)
+			drawText(img, area.Min.X-8-textWidth(label), int(y)-glyphHeight*glyphScale/2, label, plotAxis)
+		}
+
+		for j := 1; j < len(panel.X); j++ {
+			x0, y0 := axes.toPixel(area, panel.X[j-1], panel.Y[j-1])
+			x1, y1 := axes.toPixel(area, panel.X[j], panel.Y[j])
+			drawLine(img, x0, y0, x1, y1, plotLine)
+		}
+
+		// Frame drawn last so the line cannot cover it
+		minX, minY := float64(area.Min.X), float64(area.Min.Y)
+		maxX, maxY := float64(area.Max.X), float64(area.Max.Y)
+		drawLine(img, minX, minY, maxX, minY, plotAxis)
+		drawLine(img, minX, maxY, maxX, maxY, plotAxis)
+		drawLine(img, minX, minY, minX, maxY, plotAxis)
+		drawLine(img, maxX, minY, maxX, maxY, plotAxis)
+
+		drawText(img, area.Min.X, area.Min.Y-22, panel.Title, plotAxis)
+		drawText(img, (area.Min.X+area.Max.X-textWidth(panel.XLabel))/2, area.Max.Y+28, panel.XLabel, plotAxis)
+	}
+
+	out, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create plot file: %w", err)
+	}
+	defer out.Close()
+
+	if err := png.Encode(out, img); err != nil {
+		return fmt.Errorf("failed to write plot file: %w", err)
+	}
+	return nil
+}
+
+// drawLine draws a one pixel wide line, clipped to the image, stepping along
+// its longer axis
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+	if steps == 0 {
+		img.SetRGBA(int(math.Round(x0)), int(math.Round(y0)), c)
+		return
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		img.SetRGBA(int(math.Round(x0+(x1-x0)*t)), int(math.Round(y0+(y1-y0)*t)), c)
+	}
+}
+
+// The PNG renderer labels axes with a built-in 3x5 pixel font, drawn at
+// glyphScale, since the standard library has no text rendering. Text is
+// drawn in upper case; characters without a glyph are left blank.
+const (
+	glyphWidth   = 3
+	glyphHeight  = 5
+	glyphScale   = 2
+	glyphAdvance = (glyphWidth + 1) * glyphScale
+)
+
+var glyphs = map[rune][glyphHeight]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'A': {".#.", "#.#", "###", "#.#", "#.#"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"},
+	'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "##.", ".##"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#.#", "#.#", "###", "###", "#.#"},
+	'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z': {"###", "..#", ".#.", "#..", "###"},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+	'(': {".#.", "#..", "#..", "#..", ".#."},
+	')': {".#.", "..#", "..#", "..#", ".#."},
+	':': {"...", ".#.", "...", ".#.", "..."},
+}
+
+// textWidth returns the width in pixels of text drawn by drawText
+func textWidth(text string) int {
+	return len([]rune(text)) * glyphAdvance
+}
+
+// drawText draws text with its top-left corner at x, y
+func drawText(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	for _, r := range strings.ToUpper(text) {
+		if glyph, ok := glyphs[r]; ok {
+			for row, bits := range glyph {
+				for col, bit := range bits {
+					if bit != '#' {
+						continue
+					}
+					px, py := x+col*glyphScale, y+row*glyphScale
+					draw.Draw(img, image.Rect(px, py, px+glyphScale, py+glyphScale), &image.Uniform{c}, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += glyphAdvance
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"os"
+	"strconv"
+
+	"argus-collector/internal/dsp"
+	"argus-collector/internal/filewriter"
+)
+
+// psdEstimate is a Welch power spectral density, ordered from the lowest to
+// the highest frequency
+type psdEstimate struct {
+	Frequencies []float64 // Bin center frequencies in Hz
+	PowerDb     []float64 // Power in dB/Hz
+	BinWidth    float64   // Frequency resolution in Hz
+	Segments    int       // Number of periodograms averaged
+}
+
+// writePSDCSV computes a Welch power spectral density over the whole capture and
+// writes frequency vs power (dB/Hz) as CSV
+func writePSDCSV(filename string, metadata *filewriter.Metadata, totalSamples int, fftSize int, outputFile string) error {
+	psd, err := computePSD(filename, metadata, totalSamples, fftSize)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create PSD file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write([]string{"frequency_hz", "power_db"}); err != nil {
+		return err
+	}
+	for i, freq := range psd.Frequencies {
+		if err := writer.Write([]string{
+			strconv.FormatFloat(freq, 'f', 1, 64),
+			strconv.FormatFloat(psd.PowerDb[i], 'f', 2, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "📈 PSD written to %s (%d bins, %.1f Hz resolution, %d segments averaged)\n\n",
+		outputFile, fftSize, psd.BinWidth, psd.Segments)
+	return nil
+}
+
+// computePSD computes a Welch power spectral density over the whole capture.
+// Samples are streamed from disk one segment at a time, so memory use depends
+// only on the FFT size.
+func computePSD(filename string, metadata *filewriter.Metadata, totalSamples int, fftSize int) (*psdEstimate, error) {
+	if fftSize < 16 || fftSize&(fftSize-1) != 0 {
+		return nil, fmt.Errorf("PSD FFT size must be a power of two >= 16, got %d", fftSize)
+	}
+	if totalSamples < fftSize {
+		return nil, fmt.Errorf("not enough samples for PSD: have %d, need at least %d", totalSamples, fftSize)
+	}
+	if metadata.SampleRate == 0 {
+		return nil, fmt.Errorf("cannot compute PSD: invalid zero sample rate in file")
+	}
+
+	reader, err := openSampleReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	// Hann window and its power normalization
+	window := make([]float64, fftSize)
+	windowPower := 0.0
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(fftSize))
+		windowPower += window[i] * window[i]
+	}
+
+	// Welch's method: 50% overlapping segments, averaged periodograms
+	hop := fftSize / 2
+	segment := make([]complex64, fftSize)
+	buffer := make([]complex128, fftSize)
+	accum := make([]float64, fftSize)
+	segments := 0
+
+	// Fill the first segment
+	if err := readFull(reader, segment); err != nil {
+		return nil, fmt.Errorf("failed to read samples: %w", err)
+	}
+
+	fmt.Fprintf(out, "⏳ Computing Welch PSD (FFT size %d, 50%% overlap)...\n", fftSize)
+	for {
+		for i, sample := range segment {
+			buffer[i] = complex(float64(real(sample))*window[i], float64(imag(sample))*window[i])
+		}
+		dsp.FFT(buffer)
+		for i, v := range buffer {
+			mag := cmplx.Abs(v)
+			accum[i] += mag * mag
+		}
+		segments++
+
+		// Slide the window forward by one hop; EOF or a truncated file ends the estimate
+		copy(segment, segment[hop:])
+		if err := readFull(reader, segment[fftSize-hop:]); err != nil {
+			break
+		}
+	}
+
+	// Shift so the output runs from the lowest to the highest frequency,
+	// with bins centered on the tuned frequency
+	sampleRate := float64(metadata.SampleRate)
+	scale := 1.0 / (float64(segments) * sampleRate * windowPower)
+	psd := &psdEstimate{
+		Frequencies: make([]float64, fftSize),
+		PowerDb:     make([]float64, fftSize),
+		BinWidth:    sampleRate / float64(fftSize),
+		Segments:    segments,
+	}
+	for k := 0; k < fftSize; k++ {
+		bin := (k + fftSize/2) % fftSize
+		psd.Frequencies[k] = float64(metadata.Frequency) + float64(k-fftSize/2)*psd.BinWidth
+		psd.PowerDb[k] = 10 * math.Log10(accum[bin]*scale+1e-30)
+	}
+	return psd, nil
+}
+
+// readFull fills buf from reader, returning an error if the file ends first
+func readFull(reader *filewriter.SampleReader, buf []complex64) error {
+	for filled := 0; filled < len(buf); {
+		n, err := reader.Read(buf[filled:])
+		if err != nil {
+			return err
+		}
+		filled += n
+	}
+	return nil
+}
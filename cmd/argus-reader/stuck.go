@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"argus-collector/internal/filewriter"
+)
+
+// maxStuckRunsShown limits the stuck runs listed individually
+const maxStuckRunsShown = 10
+
+// checkStuckSamples scans the whole capture in a streaming pass for long runs
+// of identical consecutive samples, which a USB or driver fault produces
+// when it repeats data, and reports them with the fraction of the capture
+// they cover
+func checkStuckSamples(filename string, metadata *filewriter.Metadata) error {
+	reader, err := openSampleReader(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer reader.Close()
+
+	var detector filewriter.StuckDetector
+	buf := make([]complex64, burstReadChunk)
+	for {
+		n, err := reader.Read(buf)
+		if err != nil {
+			break // EOF or truncated file
+		}
+		detector.Add(buf[:n])
+	}
+	detector.Finish()
+
+	fmt.Fprintf(out, "🔁 Stuck Sample Check (runs of %d or more identical samples):\n", filewriter.StuckRunMinLength)
+	if detector.RunCount == 0 {
+		fmt.Fprintf(out, "No stuck samples found in %d samples\n\n", detector.Samples)
+		return nil
+	}
+
+	fmt.Fprintf(out, "⚠️  %d run(s) covering %d of %d samples (%.2f%% of the capture), longest %d samples\n",
+		detector.RunCount, detector.Affected, detector.Samples, detector.Fraction()*100, detector.Longest)
+	fmt.Fprintf(out, "   Repeated samples point to a USB or driver fault, not signal; they will confuse correlation\n")
+	fmt.Fprintf(out, "%-6s %-14s %-12s %-12s %s\n", "#", "Start Sample", "Start (s)", "Length", "Value")
+	for i, run := range detector.Runs {
+		if i >= maxStuckRunsShown {
+			fmt.Fprintf(out, "... %d more runs not shown\n", detector.RunCount-maxStuckRunsShown)
+			break
+		}
+		start := "-"
+		if metadata.SampleRate > 0 {
+			start = fmt.Sprintf("%.6f", float64(run.Start)/float64(metadata.SampleRate))
+		}
+		fmt.Fprintf(out, "%-6d %-14d %-12s %-12d %.4f%+.4fj\n", i+1, run.Start, start, run.Length, real(run.Value), imag(run.Value))
+	}
+	fmt.Fprintln(out)
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"argus-collector/internal/console"
+	"argus-collector/internal/filewriter"
+
+	"github.com/spf13/cobra"
+)
+
+// validateCmd checks data files for damage without displaying them
+var validateCmd = &cobra.Command{
+	Use:   "validate <file.dat>...",
+	Short: "Check data files for damage and report PASS or FAIL",
+	Long: `Validate checks that each file is an intact Argus data file: the ARGUS
+magic, a supported format version, a header whose fields are consistent,
+and a file size that matches the sample count in the header. A compressed
+file is decompressed in full, which also verifies its gzip checksum;
+uncompressed files carry no checksum.
+
+Each file is reported as PASS or FAIL with the reason for every failed
+check. The exit status is 1 if any file fails, so scripts can test it.`,
+	Example: `  argus-reader validate capture.dat
+  argus-reader validate --quiet data/*.dat || echo "damaged files found"`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := 0
+		for _, filename := range args {
+			if !reportValidation(filename, validateFile(filename)) {
+				failed++
+			}
+		}
+		if len(args) > 1 {
+			fmt.Fprintf(out, "%d of %d files passed\n", len(args)-failed, len(args))
+		}
+		if failed > 0 {
+			console.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+// validation is the outcome of checking one data file
+type validation struct {
+	passed   []string // Checks that passed, with what was found
+	problems []string // Checks that failed, with specifics
+}
+
+// pass records a passed check
+func (v *validation) pass(format string, args ...interface{}) {
+	v.passed = append(v.passed, fmt.Sprintf(format, args...))
+}
+
+// fail records a failed check
+func (v *validation) fail(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// validateFile runs every check that applies to filename. A file whose
+// header cannot be read is not checked further.
+func validateFile(filename string) *validation {
+	v := &validation{}
+
+	compressed, err := filewriter.IsCompressedFile(filename)
+	if err != nil {
+		v.fail("%v", err)
+		return v
+	}
+
+	metadata, sampleCount, err := filewriter.ReadMetadata(filename)
+	switch {
+	case errors.Is(err, filewriter.ErrInvalidMagic):
+		v.fail("magic: not an Argus data file (no ARGUS header)")
+		return v
+	case errors.Is(err, filewriter.ErrUnsupportedVersion):
+		v.fail("format version: %v", err)
+		return v
+	case err != nil: // Truncated or otherwise unreadable
+		v.fail("header: %v", err)
+		return v
+	}
+	v.pass("magic and format version %d", metadata.FileFormatVersion)
+
+	checkHeaderFields(v, metadata, sampleCount)
+	if compressed {
+		checkCompressedData(v, filename, metadata, sampleCount)
+	} else {
+		checkDataSize(v, filename, metadata, sampleCount)
+	}
+	return v
+}
+
+// checkHeaderFields checks that the header values are usable and agree with
+// each other
+func checkHeaderFields(v *validation, metadata *filewriter.Metadata, sampleCount uint32) {
+	before := len(v.problems)
+
+	if metadata.SampleRate == 0 {
+		v.fail("header: sample rate is 0")
+	}
+	if metadata.Frequency == 0 {
+		v.fail("header: frequency is 0")
+	}
+	if metadata.CollectionTime.Unix() <= 0 {
+		v.fail("header: collection time is not set")
+	}
+	if !metadata.NoPosition {
+		loc := metadata.GPSLocation
+		if loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180 {
+			v.fail("header: GPS position %.6f, %.6f is out of range", loc.Latitude, loc.Longitude)
+		}
+	}
+	for i, gap := range metadata.Gaps {
+		if gap.SampleIndex < 0 || gap.SampleIndex > int64(sampleCount) {
+			v.fail("header: gap %d at sample %d is outside the %d samples", i+1, gap.SampleIndex, sampleCount)
+		} else if i > 0 && gap.SampleIndex < metadata.Gaps[i-1].SampleIndex {
+			v.fail("header: gap %d at sample %d is out of order", i+1, gap.SampleIndex)
+		}
+		if gap.Duration <= 0 {
+			v.fail("header: gap %d has non-positive length %v", i+1, gap.Duration)
+		}
+	}
+	if metadata.GainStep > len(metadata.GainLadder) {
+		v.fail("header: gain step %d is beyond the %d-step gain ladder", metadata.GainStep, len(metadata.GainLadder))
+	}
+
+	if len(v.problems) == before {
+		v.pass("header fields consistent (%d samples at %d Hz, %s)", sampleCount, metadata.SampleRate, metadata.SampleFormat)
+	}
+}
+
+// checkDataSize checks that an uncompressed file holds exactly the samples
+// its header claims
+func checkDataSize(v *validation, filename string, metadata *filewriter.Metadata, sampleCount uint32) {
+	stat, err := os.Stat(filename)
+	if err != nil {
+		v.fail("file size: %v", err)
+		return
+	}
+
+	size := stat.Size()
+	expected := filewriter.DataOffset(metadata, int64(sampleCount))
+	sampleSize := int64(metadata.SampleFormat.Size())
+	switch {
+	case size < expected:
+		held := int64(0)
+		if data := size - filewriter.HeaderSize(metadata); data > 0 {
+			held = data / sampleSize
+		}
+		v.fail("file size: %v: %d bytes, header claims %d samples (%d bytes); only %d complete samples present",
+			filewriter.ErrTruncated, size, sampleCount, expected, held)
+	case size > expected:
+		v.fail("file size: %d bytes, %d more than the %d samples the header claims (%d extra samples); the header count may be stale",
+			size, size-expected, sampleCount, (size-expected)/sampleSize)
+	default:
+		v.pass("file size matches %d samples (%d bytes)", sampleCount, size)
+	}
+	v.pass("checksum: none recorded in uncompressed files")
+}
+
+// checkCompressedData decompresses the whole file, which verifies the gzip
+// checksum, and counts the samples it holds
+func checkCompressedData(v *validation, filename string, metadata *filewriter.Metadata, sampleCount uint32) {
+	reader, err := filewriter.NewSampleReader(filename)
+	if err != nil {
+		v.fail("data: %v", err)
+		return
+	}
+	defer reader.Close()
+
+	var held int64
+	buf := make([]complex64, convertChunk)
+	for {
+		n, err := reader.Read(buf)
+		held += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, gzip.ErrChecksum) {
+			v.fail("checksum: %v: gzip data is corrupt", filewriter.ErrChecksumMismatch)
+			return
+		}
+		if err != nil {
+			v.fail("data: decompression failed after %d samples: %v", held, err)
+			return
+		}
+	}
+
+	switch {
+	case held < int64(sampleCount):
+		v.fail("data: %v: header claims %d samples, only %d present", filewriter.ErrTruncated, sampleCount, held)
+	case held > int64(sampleCount):
+		v.fail("data: %d samples present, %d more than the header claims; the header count may be stale",
+			held, held-int64(sampleCount))
+	default:
+		v.pass("data holds %d samples", held)
+	}
+	if held >= int64(sampleCount) {
+		v.pass("checksum: gzip checksum verified")
+	}
+}
+
+// reportValidation prints the outcome for filename and returns whether it
+// passed
+func reportValidation(filename string, v *validation) bool {
+	name := filepath.Base(filename)
+	if len(v.problems) == 0 {
+		fmt.Fprintf(out, "✅ PASS %s\n", name)
+	} else {
+		fmt.Fprintf(out, "❌ FAIL %s\n", name)
+	}
+	for _, check := range v.passed {
+		fmt.Fprintf(out, "   ✓ %s\n", check)
+	}
+	for _, problem := range v.problems {
+		fmt.Fprintf(out, "   ✗ %s\n", problem)
+	}
+	return len(v.problems) == 0
+}
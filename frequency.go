@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseFrequency parses a frequency in Hz, written either as a plain number
+// (433920000, 96.9e6) or with a k, M or G suffix (433.92M, 145k, 1.2G), with
+// or without a trailing "Hz". Suffixes are not case sensitive.
+func parseFrequency(value string) (float64, error) {
+	s := strings.TrimSpace(value)
+	if len(s) > 2 && strings.EqualFold(s[len(s)-2:], "hz") {
+		s = strings.TrimSpace(s[:len(s)-2])
+	}
+	multiplier := 1.0
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'k', 'K':
+			multiplier = 1e3
+		case 'm', 'M':
+			multiplier = 1e6
+		case 'g', 'G':
+			multiplier = 1e9
+		}
+		if multiplier != 1 {
+			s = strings.TrimSpace(s[:n-1])
+		}
+	}
+
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("invalid frequency %q: use Hz (e.g. 433920000) or a k, M or G suffix (e.g. 433.92M)", value)
+	}
+	hz := math.Round(number * multiplier) // Whole Hz, so 433.92M is not 433919999.99...
+	if hz <= 0 {
+		return 0, fmt.Errorf("invalid frequency %q: must be positive", value)
+	}
+	return hz, nil
+}
+
+// frequencySetting parses a configured frequency, returning -1, rejected
+// when the configuration is validated, for an invalid one
+func frequencySetting(value string) float64 {
+	hz, err := parseFrequency(value)
+	if err != nil {
+		return -1
+	}
+	return hz
+}
+
+// frequencyValue is a flag holding a frequency in Hz that accepts the unit
+// suffixes parseFrequency does
+type frequencyValue float64
+
+// newFrequencyValue sets p to def and returns a flag value storing into it
+func newFrequencyValue(def float64, p *float64) *frequencyValue {
+	*p = def
+	return (*frequencyValue)(p)
+}
+
+func (f *frequencyValue) Set(value string) error {
+	hz, err := parseFrequency(value)
+	if err != nil {
+		return err
+	}
+	*f = frequencyValue(hz)
+	return nil
+}
+
+// String returns the frequency in plain Hz, which configuration binding reads back
+func (f *frequencyValue) String() string {
+	return strconv.FormatFloat(float64(*f), 'f', -1, 64)
+}
+
+func (f *frequencyValue) Type() string {
+	return "frequency"
+}
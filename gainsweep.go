@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"argus-collector/internal/config"
+	"argus-collector/internal/filewriter"
+	"argus-collector/internal/rtlsdr"
+)
+
+// Gain sweep flag variables
+var (
+	gainSweep      bool          // Measure each supported gain and recommend one instead of collecting
+	gainSweepDwell time.Duration // Capture length at each gain
+)
+
+// gainSweepMaxClipped is the largest fraction of clipped samples a gain may
+// produce and still be recommended; a rare noise spike reaching full scale
+// does not disqualify a gain, a clipped signal does
+const gainSweepMaxClipped = 1e-4
+
+// gainSweepPoint is the capture taken at one gain of a gain sweep
+type gainSweepPoint struct {
+	Gain      float64 // Tuner gain in dB
+	PowerDBFS float64 // RMS amplitude in dB relative to full scale
+	Clipped   float64 // Fraction of samples with I or Q at full scale
+	SNR       float64 // Peak-to-noise ratio in dB, as recorded in data files
+}
+
+// runGainSweep takes a short capture at each gain the tuner supports, at the
+// configured frequency, and prints the power, clipping and estimated SNR of
+// each, recommending the gain with the best SNR that does not clip. The SNR
+// is the peak-to-noise ratio saved with every capture, so it measures a signal
+// only when one is transmitting intermittently during the sweep.
+func runGainSweep(cfg *config.Config) error {
+	if gainSweepDwell <= 0 {
+		return fmt.Errorf("--gain-sweep-dwell must be positive")
+	}
+
+	dev, err := openDevice(cfg)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+
+	if err := dev.SetSampleRate(cfg.RTLSDR.SampleRate); err != nil {
+		return err
+	}
+	if err := dev.SetGainMode("manual"); err != nil {
+		return err
+	}
+	if err := dev.SetBiasTee(cfg.RTLSDR.BiasTee); err != nil {
+		return err
+	}
+	dev.SetSwapIQ(cfg.RTLSDR.SwapIQ)
+
+	gains, err := dev.GetTunerGainsFloat()
+	if err != nil {
+		return err
+	}
+	if len(gains) == 0 {
+		return fmt.Errorf("device reported no supported tuner gains")
+	}
+
+	ctx, cancel := interruptContext(out)
+	defer cancel()
+
+	freq := uint32(cfg.RTLSDR.Frequency)
+	fmt.Fprintf(out, "Gain sweep at %.6f MHz: %d gains, %v each\n", cfg.RTLSDR.Frequency/1e6, len(gains), gainSweepDwell)
+	fmt.Fprintf(out, "  Gain (dB)  Power (dBFS)  Clipped (%%)  SNR (dB)\n")
+
+	points := make([]gainSweepPoint, 0, len(gains))
+sweep:
+	for _, g := range gains {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(out, "Gain sweep interrupted after %d of %d gains\n", len(points), len(gains))
+			break sweep
+		default:
+		}
+
+		if err := dev.SetGain(g); err != nil {
+			return err
+		}
+		samples, err := dev.CaptureSamples(freq, gainSweepDwell)
+		if err != nil {
+			return err
+		}
+
+		p := gainSweepPoint{
+			Gain:      g,
+			PowerDBFS: 20 * math.Log10(math.Max(rtlsdr.SignalPower(samples), 1e-12)),
+			Clipped:   filewriter.ClippedFraction(samples),
+			SNR:       filewriter.PeakToNoise(samples),
+		}
+		points = append(points, p)
+		fmt.Fprintf(out, "  %9.1f  %12.1f  %11.3f  %8.1f\n", p.Gain, p.PowerDBFS, p.Clipped*100, p.SNR)
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("no gains measured")
+	}
+
+	fmt.Fprintf(out, "\n")
+	best := recommendGain(points)
+	switch {
+	case best < 0:
+		fmt.Fprintf(out, "Warning: every gain clips; add attenuation or move away from strong transmitters\n")
+	case points[best].SNR < filewriter.SignalPresentDB:
+		fmt.Fprintf(out, "Warning: no signal stood out at any gain (best SNR %.1f dB); sweep again while the\n", points[best].SNR)
+		fmt.Fprintf(out, "target transmits. The highest gain that does not clip is %.1f dB.\n", highestUnclipped(points))
+	default:
+		fmt.Fprintf(out, "Recommended gain: %.1f dB (SNR %.1f dB, %.3f%% clipped)\n", points[best].Gain, points[best].SNR, points[best].Clipped*100)
+	}
+	return nil
+}
+
+// recommendGain returns the index of the point with the highest SNR among
+// those that do not clip, preferring the lower gain on a tie, or -1 if every
+// point clips
+func recommendGain(points []gainSweepPoint) int {
+	best := -1
+	for i, p := range points {
+		if p.Clipped > gainSweepMaxClipped {
+			continue
+		}
+		if best < 0 || p.SNR > points[best].SNR || (p.SNR == points[best].SNR && p.Gain < points[best].Gain) {
+			best = i
+		}
+	}
+	return best
+}
+
+// highestUnclipped returns the highest gain among points that do not clip
+func highestUnclipped(points []gainSweepPoint) float64 {
+	highest := math.Inf(-1)
+	for _, p := range points {
+		if p.Clipped <= gainSweepMaxClipped {
+			highest = math.Max(highest, p.Gain)
+		}
+	}
+	return highest
+}
@@ -2,9 +2,14 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,15 +18,35 @@ import (
 	"argus-collector/internal/filewriter"
 	"argus-collector/internal/gps"
 	"argus-collector/internal/rtlsdr"
+	"argus-collector/internal/version"
 )
 
 type Collector struct {
-	config   *config.Config
-	rtlsdr   *rtlsdr.Device
-	gps      *gps.GPS
-	writer   *filewriter.Writer
-	stopChan chan struct{}
-	wg       sync.WaitGroup
+	config       *config.Config
+	rtlsdr       *rtlsdr.Device
+	gps          *gps.GPS
+	writer       *filewriter.Writer
+	sampleFormat filewriter.SampleFormat
+	stopChan     chan struct{}
+	wg           sync.WaitGroup
+	sharedGPS    bool      // GPS receiver is owned by another collector
+	lastFile     string    // Data file written by the most recent collection
+	out          io.Writer // Receives progress and warnings
+
+	statusMu sync.Mutex // Guards phase and expected, read by Status
+	phase    string     // What the collector is doing, for status displays
+	expected int64      // Samples the current capture will hold
+}
+
+// Status is a snapshot of a collector's state for live displays
+type Status struct {
+	Phase      string                 // What the collector is doing
+	Position   *gps.Position          // Current position, nil if none is known
+	FixQuality string                 // GPS fix quality, empty without a GPS receiver
+	Expected   int64                  // Samples the current capture will hold
+	Capture    rtlsdr.CaptureProgress // Progress of the current capture
+	GainMode   string                 // Tuner gain mode
+	AGCActive  bool                   // Software AGC is adjusting the gain
 }
 
 type CollectionData struct {
@@ -34,12 +59,18 @@ func NewCollector(cfg *config.Config) *Collector {
 	return &Collector{
 		config:   cfg,
 		stopChan: make(chan struct{}),
+		out:      os.Stdout,
 	}
 }
 
 func (c *Collector) Initialize() error {
 	var err error
 
+	c.sampleFormat, err = filewriter.ParseSampleFormat(c.config.Collection.SampleFormat)
+	if err != nil {
+		return err
+	}
+
 	// Choose device selection method based on configuration
 	if c.config.RTLSDR.SerialNumber != "" {
 		// Use serial number to select device
@@ -55,6 +86,8 @@ func (c *Collector) Initialize() error {
 		}
 	}
 
+	c.rtlsdr.SetOutput(c.out)
+
 	if err := c.rtlsdr.SetFrequency(uint32(c.config.RTLSDR.Frequency)); err != nil {
 		return fmt.Errorf("failed to set RTL-SDR frequency: %w", err)
 	}
@@ -63,14 +96,49 @@ func (c *Collector) Initialize() error {
 		return fmt.Errorf("failed to set RTL-SDR sample rate: %w", err)
 	}
 
-	// Set gain mode first
-	if err := c.rtlsdr.SetGainMode(c.config.RTLSDR.GainMode); err != nil {
+	// Set gain mode first; "max" is manual gain at the tuner's highest setting
+	deviceGainMode := c.config.RTLSDR.GainMode
+	if deviceGainMode == "max" {
+		deviceGainMode = "manual"
+	}
+	if err := c.rtlsdr.SetGainMode(deviceGainMode); err != nil {
 		return fmt.Errorf("failed to set RTL-SDR gain mode: %w", err)
 	}
 
-	// Set manual gain if in manual mode
-	if c.config.RTLSDR.GainMode == "manual" {
-		if err := c.rtlsdr.SetGain(c.config.RTLSDR.Gain); err != nil {
+	if c.config.RTLSDR.AGCSmoothing != 0 {
+		if err := c.rtlsdr.SetAGCSmoothing(c.config.RTLSDR.AGCSmoothing); err != nil {
+			return fmt.Errorf("failed to set RTL-SDR AGC smoothing: %w", err)
+		}
+	}
+
+	// Zero keeps the device defaults
+	if c.config.RTLSDR.ReadChunkBytes != 0 {
+		if err := c.rtlsdr.SetReadChunkBytes(c.config.RTLSDR.ReadChunkBytes); err != nil {
+			return fmt.Errorf("failed to set RTL-SDR read chunk size: %w", err)
+		}
+	}
+	if c.config.RTLSDR.ReadTimeout != 0 {
+		if err := c.rtlsdr.SetReadTimeout(c.config.RTLSDR.ReadTimeout); err != nil {
+			return fmt.Errorf("failed to set RTL-SDR read timeout: %w", err)
+		}
+	}
+	c.rtlsdr.SetSwapIQ(c.config.RTLSDR.SwapIQ)
+
+	// Set manual gain if in manual mode, or the tuner's highest gain for "max"
+	if deviceGainMode == "manual" {
+		gain := c.config.RTLSDR.Gain
+		if c.config.RTLSDR.GainMode == "max" {
+			gains, err := c.rtlsdr.GetTunerGainsFloat()
+			if err != nil {
+				return fmt.Errorf("failed to get supported gains for gain mode max: %w", err)
+			}
+			if len(gains) == 0 {
+				return fmt.Errorf("tuner reports no supported gains for gain mode max")
+			}
+			gain = slices.Max(gains)
+			fmt.Fprintf(c.out, "Gain mode max: using the highest supported gain, %.1f dB\n", gain)
+		}
+		if err := c.rtlsdr.SetGain(gain); err != nil {
 			return fmt.Errorf("failed to set RTL-SDR gain: %w", err)
 		}
 	}
@@ -86,8 +154,14 @@ func (c *Collector) Initialize() error {
 	if c.config.GPS.Disable {
 		gpsMode = "manual"
 	}
+	// A shared receiver was already started by the collector that owns it
+	if c.sharedGPS {
+		gpsMode = "shared"
+	}
 
 	switch gpsMode {
+	case "shared":
+		// Set by ShareGPS, nothing to open
 	case "nmea":
 		c.gps, err = gps.NewGPS(c.config.GPS.Port, c.config.GPS.BaudRate)
 		if err != nil {
@@ -129,19 +203,26 @@ func (c *Collector) WaitForGPSFix() error {
 }
 
 func (c *Collector) WaitForGPSFixWithContext(ctx context.Context) error {
+	c.setPhase("acquiring GPS fix", 0)
+	defer c.setPhase("idle", 0)
+
 	gpsMode := c.config.GPS.Mode
 	if c.config.GPS.Disable {
 		gpsMode = "manual"
 	}
 
+	if c.config.GPS.NoPosition {
+		fmt.Fprintf(c.out, "GPS disabled - no position will be recorded\n")
+		return nil
+	}
 	if gpsMode == "manual" {
 		// GPS is disabled, use manual coordinates
-		fmt.Printf("GPS disabled - using manual coordinates: %.8f°, %.8f°\n",
+		fmt.Fprintf(c.out, "GPS disabled - using manual coordinates: %.8f°, %.8f°\n",
 			c.config.GPS.ManualLatitude, c.config.GPS.ManualLongitude)
 		return nil
 	}
 
-	fmt.Printf("Waiting for GPS fix via %s (timeout: %v)...\n", gpsMode, c.config.GPS.Timeout)
+	fmt.Fprintf(c.out, "Waiting for GPS fix via %s (timeout: %v)...\n", gpsMode, c.config.GPS.Timeout)
 
 	// Create a channel for GPS fix result
 	type gpsResult struct {
@@ -155,38 +236,120 @@ func (c *Collector) WaitForGPSFixWithContext(ctx context.Context) error {
 		gpsResultChan <- gpsResult{pos, err}
 	}()
 
-	// Wait for GPS fix or context cancellation
+	// Wait for GPS fix or context cancellation, showing the satellite count as
+	// it climbs so the operator can judge the site
+	updates := c.gps.Updates()
+	var shown gps.Position
 	var position *gps.Position
-	select {
-	case result := <-gpsResultChan:
-		if result.err != nil {
-			return fmt.Errorf("GPS fix failed: %w", result.err)
+	for position == nil {
+		select {
+		case result := <-gpsResultChan:
+			if result.err != nil {
+				return fmt.Errorf("GPS fix failed: %w", result.err)
+			}
+			position = result.pos
+		case pos := <-updates:
+			shown = c.reportGPSUpdate(pos, shown)
+		case <-ctx.Done():
+			return fmt.Errorf("GPS fix cancelled: %w", ctx.Err())
 		}
-		position = result.pos
-	case <-ctx.Done():
-		return fmt.Errorf("GPS fix cancelled: %w", ctx.Err())
 	}
 
-	fmt.Printf("GPS fix acquired: %.6f, %.6f (quality: %s, satellites: %d)\n",
+	fmt.Fprintf(c.out, "GPS fix acquired: %.6f, %.6f (quality: %s, satellites: %s)\n",
 		position.Latitude, position.Longitude,
-		c.gps.GetFixQualityString(), position.Satellites)
+		c.gps.GetFixQualityString(), satelliteSummary(position))
+
+	shown = *position
+	if watch := c.config.GPS.FixWatch; watch > 0 {
+		fmt.Fprintf(c.out, "Watching GPS fix for %v...\n", watch)
+		timer := time.NewTimer(watch)
+		defer timer.Stop()
+		for watching := true; watching; {
+			select {
+			case pos := <-updates:
+				shown = c.reportGPSUpdate(pos, shown)
+			case <-timer.C:
+				watching = false
+			case <-ctx.Done():
+				return fmt.Errorf("GPS fix watch cancelled: %w", ctx.Err())
+			}
+		}
+	}
+
+	c.checkClockOffset(ctx)
 
 	return nil
 }
 
+// clockOffsetWait bounds how long to wait for a GPS time message after the fix
+const clockOffsetWait = 3 * time.Second
+
+// checkClockOffset compares the system clock against GPS time and warns when the
+// difference exceeds the configured threshold. Synced start relies on the system
+// clock, so any offset translates directly into TDOA error between stations.
+func (c *Collector) checkClockOffset(ctx context.Context) {
+	deadline := time.Now().Add(clockOffsetWait)
+	offset, err := c.gps.GetClockOffset()
+	for err != nil && time.Now().Before(deadline) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return
+		}
+		offset, err = c.gps.GetClockOffset()
+	}
+	if err != nil {
+		fmt.Fprintf(c.out, "Warning: unable to check system clock against GPS time: %v\n", err)
+		return
+	}
+
+	fmt.Fprintf(c.out, "System clock offset from GPS time: %v\n", offset)
+
+	threshold := c.config.GPS.ClockOffsetThreshold
+	if threshold > 0 && (offset > threshold || offset < -threshold) {
+		fmt.Fprintf(c.out, "WARNING: System clock differs from GPS time by %v (threshold: %v)\n", offset, threshold)
+		fmt.Fprintf(c.out, "WARNING: Synchronized start timing will be off by this amount - check NTP/chrony synchronization\n")
+	}
+}
+
+// StartTimeGrace is how far in the past an exact start time may be and still
+// start immediately rather than being rejected
+const StartTimeGrace = 10 * time.Second
+
+// CheckStartTime returns an error if an exact start time lies more than
+// StartTimeGrace before now
+func CheckStartTime(startTime, now time.Time) error {
+	if now.Sub(startTime) > StartTimeGrace {
+		return fmt.Errorf("start time %s is %s in the past (more than %s grace)",
+			startTime.Format("15:04:05.000"), now.Sub(startTime).Round(time.Second), StartTimeGrace)
+	}
+	return nil
+}
+
+// FlushTimeout is how long an interrupted collection may take to save the
+// samples captured before the interruption
+const FlushTimeout = 10 * time.Second
+
 func (c *Collector) Collect() error {
 	return c.CollectWithContext(context.Background())
 }
 
 func (c *Collector) CollectWithContext(ctx context.Context) error {
+	c.setPhase("waiting for start", 0)
+	defer c.setPhase("idle", 0)
+
 	var startTime time.Time
 
+	// With a pre-trigger the device starts streaming that long before the
+	// start time so the saved file includes the lead-in
+	pretrigger := c.config.Collection.Pretrigger
+
 	if c.config.Collection.StartTime > 0 {
 		// Use exact epoch timestamp from --start-time
 		startTime = time.Unix(c.config.Collection.StartTime, 0)
-		fmt.Printf("Exact start time specified - waiting until: %s\n", startTime.Format("15:04:05.000"))
+		fmt.Fprintf(c.out, "Exact start time specified - waiting until: %s\n", startTime.Format("15:04:05.000"))
 
-		waitDuration := time.Until(startTime)
+		waitDuration := time.Until(startTime.Add(-pretrigger))
 		if waitDuration > 0 {
 			// Wait for exact start time or context cancellation
 			select {
@@ -195,14 +358,14 @@ func (c *Collector) CollectWithContext(ctx context.Context) error {
 			case <-ctx.Done():
 				return fmt.Errorf("exact start time cancelled: %w", ctx.Err())
 			}
-		} else if waitDuration < -10*time.Second {
-			return fmt.Errorf("start time is too far in the past: %s", startTime.Format("15:04:05.000"))
+		} else if err := CheckStartTime(startTime, time.Now()); err != nil {
+			return err
 		}
 	} else if c.config.Collection.SyncedStart {
 		startTime = c.calculateSyncedStartTime()
-		fmt.Printf("Synchronized start enabled - waiting until: %s\n", startTime.Format("15:04:05.000"))
+		fmt.Fprintf(c.out, "Synchronized start enabled - waiting until: %s\n", startTime.Format("15:04:05.000"))
 
-		waitDuration := time.Until(startTime)
+		waitDuration := time.Until(startTime.Add(-pretrigger))
 		if waitDuration > 0 {
 
 			// Wait for sync start time or context cancellation
@@ -214,79 +377,158 @@ func (c *Collector) CollectWithContext(ctx context.Context) error {
 			}
 		}
 	} else {
-		fmt.Printf("Synchronized start disabled - starting immediately\n")
-		startTime = time.Now()
+		fmt.Fprintf(c.out, "Synchronized start disabled - starting immediately\n")
+		// Leave time for the pre-trigger buffer to fill
+		startTime = time.Now().Add(pretrigger)
+	}
+	if pretrigger > 0 {
+		fmt.Fprintf(c.out, "Pre-trigger: buffering %v of samples before %s\n", pretrigger, startTime.Format("15:04:05.000"))
 	}
 
 	// Generate collection ID based on configuration
 	var collectionID string
-	if c.config.Collection.CollectionID != "" {
+	if template := c.config.Collection.FilenameTemplate; template != "" {
+		fields := FilenameFields{
+			Prefix:       c.config.Collection.FilePrefix,
+			CollectionID: c.config.Collection.CollectionID,
+			Device:       c.getDeviceIdentifier(),
+			Frequency:    c.config.RTLSDR.Frequency,
+			Start:        startTime,
+		}
+		var err error
+		if fields.Sequence, err = NextSequence(template, fields, c.config.Collection.OutputDir); err != nil {
+			return err
+		}
+		collectionID, err = ExpandFilenameTemplate(template, fields)
+		if err != nil {
+			return err
+		}
+	} else if c.config.Collection.CollectionID != "" {
 		// Use configured collection ID with timestamp suffix
-		collectionID = fmt.Sprintf("%s_%d", c.config.Collection.CollectionID, startTime.Unix())
+		collectionID = fmt.Sprintf("%s_%s", c.config.Collection.CollectionID, CollectionTimestamp(startTime))
 	} else {
 		// Generate collection ID using file prefix and device identifier
 		deviceID := c.getDeviceIdentifier()
-		collectionID = fmt.Sprintf("%s-%s_%d", c.config.Collection.FilePrefix, deviceID, startTime.Unix())
+		collectionID = fmt.Sprintf("%s-%s_%s", c.config.Collection.FilePrefix, deviceID, CollectionTimestamp(startTime))
+	}
+	collectionID = UniqueCollectionID(collectionID)
+
+	// Refuse an existing output file before capturing rather than after
+	if c.config.Collection.Overwrite == "error" {
+		if _, err := OutputFilename(filepath.Join(c.config.Collection.OutputDir, collectionID+".dat"), "error"); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("Starting collection (ID: %s, Duration: %v)\n", collectionID, c.config.Collection.Duration)
-	// Calculate timeout buffer: 3.2x the collection duration
-	totalTimeout := time.Duration(float64(c.config.Collection.Duration) * 3.2)
+	fmt.Fprintf(c.out, "Starting collection (ID: %s, Duration: %v)\n", collectionID, c.config.Collection.Duration)
+	expected, _ := rtlsdr.TotalSamples(c.config.RTLSDR.SampleRate, c.config.Collection.Duration+pretrigger)
+	c.setPhase("capturing", expected)
+	totalTimeout := CaptureTimeout(c.config.Collection, pretrigger)
 
 	deviceInfo, err := c.rtlsdr.GetDeviceInfo()
 	if err != nil {
 		return fmt.Errorf("failed to get device info: %w", err)
 	}
-	fmt.Printf("Device: %s\n", deviceInfo)
+	fmt.Fprintf(c.out, "Device: %s\n", deviceInfo)
+
+	// Watch the GPS fix for the duration of the capture when required. Losing
+	// it cancels the capture and discards what was recorded, whose timing can
+	// no longer be trusted.
+	captureCtx, cancelCapture := context.WithCancel(ctx)
+	defer cancelCapture()
+	fixLost := make(chan error, 1)
+	discard := make(chan struct{})
+	if c.config.GPS.RequireFixThroughout && c.config.GPS.Mode != "manual" && !c.config.GPS.Disable {
+		go c.watchFix(captureCtx, fixLost)
+	}
+
+	// With a sync interval the samples are written to disk as they arrive, so
+	// a crash or power loss keeps everything up to the last sync
+	var stream *filewriter.StreamWriter
+	var filename string
+	if c.config.Collection.SyncInterval > 0 {
+		filename, err = c.outputFilename(collectionID)
+		if err != nil {
+			return err
+		}
+		stream, err = c.startStream(filename, collectionID, startTime.Add(-pretrigger))
+		if err != nil {
+			return err
+		}
+		c.rtlsdr.SetSampleSink(stream.Write)
+		defer c.rtlsdr.SetSampleSink(nil)
+	}
+
+	// Log the power of every chunk read, whether or not AGC is running
+	if c.config.Collection.PowerLog != "" {
+		plog, err := openPowerLog(c.config.Collection.PowerLog)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := plog.Close(); err != nil {
+				fmt.Fprintf(c.out, "Warning: %v\n", err)
+			}
+		}()
+		c.rtlsdr.SetPowerMonitor(plog.monitor(collectionID, startTime.Add(-pretrigger)))
+		defer c.rtlsdr.SetPowerMonitor(nil)
+	}
 
 	samplesChan := make(chan rtlsdr.IQSample, 1)
 
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
-		if err := c.rtlsdr.StartCollection(c.config.Collection.Duration, samplesChan); err != nil {
-			fmt.Printf("RTL-SDR collection error: %v\n", err)
+		var err error
+		// Cancelling the capture ends it early with the samples read so far
+		if pretrigger > 0 {
+			err = c.rtlsdr.StartCollectionWithPretrigger(captureCtx, startTime, pretrigger, c.config.Collection.Duration, samplesChan)
+		} else {
+			err = c.rtlsdr.StartCollectionWithContext(captureCtx, c.config.Collection.Duration, samplesChan)
+		}
+		if err != nil {
+			fmt.Fprintf(c.out, "RTL-SDR collection error: %v\n", err)
 		}
 		// Always close the samples channel when collection ends
 		close(samplesChan)
 	}()
 
-	// Create a done channel to coordinate goroutine completion
+	// Create a done channel to coordinate goroutine completion; saved is the
+	// file written, valid once done has been received from
 	done := make(chan error, 1)
+	var saved string
 
 	// Handle the collection result in a separate goroutine
 	go func() {
+		if stream != nil {
+			// Keep whatever was synced if the capture ends without saving
+			defer stream.Close()
+		}
 		select {
 		case samples, ok := <-samplesChan:
 			if !ok {
 				done <- fmt.Errorf("RTL-SDR collection channel closed without data")
 				return
 			}
-			var gpsPosition gps.Position
-
-			gpsMode := c.config.GPS.Mode
-			if c.config.GPS.Disable {
-				gpsMode = "manual"
+			if len(samples.Data) == 0 {
+				done <- fmt.Errorf("no samples collected")
+				return
 			}
-
-			if gpsMode == "manual" {
-				// Use manual coordinates when GPS is disabled
-				gpsPosition = gps.Position{
-					Latitude:   c.config.GPS.ManualLatitude,
-					Longitude:  c.config.GPS.ManualLongitude,
-					Altitude:   c.config.GPS.ManualAltitude,
-					Timestamp:  time.Now(),
-					FixQuality: 1, // Indicate valid fix for manual coordinates
-					Satellites: 0, // No satellites for manual coordinates
-				}
-			} else {
-				// Get position from GPS hardware (nmea or gpsd)
-				gpsPos, err := c.gps.GetCurrentPosition()
-				if err != nil {
-					done <- fmt.Errorf("failed to get GPS position: %w", err)
-					return
+			select {
+			case <-discard:
+				if stream != nil {
+					stream.Close()
+					c.discardCapture(filename)
 				}
-				gpsPosition = *gpsPos
+				done <- nil
+				return
+			default:
+			}
+			c.setPhase("saving", int64(len(samples.Data)))
+			gpsPosition, err := c.currentPosition()
+			if err != nil {
+				done <- err
+				return
 			}
 
 			collectionData := CollectionData{
@@ -295,17 +537,29 @@ func (c *Collector) CollectWithContext(ctx context.Context) error {
 				CollectionID: collectionID,
 			}
 
-			filename := filepath.Join(c.config.Collection.OutputDir, collectionID+".dat")
-			if err := c.saveData(filename, collectionData); err != nil {
+			if stream == nil {
+				filename, err = c.outputFilename(collectionID)
+				if err != nil {
+					done <- err
+					return
+				}
+			}
+			if err := c.saveData(filename, collectionData, stream); err != nil {
 				done <- fmt.Errorf("failed to save data: %w", err)
 				return
 			}
 
-			fmt.Printf("Collection saved to: %s\n", filename)
-			fmt.Printf("Samples collected: %d\n", len(samples.Data))
+			c.lastFile = filename
+			saved = filename
+			fmt.Fprintf(c.out, "Collection saved to: %s\n", filename)
+			fmt.Fprintf(c.out, "Samples collected: %d\n", len(samples.Data))
+			if samples.DeviceLost {
+				done <- fmt.Errorf("collection stopped, partial capture saved to %s: %w", filename, rtlsdr.ErrDeviceGone)
+				return
+			}
 			done <- nil
 
-		case <-time.After(c.config.Collection.Duration + 10*time.Second):
+		case <-time.After(min(c.config.Collection.Duration+pretrigger+10*time.Second, totalTimeout)):
 			done <- fmt.Errorf("collection timeout - no data received from RTL-SDR")
 		}
 	}()
@@ -317,10 +571,35 @@ func (c *Collector) CollectWithContext(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+	case err := <-fixLost:
+		close(discard)
+		cancelCapture()
+		select {
+		case <-done:
+			// The capture may have been saved just before the fix was lost
+			if saved != "" {
+				c.discardCapture(saved)
+			}
+		case <-time.After(FlushTimeout):
+			fmt.Fprintf(c.out, "Warning: capture did not stop within %v\n", FlushTimeout)
+		}
+		c.lastFile = ""
+		return fmt.Errorf("collection aborted, partial capture discarded: %w", err)
 	case <-time.After(totalTimeout):
-		return fmt.Errorf("collection timeout - exceeded maximum wait time")
+		return fmt.Errorf("collection timeout - exceeded maximum wait time of %v", totalTimeout)
 	case <-ctx.Done():
-		return fmt.Errorf("collection cancelled: %w", ctx.Err())
+		// The RTL-SDR stops at the cancellation and hands over the samples
+		// read so far; save them rather than discarding the capture
+		fmt.Fprintf(c.out, "Collection interrupted, saving samples collected so far...\n")
+		select {
+		case err := <-done:
+			if err != nil {
+				return fmt.Errorf("collection cancelled: %w", err)
+			}
+			return fmt.Errorf("collection cancelled, partial capture saved to %s: %w", c.lastFile, ctx.Err())
+		case <-time.After(FlushTimeout):
+			return fmt.Errorf("collection cancelled, partial capture not saved within %v: %w", FlushTimeout, ctx.Err())
+		}
 	}
 
 	// Wait for RTL-SDR goroutine to finish, but with a timeout
@@ -335,17 +614,191 @@ func (c *Collector) CollectWithContext(ctx context.Context) error {
 		// RTL-SDR goroutine completed normally
 	case <-time.After(5 * time.Second):
 		// RTL-SDR goroutine is taking too long, proceed anyway
-		fmt.Printf("Warning: RTL-SDR collection goroutine did not complete in time\n")
+		fmt.Fprintf(c.out, "Warning: RTL-SDR collection goroutine did not complete in time\n")
 	case <-ctx.Done():
 		// Context cancelled during cleanup
-		fmt.Printf("Warning: Cleanup cancelled, forcing exit\n")
+		fmt.Fprintf(c.out, "Warning: Cleanup cancelled, forcing exit\n")
 		return fmt.Errorf("cleanup cancelled: %w", ctx.Err())
 	}
 
 	return nil
 }
 
-func (c *Collector) saveData(filename string, data CollectionData) error {
+// fixStaleAfter is how old the last GPS update may be before the fix is
+// treated as lost
+const fixStaleAfter = 5 * time.Second
+
+// checkFix returns an error if the GPS fix is invalid or has not been updated
+// within fixStaleAfter
+func (c *Collector) checkFix() error {
+	position, err := c.gps.GetCurrentPosition()
+	if err != nil || !c.gps.IsFixValid() {
+		return fmt.Errorf("GPS fix lost (%s)", c.gps.GetFixQualityString())
+	}
+	if age := time.Since(position.Timestamp); age > fixStaleAfter {
+		return fmt.Errorf("GPS fix is stale (last update %v ago)", age.Round(time.Second))
+	}
+	return nil
+}
+
+// watchFix checks the GPS fix once a second until ctx is done, reporting the
+// first failure on lost
+func (c *Collector) watchFix(ctx context.Context, lost chan<- error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.checkFix(); err != nil {
+				fmt.Fprintf(c.out, "WARNING: %v during collection\n", err)
+				lost <- err
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// discardCapture deletes the data file of an aborted capture and its
+// metadata sidecar
+func (c *Collector) discardCapture(filename string) {
+	names := []string{filename}
+	if c.config.Collection.SidecarJSON {
+		names = append(names, filewriter.SidecarFilename(filename))
+	}
+	for _, name := range names {
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(c.out, "Warning: failed to discard %s: %v\n", name, err)
+		}
+	}
+}
+
+// setPhase records what the collector is doing for Status
+func (c *Collector) setPhase(phase string, expected int64) {
+	c.statusMu.Lock()
+	c.phase = phase
+	c.expected = expected
+	c.statusMu.Unlock()
+}
+
+// Status returns a snapshot of the collector's state. It is safe to call
+// while the collector runs.
+func (c *Collector) Status() Status {
+	c.statusMu.Lock()
+	status := Status{Phase: c.phase, Expected: c.expected}
+	c.statusMu.Unlock()
+	if status.Phase == "" {
+		status.Phase = "idle"
+	}
+
+	if c.rtlsdr != nil {
+		status.Capture = c.rtlsdr.Progress()
+		status.GainMode = c.rtlsdr.GetGainMode()
+		status.AGCActive = c.rtlsdr.AGCActive()
+	}
+	if c.gps != nil {
+		status.FixQuality = c.gps.GetFixQualityString()
+	}
+	if !c.config.GPS.NoPosition && (c.gps != nil || c.config.GPS.Mode == "manual" || c.config.GPS.Disable) {
+		if position, err := c.currentPosition(); err == nil {
+			status.Position = &position
+		}
+	}
+	return status
+}
+
+// currentPosition returns the position to record for a capture: the manual
+// coordinates, or the current fix of the GPS receiver
+func (c *Collector) currentPosition() (gps.Position, error) {
+	gpsMode := c.config.GPS.Mode
+	if c.config.GPS.Disable {
+		gpsMode = "manual"
+	}
+
+	if gpsMode == "manual" {
+		// Use manual coordinates when GPS is disabled
+		return gps.Position{
+			Latitude:   c.config.GPS.ManualLatitude,
+			Longitude:  c.config.GPS.ManualLongitude,
+			Altitude:   c.config.GPS.ManualAltitude,
+			Timestamp:  time.Now(),
+			FixQuality: 1, // Indicate valid fix for manual coordinates
+			Satellites: 0, // No satellites for manual coordinates
+		}, nil
+	}
+
+	// Get position from GPS hardware (nmea or gpsd)
+	gpsPos, err := c.gps.GetCurrentPosition()
+	if err != nil {
+		return gps.Position{}, fmt.Errorf("failed to get GPS position: %w", err)
+	}
+	return *gpsPos, nil
+}
+
+// startStream creates the output file for a streamed capture with a header
+// describing the capture as known at its start
+func (c *Collector) startStream(filename, collectionID string, start time.Time) (*filewriter.StreamWriter, error) {
+	position, err := c.currentPosition()
+	if err != nil {
+		return nil, err
+	}
+	metadata := c.buildMetadata(CollectionData{
+		IQSamples:    rtlsdr.IQSample{Timestamp: start},
+		GPSPosition:  position,
+		CollectionID: collectionID,
+	})
+	stream, err := c.writer.Create(filename, metadata, c.config.Collection.SyncInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	fmt.Fprintf(c.out, "Streaming samples to %s (sync every %v)\n", filename, c.config.Collection.SyncInterval)
+	return stream, nil
+}
+
+// saveData writes a capture to filename. A capture streamed to disk only has
+// its header completed; if streaming failed the file is written in full from
+// the samples in memory.
+func (c *Collector) saveData(filename string, data CollectionData, stream *filewriter.StreamWriter) error {
+	metadata := c.buildMetadata(data)
+
+	written := false
+	if stream != nil {
+		if stream.Count() != uint32(len(data.IQSamples.Data)) && stream.Err() == nil {
+			stream.Close()
+			fmt.Fprintf(c.out, "Warning: streamed %d of %d samples, rewriting %s\n", stream.Count(), len(data.IQSamples.Data), filename)
+		} else if err := stream.Finalize(metadata); err != nil {
+			fmt.Fprintf(c.out, "Warning: %v, rewriting %s\n", err, filename)
+		} else {
+			written = true
+		}
+	}
+	if !written {
+		if err := c.writer.WriteFile(filename, metadata, data.IQSamples.Data); err != nil {
+			return err
+		}
+	}
+
+	if metadata.PeakToNoise < filewriter.SignalPresentDB {
+		fmt.Fprintf(c.out, "Warning: no signal stands out, the capture peaks only %.1f dB above its noise floor (median power)\n", metadata.PeakToNoise)
+	} else {
+		fmt.Fprintf(c.out, "Signal: peaks %.1f dB above the noise floor\n", metadata.PeakToNoise)
+	}
+
+	if c.config.Collection.SidecarJSON {
+		sidecarFile, err := filewriter.WriteSidecar(filename, &metadata, uint32(len(data.IQSamples.Data)))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "Metadata sidecar saved to: %s\n", sidecarFile)
+	}
+
+	return nil
+}
+
+// buildMetadata assembles the file metadata for a capture
+func (c *Collector) buildMetadata(data CollectionData) filewriter.Metadata {
 	// Get actual device information including gain settings
 	deviceInfo, err := c.rtlsdr.GetDeviceInfo()
 	if err != nil {
@@ -364,11 +817,108 @@ func (c *Collector) saveData(filename string, data CollectionData) error {
 		},
 		GPSTimestamp:      data.GPSPosition.Timestamp,
 		DeviceInfo:        deviceInfo,
-		FileFormatVersion: 1,
+		FileFormatVersion: filewriter.CurrentFormatVersion,
 		CollectionID:      data.CollectionID,
+		SampleFormat:      c.sampleFormat,
+		SoftwareVersion:   version.GetFullVersion(),
+		ConfigHash:        c.config.Hash(),
+		LNAGain:           c.config.RTLSDR.LNAGain,
+		Antenna:           c.config.RTLSDR.Antenna,
+		NoPosition:        c.config.GPS.NoPosition,
+		DeviceLost:        data.IQSamples.DeviceLost,
+	}
+	for _, gap := range data.IQSamples.Gaps {
+		metadata.Gaps = append(metadata.Gaps, filewriter.Gap{SampleIndex: gap.SampleIndex, Duration: gap.Duration})
+	}
+
+	// The receiver's own UTC is authoritative; the receive time is on the
+	// system clock
+	if !data.GPSPosition.UTC.IsZero() {
+		metadata.GPSTimestamp = data.GPSPosition.UTC
 	}
 
-	return c.writer.WriteFile(filename, metadata, data.IQSamples.Data)
+	// Record the gain AGC converged to; the device info alone may have been
+	// read mid-adjustment
+	if c.rtlsdr.AGCActive() {
+		metadata.AGCUsed = true
+		metadata.AGCFinalGain = c.rtlsdr.GetFinalAGCGain()
+	}
+
+	// Record where the gain sat on the tuner's ladder, which pins it down
+	// more exactly than dB when reproducing the capture on the same tuner
+	if gains, err := c.rtlsdr.GetTunerGains(); err == nil && len(gains) > 0 {
+		gain := c.rtlsdr.GetGain()
+		if metadata.AGCUsed {
+			gain = metadata.AGCFinalGain
+		}
+		metadata.GainLadder = gains
+		metadata.GainStep = rtlsdr.NearestGainStep(gains, gain) + 1
+	}
+
+	// Record the clock offset so the processor can correct the collection time;
+	// by now more GPS time samples have arrived than at the startup check
+	if c.gps != nil {
+		if offset, err := c.gps.GetClockOffset(); err == nil {
+			metadata.ClockOffset = offset
+			metadata.ClockOffsetMeasured = true
+		}
+	}
+
+	// Record whether the capture holds a signal so tools can skip empty
+	// ones. A stream's header is written before any samples, so reserves the
+	// record with NaN, which reads as not measured should the capture be cut
+	// short.
+	metadata.SignalMeasured = true
+	metadata.PeakToNoise = math.NaN()
+	if len(data.IQSamples.Data) > 0 {
+		metadata.PeakToNoise = filewriter.PeakToNoise(data.IQSamples.Data)
+	}
+
+	return metadata
+}
+
+// outputFilename returns the path to save the capture collectionID to under
+// the configured existing file policy, warning when it has to be renamed
+func (c *Collector) outputFilename(collectionID string) (string, error) {
+	path := filepath.Join(c.config.Collection.OutputDir, collectionID+".dat")
+	filename, err := OutputFilename(path, c.config.Collection.Overwrite)
+	if err == nil && filename != path {
+		fmt.Fprintf(c.out, "Warning: %s already exists, saving as %s\n", path, filename)
+	}
+	return filename, err
+}
+
+// OutputFilename applies the existing file policy to the planned output path.
+// "overwrite" returns path unchanged, "error" fails if path exists, and
+// "suffix" (or "") returns the first of path, name_2.dat, name_3.dat, ...
+// that does not exist.
+func OutputFilename(path, policy string) (string, error) {
+	if policy == "overwrite" {
+		return path, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return path, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to check output file: %w", err)
+	}
+
+	switch policy {
+	case "error":
+		return "", fmt.Errorf("output file %s already exists (use --overwrite to replace it)", path)
+	case "suffix", "":
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		for n := 2; ; n++ {
+			candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+			if _, err := os.Stat(candidate); errors.Is(err, fs.ErrNotExist) {
+				return candidate, nil
+			} else if err != nil {
+				return "", fmt.Errorf("failed to check output file: %w", err)
+			}
+		}
+	default:
+		return "", fmt.Errorf("invalid overwrite policy: %s", policy)
+	}
 }
 
 // getDeviceIdentifier returns a device identifier for use in filenames
@@ -387,8 +937,56 @@ func (c *Collector) getDeviceIdentifier() string {
 	return "unknown"
 }
 
+// DefaultTimeoutFactor is the capture timeout multiple used when none is configured
+const DefaultTimeoutFactor = 3.2
+
+// CaptureTimeout returns how long to wait for one capture before treating the
+// hardware as hung: TimeoutFactor times the duration plus the pre-trigger,
+// capped at MaxRuntime when set
+func CaptureTimeout(cfg config.CollectionConfig, pretrigger time.Duration) time.Duration {
+	factor := cfg.TimeoutFactor
+	if factor <= 0 {
+		factor = DefaultTimeoutFactor
+	}
+	timeout := time.Duration(float64(cfg.Duration)*factor) + pretrigger
+	if cfg.MaxRuntime > 0 && cfg.MaxRuntime < timeout {
+		timeout = cfg.MaxRuntime
+	}
+	return timeout
+}
+
+// reportGPSUpdate prints a GPS status line when the fix or satellite counts
+// in pos differ from those last shown, and returns the position now shown
+func (c *Collector) reportGPSUpdate(pos, shown gps.Position) gps.Position {
+	if pos.FixQuality == shown.FixQuality && pos.Satellites == shown.Satellites &&
+		pos.SatellitesSeen == shown.SatellitesSeen {
+		return shown
+	}
+
+	status := "no fix"
+	if pos.FixQuality > 0 {
+		status = fmt.Sprintf("fix at %.6f, %.6f", pos.Latitude, pos.Longitude)
+	}
+	fmt.Fprintf(c.out, "GPS: %s, satellites: %s\n", status, satelliteSummary(&pos))
+	return pos
+}
+
+// satelliteSummary formats the satellites used in the fix, adding the number in
+// view when the receiver reports it
+func satelliteSummary(pos *gps.Position) string {
+	if pos.SatellitesSeen == 0 {
+		return fmt.Sprintf("%d", pos.Satellites)
+	}
+	return fmt.Sprintf("%d used, %d seen", pos.Satellites, pos.SatellitesSeen)
+}
+
 func (c *Collector) calculateSyncedStartTime() time.Time {
-	now := time.Now()
+	return SyncedStartTime(time.Now())
+}
+
+// SyncedStartTime returns the synchronized start time that a collection
+// beginning at now would wait for
+func SyncedStartTime(now time.Time) time.Time {
 	currentEpoch := now.Unix()
 
 	// Improved algorithm: Use fixed 100-second epochs with predetermined sync point
@@ -410,6 +1008,21 @@ func (c *Collector) calculateSyncedStartTime() time.Time {
 	return time.Unix(targetTime, 0)
 }
 
+// ShareGPS makes c use the GPS receiver of owner instead of opening its own.
+// RTL-SDRs on the same host share one antenna position, and a serial GPS
+// cannot be opened twice. Call it before Initialize; owner must already be
+// initialized and stays responsible for closing the receiver.
+func (c *Collector) ShareGPS(owner *Collector) {
+	c.gps = owner.gps
+	c.sharedGPS = true
+}
+
+// LastFile returns the data file written by the most recent successful
+// collection, or "" if nothing has been saved yet
+func (c *Collector) LastFile() string {
+	return c.lastFile
+}
+
 func (c *Collector) Stop() {
 	close(c.stopChan)
 	c.wg.Wait()
@@ -422,6 +1035,15 @@ func (c *Collector) SetGPSDebug(debug bool) {
 	}
 }
 
+// SetOutput sets where the collector and its RTL-SDR device write progress
+// and warnings, in place of standard output
+func (c *Collector) SetOutput(w io.Writer) {
+	c.out = w
+	if c.rtlsdr != nil {
+		c.rtlsdr.SetOutput(w)
+	}
+}
+
 // SetRTLSDRVerbose enables or disables RTL-SDR verbose logging
 func (c *Collector) SetRTLSDRVerbose(verbose bool) {
 	if c.rtlsdr != nil {
@@ -447,7 +1069,7 @@ func (c *Collector) Close() error {
 		}
 	}
 
-	if c.gps != nil {
+	if c.gps != nil && !c.sharedGPS {
 		if err := c.gps.Close(); err != nil {
 			errors = append(errors, fmt.Errorf("GPS close error: %w", err))
 		}
@@ -3,10 +3,13 @@ package collector
 import (
 	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"argus-collector/internal/config"
+	"argus-collector/internal/rtlsdr"
 )
 
 func TestCollectionNormalOperation(t *testing.T) {
@@ -196,3 +199,233 @@ func TestCollectionSuccess(t *testing.T) {
 
 	t.Logf("Collection succeeded in %v with %d file(s) created", elapsedTime, len(files))
 }
+
+func TestCheckStartTime(t *testing.T) {
+	now := time.Unix(1754591400, 0)
+
+	if err := CheckStartTime(now.Add(time.Minute), now); err != nil {
+		t.Errorf("future start time rejected: %v", err)
+	}
+	if err := CheckStartTime(now.Add(-5*time.Second), now); err != nil {
+		t.Errorf("start time within grace rejected: %v", err)
+	}
+	if err := CheckStartTime(now.Add(-time.Minute), now); err == nil {
+		t.Error("expected error for start time a minute in the past")
+	}
+}
+
+func TestOutputFilename(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "collector_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "test_1700000000.dat")
+	if got, err := OutputFilename(path, "error"); err != nil || got != path {
+		t.Fatalf("new file: got %q, %v", got, err)
+	}
+
+	// Occupy the path and its first suffixed alternative
+	for _, name := range []string{path, filepath.Join(tempDir, "test_1700000000_2.dat")} {
+		if err := os.WriteFile(name, []byte("ARGUS"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	if _, err := OutputFilename(path, "error"); err == nil {
+		t.Error("expected error for existing file with policy error")
+	}
+	if got, err := OutputFilename(path, "overwrite"); err != nil || got != path {
+		t.Errorf("overwrite: got %q, %v", got, err)
+	}
+	want := filepath.Join(tempDir, "test_1700000000_3.dat")
+	if got, err := OutputFilename(path, "suffix"); err != nil || got != want {
+		t.Errorf("suffix: got %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestFilenameTemplate(t *testing.T) {
+	fields := FilenameFields{
+		Prefix:    "argus",
+		Device:    "SN 0001/A",
+		Frequency: 433.92e6,
+		Start:     time.Date(2025, 7, 13, 10, 48, 26, 0, time.UTC),
+	}
+
+	got, err := ExpandFilenameTemplate("{prefix}_{freq_mhz}MHz_{device}_{datetime}", fields)
+	if want := "argus_433.920MHz_SN_0001_A_20250713T104826Z"; err != nil || got != want {
+		t.Errorf("got %q, %v, want %q", got, err, want)
+	}
+	got, err = ExpandFilenameTemplate("{date}-{freq}-{epoch}", fields)
+	if want := "20250713-433920000-1752403706"; err != nil || got != want {
+		t.Errorf("got %q, %v, want %q", got, err, want)
+	}
+
+	for _, template := range []string{
+		"",
+		"{prefix}_{device}",      // No time, captures would collide
+		"{prefix}_{frq}_{epoch}", // Unknown placeholder
+		"{prefix_{epoch}",        // Unbalanced brace
+		"data/{epoch}",           // Path separator
+		"{epoch}:{device}",       // Not allowed on Windows
+	} {
+		if err := ValidateFilenameTemplate(template); err == nil {
+			t.Errorf("expected error for template %q", template)
+		}
+	}
+
+	long := FilenameFields{Prefix: strings.Repeat("x", 300), Start: fields.Start}
+	if _, err := ExpandFilenameTemplate("{prefix}_{epoch}", long); err == nil {
+		t.Error("expected error for a name longer than the header can store")
+	}
+}
+
+func TestNextSequence(t *testing.T) {
+	tempDir := t.TempDir()
+	fields := FilenameFields{Prefix: "argus", Device: "0", Start: time.Unix(1752403706, 0)}
+	template := "{prefix}_{device}_{seq}"
+
+	if n, err := NextSequence(template, fields, tempDir); err != nil || n != 1 {
+		t.Fatalf("empty directory: got %d, %v, want 1", n, err)
+	}
+
+	for _, name := range []string{
+		"argus_0_001.dat",
+		"argus_0_007_2.dat", // Overwrite suffix
+		"argus_1_042.dat",   // Another device
+		"argus_0_099.json",  // Not a data file
+	} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("ARGUS"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	if n, err := NextSequence(template, fields, tempDir); err != nil || n != 8 {
+		t.Errorf("got %d, %v, want 8", n, err)
+	}
+	got, err := ExpandFilenameTemplate(template, FilenameFields{Prefix: "argus", Device: "0", Sequence: 8})
+	if want := "argus_0_008"; err != nil || got != want {
+		t.Errorf("got %q, %v, want %q", got, err, want)
+	}
+
+	// Time placeholders match any earlier capture's value
+	if err := os.WriteFile(filepath.Join(tempDir, "argus_20250101_012.dat"), []byte("ARGUS"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := NextSequence("{prefix}_{date}_{seq}", fields, tempDir); err != nil || n != 13 {
+		t.Errorf("with {date}: got %d, %v, want 13", n, err)
+	}
+
+	if n, err := NextSequence("{prefix}_{epoch}", fields, tempDir); err != nil || n != 0 {
+		t.Errorf("without {seq}: got %d, %v, want 0", n, err)
+	}
+}
+
+func TestCollectionTimestamp(t *testing.T) {
+	if got, want := CollectionTimestamp(time.Unix(1752403706, 0)), "1752403706"; got != want {
+		t.Errorf("whole second: got %q, want %q", got, want)
+	}
+	if got, want := CollectionTimestamp(time.Unix(1752403706, 1500)), "1752403706.000001500"; got != want {
+		t.Errorf("sub-second: got %q, want %q", got, want)
+	}
+}
+
+func TestUniqueCollectionID(t *testing.T) {
+	for _, want := range []string{"unique-test_1752403706", "unique-test_1752403706_2", "unique-test_1752403706_3"} {
+		if got := UniqueCollectionID("unique-test_1752403706"); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	// A counted ID already given out is skipped
+	UniqueCollectionID("unique-other_2")
+	UniqueCollectionID("unique-other")
+	if got, want := UniqueCollectionID("unique-other"), "unique-other_3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCaptureTimeout(t *testing.T) {
+	cfg := config.DefaultConfig().Collection
+	cfg.Duration = time.Hour
+
+	if got, want := CaptureTimeout(cfg, time.Second), 192*time.Minute+time.Second; got != want {
+		t.Errorf("default factor: got %v, want %v", got, want)
+	}
+
+	// An unset factor falls back to the default
+	cfg.TimeoutFactor = 0
+	if got, want := CaptureTimeout(cfg, 0), 192*time.Minute; got != want {
+		t.Errorf("unset factor: got %v, want %v", got, want)
+	}
+
+	cfg.TimeoutFactor = 1.5
+	if got, want := CaptureTimeout(cfg, 0), 90*time.Minute; got != want {
+		t.Errorf("factor 1.5: got %v, want %v", got, want)
+	}
+
+	// The cap bounds the timeout however long the capture
+	cfg.MaxRuntime = 70 * time.Minute
+	if got := CaptureTimeout(cfg, 0); got != cfg.MaxRuntime {
+		t.Errorf("capped: got %v, want %v", got, cfg.MaxRuntime)
+	}
+}
+
+func TestGainModeMax(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.RTLSDR.GainMode = "max"
+	cfg.GPS.Mode = "manual"
+	cfg.GPS.ManualLatitude = 35.533
+	cfg.GPS.ManualLongitude = -97.621
+
+	c := NewCollector(cfg)
+	if err := c.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize collector: %v", err)
+	}
+	defer c.Close()
+
+	gains, err := c.rtlsdr.GetTunerGainsFloat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.rtlsdr.GetGain(), gains[len(gains)-1]; got != want {
+		t.Errorf("Gain %.1f dB, want the highest supported %.1f dB", got, want)
+	}
+	if mode := c.rtlsdr.GetGainMode(); mode != "manual" {
+		t.Errorf("Device gain mode %q, want manual", mode)
+	}
+}
+
+func TestPowerLog(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "power.csv")
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// A second capture appends to the log without repeating the header
+	for _, id := range []string{"first", "second"} {
+		l, err := openPowerLog(filename)
+		if err != nil {
+			t.Fatalf("openPowerLog: %v", err)
+		}
+		monitor := l.monitor(id, start)
+		monitor(rtlsdr.ChunkPower{Time: start.Add(64 * time.Millisecond), Samples: 131072, Power: 0.1, Gain: 20.7})
+		monitor(rtlsdr.ChunkPower{Time: start.Add(128 * time.Millisecond), Samples: 131072, Power: 0, Gain: 20.7})
+		if err := l.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want a header and 4 rows:\n%s", len(lines), data)
+	}
+	if want := "2026-01-02T03:04:05.064000Z,0.064000,first,131072,0.100000,-20.00,20.7"; lines[1] != want {
+		t.Errorf("row 1: got %q, want %q", lines[1], want)
+	}
+	if !strings.HasSuffix(lines[4], ",0.000000,-200.00,20.7") || !strings.Contains(lines[4], ",second,") {
+		t.Errorf("silent chunk of second capture: got %q", lines[4])
+	}
+}
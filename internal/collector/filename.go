@@ -0,0 +1,221 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FilenameFields are the values substituted into an output filename template
+type FilenameFields struct {
+	Prefix       string    // Configured file prefix
+	CollectionID string    // Configured collection ID, may be empty
+	Device       string    // Device serial number or index
+	Frequency    float64   // Center frequency in Hz
+	Start        time.Time // Capture start time
+	Sequence     int       // Capture number, see NextSequence
+}
+
+// filenamePlaceholder matches a {name} placeholder in a filename template
+var filenamePlaceholder = regexp.MustCompile(`\{([a-z_]*)\}`)
+
+// filenameExpanders produce the value of each template placeholder
+var filenameExpanders = map[string]func(FilenameFields) string{
+	"prefix":   func(f FilenameFields) string { return f.Prefix },
+	"id":       func(f FilenameFields) string { return f.CollectionID },
+	"device":   func(f FilenameFields) string { return f.Device },
+	"freq":     func(f FilenameFields) string { return fmt.Sprintf("%.0f", f.Frequency) },
+	"freq_mhz": func(f FilenameFields) string { return fmt.Sprintf("%.3f", f.Frequency/1e6) },
+	"epoch":    func(f FilenameFields) string { return fmt.Sprintf("%d", f.Start.Unix()) },
+	"datetime": func(f FilenameFields) string { return f.Start.UTC().Format("20060102T150405Z") },
+	"date":     func(f FilenameFields) string { return f.Start.UTC().Format("20060102") },
+	"seq":      func(f FilenameFields) string { return fmt.Sprintf("%03d", f.Sequence) },
+}
+
+// timePatterns match any value of the placeholders that vary from capture to
+// capture, when looking for earlier captures' files
+var timePatterns = map[string]string{
+	"epoch":    `\d+`,
+	"datetime": `\d{8}T\d{6}Z`,
+	"date":     `\d{8}`,
+}
+
+// illegalFilenameChars may not appear in an output filename on any platform
+// the collector's files are copied to
+const illegalFilenameChars = "/\\:*?\"<>|"
+
+// maxFilenameLength bounds the expanded name, which is also stored as the
+// collection ID with a one-byte length in the file header
+const maxFilenameLength = 255
+
+// ValidateFilenameTemplate checks an output filename template. Every
+// placeholder must be known, and the name must contain {epoch}, {datetime}
+// or {seq} so repeated captures do not collide.
+func ValidateFilenameTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("filename template is empty")
+	}
+
+	for _, match := range filenamePlaceholder.FindAllStringSubmatch(template, -1) {
+		if _, ok := filenameExpanders[match[1]]; !ok {
+			return fmt.Errorf("unknown placeholder %s in filename template (known: %s)", match[0], filenamePlaceholderList())
+		}
+	}
+
+	// Literal text must be legal too; a brace left over is a mistyped placeholder
+	literal := filenamePlaceholder.ReplaceAllString(template, "")
+	if strings.ContainsAny(literal, "{}") {
+		return fmt.Errorf("unbalanced or invalid placeholder in filename template %q", template)
+	}
+	if strings.ContainsAny(literal, illegalFilenameChars) || strings.ContainsRune(literal, 0) {
+		return fmt.Errorf("filename template %q contains a character not allowed in filenames (%s)", template, illegalFilenameChars)
+	}
+
+	if !strings.Contains(template, "{epoch}") && !strings.Contains(template, "{datetime}") && !strings.Contains(template, "{seq}") {
+		return fmt.Errorf("filename template %q must contain {epoch}, {datetime} or {seq} so captures get distinct names", template)
+	}
+	return nil
+}
+
+// ExpandFilenameTemplate returns the output filename, without the .dat
+// extension, that template gives for fields. Characters in the substituted
+// values that are not allowed in filenames are replaced with '_'.
+func ExpandFilenameTemplate(template string, fields FilenameFields) (string, error) {
+	if err := ValidateFilenameTemplate(template); err != nil {
+		return "", err
+	}
+
+	name := filenamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return sanitizeFilenameValue(filenameExpanders[placeholder[1:len(placeholder)-1]](fields))
+	})
+
+	if len(name) > maxFilenameLength {
+		return "", fmt.Errorf("filename template %q gives a name of %d bytes, the limit is %d", template, len(name), maxFilenameLength)
+	}
+	return name, nil
+}
+
+// sanitizeFilenameValue replaces the characters of a substituted value that
+// are not allowed in filenames with '_'
+func sanitizeFilenameValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == 0 || r == ' ' || strings.ContainsRune(illegalFilenameChars, r) {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// NextSequence returns the {seq} number the next capture named by template
+// takes: one past the highest among the .dat files in dir that the template,
+// with fields, could have named, or 1 if there are none. Numbering so
+// continues where an interrupted or earlier run left off instead of starting
+// over and colliding with its files.
+func NextSequence(template string, fields FilenameFields, dir string) (int, error) {
+	if !strings.Contains(template, "{seq}") {
+		return 0, nil
+	}
+
+	// Fixed placeholders must match their value, time placeholders anything
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range filenamePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		name := template[loc[2]:loc[3]]
+		switch {
+		case name == "seq":
+			pattern.WriteString(`(\d+)`)
+		case timePatterns[name] != "":
+			pattern.WriteString(timePatterns[name])
+		default:
+			expand, ok := filenameExpanders[name]
+			if !ok {
+				return 0, fmt.Errorf("unknown placeholder {%s} in filename template (known: %s)", name, filenamePlaceholderList())
+			}
+			pattern.WriteString(regexp.QuoteMeta(sanitizeFilenameValue(expand(fields))))
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString(`(_\d+)?\.dat$`) // Any overwrite or session counter suffix
+	earlier, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return 0, fmt.Errorf("invalid filename template %q: %w", template, err)
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("failed to scan output directory for earlier captures: %w", err)
+	}
+	highest := 0
+	for _, entry := range entries {
+		match := earlier.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		// Every {seq} in the template holds the same number; take the first
+		if n, err := strconv.Atoi(match[1]); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1, nil
+}
+
+// filenamePlaceholderList returns the known placeholders for error messages
+func filenamePlaceholderList() string {
+	names := make([]string, 0, len(filenameExpanders))
+	for name := range filenameExpanders {
+		names = append(names, "{"+name+"}")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// CollectionTimestamp formats a capture start time for a generated collection
+// ID: Unix seconds for a start on a whole second, as synchronized and
+// scheduled starts are, so stations' files share the suffix, otherwise with
+// the nanoseconds appended so captures started within one second differ
+func CollectionTimestamp(start time.Time) string {
+	if start.Nanosecond() == 0 {
+		return fmt.Sprintf("%d", start.Unix())
+	}
+	return fmt.Sprintf("%d.%09d", start.Unix(), start.Nanosecond())
+}
+
+// issuedIDs holds the collection IDs given out in this process
+var issuedIDs = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// UniqueCollectionID returns id, or id with a "_<n>" counter appended when an
+// earlier capture in this process was given the same id, so a filename
+// template or repeated start time cannot make two captures of one session
+// write the same file
+func UniqueCollectionID(id string) string {
+	issuedIDs.Lock()
+	defer issuedIDs.Unlock()
+	for {
+		issuedIDs.counts[id]++
+		n := issuedIDs.counts[id]
+		if n == 1 {
+			return id
+		}
+		candidate := fmt.Sprintf("%s_%d", id, n)
+		if issuedIDs.counts[candidate] == 0 {
+			issuedIDs.counts[candidate] = 1
+			return candidate
+		}
+	}
+}
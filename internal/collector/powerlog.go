@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"argus-collector/internal/rtlsdr"
+)
+
+// powerLogHeader names the columns of a power log
+var powerLogHeader = []string{"time_utc", "elapsed_s", "collection_id", "samples", "rms", "power_dbfs", "gain_db"}
+
+// powerLog appends the received power of each chunk read during a capture to
+// a CSV file, giving a time series that helps explain interference or
+// dropouts found later in the data. Repeated captures append to the same log.
+type powerLog struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+	err    error // First write error; later rows are dropped
+	closed bool
+}
+
+// openPowerLog opens filename for appending, writing the header if the file
+// is new or empty
+func openPowerLog(filename string) (*powerLog, error) {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open power log: %w", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open power log: %w", err)
+	}
+
+	l := &powerLog{file: file, writer: csv.NewWriter(file)}
+	if stat.Size() == 0 {
+		l.err = l.writer.Write(powerLogHeader)
+	}
+	return l, nil
+}
+
+// monitor returns a power monitor logging the chunks of one capture, with
+// their time from start, the time of the capture's first sample
+func (l *powerLog) monitor(collectionID string, start time.Time) rtlsdr.PowerMonitor {
+	return func(p rtlsdr.ChunkPower) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.err != nil || l.closed {
+			return
+		}
+		l.err = l.writer.Write([]string{
+			p.Time.UTC().Format("2006-01-02T15:04:05.000000Z"),
+			strconv.FormatFloat(p.Time.Sub(start).Seconds(), 'f', 6, 64),
+			collectionID,
+			strconv.Itoa(p.Samples),
+			strconv.FormatFloat(p.Power, 'f', 6, 64),
+			strconv.FormatFloat(powerDBFS(p.Power), 'f', 2, 64),
+			strconv.FormatFloat(p.Gain, 'f', 1, 64),
+		})
+	}
+}
+
+// Close flushes and closes the log, reporting the first write error
+func (l *powerLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	l.writer.Flush()
+	if l.err == nil {
+		l.err = l.writer.Error()
+	}
+	closeErr := l.file.Close()
+	if l.err != nil {
+		return fmt.Errorf("failed to write power log: %w", l.err)
+	}
+	return closeErr
+}
+
+// powerDBFS converts an RMS amplitude to dB relative to full scale, with
+// silence at -200 dBFS rather than -Inf
+func powerDBFS(rms float64) float64 {
+	return math.Max(20*math.Log10(rms), -200)
+}
@@ -0,0 +1,227 @@
+//go:build !rtlsdr
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"argus-collector/internal/filewriter"
+	"argus-collector/internal/gps"
+	"argus-collector/internal/processor"
+	"argus-collector/internal/rtlsdr"
+)
+
+// TestProcessorRecoversInjectedDelay feeds the stub devices one synthetic
+// transmission arriving at different times and checks the processor measures
+// the injected delays
+func TestProcessorRecoversInjectedDelay(t *testing.T) {
+	// Within what the ~5 km baselines between the test stations allow
+	offsets := []time.Duration{0, 5 * time.Microsecond, 12 * time.Microsecond}
+
+	for _, kind := range []string{"noise", "chirp"} {
+		t.Run(kind, func(t *testing.T) {
+			collectors := newTestCollectors(t, t.TempDir())
+			for i, c := range collectors {
+				c.rtlsdr.SetSyntheticSignal(&rtlsdr.SyntheticSignal{
+					Kind:   kind,
+					Seed:   42,
+					Offset: offsets[i],
+				})
+			}
+
+			checkInjectedDelays(t, collectAndProcess(t, collectors), offsets)
+		})
+	}
+}
+
+// TestProcessorCorrelatesSegment checks that correlating only a segment of
+// the captures measures the same delays as the whole capture
+func TestProcessorCorrelatesSegment(t *testing.T) {
+	offsets := []time.Duration{0, 5 * time.Microsecond, 12 * time.Microsecond}
+
+	collectors := newTestCollectors(t, t.TempDir())
+	for i, c := range collectors {
+		c.rtlsdr.SetSyntheticSignal(&rtlsdr.SyntheticSignal{Seed: 7, Offset: offsets[i]})
+	}
+	files := collectFiles(t, collectors)
+
+	cfg := defaultProcessorConfig()
+	cfg.CorrStart = 120 * time.Millisecond
+	cfg.CorrDuration = 40 * time.Millisecond
+	checkInjectedDelays(t, processFiles(t, files, cfg), offsets)
+
+	// A segment past the end of the captures is an error
+	cfg = defaultProcessorConfig()
+	cfg.CorrStart = time.Second
+	proc, err := processor.NewProcessor(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+	if _, err := proc.ProcessFiles(files); err == nil {
+		t.Error("Expected error for a segment starting after the captures end")
+	}
+}
+
+// TestGapKeepsSampleTiming drops samples from one receiver mid-capture and
+// checks the gap is recorded and the processor still measures the injected
+// delays in a segment after it
+func TestGapKeepsSampleTiming(t *testing.T) {
+	offsets := []time.Duration{0, 5 * time.Microsecond, 12 * time.Microsecond}
+
+	collectors := newTestCollectors(t, t.TempDir())
+	for i, c := range collectors {
+		c.rtlsdr.SetSyntheticSignal(&rtlsdr.SyntheticSignal{Seed: 11, Offset: offsets[i]})
+	}
+	collectors[1].rtlsdr.SetOverrun(50*time.Millisecond, 3*time.Millisecond)
+	files := collectFiles(t, collectors)
+
+	metadata, samples, err := filewriter.ReadFile(files[1])
+	if err != nil {
+		t.Fatalf("Failed to read capture with a gap: %v", err)
+	}
+	want := []filewriter.Gap{{SampleIndex: 102400, Duration: 3 * time.Millisecond}}
+	if !slices.Equal(metadata.Gaps, want) {
+		t.Errorf("Recorded gaps %v, want %v", metadata.Gaps, want)
+	}
+	// 3 ms of the 200 ms capture at 2.048 MSps are missing
+	if want := 409600 - 6144; len(samples) != want {
+		t.Errorf("Saved %d samples, want %d", len(samples), want)
+	}
+
+	cfg := defaultProcessorConfig()
+	cfg.CorrStart = 120 * time.Millisecond
+	cfg.CorrDuration = 40 * time.Millisecond
+	checkInjectedDelays(t, processFiles(t, files, cfg), offsets)
+}
+
+// TestDeviceLostSavesPartialCapture unplugs the stub device mid-capture and
+// checks the samples read so far are saved to a valid file flagged as cut short
+func TestDeviceLostSavesPartialCapture(t *testing.T) {
+	c := newTestCollectors(t, t.TempDir())[0]
+	c.rtlsdr.SetDisconnectAfter(50 * time.Millisecond)
+
+	err := c.CollectWithContext(context.Background())
+	if !errors.Is(err, rtlsdr.ErrDeviceGone) {
+		t.Fatalf("Expected ErrDeviceGone, got %v", err)
+	}
+	if c.LastFile() == "" {
+		t.Fatal("Partial capture was not saved")
+	}
+
+	metadata, samples, err := filewriter.ReadFile(c.LastFile())
+	if err != nil {
+		t.Fatalf("Partial capture is not readable: %v", err)
+	}
+	if !metadata.DeviceLost {
+		t.Error("Partial capture is not flagged as device lost")
+	}
+	// 50 ms of the 200 ms capture at 2.048 MSps
+	if want := 102400; len(samples) != want {
+		t.Errorf("Saved %d samples, want %d", len(samples), want)
+	}
+}
+
+// stubGPS is a receiver with a valid fix at a fixed position until lostAt
+type stubGPS struct {
+	position gps.Position
+	lostAt   time.Time
+}
+
+func (g *stubGPS) Start() error { return nil }
+
+func (g *stubGPS) WaitForFix(timeout time.Duration) (*gps.Position, error) {
+	return g.GetCurrentPosition()
+}
+
+func (g *stubGPS) GetCurrentPosition() (*gps.Position, error) {
+	pos := g.position
+	pos.Timestamp = time.Now()
+	if !g.IsFixValid() {
+		pos.FixQuality = 0
+	}
+	return &pos, nil
+}
+
+func (g *stubGPS) IsFixValid() bool { return time.Now().Before(g.lostAt) }
+
+func (g *stubGPS) GetFixQualityString() string {
+	if g.IsFixValid() {
+		return "GPS Fix"
+	}
+	return "No Fix"
+}
+
+func (g *stubGPS) GetClockOffset() (time.Duration, error) { return 0, nil }
+func (g *stubGPS) Updates() <-chan gps.Position           { return nil }
+func (g *stubGPS) Close() error                           { return nil }
+
+// TestFixLostDiscardsCapture drops the stub GPS fix mid-capture with
+// --require-fix-throughout and checks the capture stops and leaves no file,
+// whether it is held in memory or streamed to disk
+func TestFixLostDiscardsCapture(t *testing.T) {
+	for _, syncInterval := range []time.Duration{0, 100 * time.Millisecond} {
+		t.Run(fmt.Sprintf("sync interval %v", syncInterval), func(t *testing.T) {
+			dir := t.TempDir()
+			c := newTestCollectors(t, dir)[0]
+			c.config.Collection.Duration = 5 * time.Second
+			c.config.Collection.SyncInterval = syncInterval
+			c.config.Collection.SidecarJSON = true
+			c.config.GPS.Mode = "nmea"
+			c.config.GPS.RequireFixThroughout = true
+			c.gps = gps.NewGPSWith(&stubGPS{
+				position: gps.Position{Latitude: 35.533, Longitude: -97.621, Altitude: 365, FixQuality: 1, Satellites: 8},
+				lostAt:   time.Now().Add(500 * time.Millisecond),
+			})
+
+			start := time.Now()
+			err := c.CollectWithContext(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "GPS fix lost") {
+				t.Fatalf("Expected a GPS fix lost error, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed >= c.config.Collection.Duration {
+				t.Errorf("Capture ran for %v, not stopped at the fix loss", elapsed)
+			}
+			if c.LastFile() != "" {
+				t.Errorf("Aborted capture reported as saved to %s", c.LastFile())
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				t.Errorf("Aborted capture left %s behind", e.Name())
+			}
+		})
+	}
+}
+
+// checkInjectedDelays checks that every measurement of result matches the
+// difference of the injected offsets, to the nearest sample
+func checkInjectedDelays(t *testing.T, result *processor.Result, offsets []time.Duration) {
+	t.Helper()
+
+	const sampleRate = 2048000
+	// Delays are only resolved to whole samples
+	samplePeriod := 1e9 / float64(sampleRate)
+	toSamples := func(d time.Duration) float64 {
+		return math.Round(d.Seconds() * sampleRate)
+	}
+	ids := map[string]int{"R1": 0, "R2": 1, "R3": 2}
+	if len(result.TDOAMeasurements) == 0 {
+		t.Fatal("No TDOA measurements")
+	}
+	for _, m := range result.TDOAMeasurements {
+		want := (toSamples(offsets[ids[m.Receiver2ID]]) - toSamples(offsets[ids[m.Receiver1ID]])) * samplePeriod
+		if math.Abs(m.TimeDiff-want) > samplePeriod/2 {
+			t.Errorf("%s-%s: measured %.0f ns, injected %.0f ns", m.Receiver1ID, m.Receiver2ID, m.TimeDiff, want)
+		}
+	}
+}
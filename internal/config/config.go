@@ -2,6 +2,10 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"runtime"
 	"time"
 )
 
@@ -19,17 +23,23 @@ type RTLSDRConfig struct {
 	SampleRate         uint32  `yaml:"sample_rate"`          // Sample rate in Hz
 	Gain               float64 `yaml:"gain"`                 // RF gain in dB (used when GainMode is "manual")
 	GainMode           string  `yaml:"gain_mode"`            // Gain mode: "auto" (AGC) or "manual"
+	AGCSmoothing       float64 `yaml:"agc_smoothing"`        // Weight of each new chunk in the AGC power average (0-1], 1 = unsmoothed, 0 = default
 	DeviceIndex        int     `yaml:"device_index"`         // RTL-SDR device index (0-based, used if SerialNumber is empty)
 	SerialNumber       string  `yaml:"serial_number"`        // RTL-SDR device serial number (preferred over device_index)
 	BiasTee            bool    `yaml:"bias_tee"`             // Enable bias tee for powering external LNAs
 	FrequencyCorrection int     `yaml:"frequency_correction"` // Frequency correction in PPM
+	LNAGain             float64 `yaml:"lna_gain"`             // External LNA gain in dB, recorded in metadata only
+	Antenna             string  `yaml:"antenna"`              // Antenna description, recorded in metadata only
+	ReadChunkBytes      int           `yaml:"read_chunk_bytes"` // Bytes requested per device read, a positive multiple of 2
+	ReadTimeout         time.Duration `yaml:"read_timeout"`     // Longest a device read may take before the capture ends as overrun
+	SwapIQ              bool          `yaml:"swap_iq"`          // Exchange I and Q of each sample, for hardware that delivers Q first
 }
 
 // GPSConfig contains GPS receiver configuration parameters
 type GPSConfig struct {
 	Mode            string        `yaml:"mode"`             // GPS mode: "nmea", "gpsd", or "manual"
 	Port            string        `yaml:"port"`             // Serial port device path (for NMEA mode)
-	BaudRate        int           `yaml:"baud_rate"`        // Serial communication baud rate (for NMEA mode)
+	BaudRate        int           `yaml:"baud_rate"`        // Serial communication baud rate (for NMEA mode), 0 or "auto" to detect it
 	GPSDHost        string        `yaml:"gpsd_host"`        // GPSD host address (for gpsd mode)
 	GPSDPort        string        `yaml:"gpsd_port"`        // GPSD port (for gpsd mode)
 	Timeout         time.Duration `yaml:"timeout"`          // Timeout for GPS fix acquisition
@@ -37,6 +47,14 @@ type GPSConfig struct {
 	ManualLatitude  float64       `yaml:"manual_latitude"`  // Manual latitude in decimal degrees
 	ManualLongitude float64       `yaml:"manual_longitude"` // Manual longitude in decimal degrees
 	ManualAltitude  float64       `yaml:"manual_altitude"`  // Manual altitude in meters
+	NoPosition      bool          `yaml:"no_position"`      // Skip GPS and record no position (bench testing)
+
+	DEMDir           string `yaml:"dem_dir"`           // Directory of SRTM .hgt tiles giving the manual altitude when none is set
+	AltitudeRequired bool   `yaml:"altitude_required"` // Refuse manual mode without an altitude from the config, flags or DEM
+
+	ClockOffsetThreshold time.Duration `yaml:"clock_offset_threshold"` // Warn if system clock differs from GPS time by more than this
+	FixWatch             time.Duration `yaml:"fix_watch"`              // Keep reporting satellites for this long after the fix (station siting)
+	RequireFixThroughout bool          `yaml:"require_fix_throughout"` // Abort collection if the GPS fix is lost or goes stale mid-capture
 }
 
 // CollectionConfig contains data collection configuration parameters
@@ -47,6 +65,18 @@ type CollectionConfig struct {
 	CollectionID string        `yaml:"collection_id"` // Collection identifier for filename
 	SyncedStart  bool          `yaml:"synced_start"`  // Enable synchronized start timing
 	StartTime    int64         `yaml:"start_time"`    // Exact epoch timestamp for collection start
+	SampleFormat string        `yaml:"sample_format"` // Sample storage format: "complex64", "int16" or "uint8"
+	SidecarJSON  bool          `yaml:"sidecar_json"`  // Also write metadata as collectionID.json
+	Pretrigger   time.Duration `yaml:"pretrigger"`    // Samples kept from before the start time
+	Repeat       int           `yaml:"repeat"`        // Number of captures, each re-aligned to the next synced start
+	Overwrite    string        `yaml:"overwrite"`     // Existing output file policy: "suffix", "error" or "overwrite"
+
+	TimeoutFactor float64       `yaml:"timeout_factor"` // Capture timeout as a multiple of duration (plus pre-trigger)
+	MaxRuntime    time.Duration `yaml:"max_runtime"`    // Absolute cap on one capture's wait, 0 = no cap
+	SyncInterval  time.Duration `yaml:"sync_interval"`  // Stream samples to disk, syncing this often; 0 = write at the end
+	PowerLog      string        `yaml:"power_log"`      // CSV file the power of each read chunk is appended to; empty = no log
+
+	FilenameTemplate string `yaml:"filename_template"` // Output filename with {prefix}, {device}, {epoch}, ... placeholders; empty = default naming
 }
 
 // LoggingConfig contains logging configuration parameters
@@ -55,6 +85,15 @@ type LoggingConfig struct {
 	File  string `yaml:"file"`  // Log file path
 }
 
+// DefaultGPSPort returns the usual serial port of a USB GPS receiver on
+// this platform
+func DefaultGPSPort() string {
+	if runtime.GOOS == "windows" {
+		return "COM3"
+	}
+	return "/dev/ttyUSB0"
+}
+
 // DefaultConfig returns a configuration with sensible default values
 func DefaultConfig() *Config {
 	return &Config{
@@ -63,14 +102,17 @@ func DefaultConfig() *Config {
 			SampleRate:         2048000,  // 2.048 MSps
 			Gain:               20.7,     // 20.7 dB gain
 			GainMode:           "manual", // Manual gain control by default
+			AGCSmoothing:       0.3,      // Average AGC power over a few chunks
 			DeviceIndex:        0,        // First RTL-SDR device
 			SerialNumber:       "",       // Use device_index by default
 			BiasTee:            false,    // Bias tee disabled by default
 			FrequencyCorrection: 0,       // No frequency correction by default
+			ReadChunkBytes:      262144,          // 256 KB per read, 64 ms at 2.048 MSps
+			ReadTimeout:         2 * time.Second, // A read stalled for 2 s ends the capture
 		},
 		GPS: GPSConfig{
 			Mode:            "nmea",           // Default to NMEA serial mode
-			Port:            "/dev/ttyUSB0",   // Common USB GPS device path
+			Port:            DefaultGPSPort(), // Common USB GPS port for the platform
 			BaudRate:        9600,             // Standard NMEA baud rate
 			GPSDHost:        "localhost",      // Default gpsd host
 			GPSDPort:        "2947",           // Default gpsd port
@@ -79,6 +121,9 @@ func DefaultConfig() *Config {
 			ManualLatitude:  0.0,              // Default latitude (equator)
 			ManualLongitude: 0.0,              // Default longitude (prime meridian)
 			ManualAltitude:  0.0,              // Default altitude (sea level)
+
+			ClockOffsetThreshold: 50 * time.Millisecond, // 50 ms clock offset warning threshold
+			RequireFixThroughout: false,                 // Only the fix at the start of collection is required
 		},
 		Collection: CollectionConfig{
 			Duration:     60 * time.Second, // 60 second collection duration
@@ -86,6 +131,15 @@ func DefaultConfig() *Config {
 			FilePrefix:   "argus",          // File prefix for output files
 			CollectionID: "",               // No default collection ID
 			SyncedStart:  true,             // Enable synchronized start by default
+			SampleFormat: "complex64",      // Store samples as float32 I/Q pairs
+			SidecarJSON:  false,            // Binary header only by default
+			Pretrigger:   0,                // No pre-trigger context by default
+			Repeat:       1,                // Single capture by default
+			Overwrite:    "suffix",         // Never replace an existing capture by default
+
+			TimeoutFactor: 3.2, // Allow 3.2x the duration before declaring a capture hung
+			MaxRuntime:    0,   // No absolute cap by default
+			SyncInterval:  0,   // Write the file once the capture completes
 		},
 		Logging: LoggingConfig{
 			Level: "info",      // Info level logging
@@ -93,3 +147,13 @@ func DefaultConfig() *Config {
 		},
 	}
 }
+
+// Hash returns a short fingerprint of the effective configuration. Captures
+// recorded with the same hash were made with identical settings.
+func (c *Config) Hash() string {
+	// Every field is a plain value, so marshaling cannot fail and field order
+	// is fixed by the struct definitions
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
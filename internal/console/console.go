@@ -0,0 +1,185 @@
+// Package console removes the decoration (emoji, box drawing and other
+// symbols) from the tools' terminal output for logs, scripts and terminals
+// without UTF-8 support.
+package console
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// replacements are plain spellings of symbols that carry meaning in the output
+var replacements = map[rune]string{
+	'→': "->",
+	'←': "<-",
+	'↔': "<->",
+	'↑': "up",
+	'↓': "down",
+	'±': "+/-",
+	'°': "deg",
+	'Δ': "d",
+	'μ': "u",
+	'µ': "u",
+	'²': "^2",
+	'×': "x",
+	'…': "...",
+	'•': "-",
+	'≈': "~",
+	'≤': "<=",
+	'≥': ">=",
+	'–': "-",
+	'—': "-",
+}
+
+// isDecoration reports whether r is an emoji, pictograph, box-drawing or
+// similar character that only decorates the output
+func isDecoration(r rune) bool {
+	switch {
+	case r == 0x200D || r == 0x20E3 || r == 0xFE0F: // Joiners and emoji presentation selectors
+		return true
+	case r == 0x2139: // Information source
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // Miscellaneous technical (hourglass, stopwatch)
+		return true
+	case r >= 0x2500 && r <= 0x25FF: // Box drawing, block elements, geometric shapes
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // Miscellaneous symbols and arrows (stars)
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF: // Emoji and pictographs
+		return true
+	}
+	return false
+}
+
+// Plain returns line with decoration removed, along with the spaces that
+// followed each removed character, and symbols spelled out in ASCII. keep is
+// false for a line that held nothing but decoration, such as a banner rule.
+func Plain(line string) (plain string, keep bool) {
+	var b strings.Builder
+	decorated := false
+	afterDecoration := false
+	for _, r := range line {
+		if isDecoration(r) {
+			decorated = true
+			afterDecoration = true
+			continue
+		}
+		if afterDecoration && r == ' ' {
+			continue
+		}
+		afterDecoration = false
+		if s, ok := replacements[r]; ok {
+			b.WriteString(s)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	plain = b.String()
+	if decorated {
+		plain = strings.TrimRight(plain, " ")
+		if strings.TrimSpace(plain) == "" {
+			return "", false
+		}
+	}
+	return plain, true
+}
+
+// Writer passes what is written to it on to another writer a line at a time,
+// with decoration removed as described for Plain. A carriage return also ends
+// a line, so progress redrawn in place is logged as one line per update. It
+// is safe for concurrent use.
+type Writer struct {
+	mu   sync.Mutex
+	dst  io.Writer
+	line []byte // Unfinished line
+}
+
+// NewWriter returns a Writer filtering output to dst. Call Flush, or Exit
+// instead of os.Exit, before the program ends so an unfinished last line is
+// written.
+func NewWriter(dst io.Writer) *Writer {
+	w := &Writer{dst: dst}
+	OnFlush(w.Flush)
+	return w
+}
+
+// Write filters the complete lines of p to the destination and holds the rest
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, c := range p {
+		switch c {
+		case '\n':
+			if err := w.writeLine("\n"); err != nil {
+				return 0, err
+			}
+		case '\r':
+			if len(w.line) > 0 {
+				if err := w.writeLine("\n"); err != nil {
+					return 0, err
+				}
+			}
+		default:
+			w.line = append(w.line, c)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes the unfinished line, if any, without a line ending
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.line) > 0 {
+		w.writeLine("")
+	}
+}
+
+// writeLine writes the held line, filtered and followed by end, and clears it
+func (w *Writer) writeLine(end string) error {
+	plain, keep := Plain(string(w.line))
+	w.line = w.line[:0]
+	if !keep {
+		return nil
+	}
+	_, err := io.WriteString(w.dst, plain+end)
+	return err
+}
+
+var (
+	mu       sync.Mutex
+	flushers []func() // Registered with OnFlush, in order
+)
+
+// OnFlush registers f to be called by Flush, and so by Exit, for output that
+// is held back, such as by a live display, to be written before the program
+// ends. Functions are called latest first.
+func OnFlush(f func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	flushers = append(flushers, f)
+}
+
+// Flush calls the functions registered with OnFlush, writing the unfinished
+// lines of every Writer
+func Flush() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := len(flushers) - 1; i >= 0; i-- {
+		flushers[i]()
+	}
+	flushers = nil
+}
+
+// Exit flushes the output and exits the program with code
+func Exit(code int) {
+	Flush()
+	os.Exit(code)
+}
@@ -0,0 +1,66 @@
+package console
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlain(t *testing.T) {
+	tests := []struct {
+		line  string
+		plain string
+		keep  bool
+	}{
+		{"✅ TDOA Processing Complete!", "TDOA Processing Complete!", true},
+		{"   📁 Loading argus-0.dat (1.2 MB) (1/3)...", "   Loading argus-0.dat (1.2 MB) (1/3)...", true},
+		{"⚠️  Skipping argus-2.dat", "Skipping argus-2.dat", true},
+		{"🖼️  Plot written to capture.png", "Plot written to capture.png", true},
+		{"      ✅ R1↔R2: Δt=12.5ns", "      R1<->R2: dt=12.5ns", true},
+		{"Estimated Location: 35.600000°, -97.600000°", "Estimated Location: 35.600000deg, -97.600000deg", true},
+		{"━━━━━━━━━━━━━━━━━━━━", "", false},
+		{"", "", true},
+		{"Plain ASCII line", "Plain ASCII line", true},
+	}
+
+	for _, tt := range tests {
+		plain, keep := Plain(tt.line)
+		if plain != tt.plain || keep != tt.keep {
+			t.Errorf("Plain(%q) = %q, %v; want %q, %v", tt.line, plain, keep, tt.plain, tt.keep)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	input := "📊 Results Summary:\n════════\nProgress 10%\rProgress 50%\rProgress 100%\nlast line"
+	want := "Results Summary:\nProgress 10%\nProgress 50%\nProgress 100%\nlast line"
+
+	var out strings.Builder
+	w := NewWriter(&out)
+	// Split mid-line and mid-rune, as a caller's writes may be
+	for _, part := range []string{input[:10], input[10:30], input[30:]} {
+		if _, err := io.WriteString(w, part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := out.String(); got != strings.TrimSuffix(want, "last line") {
+		t.Errorf("output before Flush = %q, want the complete lines only", got)
+	}
+	Flush()
+	if out.String() != want {
+		t.Errorf("Writer output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestFlushCallsOnFlushLatestFirst(t *testing.T) {
+	var calls []int
+	OnFlush(func() { calls = append(calls, 1) })
+	OnFlush(func() { calls = append(calls, 2) })
+
+	Flush()
+	Flush()
+	if want := []int{2, 1}; !slices.Equal(calls, want) {
+		t.Errorf("flush calls = %v, want %v", calls, want)
+	}
+}
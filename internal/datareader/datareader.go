@@ -0,0 +1,470 @@
+// Package datareader provides fast bulk reading of argus data files, memory
+// mapping large captures so samples can be decoded without per-sample reads
+package datareader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	"argus-collector/internal/filewriter"
+)
+
+// MmapThreshold is the file size above which files are memory mapped
+const MmapThreshold = 50 * 1024 * 1024
+
+// readChunkSamples bounds each streamed read so the raw read buffer stays small
+const readChunkSamples = 65536
+
+// hostLittleEndian reports whether the host stores integers and floats in the
+// file's little-endian byte order. The unsafe fast paths below reinterpret file
+// bytes in place and are only correct when it is true; big-endian hosts decode
+// through encoding/binary instead.
+var hostLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// le16, le32 and le64 read little-endian values from the start of b
+func le16(b []byte) uint16 {
+	if hostLittleEndian {
+		return *(*uint16)(unsafe.Pointer(&b[0]))
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func le32(b []byte) uint32 {
+	if hostLittleEndian {
+		return *(*uint32)(unsafe.Pointer(&b[0]))
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func le64(b []byte) uint64 {
+	if hostLittleEndian {
+		return *(*uint64)(unsafe.Pointer(&b[0]))
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// mmapFile maps a file into memory; a variable so tests can make it fail
+var mmapFile = mapFile
+
+// Reader provides optimized file I/O for argus data files. Files larger than
+// MmapThreshold are memory mapped; smaller and gzip-compressed files are
+// streamed, as are large files on systems where mapping fails.
+type Reader struct {
+	filename    string
+	file        *os.File
+	mmap        []byte
+	size        int64
+	metadata    *filewriter.Metadata
+	sampleCount uint32
+	dataOffset  int
+	samples     *filewriter.SampleReader
+	workers     int   // Goroutines decoding streamed samples, 1 decodes on the reading goroutine
+	swapIQ      bool  // Exchange I and Q of each sample read
+	compressed  bool  // File is gzip-compressed and read through filewriter.SampleReader
+	mmapErr     error // Why mapping a large file failed, nil if it was not attempted or succeeded
+}
+
+// NewReader opens filename and reads its header
+func NewReader(filename string) (*Reader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	r := &Reader{
+		filename: filename,
+		file:     file,
+		size:     stat.Size(),
+		workers:  1,
+	}
+
+	// Memory map the entire file for large files; a compressed file can only
+	// be read through the decompressor. Some filesystems cannot be mapped and
+	// mapping fails under memory pressure, so fall back to streaming then.
+	r.compressed = filewriter.IsCompressed(file)
+	if r.size > MmapThreshold && !r.compressed {
+		r.mmap, r.mmapErr = mmapFile(file.Fd(), int(r.size))
+		if r.mmapErr != nil {
+			r.mmap = nil
+		}
+	}
+	if r.mmap != nil {
+		r.metadata, r.sampleCount, r.dataOffset, err = parseHeader(r.mmap)
+	} else {
+		r.samples, err = filewriter.NewSampleReader(filename)
+		if err == nil {
+			r.metadata = r.samples.Metadata()
+			r.sampleCount = r.samples.SampleCount()
+		}
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Metadata returns the file header
+func (r *Reader) Metadata() *filewriter.Metadata {
+	return r.metadata
+}
+
+// SampleCount returns the number of samples recorded in the header
+func (r *Reader) SampleCount() uint32 {
+	return r.sampleCount
+}
+
+// MemoryMapped reports whether the file is memory mapped
+func (r *Reader) MemoryMapped() bool {
+	return r.mmap != nil
+}
+
+// MmapError returns why memory mapping a large file failed, leaving it to be
+// streamed, or nil if mapping succeeded or was not attempted
+func (r *Reader) MmapError() error {
+	return r.mmapErr
+}
+
+// SetDecodeWorkers sets the number of goroutines decoding samples of a
+// streamed (not memory mapped) file, 0 for one per CPU. Reading stays on one
+// goroutine; decoding is CPU-bound and spreads over the workers. The default
+// of 1 decodes on the reading goroutine.
+func (r *Reader) SetDecodeWorkers(n int) {
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	r.workers = n
+}
+
+// SetSwapIQ exchanges I and Q of every sample read, correcting a capture
+// from hardware that delivered Q before I
+func (r *Reader) SetSwapIQ(swap bool) {
+	r.swapIQ = swap
+}
+
+// Size returns the file size in bytes
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// Close closes the file reader and cleans up resources
+func (r *Reader) Close() error {
+	var err error
+	if r.mmap != nil {
+		if unmapErr := unmapFile(r.mmap); unmapErr != nil {
+			err = fmt.Errorf("failed to unmap memory: %w", unmapErr)
+		}
+	}
+	if r.samples != nil {
+		r.samples.Close()
+	}
+	if r.file != nil {
+		if closeErr := r.file.Close(); closeErr != nil {
+			if err != nil {
+				err = fmt.Errorf("multiple errors: %v, %v", err, closeErr)
+			} else {
+				err = closeErr
+			}
+		}
+	}
+	return err
+}
+
+// ReadFile reads every sample recorded in the header. A file holding fewer
+// samples than its header claims returns filewriter.ErrTruncated.
+func (r *Reader) ReadFile() (*filewriter.Metadata, []complex64, error) {
+	samples := make([]complex64, r.sampleCount)
+	n, err := r.ReadSamples(0, samples)
+	if err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	if n < len(samples) {
+		return nil, nil, fmt.Errorf("%w: expected %d samples, got %d", filewriter.ErrTruncated, r.sampleCount, n)
+	}
+	return r.metadata, samples, nil
+}
+
+// ReadSamples decodes samples starting at sample index start into out and
+// returns the number decoded. It returns io.EOF if the file ends before out
+// is filled. Unlike ReadFile it reads up to the end of the file, so samples
+// beyond a stale header count are still available.
+func (r *Reader) ReadSamples(start int64, out []complex64) (int, error) {
+	n, err := r.readSamples(start, out)
+	if r.swapIQ {
+		filewriter.SwapIQ(out[:n])
+	}
+	return n, err
+}
+
+// readSamples decodes samples starting at sample index start into out as
+// they are stored
+func (r *Reader) readSamples(start int64, out []complex64) (int, error) {
+	if start < 0 {
+		return 0, fmt.Errorf("invalid sample index %d", start)
+	}
+
+	if r.mmap == nil && !r.compressed && r.workers > 1 && len(out) > readChunkSamples {
+		return r.readParallel(start, out)
+	}
+	if r.mmap == nil {
+		if err := r.samples.SeekSample(start); err != nil {
+			return 0, err
+		}
+		filled := 0
+		for filled < len(out) {
+			n, err := r.samples.Read(out[filled:min(filled+readChunkSamples, len(out))])
+			if err != nil {
+				return filled, err
+			}
+			filled += n
+		}
+		return filled, nil
+	}
+
+	sampleSize := r.metadata.SampleFormat.Size()
+	begin := int64(r.dataOffset) + start*int64(sampleSize)
+	available := (int64(len(r.mmap)) - begin) / int64(sampleSize)
+	if available <= 0 {
+		return 0, io.EOF
+	}
+	count := len(out)
+	if int64(count) > available {
+		count = int(available)
+	}
+	if err := decode(r.metadata.SampleFormat, r.mmap[begin:begin+int64(count*sampleSize)], out[:count]); err != nil {
+		return 0, err
+	}
+
+	if count < len(out) {
+		return count, io.EOF
+	}
+	return count, nil
+}
+
+// readParallel streams samples starting at sample index start into out,
+// reading chunks in order on this goroutine and decoding them on r.workers
+// goroutines. Each chunk fills its own region of out, so the workers need no
+// coordination beyond recycling the raw read buffers.
+func (r *Reader) readParallel(start int64, out []complex64) (int, error) {
+	format := r.metadata.SampleFormat
+	size := format.Size()
+
+	type chunk struct {
+		raw []byte
+		out []complex64
+	}
+	chunks := make(chan chunk, r.workers)
+	free := make(chan []byte, r.workers+1) // Raw buffers not being read into or decoded
+	for i := 0; i < r.workers+1; i++ {
+		free <- make([]byte, readChunkSamples*size)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				// The raw data holds exactly len(c.out) samples, so decode cannot fail
+				decode(format, c.raw, c.out)
+				free <- c.raw[:cap(c.raw)]
+			}
+		}()
+	}
+
+	filled := 0
+	var err error
+	for filled < len(out) {
+		raw := <-free
+		n := min(readChunkSamples, len(out)-filled)
+		read, readErr := r.file.ReadAt(raw[:n*size], filewriter.DataOffset(r.metadata, start+int64(filled)))
+
+		// A partial sample at the end of a truncated file is discarded
+		complete := read / size
+		if complete > 0 {
+			chunks <- chunk{raw: raw[:complete*size], out: out[filled : filled+complete]}
+			filled += complete
+		} else {
+			free <- raw
+		}
+
+		if readErr == io.EOF && complete < n {
+			err = io.EOF
+			break
+		}
+		if readErr != nil && readErr != io.EOF {
+			err = fmt.Errorf("failed to read samples: %w", readErr)
+			break
+		}
+	}
+
+	close(chunks)
+	wg.Wait()
+	return filled, err
+}
+
+// maxViewFloats is the length of the fixed-size array type used to view
+// sample bytes as float32 values; larger blocks are decoded in pieces
+const maxViewFloats = 1 << 30
+
+// decode converts sample bytes to complex64, viewing complex64 data in place
+// rather than reading individual float32 values on little-endian hosts. It
+// returns filewriter.ErrTruncated if data holds fewer than len(out) samples.
+func decode(format filewriter.SampleFormat, data []byte, out []complex64) error {
+	if need := len(out) * format.Size(); len(data) < need {
+		return fmt.Errorf("%w: need %d bytes of sample data, have %d", filewriter.ErrTruncated, need, len(data))
+	}
+	if format != filewriter.SampleFormatComplex64 || len(out) == 0 || !hostLittleEndian {
+		filewriter.DecodeSamples(format, data, out)
+		return nil
+	}
+
+	// Never slice the array view beyond its declared length
+	for len(out) > 0 {
+		n := min(len(out), maxViewFloats/2)
+		floatPtr := (*float32)(unsafe.Pointer(&data[0]))
+		floatSlice := (*[maxViewFloats]float32)(unsafe.Pointer(floatPtr))[: n*2 : n*2]
+
+		for i := range out[:n] {
+			real := floatSlice[i*2]
+			imag := floatSlice[i*2+1]
+			out[i] = complex(real, imag)
+		}
+		out = out[n:]
+		data = data[n*8:]
+	}
+	return nil
+}
+
+// parseHeader decodes the file header from a memory mapped file, returning
+// the metadata, header sample count and offset of the first sample
+func parseHeader(data []byte) (*filewriter.Metadata, uint32, int, error) {
+	offset := 0
+
+	// Read magic header
+	if len(data) < 5 {
+		return nil, 0, 0, fmt.Errorf("%w while reading magic", filewriter.ErrTruncated)
+	}
+	if string(data[0:5]) != "ARGUS" {
+		return nil, 0, 0, filewriter.ErrInvalidMagic
+	}
+	offset += 5
+
+	var metadata filewriter.Metadata
+
+	// Read metadata fields, in place on little-endian hosts for speed
+	if len(data) < offset+2 {
+		return nil, 0, 0, fmt.Errorf("%w while reading version", filewriter.ErrTruncated)
+	}
+	metadata.FileFormatVersion = le16(data[offset:])
+	offset += 2
+	if err := filewriter.CheckFormatVersion(metadata.FileFormatVersion); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if len(data) < offset+8 {
+		return nil, 0, 0, fmt.Errorf("%w while reading frequency", filewriter.ErrTruncated)
+	}
+	metadata.Frequency = le64(data[offset:])
+	offset += 8
+
+	if len(data) < offset+4 {
+		return nil, 0, 0, fmt.Errorf("%w while reading sample rate", filewriter.ErrTruncated)
+	}
+	metadata.SampleRate = le32(data[offset:])
+	offset += 4
+
+	// Read collection timestamp
+	if len(data) < offset+12 {
+		return nil, 0, 0, fmt.Errorf("%w while reading collection time", filewriter.ErrTruncated)
+	}
+	collectionTimeUnix := int64(le64(data[offset:]))
+	offset += 8
+	collectionTimeNano := int32(le32(data[offset:]))
+	offset += 4
+	metadata.CollectionTime = time.Unix(collectionTimeUnix, int64(collectionTimeNano))
+
+	// Read GPS location
+	if len(data) < offset+24 {
+		return nil, 0, 0, fmt.Errorf("%w while reading GPS location", filewriter.ErrTruncated)
+	}
+	metadata.GPSLocation.Latitude = math.Float64frombits(le64(data[offset:]))
+	offset += 8
+	metadata.GPSLocation.Longitude = math.Float64frombits(le64(data[offset:]))
+	offset += 8
+	metadata.GPSLocation.Altitude = math.Float64frombits(le64(data[offset:]))
+	offset += 8
+
+	// Read GPS timestamp
+	if len(data) < offset+12 {
+		return nil, 0, 0, fmt.Errorf("%w while reading GPS timestamp", filewriter.ErrTruncated)
+	}
+	gpsTimeUnix := int64(le64(data[offset:]))
+	offset += 8
+	gpsTimeNano := int32(le32(data[offset:]))
+	offset += 4
+	metadata.GPSTimestamp = time.Unix(gpsTimeUnix, int64(gpsTimeNano))
+
+	// Read device info
+	if len(data) < offset+1 {
+		return nil, 0, 0, fmt.Errorf("%w while reading device info length", filewriter.ErrTruncated)
+	}
+	deviceInfoLen := data[offset]
+	offset += 1
+	if len(data) < offset+int(deviceInfoLen) {
+		return nil, 0, 0, fmt.Errorf("%w while reading device info", filewriter.ErrTruncated)
+	}
+	metadata.DeviceInfo = string(data[offset : offset+int(deviceInfoLen)])
+	offset += int(deviceInfoLen)
+
+	// Read collection ID
+	if len(data) < offset+1 {
+		return nil, 0, 0, fmt.Errorf("%w while reading collection ID length", filewriter.ErrTruncated)
+	}
+	collectionIDLen := data[offset]
+	offset += 1
+	if len(data) < offset+int(collectionIDLen) {
+		return nil, 0, 0, fmt.Errorf("%w while reading collection ID", filewriter.ErrTruncated)
+	}
+	metadata.CollectionID = string(data[offset : offset+int(collectionIDLen)])
+	offset += int(collectionIDLen)
+
+	// Read extension block (format version 2 and later)
+	if metadata.FileFormatVersion >= filewriter.FormatVersion2 {
+		if len(data) < offset+2 {
+			return nil, 0, 0, fmt.Errorf("%w while reading extension length", filewriter.ErrTruncated)
+		}
+		extensionLen := int(le16(data[offset:]))
+		offset += 2
+		if len(data) < offset+extensionLen {
+			return nil, 0, 0, fmt.Errorf("%w while reading extensions", filewriter.ErrTruncated)
+		}
+		if err := filewriter.DecodeExtensions(&metadata, data[offset:offset+extensionLen]); err != nil {
+			return nil, 0, 0, err
+		}
+		offset += extensionLen
+	}
+
+	// Read sample count
+	if len(data) < offset+4 {
+		return nil, 0, 0, fmt.Errorf("%w while reading sample count", filewriter.ErrTruncated)
+	}
+	sampleCount := le32(data[offset:])
+	offset += 4
+
+	return &metadata, sampleCount, offset, nil
+}
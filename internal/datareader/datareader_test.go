@@ -0,0 +1,330 @@
+package datareader
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"argus-collector/internal/filewriter"
+)
+
+func TestParseHeaderMatchesFilewriter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "datareader_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	samples := []complex64{complex(0.5, -0.5), complex(0.25, 0.75), complex(-1, 1)}
+	metadata := filewriter.Metadata{
+		Frequency:           433920000,
+		SampleRate:          2048000,
+		CollectionTime:      time.Unix(1700000000, 123456789),
+		DeviceInfo:          "Generic RTL2832U",
+		FileFormatVersion:   filewriter.CurrentFormatVersion,
+		CollectionID:        "test_1700000000",
+		ClockOffset:         5 * time.Millisecond,
+		ClockOffsetMeasured: true,
+	}
+
+	filename := filepath.Join(tempDir, "test.dat")
+	if err := filewriter.NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	// Parse the raw bytes as the memory mapped path does
+	parsed, sampleCount, offset, err := parseHeader(data)
+	if err != nil {
+		t.Fatalf("parseHeader failed: %v", err)
+	}
+	if sampleCount != uint32(len(samples)) {
+		t.Errorf("expected %d samples, got %d", len(samples), sampleCount)
+	}
+	if int64(offset) != filewriter.HeaderSize(parsed) {
+		t.Errorf("data offset %d does not match header size %d", offset, filewriter.HeaderSize(parsed))
+	}
+	if parsed.CollectionID != metadata.CollectionID || parsed.ClockOffset != metadata.ClockOffset {
+		t.Errorf("metadata mismatch: %+v", parsed)
+	}
+
+	decoded := make([]complex64, len(samples))
+	if err := decode(parsed.SampleFormat, data[offset:], decoded); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	// Decoding more samples than the data holds must fail cleanly
+	if err := decode(parsed.SampleFormat, data[offset:], make([]complex64, len(samples)+1)); !errors.Is(err, filewriter.ErrTruncated) {
+		t.Errorf("expected ErrTruncated decoding past the data, got %v", err)
+	}
+	for i := range samples {
+		if decoded[i] != samples[i] {
+			t.Errorf("sample %d mismatch: %v != %v", i, decoded[i], samples[i])
+		}
+	}
+
+	// The streamed path must return the same samples
+	reader, err := NewReader(filename)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	_, streamed, err := reader.ReadFile()
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	for i := range samples {
+		if streamed[i] != samples[i] {
+			t.Errorf("streamed sample %d mismatch: %v != %v", i, streamed[i], samples[i])
+		}
+	}
+}
+
+func TestPortableDecodeMatchesInPlace(t *testing.T) {
+	if !hostLittleEndian {
+		t.Skip("in-place decoding is only used on little-endian hosts")
+	}
+
+	tempDir, err := os.MkdirTemp("", "datareader_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	samples := []complex64{complex(0.5, -0.5), complex(-0.125, 0.875)}
+	metadata := filewriter.Metadata{
+		Frequency:         162400000,
+		SampleRate:        2048000,
+		CollectionTime:    time.Unix(1700000000, 5),
+		GPSLocation:       filewriter.GPSLocation{Latitude: 35.5, Longitude: -97.25, Altitude: 380},
+		GPSTimestamp:      time.Unix(1700000001, 6),
+		FileFormatVersion: filewriter.CurrentFormatVersion,
+		CollectionID:      "endian",
+	}
+	filename := filepath.Join(tempDir, "endian.dat")
+	if err := filewriter.NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	inPlace, _, offset, err := parseHeader(data)
+	if err != nil {
+		t.Fatalf("parseHeader failed: %v", err)
+	}
+	inPlaceSamples := make([]complex64, len(samples))
+	if err := decode(inPlace.SampleFormat, data[offset:], inPlaceSamples); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	// Force the encoding/binary path used on big-endian hosts
+	hostLittleEndian = false
+	defer func() { hostLittleEndian = true }()
+
+	portable, _, _, err := parseHeader(data)
+	if err != nil {
+		t.Fatalf("parseHeader (portable) failed: %v", err)
+	}
+	portableSamples := make([]complex64, len(samples))
+	if err := decode(portable.SampleFormat, data[offset:], portableSamples); err != nil {
+		t.Fatalf("decode (portable) failed: %v", err)
+	}
+
+	if portable.Frequency != inPlace.Frequency || portable.GPSLocation != inPlace.GPSLocation ||
+		!portable.CollectionTime.Equal(inPlace.CollectionTime) || !portable.GPSTimestamp.Equal(inPlace.GPSTimestamp) {
+		t.Errorf("portable header %+v differs from in-place %+v", portable, inPlace)
+	}
+	for i := range samples {
+		if portableSamples[i] != inPlaceSamples[i] || portableSamples[i] != samples[i] {
+			t.Errorf("sample %d: portable %v, in-place %v, want %v", i, portableSamples[i], inPlaceSamples[i], samples[i])
+		}
+	}
+}
+
+// writeTestCapture writes a streamed-size capture of count samples in format
+// and returns its path and samples
+func writeTestCapture(tb testing.TB, format filewriter.SampleFormat, count int) (string, []complex64) {
+	tb.Helper()
+
+	samples := make([]complex64, count)
+	for i := range samples {
+		samples[i] = complex(float32(i%255)/255, -float32(i%127)/127)
+	}
+	metadata := filewriter.Metadata{
+		SampleRate:        2048000,
+		FileFormatVersion: filewriter.CurrentFormatVersion,
+		SampleFormat:      format,
+	}
+	filename := filepath.Join(tb.TempDir(), "capture.dat")
+	if err := filewriter.NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		tb.Fatalf("WriteFile failed: %v", err)
+	}
+	return filename, samples
+}
+
+func TestParallelDecodeMatchesSerial(t *testing.T) {
+	for _, format := range []filewriter.SampleFormat{filewriter.SampleFormatComplex64, filewriter.SampleFormatInt16} {
+		// Not a whole number of chunks, so the last chunk is partial
+		filename, _ := writeTestCapture(t, format, 5*readChunkSamples+123)
+
+		serial, err := NewReader(filename)
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		defer serial.Close()
+		_, want, err := serial.ReadFile()
+		if err != nil {
+			t.Fatalf("%s: serial ReadFile failed: %v", format, err)
+		}
+
+		parallel, err := NewReader(filename)
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		defer parallel.Close()
+		parallel.SetDecodeWorkers(4)
+		_, got, err := parallel.ReadFile()
+		if err != nil {
+			t.Fatalf("%s: parallel ReadFile failed: %v", format, err)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: sample %d: parallel %v, serial %v", format, i, got[i], want[i])
+			}
+		}
+
+		// Reading past the end fills what the file holds and reports io.EOF
+		out := make([]complex64, 2*readChunkSamples)
+		n, err := parallel.ReadSamples(int64(len(want)-100), out)
+		if n != 100 || err != io.EOF {
+			t.Errorf("%s: reading past the end: got %d samples, %v; want 100, io.EOF", format, n, err)
+		}
+	}
+}
+
+func TestCompressedFile(t *testing.T) {
+	plain, samples := writeTestCapture(t, filewriter.SampleFormatInt16, 3*readChunkSamples+5)
+	metadata, want, err := filewriter.ReadFile(plain)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	filename := plain + filewriter.CompressedExtension
+	if err := filewriter.NewWriter().WriteFile(filename, *metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	reader, err := NewReader(filename)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+	// Parallel decoding reads the file at offsets, which a compressed file lacks
+	reader.SetDecodeWorkers(4)
+
+	if reader.MemoryMapped() {
+		t.Error("compressed file reported as memory mapped")
+	}
+	_, got, err := reader.ReadFile()
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d samples, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSwapIQ(t *testing.T) {
+	filename, samples := writeTestCapture(t, filewriter.SampleFormatComplex64, 1000)
+	reader, err := NewReader(filename)
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	defer reader.Close()
+	reader.SetSwapIQ(true)
+
+	_, got, err := reader.ReadFile()
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	for i, s := range samples {
+		if want := complex(imag(s), real(s)); got[i] != want {
+			t.Fatalf("sample %d: got %v, want %v", i, got[i], want)
+		}
+	}
+}
+
+func TestMmapFailureFallsBackToStreaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes a file larger than MmapThreshold")
+	}
+	errNoMmap := errors.New("mmap: no such device")
+	mmapFile = func(uintptr, int) ([]byte, error) { return nil, errNoMmap }
+	defer func() { mmapFile = mapFile }()
+
+	filename, samples := writeTestCapture(t, filewriter.SampleFormatComplex64, MmapThreshold/8+5)
+	reader, err := NewReader(filename)
+	if err != nil {
+		t.Fatalf("NewReader failed when mapping was unavailable: %v", err)
+	}
+	defer reader.Close()
+
+	if reader.MemoryMapped() {
+		t.Error("file reported as memory mapped although mapping failed")
+	}
+	if !errors.Is(reader.MmapError(), errNoMmap) {
+		t.Errorf("MmapError() = %v, want %v", reader.MmapError(), errNoMmap)
+	}
+	_, got, err := reader.ReadFile()
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(got) != len(samples) {
+		t.Fatalf("read %d samples, want %d", len(got), len(samples))
+	}
+	for i := range samples {
+		if got[i] != samples[i] {
+			t.Fatalf("sample %d: got %v, want %v", i, got[i], samples[i])
+		}
+	}
+}
+
+// BenchmarkReadFile compares serial and parallel decoding of a streamed file
+func BenchmarkReadFile(b *testing.B) {
+	filename, _ := writeTestCapture(b, filewriter.SampleFormatInt16, 4<<20)
+
+	for _, workers := range []int{1, 0} {
+		name := "serial"
+		if workers == 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			reader, err := NewReader(filename)
+			if err != nil {
+				b.Fatalf("NewReader failed: %v", err)
+			}
+			defer reader.Close()
+			reader.SetDecodeWorkers(workers)
+
+			b.SetBytes(reader.Size())
+			for i := 0; i < b.N; i++ {
+				if _, _, err := reader.ReadFile(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
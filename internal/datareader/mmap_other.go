@@ -0,0 +1,19 @@
+//go:build !unix
+
+package datareader
+
+import "errors"
+
+// errMmapUnsupported reports that this platform has no memory mapping, so
+// large files are streamed like small ones
+var errMmapUnsupported = errors.New("memory mapping not supported on this platform")
+
+// mapFile always fails, leaving the file to be streamed
+func mapFile(fd uintptr, size int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+// unmapFile does nothing, as nothing is ever mapped
+func unmapFile(data []byte) error {
+	return nil
+}
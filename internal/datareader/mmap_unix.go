@@ -0,0 +1,15 @@
+//go:build unix
+
+package datareader
+
+import "syscall"
+
+// mapFile maps the first size bytes of the open file fd read-only into memory
+func mapFile(fd uintptr, size int) ([]byte, error) {
+	return syscall.Mmap(int(fd), 0, size, syscall.PROT_READ, syscall.MAP_PRIVATE)
+}
+
+// unmapFile releases memory returned by mapFile
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
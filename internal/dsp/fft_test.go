@@ -0,0 +1,56 @@
+package dsp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// dft is the direct O(n²) transform FFT must match
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	for k := range out {
+		for t, v := range x {
+			out[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*t)/float64(n)))
+		}
+	}
+	return out
+}
+
+func TestFFTMatchesDFT(t *testing.T) {
+	for _, n := range []int{1, 2, 8, 64} {
+		x := make([]complex128, n)
+		for i := range x {
+			x[i] = complex(math.Cos(0.3*float64(i))+0.1*float64(i%3), math.Sin(0.7*float64(i)))
+		}
+		want := dft(x)
+
+		FFT(x)
+		for k := range x {
+			if cmplx.Abs(x[k]-want[k]) > 1e-9 {
+				t.Errorf("n=%d: bin %d = %v, want %v", n, k, x[k], want[k])
+			}
+		}
+	}
+}
+
+func TestFFTTone(t *testing.T) {
+	// A complex tone centred on bin 5 puts all its energy in that bin
+	const n, bin = 32, 5
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = cmplx.Exp(complex(0, 2*math.Pi*bin*float64(i)/n))
+	}
+
+	FFT(x)
+	for k, v := range x {
+		want := 0.0
+		if k == bin {
+			want = n
+		}
+		if math.Abs(cmplx.Abs(v)-want) > 1e-9 {
+			t.Errorf("bin %d magnitude = %.6f, want %.0f", k, cmplx.Abs(v), want)
+		}
+	}
+}
@@ -1,27 +1,211 @@
 package filewriter
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"time"
 )
 
+// File format versions
+const (
+	FormatVersion1 uint16 = 1 // Original fixed header layout
+	FormatVersion2 uint16 = 2 // Adds a tagged extension block after the collection ID
+
+	CurrentFormatVersion = FormatVersion2
+)
+
+// Errors returned when reading data files; test for them with errors.Is
+var (
+	ErrInvalidMagic       = errors.New("invalid file format: missing ARGUS header") // Not an Argus data file
+	ErrTruncated          = errors.New("file truncated")                            // File ends before the header or samples are complete
+	ErrUnsupportedVersion = errors.New("unsupported file format version")           // Written by a newer (or unknown) format version
+	ErrChecksumMismatch   = errors.New("checksum mismatch")                         // Stored data does not match its recorded checksum
+)
+
+// Extension block tags (format version 2 and later)
+const (
+	tagClockOffset     uint8 = 1  // int64 nanoseconds, system clock minus GPS time
+	tagSampleFormat    uint8 = 2  // uint8 SampleFormat, absent means complex64
+	tagSoftwareVersion uint8 = 3  // UTF-8 version string of the writing software
+	tagConfigHash      uint8 = 4  // UTF-8 fingerprint of the effective configuration
+	tagLNAGain         uint8 = 5  // float64 external LNA gain in dB
+	tagAntenna         uint8 = 6  // UTF-8 antenna description
+	tagAGCFinalGain    uint8 = 7  // float64 gain in dB that software AGC settled on
+	tagNoPosition      uint8 = 8  // empty; present if the capture has no position
+	tagDeviceLost      uint8 = 9  // empty; present if the device disconnected and the capture ended early
+	tagGaps            uint8 = 10 // int64 sample index and int64 nanoseconds per gap in the sample stream
+	tagGainLadder      uint8 = 11 // int16 1-based gain step, then int16 tenths of dB per supported tuner gain
+	tagPeakToNoise     uint8 = 12 // float64 dB the loudest block of the capture rises above the noise floor
+)
+
+// SampleFormat identifies how I/Q samples are encoded in the data section
+type SampleFormat uint8
+
+const (
+	SampleFormatComplex64 SampleFormat = 0 // Interleaved float32 I/Q (default)
+	SampleFormatInt16     SampleFormat = 1 // Interleaved int16 I/Q, 32767 = full scale
+	SampleFormatUint8     SampleFormat = 2 // Interleaved uint8 I/Q as read from the RTL-SDR, 127.5 = zero
+)
+
+// int16Scale maps normalized [-1, 1] samples to int16 full scale
+const int16Scale = 32767.0
+
+// uint8Offset is the zero level of the RTL-SDR's unsigned 8-bit samples; it is
+// also the scale, so 0 and 255 are -1 and 1
+const uint8Offset = 127.5
+
+// ParseSampleFormat converts a format name into a SampleFormat
+func ParseSampleFormat(name string) (SampleFormat, error) {
+	switch name {
+	case "", "complex64":
+		return SampleFormatComplex64, nil
+	case "int16":
+		return SampleFormatInt16, nil
+	case "uint8":
+		return SampleFormatUint8, nil
+	default:
+		return 0, fmt.Errorf("invalid sample format: %s (must be 'complex64', 'int16' or 'uint8')", name)
+	}
+}
+
+func (f SampleFormat) String() string {
+	switch f {
+	case SampleFormatComplex64:
+		return "complex64"
+	case SampleFormatInt16:
+		return "int16"
+	case SampleFormatUint8:
+		return "uint8"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(f))
+	}
+}
+
+// MarshalText encodes the sample format by name for JSON output
+func (f SampleFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText decodes a sample format name
+func (f *SampleFormat) UnmarshalText(text []byte) error {
+	format, err := ParseSampleFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = format
+	return nil
+}
+
+// Size returns the number of bytes used by one complex sample
+func (f SampleFormat) Size() int {
+	switch f {
+	case SampleFormatInt16:
+		return 4
+	case SampleFormatUint8:
+		return 2
+	default:
+		return 8
+	}
+}
+
+// Metadata describes a capture. The JSON tags define the sidecar format, so the
+// binary header and the sidecar are always generated from the same fields.
 type Metadata struct {
-	Frequency         uint64
-	SampleRate        uint32
-	CollectionTime    time.Time
-	GPSLocation       GPSLocation
-	GPSTimestamp      time.Time
-	DeviceInfo        string
-	FileFormatVersion uint16
-	CollectionID      string
+	Frequency         uint64      `json:"frequency_hz"`
+	SampleRate        uint32      `json:"sample_rate_hz"`
+	CollectionTime    time.Time   `json:"collection_time"`
+	GPSLocation       GPSLocation `json:"gps_location"`
+	GPSTimestamp      time.Time   `json:"gps_timestamp"`
+	DeviceInfo        string      `json:"device_info"`
+	FileFormatVersion uint16      `json:"file_format_version"`
+	CollectionID      string      `json:"collection_id"`
+
+	// Format version 2 fields
+	ClockOffset         time.Duration `json:"clock_offset_ns"`                 // System clock minus GPS time, measured at startup
+	ClockOffsetMeasured bool          `json:"clock_offset_measured"`           // True if ClockOffset holds a real measurement
+	SampleFormat        SampleFormat  `json:"sample_format"`                   // Encoding of the sample data
+	SoftwareVersion     string        `json:"software_version,omitempty"`      // Version of the software that wrote the file
+	ConfigHash          string        `json:"config_hash,omitempty"`           // Fingerprint of the configuration used for the capture
+	LNAGain             float64       `json:"lna_gain_db,omitempty"`           // External LNA gain in dB, 0 if not recorded (descriptive only)
+	Antenna             string        `json:"antenna,omitempty"`               // Antenna description (descriptive only)
+	AGCUsed             bool          `json:"agc_used,omitempty"`              // True if software AGC controlled the gain during the capture
+	AGCFinalGain        float64       `json:"agc_final_gain_db,omitempty"`     // Gain in dB the AGC had converged to when the capture ended
+	NoPosition          bool          `json:"no_position,omitempty"`           // True if collected without GPS; GPSLocation is a placeholder
+	DeviceLost          bool          `json:"device_lost,omitempty"`           // True if the device disconnected and the capture is shorter than planned
+	Gaps                []Gap         `json:"gaps,omitempty"`                  // Stretches the device sampled but never delivered, in order
+	GainLadder          []int         `json:"gain_ladder_tenths_db,omitempty"` // Tuner's supported gains in tenths of dB, in ascending order
+	GainStep            int           `json:"gain_step,omitempty"`             // 1-based position of the tuner gain on GainLadder, 0 if not recorded
+	SignalMeasured      bool          `json:"signal_measured,omitempty"`       // True if PeakToNoise was measured when the capture was saved
+	PeakToNoise         float64       `json:"peak_to_noise_db"`                // dB the loudest block rises above the noise floor, see PeakToNoise
+
+	extensionLen int // Size of the extension block as read from the file
+}
+
+// Gap is a stretch of the capture missing from the sample data, usually
+// after a buffer overrun. Samples from SampleIndex on were taken Duration
+// later than their position in the file implies.
+type Gap struct {
+	SampleIndex int64         `json:"sample_index"` // Index of the first sample after the gap
+	Duration    time.Duration `json:"duration_ns"`  // Estimated length of the missing stretch
+}
+
+// maxGaps is the most gaps a single extension record can hold
+const maxGaps = math.MaxUint16 / 16
+
+// Samples returns how many samples at sampleRate the gap is missing
+func (g Gap) Samples(sampleRate uint32) int64 {
+	return int64(math.Round(g.Duration.Seconds() * float64(sampleRate)))
 }
 
 type GPSLocation struct {
-	Latitude  float64
-	Longitude float64
-	Altitude  float64
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude_m"`
+}
+
+// CorrectedCollectionTime returns the collection time adjusted by the measured
+// system clock offset, i.e. the collection start expressed in GPS time
+func (m *Metadata) CorrectedCollectionTime() time.Time {
+	if !m.ClockOffsetMeasured {
+		return m.CollectionTime
+	}
+	return m.CollectionTime.Add(-m.ClockOffset)
+}
+
+// ExtensionSize returns the number of bytes the version 2 extension block
+// (including its length prefix) occupies in the header
+func (m *Metadata) ExtensionSize() int {
+	if m.FileFormatVersion < FormatVersion2 {
+		return 0
+	}
+	if m.extensionLen == 0 {
+		// Not read from a file; size the block this package would write
+		return 2 + len(encodeExtensions(m))
+	}
+	return 2 + m.extensionLen
+}
+
+// fixedHeaderSize is the size of all fixed-length header fields: magic (5),
+// version (2), frequency (8), sample rate (4), collection time (12),
+// GPS location (24), GPS timestamp (12), the two string length prefixes (2)
+// and the sample count (4)
+const fixedHeaderSize = 5 + 2 + 8 + 4 + 12 + 24 + 12 + 1 + 1 + 4
+
+// HeaderSize returns the size in bytes of the file header for m, which is
+// also the offset of the first sample
+func HeaderSize(m *Metadata) int64 {
+	return int64(fixedHeaderSize + min(len(m.DeviceInfo), 255) + min(len(m.CollectionID), 255) + m.ExtensionSize())
+}
+
+// DataOffset returns the byte offset of the sample at sampleIndex
+func DataOffset(m *Metadata, sampleIndex int64) int64 {
+	return HeaderSize(m) + sampleIndex*int64(m.SampleFormat.Size())
 }
 
 type Writer struct{}
@@ -30,6 +214,8 @@ func NewWriter() *Writer {
 	return &Writer{}
 }
 
+// WriteFile writes a complete data file. A filename ending in .gz is written
+// gzip-compressed.
 func (w *Writer) WriteFile(filename string, metadata Metadata, samples []complex64) error {
 	file, err := os.Create(filename)
 	if err != nil {
@@ -37,19 +223,36 @@ func (w *Writer) WriteFile(filename string, metadata Metadata, samples []complex
 	}
 	defer file.Close()
 
-	if err := w.writeHeader(file, metadata, uint32(len(samples))); err != nil {
+	if metadata.SampleFormat != SampleFormatComplex64 && metadata.FileFormatVersion < FormatVersion2 {
+		return fmt.Errorf("sample format %s requires file format version %d", metadata.SampleFormat, FormatVersion2)
+	}
+
+	var out io.Writer = file
+	var gz *gzip.Writer
+	if IsCompressedName(filename) {
+		gz = gzip.NewWriter(file)
+		out = gz
+	}
+
+	if err := w.writeHeader(out, metadata, uint32(len(samples))); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	if err := w.writeSamples(file, samples); err != nil {
+	if err := w.writeSamples(out, samples, metadata.SampleFormat); err != nil {
 		return fmt.Errorf("failed to write samples: %w", err)
 	}
 
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to compress samples: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func (w *Writer) writeHeader(file *os.File, metadata Metadata, sampleCount uint32) error {
-	if _, err := file.WriteString("ARGUS"); err != nil {
+func (w *Writer) writeHeader(file io.Writer, metadata Metadata, sampleCount uint32) error {
+	if _, err := io.WriteString(file, "ARGUS"); err != nil {
 		return err
 	}
 
@@ -117,6 +320,19 @@ func (w *Writer) writeHeader(file *os.File, metadata Metadata, sampleCount uint3
 		return err
 	}
 
+	if metadata.FileFormatVersion >= FormatVersion2 {
+		extensions := encodeExtensions(&metadata)
+		if len(extensions) > 0xFFFF {
+			return fmt.Errorf("extension block too large: %d bytes", len(extensions))
+		}
+		if err := binary.Write(file, binary.LittleEndian, uint16(len(extensions))); err != nil {
+			return err
+		}
+		if _, err := file.Write(extensions); err != nil {
+			return err
+		}
+	}
+
 	if err := binary.Write(file, binary.LittleEndian, sampleCount); err != nil {
 		return err
 	}
@@ -124,7 +340,26 @@ func (w *Writer) writeHeader(file *os.File, metadata Metadata, sampleCount uint3
 	return nil
 }
 
-func (w *Writer) writeSamples(file *os.File, samples []complex64) error {
+func (w *Writer) writeSamples(file io.Writer, samples []complex64, format SampleFormat) error {
+	if format == SampleFormatUint8 {
+		raw := make([]byte, len(samples)*2)
+		for i, sample := range samples {
+			raw[i*2] = toUint8(real(sample))
+			raw[i*2+1] = toUint8(imag(sample))
+		}
+		_, err := file.Write(raw)
+		return err
+	}
+
+	if format == SampleFormatInt16 {
+		ints := make([]int16, len(samples)*2)
+		for i, sample := range samples {
+			ints[i*2] = toInt16(real(sample))
+			ints[i*2+1] = toInt16(imag(sample))
+		}
+		return binary.Write(file, binary.LittleEndian, ints)
+	}
+
 	// Convert complex64 samples to interleaved float32 array for efficient bulk write
 	floats := make([]float32, len(samples)*2)
 	for i, sample := range samples {
@@ -134,195 +369,380 @@ func (w *Writer) writeSamples(file *os.File, samples []complex64) error {
 	return binary.Write(file, binary.LittleEndian, floats)
 }
 
-// ReadFile reads the complete file including all sample data
-func ReadFile(filename string) (*Metadata, []complex64, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+// toInt16 scales a normalized sample component to int16, clipping at full scale
+func toInt16(v float32) int16 {
+	scaled := math.Round(float64(v) * int16Scale)
+	if scaled > math.MaxInt16 {
+		return math.MaxInt16
 	}
-	defer file.Close()
+	if scaled < -math.MaxInt16 {
+		return -math.MaxInt16
+	}
+	return int16(scaled)
+}
 
-	magic := make([]byte, 5)
-	if _, err := file.Read(magic); err != nil {
-		return nil, nil, fmt.Errorf("failed to read magic: %w", err)
+// toUint8 converts a normalized sample component back to the RTL-SDR byte it
+// was read as, clipping at full scale. Samples converted from the dongle's
+// bytes map back to exactly those bytes.
+func toUint8(v float32) uint8 {
+	scaled := math.Round(float64(v)*uint8Offset + uint8Offset)
+	if scaled > math.MaxUint8 {
+		return math.MaxUint8
 	}
-	if string(magic) != "ARGUS" {
-		return nil, nil, fmt.Errorf("invalid file format")
+	if scaled < 0 {
+		return 0
 	}
+	return uint8(scaled)
+}
 
-	var metadata Metadata
-	if err := binary.Read(file, binary.LittleEndian, &metadata.FileFormatVersion); err != nil {
-		return nil, nil, err
+// DecodeSamples converts raw sample bytes in the given format into out.
+// data must hold at least len(out)*format.Size() bytes.
+func DecodeSamples(format SampleFormat, data []byte, out []complex64) {
+	switch format {
+	case SampleFormatUint8:
+		// Same conversion as the RTL-SDR driver applies when capturing
+		for i := range out {
+			out[i] = complex((float32(data[i*2])-uint8Offset)/uint8Offset, (float32(data[i*2+1])-uint8Offset)/uint8Offset)
+		}
+	case SampleFormatInt16:
+		for i := range out {
+			re := int16(binary.LittleEndian.Uint16(data[i*4:]))
+			im := int16(binary.LittleEndian.Uint16(data[i*4+2:]))
+			out[i] = complex(float32(re)/int16Scale, float32(im)/int16Scale)
+		}
+	default:
+		for i := range out {
+			re := math.Float32frombits(binary.LittleEndian.Uint32(data[i*8:]))
+			im := math.Float32frombits(binary.LittleEndian.Uint32(data[i*8+4:]))
+			out[i] = complex(re, im)
+		}
 	}
+}
 
-	if err := binary.Read(file, binary.LittleEndian, &metadata.Frequency); err != nil {
-		return nil, nil, err
+// SwapIQ exchanges the real and imaginary parts of samples in place,
+// correcting a capture from hardware that delivered Q before I
+func SwapIQ(samples []complex64) {
+	for i, s := range samples {
+		samples[i] = complex(imag(s), real(s))
 	}
+}
 
-	if err := binary.Read(file, binary.LittleEndian, &metadata.SampleRate); err != nil {
-		return nil, nil, err
+// ReadSample reads a single sample in the given format from r
+func ReadSample(r io.Reader, format SampleFormat) (complex64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:format.Size()]); err != nil {
+		return 0, err
 	}
+	var out [1]complex64
+	DecodeSamples(format, buf[:], out[:])
+	return out[0], nil
+}
 
-	var collectionTimeUnix int64
-	var collectionTimeNano int32
-	if err := binary.Read(file, binary.LittleEndian, &collectionTimeUnix); err != nil {
-		return nil, nil, err
+// encodeExtensions serializes the version 2 fields as a sequence of
+// tag (uint8), length (uint16), value records
+func encodeExtensions(metadata *Metadata) []byte {
+	var buf bytes.Buffer
+
+	if metadata.ClockOffsetMeasured {
+		writeExtension(&buf, tagClockOffset, int64(metadata.ClockOffset))
 	}
-	if err := binary.Read(file, binary.LittleEndian, &collectionTimeNano); err != nil {
-		return nil, nil, err
+	if metadata.SampleFormat != SampleFormatComplex64 {
+		writeExtension(&buf, tagSampleFormat, uint8(metadata.SampleFormat))
 	}
-	metadata.CollectionTime = time.Unix(collectionTimeUnix, int64(collectionTimeNano))
-
-	if err := binary.Read(file, binary.LittleEndian, &metadata.GPSLocation.Latitude); err != nil {
-		return nil, nil, err
+	if metadata.SoftwareVersion != "" {
+		writeExtension(&buf, tagSoftwareVersion, []byte(metadata.SoftwareVersion))
 	}
-	if err := binary.Read(file, binary.LittleEndian, &metadata.GPSLocation.Longitude); err != nil {
-		return nil, nil, err
+	if metadata.ConfigHash != "" {
+		writeExtension(&buf, tagConfigHash, []byte(metadata.ConfigHash))
 	}
-	if err := binary.Read(file, binary.LittleEndian, &metadata.GPSLocation.Altitude); err != nil {
-		return nil, nil, err
+	if metadata.LNAGain != 0 {
+		writeExtension(&buf, tagLNAGain, metadata.LNAGain)
 	}
-
-	var gpsTimeUnix int64
-	var gpsTimeNano int32
-	if err := binary.Read(file, binary.LittleEndian, &gpsTimeUnix); err != nil {
-		return nil, nil, err
+	if metadata.Antenna != "" {
+		writeExtension(&buf, tagAntenna, []byte(metadata.Antenna))
 	}
-	if err := binary.Read(file, binary.LittleEndian, &gpsTimeNano); err != nil {
-		return nil, nil, err
+	if metadata.AGCUsed {
+		writeExtension(&buf, tagAGCFinalGain, metadata.AGCFinalGain)
 	}
-	metadata.GPSTimestamp = time.Unix(gpsTimeUnix, int64(gpsTimeNano))
-
-	var deviceInfoLen uint8
-	if err := binary.Read(file, binary.LittleEndian, &deviceInfoLen); err != nil {
-		return nil, nil, err
+	if metadata.NoPosition {
+		writeExtension(&buf, tagNoPosition, []byte{})
 	}
-	deviceInfoBytes := make([]byte, deviceInfoLen)
-	if _, err := file.Read(deviceInfoBytes); err != nil {
-		return nil, nil, err
+	if metadata.DeviceLost {
+		writeExtension(&buf, tagDeviceLost, []byte{})
 	}
-	metadata.DeviceInfo = string(deviceInfoBytes)
-
-	var collectionIDLen uint8
-	if err := binary.Read(file, binary.LittleEndian, &collectionIDLen); err != nil {
-		return nil, nil, err
+	if len(metadata.Gaps) > 0 {
+		gaps := metadata.Gaps[:min(len(metadata.Gaps), maxGaps)]
+		value := make([]int64, 0, 2*len(gaps))
+		for _, g := range gaps {
+			value = append(value, g.SampleIndex, int64(g.Duration))
+		}
+		writeExtension(&buf, tagGaps, value)
 	}
-	collectionIDBytes := make([]byte, collectionIDLen)
-	if _, err := file.Read(collectionIDBytes); err != nil {
-		return nil, nil, err
+	if metadata.GainStep > 0 && len(metadata.GainLadder) > 0 {
+		value := make([]int16, 0, 1+len(metadata.GainLadder))
+		value = append(value, int16(metadata.GainStep))
+		for _, g := range metadata.GainLadder {
+			value = append(value, int16(g))
+		}
+		writeExtension(&buf, tagGainLadder, value)
 	}
-	metadata.CollectionID = string(collectionIDBytes)
-
-	var sampleCount uint32
-	if err := binary.Read(file, binary.LittleEndian, &sampleCount); err != nil {
-		return nil, nil, err
+	if metadata.SignalMeasured {
+		writeExtension(&buf, tagPeakToNoise, metadata.PeakToNoise)
 	}
 
-	samples := make([]complex64, sampleCount)
-	for i := uint32(0); i < sampleCount; i++ {
-		var real, imag float32
-		if err := binary.Read(file, binary.LittleEndian, &real); err != nil {
-			return nil, nil, err
+	return buf.Bytes()
+}
+
+// writeExtension appends a single tagged record holding a fixed-size value or
+// a byte slice
+func writeExtension(buf *bytes.Buffer, tag uint8, value interface{}) {
+	buf.WriteByte(tag)
+	binary.Write(buf, binary.LittleEndian, uint16(binary.Size(value)))
+	binary.Write(buf, binary.LittleEndian, value)
+}
+
+// DecodeExtensions parses a version 2 extension block into metadata.
+// Unknown tags are skipped so older readers can open newer files.
+func DecodeExtensions(metadata *Metadata, data []byte) error {
+	metadata.extensionLen = len(data)
+
+	for offset := 0; offset < len(data); {
+		if len(data) < offset+3 {
+			return fmt.Errorf("%w: extension record at offset %d", ErrTruncated, offset)
+		}
+		tag := data[offset]
+		length := int(binary.LittleEndian.Uint16(data[offset+1 : offset+3]))
+		offset += 3
+		if len(data) < offset+length {
+			return fmt.Errorf("%w: extension value for tag %d", ErrTruncated, tag)
 		}
-		if err := binary.Read(file, binary.LittleEndian, &imag); err != nil {
-			return nil, nil, err
+		value := data[offset : offset+length]
+		offset += length
+
+		switch tag {
+		case tagClockOffset:
+			if length != 8 {
+				return fmt.Errorf("invalid clock offset length %d", length)
+			}
+			metadata.ClockOffset = time.Duration(int64(binary.LittleEndian.Uint64(value)))
+			metadata.ClockOffsetMeasured = true
+		case tagSampleFormat:
+			if length != 1 {
+				return fmt.Errorf("invalid sample format length %d", length)
+			}
+			metadata.SampleFormat = SampleFormat(value[0])
+			if metadata.SampleFormat > SampleFormatUint8 {
+				return fmt.Errorf("unsupported sample format %d", value[0])
+			}
+		case tagSoftwareVersion:
+			metadata.SoftwareVersion = string(value)
+		case tagConfigHash:
+			metadata.ConfigHash = string(value)
+		case tagLNAGain:
+			if length != 8 {
+				return fmt.Errorf("invalid LNA gain length %d", length)
+			}
+			metadata.LNAGain = math.Float64frombits(binary.LittleEndian.Uint64(value))
+		case tagAntenna:
+			metadata.Antenna = string(value)
+		case tagAGCFinalGain:
+			if length != 8 {
+				return fmt.Errorf("invalid AGC final gain length %d", length)
+			}
+			metadata.AGCUsed = true
+			metadata.AGCFinalGain = math.Float64frombits(binary.LittleEndian.Uint64(value))
+		case tagNoPosition:
+			metadata.NoPosition = true
+		case tagDeviceLost:
+			metadata.DeviceLost = true
+		case tagGaps:
+			if length%16 != 0 {
+				return fmt.Errorf("invalid gaps length %d", length)
+			}
+			metadata.Gaps = make([]Gap, length/16)
+			for i := range metadata.Gaps {
+				metadata.Gaps[i] = Gap{
+					SampleIndex: int64(binary.LittleEndian.Uint64(value[i*16:])),
+					Duration:    time.Duration(int64(binary.LittleEndian.Uint64(value[i*16+8:]))),
+				}
+			}
+		case tagGainLadder:
+			if length < 4 || length%2 != 0 {
+				return fmt.Errorf("invalid gain ladder length %d", length)
+			}
+			metadata.GainStep = int(int16(binary.LittleEndian.Uint16(value)))
+			metadata.GainLadder = make([]int, length/2-1)
+			for i := range metadata.GainLadder {
+				metadata.GainLadder[i] = int(int16(binary.LittleEndian.Uint16(value[2+i*2:])))
+			}
+		case tagPeakToNoise:
+			if length != 8 {
+				return fmt.Errorf("invalid peak-to-noise length %d", length)
+			}
+			// A streamed capture cut short keeps the NaN reserved at its start
+			if ratio := math.Float64frombits(binary.LittleEndian.Uint64(value)); !math.IsNaN(ratio) {
+				metadata.SignalMeasured = true
+				metadata.PeakToNoise = ratio
+			}
 		}
-		samples[i] = complex(real, imag)
 	}
 
-	return &metadata, samples, nil
+	return nil
 }
 
-// ReadMetadata reads only the metadata header without loading sample data
-func ReadMetadata(filename string) (*Metadata, uint32, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+// CheckFormatVersion returns ErrUnsupportedVersion if files of the given
+// format version cannot be read by this package
+func CheckFormatVersion(version uint16) error {
+	if version < FormatVersion1 || version > CurrentFormatVersion {
+		return fmt.Errorf("%w: %d (supported: %d-%d)", ErrUnsupportedVersion, version, FormatVersion1, CurrentFormatVersion)
 	}
-	defer file.Close()
+	return nil
+}
 
+// readError wraps a failed header or sample read, reporting a short read as ErrTruncated
+func readError(field string, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w while reading %s", ErrTruncated, field)
+	}
+	return fmt.Errorf("failed to read %s: %w", field, err)
+}
+
+// readHeader reads the file header from r, leaving r positioned at the first sample
+func readHeader(r io.Reader) (*Metadata, uint32, error) {
 	// Read magic header
 	magic := make([]byte, 5)
-	if _, err := file.Read(magic); err != nil {
-		return nil, 0, fmt.Errorf("failed to read magic: %w", err)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, 0, readError("magic", err)
 	}
 	if string(magic) != "ARGUS" {
-		return nil, 0, fmt.Errorf("invalid file format")
+		return nil, 0, ErrInvalidMagic
 	}
 
 	var metadata Metadata
 
 	// Read metadata fields in order
-	if err := binary.Read(file, binary.LittleEndian, &metadata.FileFormatVersion); err != nil {
+	if err := binary.Read(r, binary.LittleEndian, &metadata.FileFormatVersion); err != nil {
+		return nil, 0, readError("version", err)
+	}
+	if err := CheckFormatVersion(metadata.FileFormatVersion); err != nil {
 		return nil, 0, err
 	}
 
-	if err := binary.Read(file, binary.LittleEndian, &metadata.Frequency); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &metadata.Frequency); err != nil {
+		return nil, 0, readError("frequency", err)
 	}
 
-	if err := binary.Read(file, binary.LittleEndian, &metadata.SampleRate); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &metadata.SampleRate); err != nil {
+		return nil, 0, readError("sample rate", err)
 	}
 
 	var collectionTimeUnix int64
 	var collectionTimeNano int32
-	if err := binary.Read(file, binary.LittleEndian, &collectionTimeUnix); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &collectionTimeUnix); err != nil {
+		return nil, 0, readError("collection time", err)
 	}
-	if err := binary.Read(file, binary.LittleEndian, &collectionTimeNano); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &collectionTimeNano); err != nil {
+		return nil, 0, readError("collection time", err)
 	}
 	metadata.CollectionTime = time.Unix(collectionTimeUnix, int64(collectionTimeNano))
 
-	if err := binary.Read(file, binary.LittleEndian, &metadata.GPSLocation.Latitude); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &metadata.GPSLocation.Latitude); err != nil {
+		return nil, 0, readError("GPS location", err)
 	}
-	if err := binary.Read(file, binary.LittleEndian, &metadata.GPSLocation.Longitude); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &metadata.GPSLocation.Longitude); err != nil {
+		return nil, 0, readError("GPS location", err)
 	}
-	if err := binary.Read(file, binary.LittleEndian, &metadata.GPSLocation.Altitude); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &metadata.GPSLocation.Altitude); err != nil {
+		return nil, 0, readError("GPS location", err)
 	}
 
 	var gpsTimeUnix int64
 	var gpsTimeNano int32
-	if err := binary.Read(file, binary.LittleEndian, &gpsTimeUnix); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &gpsTimeUnix); err != nil {
+		return nil, 0, readError("GPS timestamp", err)
 	}
-	if err := binary.Read(file, binary.LittleEndian, &gpsTimeNano); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &gpsTimeNano); err != nil {
+		return nil, 0, readError("GPS timestamp", err)
 	}
 	metadata.GPSTimestamp = time.Unix(gpsTimeUnix, int64(gpsTimeNano))
 
 	var deviceInfoLen uint8
-	if err := binary.Read(file, binary.LittleEndian, &deviceInfoLen); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &deviceInfoLen); err != nil {
+		return nil, 0, readError("device info length", err)
 	}
 	deviceInfoBytes := make([]byte, deviceInfoLen)
-	if _, err := file.Read(deviceInfoBytes); err != nil {
-		return nil, 0, err
+	if _, err := io.ReadFull(r, deviceInfoBytes); err != nil {
+		return nil, 0, readError("device info", err)
 	}
 	metadata.DeviceInfo = string(deviceInfoBytes)
 
 	var collectionIDLen uint8
-	if err := binary.Read(file, binary.LittleEndian, &collectionIDLen); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &collectionIDLen); err != nil {
+		return nil, 0, readError("collection ID length", err)
 	}
 	collectionIDBytes := make([]byte, collectionIDLen)
-	if _, err := file.Read(collectionIDBytes); err != nil {
-		return nil, 0, err
+	if _, err := io.ReadFull(r, collectionIDBytes); err != nil {
+		return nil, 0, readError("collection ID", err)
 	}
 	metadata.CollectionID = string(collectionIDBytes)
 
+	if metadata.FileFormatVersion >= FormatVersion2 {
+		var extensionLen uint16
+		if err := binary.Read(r, binary.LittleEndian, &extensionLen); err != nil {
+			return nil, 0, readError("extension length", err)
+		}
+		extensions := make([]byte, extensionLen)
+		if _, err := io.ReadFull(r, extensions); err != nil {
+			return nil, 0, readError("extensions", err)
+		}
+		if err := DecodeExtensions(&metadata, extensions); err != nil {
+			return nil, 0, err
+		}
+	}
+
 	var sampleCount uint32
-	if err := binary.Read(file, binary.LittleEndian, &sampleCount); err != nil {
-		return nil, 0, err
+	if err := binary.Read(r, binary.LittleEndian, &sampleCount); err != nil {
+		return nil, 0, readError("sample count", err)
 	}
 
 	return &metadata, sampleCount, nil
 }
 
+// ReadFile reads the complete file including all sample data, decompressing
+// a gzip-compressed file
+func ReadFile(filename string) (*Metadata, []complex64, error) {
+	file, err := openDataFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	metadata, sampleCount, err := readHeader(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := make([]byte, int(sampleCount)*metadata.SampleFormat.Size())
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, nil, readError("sample data", err)
+	}
+	samples := make([]complex64, sampleCount)
+	DecodeSamples(metadata.SampleFormat, data, samples)
+
+	return metadata, samples, nil
+}
+
+// ReadMetadata reads only the metadata header without loading sample data
+func ReadMetadata(filename string) (*Metadata, uint32, error) {
+	file, err := openDataFile(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	return readHeader(file)
+}
+
 // ReadSamples reads only a specified number of samples from the file
 func ReadSamples(filename string, offset, count uint32) ([]complex64, error) {
 	// Use the existing ReadFile function but limit processing
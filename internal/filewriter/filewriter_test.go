@@ -0,0 +1,604 @@
+package filewriter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "filewriter_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	samples := []complex64{complex(0.5, -0.5), complex(0.25, 0.75), complex(-1, 1)}
+
+	for _, version := range []uint16{FormatVersion1, FormatVersion2} {
+		metadata := Metadata{
+			Frequency:      433920000,
+			SampleRate:     2048000,
+			CollectionTime: time.Unix(1700000000, 123456789),
+			GPSLocation: GPSLocation{
+				Latitude:  35.533,
+				Longitude: -97.621,
+				Altitude:  365.0,
+			},
+			GPSTimestamp:        time.Unix(1700000000, 0),
+			DeviceInfo:          "Generic RTL2832U",
+			FileFormatVersion:   version,
+			CollectionID:        "test_1700000000",
+			ClockOffset:         -12 * time.Millisecond,
+			ClockOffsetMeasured: true,
+			SoftwareVersion:     "0.02-abc1234",
+			ConfigHash:          "0123456789ab",
+			LNAGain:             20.5,
+			Antenna:             "discone, 10 m LMR-400",
+			AGCUsed:             true,
+			AGCFinalGain:        0, // A legitimate converged gain, kept apart from "not used"
+			NoPosition:          true,
+			DeviceLost:          true,
+			Gaps:                []Gap{{SampleIndex: 1, Duration: 3 * time.Millisecond}, {SampleIndex: 2, Duration: 500 * time.Microsecond}},
+			GainLadder:          []int{-10, 15, 40, 207, 496},
+			GainStep:            4,
+			SignalMeasured:      true,
+			PeakToNoise:         17.5,
+		}
+
+		filename := filepath.Join(tempDir, "test.dat")
+		if err := NewWriter().WriteFile(filename, metadata, samples); err != nil {
+			t.Fatalf("v%d: WriteFile failed: %v", version, err)
+		}
+
+		readMetadata, readSamples, err := ReadFile(filename)
+		if err != nil {
+			t.Fatalf("v%d: ReadFile failed: %v", version, err)
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatalf("v%d: failed to stat file: %v", version, err)
+		}
+		dataSize := int64(len(samples) * metadata.SampleFormat.Size())
+		if size := HeaderSize(readMetadata) + dataSize; size != info.Size() {
+			t.Errorf("v%d: header size of read metadata gives file size %d, actual %d", version, size, info.Size())
+		}
+		if size := DataOffset(&metadata, int64(len(samples))); size != info.Size() {
+			t.Errorf("v%d: data offset of written metadata gives file size %d, actual %d", version, size, info.Size())
+		}
+
+		if readMetadata.FileFormatVersion != version {
+			t.Errorf("v%d: expected version %d, got %d", version, version, readMetadata.FileFormatVersion)
+		}
+		if !readMetadata.CollectionTime.Equal(metadata.CollectionTime) {
+			t.Errorf("v%d: collection time mismatch: %v != %v", version, readMetadata.CollectionTime, metadata.CollectionTime)
+		}
+		if readMetadata.CollectionID != metadata.CollectionID {
+			t.Errorf("v%d: collection ID mismatch: %q != %q", version, readMetadata.CollectionID, metadata.CollectionID)
+		}
+		if len(readSamples) != len(samples) {
+			t.Fatalf("v%d: expected %d samples, got %d", version, len(samples), len(readSamples))
+		}
+		for i := range samples {
+			if readSamples[i] != samples[i] {
+				t.Errorf("v%d: sample %d mismatch: %v != %v", version, i, readSamples[i], samples[i])
+			}
+		}
+
+		// Version 1 files have nowhere to store the clock offset
+		if version == FormatVersion1 {
+			if readMetadata.ClockOffsetMeasured || readMetadata.SoftwareVersion != "" {
+				t.Errorf("v1: extension fields should not be present")
+			}
+			continue
+		}
+		if !readMetadata.ClockOffsetMeasured || readMetadata.ClockOffset != metadata.ClockOffset {
+			t.Errorf("v2: clock offset mismatch: %v (measured %t)", readMetadata.ClockOffset, readMetadata.ClockOffsetMeasured)
+		}
+		if readMetadata.SoftwareVersion != metadata.SoftwareVersion || readMetadata.ConfigHash != metadata.ConfigHash {
+			t.Errorf("v2: provenance mismatch: version %q, config hash %q", readMetadata.SoftwareVersion, readMetadata.ConfigHash)
+		}
+		if readMetadata.LNAGain != metadata.LNAGain || readMetadata.Antenna != metadata.Antenna {
+			t.Errorf("v2: gain chain mismatch: LNA %v dB, antenna %q", readMetadata.LNAGain, readMetadata.Antenna)
+		}
+		if !readMetadata.AGCUsed || readMetadata.AGCFinalGain != metadata.AGCFinalGain {
+			t.Errorf("v2: AGC mismatch: used %t, final gain %v dB", readMetadata.AGCUsed, readMetadata.AGCFinalGain)
+		}
+		if !readMetadata.NoPosition {
+			t.Errorf("v2: no-position flag lost")
+		}
+		if !readMetadata.DeviceLost {
+			t.Errorf("v2: device-lost flag lost")
+		}
+		if !slices.Equal(readMetadata.Gaps, metadata.Gaps) {
+			t.Errorf("v2: gaps mismatch: %v != %v", readMetadata.Gaps, metadata.Gaps)
+		}
+		if readMetadata.GainStep != metadata.GainStep || !slices.Equal(readMetadata.GainLadder, metadata.GainLadder) {
+			t.Errorf("v2: gain step %d of %v, want %d of %v",
+				readMetadata.GainStep, readMetadata.GainLadder, metadata.GainStep, metadata.GainLadder)
+		}
+		if !readMetadata.SignalMeasured || readMetadata.PeakToNoise != metadata.PeakToNoise {
+			t.Errorf("v2: peak-to-noise mismatch: %v dB (measured %t)", readMetadata.PeakToNoise, readMetadata.SignalMeasured)
+		}
+	}
+}
+
+func TestPeakToNoiseReserved(t *testing.T) {
+	// A streamed header reserves the record with NaN, which reads as not measured
+	metadata := Metadata{FileFormatVersion: FormatVersion2, SignalMeasured: true, PeakToNoise: math.NaN()}
+	var read Metadata
+	if err := DecodeExtensions(&read, encodeExtensions(&metadata)); err != nil {
+		t.Fatalf("DecodeExtensions failed: %v", err)
+	}
+	if read.SignalMeasured {
+		t.Errorf("reserved record read as measured (%v dB)", read.PeakToNoise)
+	}
+}
+
+func TestDecodeExtensionsSkipsUnknownTags(t *testing.T) {
+	// Unknown tag 200 with a 3 byte value followed by a clock offset record
+	data := []byte{200, 3, 0, 1, 2, 3, tagClockOffset, 8, 0, 0xE8, 0x03, 0, 0, 0, 0, 0, 0}
+
+	var metadata Metadata
+	if err := DecodeExtensions(&metadata, data); err != nil {
+		t.Fatalf("DecodeExtensions failed: %v", err)
+	}
+	if !metadata.ClockOffsetMeasured || metadata.ClockOffset != 1000 {
+		t.Errorf("expected clock offset 1000ns, got %v (measured %t)", metadata.ClockOffset, metadata.ClockOffsetMeasured)
+	}
+
+	// A record running past the end of the block must be rejected
+	if err := DecodeExtensions(&metadata, data[:8]); err == nil {
+		t.Errorf("expected error for truncated extension block")
+	}
+}
+
+func TestInt16SampleFormat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "filewriter_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Includes out-of-range values that must clip to full scale
+	samples := []complex64{complex(0.5, -0.5), complex(1.5, -2), complex(0, 0.001)}
+	metadata := Metadata{
+		SampleRate:        2048000,
+		FileFormatVersion: FormatVersion2,
+		SampleFormat:      SampleFormatInt16,
+	}
+
+	filename := filepath.Join(tempDir, "int16.dat")
+	if err := NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	readMetadata, readSamples, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if readMetadata.SampleFormat != SampleFormatInt16 {
+		t.Fatalf("expected int16 sample format, got %s", readMetadata.SampleFormat)
+	}
+
+	expected := []complex64{complex(0.5, -0.5), complex(1, -1), complex(0, 0.001)}
+	for i := range expected {
+		if diff := readSamples[i] - expected[i]; real(diff) > 1e-4 || real(diff) < -1e-4 || imag(diff) > 1e-4 || imag(diff) < -1e-4 {
+			t.Errorf("sample %d: expected %v, got %v", i, expected[i], readSamples[i])
+		}
+	}
+
+	// int16 needs the version 2 extension block to record the format
+	metadata.FileFormatVersion = FormatVersion1
+	if err := NewWriter().WriteFile(filename, metadata, samples); err == nil {
+		t.Errorf("expected error writing int16 samples to a version 1 file")
+	}
+}
+
+func TestUint8SampleFormat(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Every byte value the dongle can produce, converted as the driver does
+	raw := make([]byte, 512)
+	samples := make([]complex64, 256)
+	for i := range samples {
+		raw[i*2], raw[i*2+1] = byte(i), byte(255-i)
+		samples[i] = complex((float32(i)-127.5)/127.5, (float32(255-i)-127.5)/127.5)
+	}
+	metadata := Metadata{
+		SampleRate:        2048000,
+		FileFormatVersion: FormatVersion2,
+		SampleFormat:      SampleFormatUint8,
+	}
+
+	filename := filepath.Join(tempDir, "uint8.dat")
+	if err := NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	readMetadata, readSamples, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if readMetadata.SampleFormat != SampleFormatUint8 {
+		t.Fatalf("expected uint8 sample format, got %s", readMetadata.SampleFormat)
+	}
+
+	// The data section holds the original bytes verbatim
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := HeaderSize(readMetadata)
+	if stored := data[offset : offset+int64(len(raw))]; !bytes.Equal(stored, raw) {
+		t.Errorf("stored bytes differ from the original RTL-SDR bytes")
+	}
+	for i := range samples {
+		if readSamples[i] != samples[i] {
+			t.Errorf("sample %d: expected %v, got %v", i, samples[i], readSamples[i])
+		}
+	}
+
+	// Out-of-range values clip to the byte range
+	if got := []byte{toUint8(1.5), toUint8(-2)}; got[0] != 255 || got[1] != 0 {
+		t.Errorf("expected clipping to 255 and 0, got %v", got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "filewriter_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filename := filepath.Join(tempDir, "test.dat")
+	metadata := Metadata{SampleRate: 2048000, FileFormatVersion: CurrentFormatVersion}
+	if err := NewWriter().WriteFile(filename, metadata, make([]complex64, 100)); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		contents []byte
+		expected error
+	}{
+		{"truncated samples", data[:len(data)-10], ErrTruncated},
+		{"truncated header", data[:20], ErrTruncated},
+		{"invalid magic", append([]byte("NOPE!"), data[5:]...), ErrInvalidMagic},
+		{"unsupported version", append(append([]byte("ARGUS"), 99, 0), data[7:]...), ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(tempDir, "bad.dat")
+		if err := os.WriteFile(path, tt.contents, 0644); err != nil {
+			t.Fatalf("%s: failed to write file: %v", tt.name, err)
+		}
+		if _, _, err := ReadFile(path); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestSampleReader(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "filewriter_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	samples := make([]complex64, 1000)
+	for i := range samples {
+		samples[i] = complex(float32(i)/1000, -float32(i)/1000)
+	}
+	filename := filepath.Join(tempDir, "test.dat")
+	metadata := Metadata{SampleRate: 2048000, FileFormatVersion: CurrentFormatVersion, CollectionID: "reader_test"}
+	if err := NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	reader, err := NewSampleReader(filename)
+	if err != nil {
+		t.Fatalf("NewSampleReader failed: %v", err)
+	}
+	defer reader.Close()
+
+	if reader.SampleCount() != uint32(len(samples)) {
+		t.Errorf("expected sample count %d, got %d", len(samples), reader.SampleCount())
+	}
+
+	// Sequential reads in odd-sized chunks must return every sample then EOF
+	buf := make([]complex64, 300)
+	var got []complex64
+	for {
+		n, err := reader.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if len(got) != len(samples) {
+		t.Fatalf("expected %d samples, got %d", len(samples), len(got))
+	}
+	for i := range samples {
+		if got[i] != samples[i] {
+			t.Fatalf("sample %d mismatch: %v != %v", i, got[i], samples[i])
+		}
+	}
+
+	if err := reader.SeekSample(750); err != nil {
+		t.Fatalf("SeekSample failed: %v", err)
+	}
+	if _, err := reader.Read(buf[:1]); err != nil || buf[0] != samples[750] {
+		t.Errorf("after seek expected %v, got %v (err %v)", samples[750], buf[0], err)
+	}
+}
+
+func TestStreamWriter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "filewriter_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	metadata := Metadata{
+		Frequency:         162400000,
+		SampleRate:        2048000,
+		CollectionTime:    time.Unix(1700000000, 0),
+		DeviceInfo:        "Generic RTL2832U",
+		FileFormatVersion: CurrentFormatVersion,
+		CollectionID:      "stream_1700000000",
+		SampleFormat:      SampleFormatInt16,
+	}
+	filename := filepath.Join(tempDir, "stream.dat")
+
+	// A long interval leaves the second chunk unsynced
+	stream, err := NewWriter().Create(filename, metadata, time.Hour)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := stream.Write([]complex64{complex(0.5, -0.5), complex(0.25, 0.75)}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := stream.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if err := stream.Write([]complex64{complex(-1, 1)}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Before the capture ends the file already reads as the synced samples
+	_, synced, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile of unfinished file failed: %v", err)
+	}
+	if len(synced) != 2 {
+		t.Errorf("unfinished file: expected 2 synced samples, got %d", len(synced))
+	}
+
+	final := metadata
+	final.CollectionTime = time.Unix(1700000000, 250)
+	final.GPSLocation = GPSLocation{Latitude: 35.5, Longitude: -97.5, Altitude: 300}
+	if err := stream.Finalize(final); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	readMetadata, samples, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(samples) != 3 {
+		t.Errorf("expected 3 samples, got %d", len(samples))
+	}
+	if !readMetadata.CollectionTime.Equal(final.CollectionTime) || readMetadata.GPSLocation != final.GPSLocation {
+		t.Errorf("final metadata not written: %+v", readMetadata)
+	}
+
+	// A header that no longer fits the space written at the start is refused
+	stream, err = NewWriter().Create(filename, metadata, 0)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	final.CollectionID = "a_longer_collection_id"
+	if err := stream.Finalize(final); err == nil {
+		t.Error("expected error finalizing with a different header size")
+	}
+}
+
+func TestCompressedFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	samples := make([]complex64, 5000)
+	for i := range samples {
+		samples[i] = complex(float32(i%100)/100, -float32(i%37)/37)
+	}
+	metadata := Metadata{SampleRate: 2048000, FileFormatVersion: CurrentFormatVersion, CollectionID: "gzip_test", SampleFormat: SampleFormatInt16}
+	plain := filepath.Join(tempDir, "test.dat")
+	compressed := filepath.Join(tempDir, "test.dat.gz")
+	for _, filename := range []string{plain, compressed} {
+		if err := NewWriter().WriteFile(filename, metadata, samples); err != nil {
+			t.Fatalf("WriteFile %s failed: %v", filename, err)
+		}
+	}
+
+	plainInfo, _ := os.Stat(plain)
+	compressedInfo, _ := os.Stat(compressed)
+	if compressedInfo.Size() >= plainInfo.Size() {
+		t.Errorf("compressed file is %d bytes, uncompressed %d", compressedInfo.Size(), plainInfo.Size())
+	}
+	if ok, err := IsCompressedFile(compressed); err != nil || !ok {
+		t.Errorf("IsCompressedFile(%s) = %t, %v", compressed, ok, err)
+	}
+	if ok, err := IsCompressedFile(plain); err != nil || ok {
+		t.Errorf("IsCompressedFile(%s) = %t, %v", plain, ok, err)
+	}
+
+	_, want, err := ReadFile(plain)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	readMetadata, got, err := ReadFile(compressed)
+	if err != nil {
+		t.Fatalf("ReadFile of compressed file failed: %v", err)
+	}
+	if readMetadata.CollectionID != metadata.CollectionID || len(got) != len(want) {
+		t.Fatalf("compressed file read back as %q with %d samples", readMetadata.CollectionID, len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("sample %d mismatch: %v != %v", i, got[i], want[i])
+		}
+	}
+	if _, count, err := ReadMetadata(compressed); err != nil || count != uint32(len(samples)) {
+		t.Errorf("ReadMetadata of compressed file: count %d, %v", count, err)
+	}
+
+	// Seeking forward skips ahead, seeking back decompresses again
+	reader, err := NewSampleReader(compressed)
+	if err != nil {
+		t.Fatalf("NewSampleReader failed: %v", err)
+	}
+	defer reader.Close()
+	buf := make([]complex64, 1)
+	for _, index := range []int64{3000, 4999, 10} {
+		if err := reader.SeekSample(index); err != nil {
+			t.Fatalf("SeekSample(%d) failed: %v", index, err)
+		}
+		if _, err := reader.Read(buf); err != nil || buf[0] != want[index] {
+			t.Errorf("after seek to %d expected %v, got %v (err %v)", index, want[index], buf[0], err)
+		}
+	}
+	if err := reader.SeekSample(int64(len(samples))); err != nil {
+		t.Fatalf("SeekSample to the end failed: %v", err)
+	}
+	if _, err := reader.Read(buf); err != io.EOF {
+		t.Errorf("expected EOF reading past the end, got %v", err)
+	}
+
+	// A compressed file cut short reads as truncated
+	data, err := os.ReadFile(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut := filepath.Join(tempDir, "cut.dat.gz")
+	if err := os.WriteFile(cut, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadFile(cut); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated for a cut compressed file, got %v", err)
+	}
+
+	if _, err := NewWriter().Create(filepath.Join(tempDir, "stream.dat.gz"), metadata, 0); err == nil {
+		t.Error("expected an error streaming to a compressed file")
+	}
+}
+
+func TestTrimExtension(t *testing.T) {
+	for name, want := range map[string]string{
+		"capture.dat":        "capture",
+		"dir/capture.dat.gz": "dir/capture",
+		"capture.DAT.GZ":     "capture",
+		"argus-0_1754061697": "argus-0_1754061697",
+	} {
+		if got := TrimExtension(name); got != want {
+			t.Errorf("TrimExtension(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := SidecarFilename("capture.dat.gz"); got != "capture.json" {
+		t.Errorf("SidecarFilename of a compressed file = %q", got)
+	}
+}
+
+func TestStuckDetector(t *testing.T) {
+	// Noise-like samples with a stuck run in the middle and another at the end
+	samples := make([]complex64, 10000)
+	for i := range samples {
+		samples[i] = complex(float32(i%7), float32(i%5))
+	}
+	for i := 2000; i < 2000+StuckRunMinLength+10; i++ {
+		samples[i] = complex(0.5, -0.5)
+	}
+	for i := 8500; i < len(samples); i++ {
+		samples[i] = 0
+	}
+	// A run one sample too short to count
+	for i := 5000; i < 5000+StuckRunMinLength-1; i++ {
+		samples[i] = complex(0.25, 0.25)
+	}
+
+	// Feed in uneven chunks so runs span chunk boundaries
+	var detector StuckDetector
+	for start := 0; start < len(samples); start += 777 {
+		detector.Add(samples[start:min(start+777, len(samples))])
+	}
+	detector.Finish()
+
+	want := []StuckRun{
+		{Start: 2000, Length: StuckRunMinLength + 10, Value: complex(0.5, -0.5)},
+		{Start: 8500, Length: 1500, Value: 0},
+	}
+	if detector.RunCount != 2 || !slices.Equal(detector.Runs, want) {
+		t.Fatalf("found %d runs %v, want %v", detector.RunCount, detector.Runs, want)
+	}
+	if detector.Affected != StuckRunMinLength+1510 || detector.Longest != 1500 {
+		t.Errorf("affected %d samples, longest %d", detector.Affected, detector.Longest)
+	}
+	if detector.Samples != int64(len(samples)) {
+		t.Errorf("examined %d samples, want %d", detector.Samples, len(samples))
+	}
+}
+
+func TestPeakToNoise(t *testing.T) {
+	noise := func(n int) []complex64 {
+		samples := make([]complex64, n)
+		for i := range samples {
+			// Deterministic noise-like values of constant power per block
+			samples[i] = complex(float32(0.01*math.Cos(float64(i)*1.7)), float32(0.01*math.Sin(float64(i)*1.7)))
+		}
+		return samples
+	}
+
+	if got := PeakToNoise(noise(20 * SignalBlockSamples)); math.Abs(got) > 0.1 {
+		t.Errorf("noise only: got %.2f dB, want about 0", got)
+	}
+
+	// A burst 100 times the noise power in one block
+	samples := noise(20 * SignalBlockSamples)
+	for i := 5 * SignalBlockSamples; i < 6*SignalBlockSamples; i++ {
+		samples[i] *= 10
+	}
+	if got := PeakToNoise(samples); math.Abs(got-20) > 0.1 || got < SignalPresentDB {
+		t.Errorf("burst: got %.2f dB, want 20", got)
+	}
+
+	if got := PeakToNoise(make([]complex64, 4*SignalBlockSamples)); got != 0 {
+		t.Errorf("silence: got %.2f dB, want 0", got)
+	}
+	if got := PeakToNoise(noise(SignalBlockSamples / 2)); got != 0 {
+		t.Errorf("shorter than two blocks: got %.2f dB, want 0", got)
+	}
+}
+
+func TestClippedFraction(t *testing.T) {
+	samples := []complex64{complex(0.5, -0.5), complex(1, 0), complex(0.2, -1), complex(0.99, 0.99)}
+	if got := ClippedFraction(samples); got != 0.5 {
+		t.Errorf("got %v, want 0.5", got)
+	}
+	if got := ClippedFraction(nil); got != 0 {
+		t.Errorf("no samples: got %v, want 0", got)
+	}
+}
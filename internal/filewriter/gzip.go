@@ -0,0 +1,119 @@
+package filewriter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompressedExtension marks a gzip-compressed data file, e.g. capture.dat.gz.
+// Writing to such a name compresses; reading detects compression from the
+// content, so a file compressed with the gzip tool reads the same way.
+const CompressedExtension = ".gz"
+
+// gzipMagic starts every gzip stream; an uncompressed data file starts with "ARGUS"
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// IsCompressedName reports whether filename asks for a gzip-compressed file
+func IsCompressedName(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), CompressedExtension)
+}
+
+// TrimExtension returns filename without its extension, including the .gz of
+// a compressed file (capture.dat.gz -> capture)
+func TrimExtension(filename string) string {
+	if IsCompressedName(filename) {
+		filename = filename[:len(filename)-len(CompressedExtension)]
+	}
+	return strings.TrimSuffix(filename, filepath.Ext(filename))
+}
+
+// IsCompressed reports whether the data in r is gzip-compressed
+func IsCompressed(r io.ReaderAt) bool {
+	magic := make([]byte, len(gzipMagic))
+	n, _ := r.ReadAt(magic, 0)
+	return n == len(magic) && bytes.Equal(magic, gzipMagic)
+}
+
+// IsCompressedFile reports whether the data file filename is gzip-compressed
+func IsCompressedFile(filename string) (bool, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	return IsCompressed(file), nil
+}
+
+// dataFile reads a data file sequentially from its start, decompressing it
+// if it is gzip-compressed
+type dataFile struct {
+	file *os.File
+	gz   *gzip.Reader // Decompressor, nil for an uncompressed file
+	pos  int64        // Offset into the uncompressed data of a compressed file
+}
+
+// openDataFile opens filename for reading from the start of its header
+func openDataFile(filename string) (*dataFile, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	d := &dataFile{file: file}
+	if IsCompressed(file) {
+		if d.gz, err = gzip.NewReader(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to open compressed file: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// Read reads uncompressed file data
+func (d *dataFile) Read(p []byte) (int, error) {
+	if d.gz == nil {
+		return d.file.Read(p)
+	}
+	n, err := d.gz.Read(p)
+	d.pos += int64(n)
+	return n, err
+}
+
+// SeekTo positions the reader offset bytes into the uncompressed data. A
+// compressed file has no index: seeking forward decompresses up to offset,
+// and seeking back starts again from the beginning.
+func (d *dataFile) SeekTo(offset int64) error {
+	if d.gz == nil {
+		_, err := d.file.Seek(offset, io.SeekStart)
+		return err
+	}
+
+	if offset < d.pos {
+		if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := d.gz.Reset(d.file); err != nil {
+			return err
+		}
+		d.pos = 0
+	}
+	skipped, err := io.CopyN(io.Discard, d.gz, offset-d.pos)
+	d.pos += skipped
+	if err == io.EOF {
+		err = nil // Past the end; the next read reports it
+	}
+	return err
+}
+
+// Close closes the file
+func (d *dataFile) Close() error {
+	if d.gz != nil {
+		d.gz.Close()
+	}
+	return d.file.Close()
+}
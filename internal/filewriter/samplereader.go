@@ -0,0 +1,103 @@
+package filewriter
+
+import (
+	"fmt"
+	"io"
+)
+
+// SampleReader streams decoded samples from a data file, handling the header
+// and sample format internally. Reads stop at the end of the file rather than
+// at the header sample count, so truncated captures can still be processed.
+// Reads go straight to the file, so pass buffers of a few thousand samples
+// for sequential access. A gzip-compressed file is decompressed as it is read.
+type SampleReader struct {
+	file        *dataFile
+	metadata    *Metadata
+	sampleCount uint32
+	raw         []byte
+	swapIQ      bool // Exchange I and Q of each sample read
+}
+
+// NewSampleReader opens filename and positions the reader at the first sample
+func NewSampleReader(filename string) (*SampleReader, error) {
+	file, err := openDataFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, sampleCount, err := readHeader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &SampleReader{
+		file:        file,
+		metadata:    metadata,
+		sampleCount: sampleCount,
+	}, nil
+}
+
+// Metadata returns the file header
+func (r *SampleReader) Metadata() *Metadata {
+	return r.metadata
+}
+
+// SampleCount returns the number of samples recorded in the header
+func (r *SampleReader) SampleCount() uint32 {
+	return r.sampleCount
+}
+
+// Read decodes up to len(buf) samples into buf and returns the number read.
+// It returns io.EOF once no complete samples remain; a partial sample at the
+// end of a truncated file is discarded.
+func (r *SampleReader) Read(buf []complex64) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	size := r.metadata.SampleFormat.Size()
+	if need := len(buf) * size; cap(r.raw) < need {
+		r.raw = make([]byte, need)
+	}
+	raw := r.raw[:len(buf)*size]
+
+	n, err := io.ReadFull(r.file, raw)
+	complete := n / size
+	if complete == 0 {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read samples: %w", err)
+		}
+	}
+
+	DecodeSamples(r.metadata.SampleFormat, raw, buf[:complete])
+	if r.swapIQ {
+		SwapIQ(buf[:complete])
+	}
+	return complete, nil
+}
+
+// SetSwapIQ exchanges I and Q of every sample read from here on
+func (r *SampleReader) SetSwapIQ(swap bool) {
+	r.swapIQ = swap
+}
+
+// SeekSample positions the reader at the given sample index. In a compressed
+// file seeking back is slow, as the file is decompressed again from the start.
+func (r *SampleReader) SeekSample(sampleIndex int64) error {
+	if sampleIndex < 0 {
+		return fmt.Errorf("invalid sample index %d", sampleIndex)
+	}
+	if err := r.file.SeekTo(DataOffset(r.metadata, sampleIndex)); err != nil {
+		return fmt.Errorf("failed to seek to sample %d: %w", sampleIndex, err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (r *SampleReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,43 @@
+package filewriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Sidecar is the JSON document written alongside a .dat file for tools that
+// cannot parse the binary header
+type Sidecar struct {
+	Metadata
+	SampleCount uint32 `json:"sample_count"`
+	DataFile    string `json:"data_file"`
+}
+
+// SidecarFilename returns the sidecar path for a data file (capture.dat or
+// capture.dat.gz -> capture.json)
+func SidecarFilename(dataFilename string) string {
+	return TrimExtension(dataFilename) + ".json"
+}
+
+// WriteSidecar writes the metadata of dataFilename as JSON next to it and returns the sidecar path
+func WriteSidecar(dataFilename string, metadata *Metadata, sampleCount uint32) (string, error) {
+	sidecar := Sidecar{
+		Metadata:    *metadata,
+		SampleCount: sampleCount,
+		DataFile:    filepath.Base(dataFilename),
+	}
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode sidecar: %w", err)
+	}
+
+	filename := SidecarFilename(dataFilename)
+	if err := os.WriteFile(filename, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write sidecar: %w", err)
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,66 @@
+package filewriter
+
+import (
+	"math"
+	"sort"
+)
+
+// SignalBlockSamples is the length of the blocks whose power PeakToNoise
+// compares: long enough to average out sample noise, short enough to resolve
+// a burst. It matches the blocks the processor reports power over.
+const SignalBlockSamples = 1024
+
+// SignalPresentDB is the peak-to-noise ratio above which a capture is taken to
+// hold a signal. Noise alone rarely puts one block of SignalBlockSamples more
+// than a few dB above the median.
+const SignalPresentDB = 6.0
+
+// maxPeakToNoiseDB stands in for a signal over a noise floor of exact zeros,
+// which has no finite ratio
+const maxPeakToNoiseDB = 200.0
+
+// PeakToNoise measures how far the loudest block of SignalBlockSamples in the
+// capture rises above its noise floor, in dB. The noise floor is the median
+// block power, which a signal present for under half the capture does not
+// move; a transmission filling most of the capture raises the floor with it
+// and reads as no signal. A capture of a single block, or of silence, gives 0.
+func PeakToNoise(samples []complex64) float64 {
+	blocks := make([]float64, 0, len(samples)/SignalBlockSamples)
+	for start := 0; start+SignalBlockSamples <= len(samples); start += SignalBlockSamples {
+		var power float64
+		for _, s := range samples[start : start+SignalBlockSamples] {
+			power += float64(real(s))*float64(real(s)) + float64(imag(s))*float64(imag(s))
+		}
+		blocks = append(blocks, power/SignalBlockSamples)
+	}
+	if len(blocks) < 2 {
+		return 0
+	}
+
+	sort.Float64s(blocks)
+	noise := blocks[len(blocks)/2]
+	peak := blocks[len(blocks)-1]
+	if noise <= 0 {
+		if peak > 0 {
+			return maxPeakToNoiseDB
+		}
+		return 0
+	}
+	return math.Min(10*math.Log10(peak/noise), maxPeakToNoiseDB)
+}
+
+// ClippedFraction returns the fraction of samples with I or Q at full scale,
+// where the receiver's converter saturates: the RTL-SDR's 0 and 255 bytes.
+// Any clipping distorts the signal and the timing taken from it.
+func ClippedFraction(samples []complex64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	clipped := 0
+	for _, s := range samples {
+		if math.Abs(float64(real(s))) >= 1 || math.Abs(float64(imag(s))) >= 1 {
+			clipped++
+		}
+	}
+	return float64(clipped) / float64(len(samples))
+}
@@ -0,0 +1,153 @@
+package filewriter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// StreamWriter writes a data file incrementally as samples arrive. At each
+// sync interval it records the sample count written so far in the header and
+// flushes the file to stable storage, so after a crash or power loss the file
+// on disk is a valid capture of every sample synced before it.
+type StreamWriter struct {
+	writer       *Writer
+	file         *os.File
+	metadata     Metadata
+	count        uint32
+	syncInterval time.Duration
+	lastSync     time.Time
+	err          error // First write or sync failure; later writes are refused
+	closed       bool
+}
+
+// Create writes the header for metadata to a new file and returns a writer
+// for its samples. A syncInterval of 0 syncs after every write. Streamed files
+// cannot be compressed, as the header is rewritten when the capture ends.
+func (w *Writer) Create(filename string, metadata Metadata, syncInterval time.Duration) (*StreamWriter, error) {
+	if IsCompressedName(filename) {
+		return nil, fmt.Errorf("cannot stream to compressed file %s", filename)
+	}
+	if metadata.SampleFormat != SampleFormatComplex64 && metadata.FileFormatVersion < FormatVersion2 {
+		return nil, fmt.Errorf("sample format %s requires file format version %d", metadata.SampleFormat, FormatVersion2)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	if err := w.writeHeader(file, metadata, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	s := &StreamWriter{
+		writer:       w,
+		file:         file,
+		metadata:     metadata,
+		syncInterval: syncInterval,
+	}
+	if err := s.Sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write appends samples to the file, syncing when the interval has elapsed
+func (s *StreamWriter) Write(samples []complex64) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return fmt.Errorf("write to closed stream")
+	}
+	if uint64(s.count)+uint64(len(samples)) > math.MaxUint32 {
+		s.err = fmt.Errorf("capture exceeds the %d samples a data file can hold", uint32(math.MaxUint32))
+		return s.err
+	}
+	if err := s.writer.writeSamples(s.file, samples, s.metadata.SampleFormat); err != nil {
+		s.err = fmt.Errorf("failed to write samples: %w", err)
+		return s.err
+	}
+	s.count += uint32(len(samples))
+
+	if time.Since(s.lastSync) >= s.syncInterval {
+		if err := s.Sync(); err != nil {
+			s.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync records the number of samples written in the header and flushes the
+// file to stable storage
+func (s *StreamWriter) Sync() error {
+	var count [4]byte
+	binary.LittleEndian.PutUint32(count[:], s.count)
+	if _, err := s.file.WriteAt(count[:], HeaderSize(&s.metadata)-4); err != nil {
+		return fmt.Errorf("failed to update sample count: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	s.lastSync = time.Now()
+	return nil
+}
+
+// Count returns the number of samples written
+func (s *StreamWriter) Count() uint32 {
+	return s.count
+}
+
+// Err returns the first write or sync failure, if any
+func (s *StreamWriter) Err() error {
+	return s.err
+}
+
+// Finalize replaces the header with metadata known only at the end of the
+// capture, records the final sample count and closes the file. The new header
+// must encode to the same size as the one written by Create.
+func (s *StreamWriter) Finalize(metadata Metadata) error {
+	if s.closed {
+		return fmt.Errorf("stream already closed")
+	}
+	if s.err != nil {
+		s.Close()
+		return s.err
+	}
+	if HeaderSize(&metadata) != HeaderSize(&s.metadata) || metadata.SampleFormat != s.metadata.SampleFormat {
+		s.Close()
+		return fmt.Errorf("final header does not match the layout written at the start of the capture")
+	}
+
+	var header bytes.Buffer
+	if err := s.writer.writeHeader(&header, metadata, s.count); err != nil {
+		s.Close()
+		return fmt.Errorf("failed to encode header: %w", err)
+	}
+	if _, err := s.file.WriteAt(header.Bytes(), 0); err != nil {
+		s.Close()
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	s.metadata = metadata
+	return s.Close()
+}
+
+// Close records the final sample count, syncs and closes the file, keeping
+// the header written by Create. Closing a closed stream does nothing.
+func (s *StreamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	err := s.Sync()
+	if closeErr := s.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close file: %w", closeErr)
+	}
+	return err
+}
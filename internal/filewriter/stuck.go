@@ -0,0 +1,76 @@
+package filewriter
+
+// StuckRunMinLength is the shortest run of identical consecutive samples
+// treated as stuck. Receiver noise changes at least one of I and Q within a
+// few samples, and even a strong carrier sitting almost exactly on the tuned
+// frequency moves to a new 8-bit level every few hundred samples, so longer
+// runs come from the USB transfer or driver repeating data rather than from
+// the antenna.
+const StuckRunMinLength = 1024
+
+// maxStuckRunsListed limits the runs a StuckDetector keeps individually
+const maxStuckRunsListed = 100
+
+// StuckRun is a run of identical consecutive samples
+type StuckRun struct {
+	Start  int64     // Index of the first sample of the run
+	Length int64     // Number of samples in the run
+	Value  complex64 // The repeated sample
+}
+
+// StuckDetector finds runs of at least StuckRunMinLength identical
+// consecutive samples in a capture fed to it in order, one chunk at a time
+type StuckDetector struct {
+	Runs     []StuckRun // The first runs found, up to maxStuckRunsListed
+	RunCount int64      // Number of runs found
+	Affected int64      // Samples in all runs found
+	Longest  int64      // Length of the longest run
+	Samples  int64      // Samples examined
+
+	value    complex64 // Sample repeated by the current run
+	runStart int64     // Index of the first sample of the current run
+}
+
+// Add examines the next chunk of the capture
+func (d *StuckDetector) Add(samples []complex64) {
+	for i, s := range samples {
+		index := d.Samples + int64(i)
+		if index > 0 && s == d.value {
+			continue
+		}
+		d.endRun(index)
+		d.value = s
+		d.runStart = index
+	}
+	d.Samples += int64(len(samples))
+}
+
+// Finish ends the run at the end of the capture; call it once every chunk
+// has been added
+func (d *StuckDetector) Finish() {
+	d.endRun(d.Samples)
+	d.runStart = d.Samples
+}
+
+// Fraction returns the fraction of the samples examined that are in a run
+func (d *StuckDetector) Fraction() float64 {
+	if d.Samples == 0 {
+		return 0
+	}
+	return float64(d.Affected) / float64(d.Samples)
+}
+
+// endRun records the current run, which ends before sample end, if it is
+// long enough to count as stuck
+func (d *StuckDetector) endRun(end int64) {
+	length := end - d.runStart
+	if length < StuckRunMinLength {
+		return
+	}
+	d.RunCount++
+	d.Affected += length
+	d.Longest = max(d.Longest, length)
+	if len(d.Runs) < maxStuckRunsListed {
+		d.Runs = append(d.Runs, StuckRun{Start: d.runStart, Length: length, Value: d.value})
+	}
+}
@@ -0,0 +1,104 @@
+package gps
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrianmo/go-nmea"
+	"go.bug.st/serial"
+)
+
+// AutoBaudRates are the baud rates tried, in order, when detecting the baud
+// rate of a GPS receiver
+var AutoBaudRates = []int{4800, 9600, 38400, 115200}
+
+// Baud rate detection settings
+const (
+	baudProbeTime      = 1500 * time.Millisecond // Longest each rate is listened to; most receivers send at least once a second
+	baudProbeSentences = 2                       // Valid sentences needed to lock onto a rate
+	baudProbeRead      = 100 * time.Millisecond  // Read timeout while probing, so an idle port does not block past the probe time
+)
+
+// ParseBaudRate parses a GPS baud rate setting: a positive number, or "auto"
+// (or 0) to detect the rate, for which it returns 0
+func ParseBaudRate(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "auto") {
+		return 0, nil
+	}
+	baud, err := strconv.Atoi(value)
+	if err != nil || baud < 0 {
+		return 0, fmt.Errorf("invalid GPS baud rate %q: use a positive number such as 9600, or 'auto' to detect it", value)
+	}
+	return baud, nil
+}
+
+// countNMEASentences counts the complete lines of data that are NMEA
+// sentences with a valid checksum, whether or not their type is one this
+// package parses. Bytes read at the wrong baud rate almost never form one.
+func countNMEASentences(data []byte) int {
+	lines := strings.Split(string(data), "\n")
+	count := 0
+	// The last element is a partial line still being received
+	for _, line := range lines[:len(lines)-1] {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] != '$' {
+			continue
+		}
+		_, err := nmea.Parse(line)
+		var notSupported *nmea.NotSupportedError
+		if err == nil || errors.As(err, &notSupported) {
+			count++
+		}
+	}
+	return count
+}
+
+// detectBaudRate listens to port at each of AutoBaudRates in turn, leaving it
+// set to the first rate at which it receives valid NMEA sentences
+func detectBaudRate(port serial.Port, portName string) (int, error) {
+	if err := port.SetReadTimeout(baudProbeRead); err != nil {
+		return 0, fmt.Errorf("failed to set GPS port read timeout: %w", err)
+	}
+	defer port.SetReadTimeout(serial.NoTimeout)
+
+	buf := make([]byte, 512)
+	for _, baud := range AutoBaudRates {
+		mode := &serial.Mode{
+			BaudRate: baud,
+			Parity:   serial.NoParity,
+			DataBits: 8,
+			StopBits: serial.OneStopBit,
+		}
+		if err := port.SetMode(mode); err != nil {
+			return 0, fmt.Errorf("failed to set GPS port %s to %d baud: %w", portName, baud, err)
+		}
+		port.ResetInputBuffer()
+
+		var received []byte
+		deadline := time.Now().Add(baudProbeTime)
+		for time.Now().Before(deadline) {
+			n, err := port.Read(buf)
+			if err != nil {
+				return 0, fmt.Errorf("failed to read GPS port %s: %w", portName, err)
+			}
+			received = append(received, buf[:n]...)
+			if countNMEASentences(received) >= baudProbeSentences {
+				log.Printf("GPS: Detected %d baud on %s", baud, portName)
+				return baud, nil
+			}
+		}
+		log.Printf("GPS: No NMEA sentences at %d baud (%d bytes received)", baud, len(received))
+	}
+
+	rates := make([]string, len(AutoBaudRates))
+	for i, baud := range AutoBaudRates {
+		rates[i] = strconv.Itoa(baud)
+	}
+	return 0, fmt.Errorf("no NMEA sentences received from GPS port %s at %s baud; check the receiver is powered and sending NMEA, or set --gps-baud",
+		portName, strings.Join(rates, ", "))
+}
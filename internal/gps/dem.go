@@ -0,0 +1,91 @@
+package gps
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// demVoid marks a missing elevation in an SRTM tile
+const demVoid = -32768
+
+// DEMTileName returns the SRTM tile file name covering lat, lon, e.g.
+// N35W098.hgt for 35.5, -97.6. Tiles are named after their south-west corner.
+func DEMTileName(lat, lon float64) string {
+	south, west := int(math.Floor(lat)), int(math.Floor(lon))
+	ns, ew := 'N', 'E'
+	if south < 0 {
+		ns, south = 'S', -south
+	}
+	if west < 0 {
+		ew, west = 'W', -west
+	}
+	return fmt.Sprintf("%c%02d%c%03d.hgt", ns, south, ew, west)
+}
+
+// LookupElevation returns the ground elevation in meters above sea level at
+// lat, lon, interpolated from the SRTM .hgt tile covering it in dir. Both the
+// 1 arc-second (3601×3601) and 3 arc-second (1201×1201) tile sizes are read.
+func LookupElevation(dir string, lat, lon float64) (float64, error) {
+	if lat < -90 || lat >= 90 || lon < -180 || lon >= 180 {
+		return 0, fmt.Errorf("position %.6f, %.6f is outside the DEM coverage", lat, lon)
+	}
+
+	filename := filepath.Join(dir, DEMTileName(lat, lon))
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open DEM tile: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read DEM tile: %w", err)
+	}
+	var size int
+	switch info.Size() {
+	case 3601 * 3601 * 2:
+		size = 3601
+	case 1201 * 1201 * 2:
+		size = 1201
+	default:
+		return 0, fmt.Errorf("DEM tile %s has unexpected size %d bytes", filename, info.Size())
+	}
+
+	// Rows run north to south from the tile's top edge, columns west to east
+	y := (math.Floor(lat) + 1 - lat) * float64(size-1)
+	x := (lon - math.Floor(lon)) * float64(size-1)
+	row, col := int(y), int(x)
+	fy, fx := y-float64(row), x-float64(col)
+
+	// Bilinear interpolation between the four surrounding posts, skipping voids
+	var sum, weightSum float64
+	for _, post := range []struct {
+		row, col int
+		weight   float64
+	}{
+		{row, col, (1 - fy) * (1 - fx)},
+		{row, col + 1, (1 - fy) * fx},
+		{row + 1, col, fy * (1 - fx)},
+		{row + 1, col + 1, fy * fx},
+	} {
+		r, c := min(post.row, size-1), min(post.col, size-1)
+		var buf [2]byte
+		if _, err := file.ReadAt(buf[:], int64(r*size+c)*2); err != nil {
+			return 0, fmt.Errorf("failed to read DEM tile: %w", err)
+		}
+		elevation := int16(binary.BigEndian.Uint16(buf[:]))
+		if elevation == demVoid {
+			continue
+		}
+		sum += post.weight * float64(elevation)
+		weightSum += post.weight
+	}
+
+	if weightSum == 0 {
+		return 0, fmt.Errorf("no elevation data at %.6f, %.6f in %s", lat, lon, filename)
+	}
+	return sum / weightSum, nil
+}
@@ -0,0 +1,76 @@
+package gps
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestTile writes a 3 arc-second tile whose elevation rises 1 m per post
+// eastward and 2 m per post southward, with one void post
+func writeTestTile(t *testing.T, dir, name string) {
+	t.Helper()
+
+	const size = 1201
+	data := make([]byte, size*size*2)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			elevation := int16(col + 2*row)
+			if row == 10 && col == 10 {
+				elevation = demVoid
+			}
+			binary.BigEndian.PutUint16(data[(row*size+col)*2:], uint16(elevation))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDEMTileName(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		want     string
+	}{
+		{35.5, -97.6, "N35W098.hgt"},
+		{-33.9, 151.2, "S34E151.hgt"},
+		{0.5, 0.5, "N00E000.hgt"},
+	}
+	for _, tt := range tests {
+		if got := DEMTileName(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("DEMTileName(%v, %v) = %s, want %s", tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestLookupElevation(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTile(t, dir, "N35W098.hgt")
+	post := 1.0 / 1200 // Degrees between posts
+
+	// North-west corner of the tile is post (0, 0)
+	if e, err := LookupElevation(dir, 36-1e-9, -98); err != nil || math.Abs(e) > 0.01 {
+		t.Errorf("North-west corner: got %.2f, %v", e, err)
+	}
+
+	// Halfway between posts interpolates: row 100.5, column 200.5
+	e, err := LookupElevation(dir, 36-100.5*post, -98+200.5*post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 200.5 + 2*100.5; math.Abs(e-want) > 0.01 {
+		t.Errorf("Interpolated elevation %.2f, want %.2f", e, want)
+	}
+
+	// The void post is left out: the other three posts (31, 32 and 33 m)
+	// share the weight equally
+	if e, err := LookupElevation(dir, 36-10.5*post, -98+10.5*post); err != nil || math.Abs(e-32) > 0.01 {
+		t.Errorf("Next to void: got %.2f, %v, want 32", e, err)
+	}
+
+	if _, err := LookupElevation(dir, 40.5, -98.5); err == nil {
+		t.Error("Expected error for a missing tile")
+	}
+}
@@ -16,9 +16,18 @@ type Position struct {
 	Latitude   float64
 	Longitude  float64
 	Altitude   float64
-	Timestamp  time.Time
+	Timestamp  time.Time // System time the fix was received, for judging its age
 	FixQuality int
-	Satellites int
+	Satellites int // Satellites used in the fix
+
+	// UTC is the GPS-derived UTC time of the fix as reported by the receiver,
+	// zero if it has not reported one. Unlike Timestamp it does not depend on
+	// the system clock.
+	UTC time.Time
+
+	// SatellitesSeen is the number of satellites in view (tracked, whether or
+	// not used in the fix), 0 if the receiver has not reported it
+	SatellitesSeen int
 }
 
 // GPSInterface defines the common interface for GPS implementations
@@ -28,6 +37,8 @@ type GPSInterface interface {
 	GetCurrentPosition() (*Position, error)
 	IsFixValid() bool
 	GetFixQualityString() string
+	GetClockOffset() (time.Duration, error)
+	Updates() <-chan Position
 	Close() error
 }
 
@@ -41,8 +52,14 @@ type NMEASerial struct {
 	port     serial.Port
 	position Position
 	fixChan  chan Position
+	updates  chan Position
 	mu       sync.RWMutex
 	debug    bool
+
+	clockOffsets []time.Duration // Recent system clock minus GPS time samples
+	burst        burstClock      // Start of the sentence burst being read
+	inView       map[string]int  // Satellites in view per GSV talker (GP, GL, ...)
+	lastRMC      time.Time       // UTC date and time of the latest valid RMC, dating the time-only GGA
 }
 
 // GPSDClient implements GPS via gpsd daemon
@@ -50,10 +67,112 @@ type GPSDClient struct {
 	client     *gpsd.Session
 	position   Position
 	fixChan    chan Position
+	updates    chan Position
 	host       string
 	port       string
 	satCount   int  // Track satellite count separately from position
-	mu         sync.RWMutex  // Protect position and satCount from concurrent access
+	satSeen    int  // Satellites in view from the latest SKY report
+	mu         sync.RWMutex  // Guards position, satCount, satSeen and clockOffsets, written by the gpsd filters
+
+	clockOffsets []time.Duration // Recent system clock minus GPS time samples
+}
+
+// fixUTC returns the UTC time of a fix reported only as time of day t, dated
+// to the day that puts it closest to reference (a fix just before midnight
+// may be dated by a reference just after it). It returns the zero time if t
+// is not valid.
+func fixUTC(t nmea.Time, reference time.Time) time.Time {
+	if !t.Valid {
+		return time.Time{}
+	}
+	reference = reference.UTC()
+	utc := time.Date(reference.Year(), reference.Month(), reference.Day(),
+		t.Hour, t.Minute, t.Second, t.Millisecond*1e6, time.UTC)
+	if d := utc.Sub(reference); d > 12*time.Hour {
+		utc = utc.AddDate(0, 0, -1)
+	} else if d < -12*time.Hour {
+		utc = utc.AddDate(0, 0, 1)
+	}
+	return utc
+}
+
+// nmeaBurstGap is the shortest silence on the serial line taken to separate
+// the sentence bursts a receiver sends after each epoch
+const nmeaBurstGap = 100 * time.Millisecond
+
+// burstClock estimates when each burst of NMEA sentences began arriving. A
+// receiver sends the sentences describing an epoch together, shortly after
+// it, and a sentence is only read once its last byte has arrived, up to
+// several hundred milliseconds after the epoch at 9600 baud. Measuring the
+// clock offset from the first byte of the burst removes the serial
+// transmission time, leaving the receiver's output delay of a few tens of
+// milliseconds.
+type burstClock struct {
+	baud  int       // Serial bits per second; 0 if unknown, counting no transmission time
+	start time.Time // Estimated arrival of the first byte of the current burst
+	last  time.Time // Arrival of the last byte of the previous line
+}
+
+// lineTime returns how long a line of length bytes, with its CR LF, takes
+// to arrive at baud with 10 bits per byte (8N1)
+func lineTime(length, baud int) time.Duration {
+	if baud <= 0 {
+		return 0
+	}
+	return time.Duration(length+2) * 10 * time.Second / time.Duration(baud)
+}
+
+// arrived records a line of length bytes read completely at now and returns
+// the estimated time its burst began arriving
+func (b *burstClock) arrived(now time.Time, length int) time.Time {
+	sending := lineTime(length, b.baud)
+	if b.last.IsZero() || now.Sub(b.last) > sending+nmeaBurstGap {
+		b.start = now.Add(-sending)
+	}
+	b.last = now
+	return b.start
+}
+
+// maxClockSamples is the number of recent clock offset samples kept per receiver
+const maxClockSamples = 10
+
+// addClockSample records a system-minus-GPS offset, keeping only the most recent samples
+func addClockSample(samples []time.Duration, offset time.Duration) []time.Duration {
+	samples = append(samples, offset)
+	if len(samples) > maxClockSamples {
+		samples = samples[len(samples)-maxClockSamples:]
+	}
+	return samples
+}
+
+// minClockOffset returns the smallest recorded offset. Time messages always arrive
+// after the epoch they describe, so the minimum is the sample with the least
+// serial/daemon latency and the best estimate of the true clock offset.
+func minClockOffset(samples []time.Duration) (time.Duration, error) {
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("no GPS time received yet")
+	}
+	offset := samples[0]
+	for _, sample := range samples[1:] {
+		if sample < offset {
+			offset = sample
+		}
+	}
+	return offset, nil
+}
+
+// publishUpdate hands pos to the updates channel without blocking the reader
+// goroutine. An update the consumer has not taken yet is replaced, so the
+// channel always holds the latest state.
+func publishUpdate(updates chan Position, pos Position) {
+	select {
+	case <-updates:
+	default:
+	}
+	select {
+	case updates <- pos:
+	default:
+	}
 }
 
 // NewGPS creates a GPS instance with NMEA serial interface
@@ -74,13 +193,24 @@ func NewGPSD(host, port string) (*GPS, error) {
 	return &GPS{impl: gpsdClient}, nil
 }
 
+// NewGPSWith creates a GPS instance backed by impl, such as a simulated
+// receiver in tests
+func NewGPSWith(impl GPSInterface) *GPS {
+	return &GPS{impl: impl}
+}
+
 // NewNMEASerial creates a new NMEA serial GPS interface
 func NewNMEASerial(portName string, baudRate int) (*NMEASerial, error) {
 	return NewNMEASerialWithDebug(portName, baudRate, false)
 }
 
-// NewNMEASerialWithDebug creates a new NMEA serial GPS interface with debug option
+// NewNMEASerialWithDebug creates a new NMEA serial GPS interface with debug option.
+// A baud rate of 0 detects the receiver's rate by trying each of AutoBaudRates.
 func NewNMEASerialWithDebug(portName string, baudRate int, debug bool) (*NMEASerial, error) {
+	detect := baudRate == 0
+	if detect {
+		baudRate = AutoBaudRates[0]
+	}
 	mode := &serial.Mode{
 		BaudRate: baudRate,
 		Parity:   serial.NoParity,
@@ -88,15 +218,27 @@ func NewNMEASerialWithDebug(portName string, baudRate int, debug bool) (*NMEASer
 		StopBits: serial.OneStopBit,
 	}
 
+	portName, err := NormalizeSerialPort(portName)
+	if err != nil {
+		return nil, err
+	}
 	port, err := serial.Open(portName, mode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open GPS port %s: %w", portName, err)
+		return nil, openError(portName, err)
+	}
+	if detect {
+		if baudRate, err = detectBaudRate(port, portName); err != nil {
+			port.Close()
+			return nil, err
+		}
 	}
 
 	nmea := &NMEASerial{
 		port:    port,
 		fixChan: make(chan Position, 10),
+		updates: make(chan Position, 1),
 		debug:   debug,
+		burst:   burstClock{baud: baudRate},
 	}
 
 	// Try to configure u-blox GPS to output NMEA GGA messages if it's not already
@@ -131,6 +273,7 @@ func (n *NMEASerial) configureUbloxNMEA() {
 func NewGPSDClient(host, port string) (*GPSDClient, error) {
 	return &GPSDClient{
 		fixChan: make(chan Position, 10),
+		updates: make(chan Position, 1),
 		host:    host,
 		port:    port,
 	}, nil
@@ -157,6 +300,17 @@ func (g *GPS) GetFixQualityString() string {
 	return g.impl.GetFixQualityString()
 }
 
+func (g *GPS) GetClockOffset() (time.Duration, error) {
+	return g.impl.GetClockOffset()
+}
+
+// Updates returns a channel receiving the latest position and satellite
+// counts whenever they change, before and after a fix is acquired. Positions
+// without a fix have FixQuality 0. Updates the caller misses are dropped.
+func (g *GPS) Updates() <-chan Position {
+	return g.impl.Updates()
+}
+
 func (g *GPS) Close() error {
 	return g.impl.Close()
 }
@@ -180,6 +334,7 @@ func (n *NMEASerial) readLoop() {
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		epoch := n.burst.arrived(time.Now(), len(line))
 
 		// Only process lines that look like NMEA sentences (start with $ and contain only printable ASCII)
 		if len(line) == 0 || line[0] != '$' {
@@ -215,13 +370,15 @@ func (n *NMEASerial) readLoop() {
 			if n.debug {
 				log.Printf("GPS: Processing GGA message")
 			}
-			n.processGGA(s)
+			n.processGGA(s, epoch)
 		case nmea.RMC:
 			if n.debug {
 				log.Printf("GPS: Processing RMC message")
 			}
-			n.processRMC(s)
-		case nmea.GLL, nmea.VTG, nmea.GSA, nmea.GSV:
+			n.processRMC(s, epoch)
+		case nmea.GSV:
+			n.processGSV(s)
+		case nmea.GLL, nmea.VTG, nmea.GSA:
 			// These are valid NMEA sentences but don't contain position fixes we need
 			if n.debug {
 				log.Printf("GPS: Received %T message (not needed for position)", s)
@@ -240,7 +397,9 @@ func (n *NMEASerial) readLoop() {
 	log.Printf("GPS: NMEA read loop ended")
 }
 
-func (n *NMEASerial) processGGA(s nmea.GGA) {
+// processGGA updates the position from a GGA sentence. epoch is the local
+// time the sentence's burst began arriving, see burstClock.
+func (n *NMEASerial) processGGA(s nmea.GGA, epoch time.Time) {
 	if n.debug {
 		log.Printf("GPS: Processing GGA - Quality: %v, Lat: %f, Lon: %f, Sats: %d",
 			s.FixQuality, s.Latitude, s.Longitude, s.NumSatellites)
@@ -269,18 +428,30 @@ func (n *NMEASerial) processGGA(s nmea.GGA) {
 		// Some GPS receivers output (0,0) when they don't have a fix yet, but
 		// if fix quality is valid, we should trust the coordinates
 		if fixQuality > 0 {
+			now := time.Now()
 			pos := Position{
 				Latitude:   s.Latitude,
 				Longitude:  s.Longitude,
 				Altitude:   s.Altitude,
-				Timestamp:  time.Now(),
+				Timestamp:  now,
 				FixQuality: fixQuality,
 				Satellites: int(s.NumSatellites),
 			}
 
 			n.mu.Lock()
+			// GGA has no date; take it from the latest RMC, or the system clock before one arrives
+			reference := n.lastRMC
+			if reference.IsZero() {
+				reference = now
+			}
+			pos.UTC = fixUTC(s.Time, reference)
+			if !pos.UTC.IsZero() {
+				n.clockOffsets = addClockSample(n.clockOffsets, epoch.Sub(pos.UTC))
+			}
+			pos.SatellitesSeen = n.position.SatellitesSeen
 			n.position = pos
 			n.mu.Unlock()
+			publishUpdate(n.updates, pos)
 
 			if n.debug {
 				log.Printf("GPS: Updated position - Lat: %.6f, Lon: %.6f, Alt: %.1f, Quality: %d, Sats: %d",
@@ -295,7 +466,26 @@ func (n *NMEASerial) processGGA(s nmea.GGA) {
 	}
 }
 
-func (n *NMEASerial) processRMC(s nmea.RMC) {
+// processGSV records the satellites in view reported by one constellation;
+// receivers send a GSV sequence per talker, so the counts are summed
+func (n *NMEASerial) processGSV(s nmea.GSV) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.inView == nil {
+		n.inView = make(map[string]int)
+	}
+	n.inView[s.Talker] = int(s.NumberSVsInView)
+	seen := 0
+	for _, count := range n.inView {
+		seen += count
+	}
+	n.position.SatellitesSeen = seen
+	publishUpdate(n.updates, n.position)
+}
+
+// processRMC updates the position and GPS date from an RMC sentence. epoch is
+// the local time the sentence's burst began arriving, see burstClock.
+func (n *NMEASerial) processRMC(s nmea.RMC, epoch time.Time) {
 	// RMC provides additional validation and time info
 	if n.debug {
 		log.Printf("GPS: Processing RMC - Valid: %t, Lat: %f, Lon: %f",
@@ -304,32 +494,36 @@ func (n *NMEASerial) processRMC(s nmea.RMC) {
 
 	// Use RMC to supplement/validate position if we have one
 	if s.Validity == "A" {
+		// RMC carries the full UTC date and time of the fix, which is what the
+		// system clock is checked against
+		now := time.Now()
+		utc := nmea.DateTime(0, s.Date, s.Time)
+		if !utc.IsZero() {
+			n.mu.Lock()
+			n.clockOffsets = addClockSample(n.clockOffsets, epoch.Sub(utc))
+			n.lastRMC = utc
+			n.mu.Unlock()
+		}
+
 		n.mu.RLock()
 		currentPos := n.position
 		n.mu.RUnlock()
 
-		// If we have a current position, update with RMC timestamp if more recent
+		// If we have a current position, update it with the RMC fix
 		if currentPos.FixQuality > 0 {
-			// Convert NMEA time to Go time.Time
-			// RMC provides time but not full timestamp, so we use current date
-			rncTime := time.Now()
-			if s.Time.Valid {
-				// Use today's date with the GPS time
-				rncTime = time.Date(
-					rncTime.Year(), rncTime.Month(), rncTime.Day(),
-					s.Time.Hour, s.Time.Minute, s.Time.Second,
-					int(s.Time.Millisecond)*1000000, // Convert ms to ns
-					time.UTC,
-				)
+			if utc.IsZero() {
+				utc = fixUTC(s.Time, now)
 			}
 
 			pos := Position{
-				Latitude:   s.Latitude,
-				Longitude:  s.Longitude,
-				Altitude:   currentPos.Altitude, // RMC doesn't have altitude
-				Timestamp:  rncTime,
-				FixQuality: currentPos.FixQuality,
-				Satellites: currentPos.Satellites,
+				Latitude:       s.Latitude,
+				Longitude:      s.Longitude,
+				Altitude:       currentPos.Altitude, // RMC doesn't have altitude
+				Timestamp:      now,
+				FixQuality:     currentPos.FixQuality,
+				Satellites:     currentPos.Satellites,
+				SatellitesSeen: currentPos.SatellitesSeen,
+				UTC:            utc,
 			}
 
 			n.mu.Lock()
@@ -402,6 +596,19 @@ func (n *NMEASerial) GetFixQualityString() string {
 	}
 }
 
+// GetClockOffset returns the estimated system clock offset from GPS time (system minus GPS)
+func (n *NMEASerial) GetClockOffset() (time.Duration, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return minClockOffset(n.clockOffsets)
+}
+
+// Updates returns a channel receiving the position whenever it or the
+// satellite counts change
+func (n *NMEASerial) Updates() <-chan Position {
+	return n.updates
+}
+
 func (n *NMEASerial) Close() error {
 	if n.port != nil {
 		return n.port.Close()
@@ -437,76 +644,98 @@ func (g *GPSDClient) Start() error {
 
 	g.client = client
 
-	// Start watching for GPS data
-	g.client.AddFilter("TPV", func(r interface{}) {
-		tpv, ok := r.(*gpsd.TPVReport)
-		if !ok {
-			return
-		}
-
-		// Convert gpsd fix mode to our quality system
-		var fixQuality int
-		switch tpv.Mode {
-		case 0, 1: // No fix or invalid
-			fixQuality = 0
-		case 2: // 2D fix
-			fixQuality = 1
-		case 3: // 3D fix
-			fixQuality = 1
-		default:
-			fixQuality = 0
-		}
+	// Start watching for GPS data, and for satellite info
+	g.client.AddFilter("TPV", g.handleTPV)
+	g.client.AddFilter("SKY", g.handleSKY)
 
-		// Only process valid fixes
-		if fixQuality > 0 && tpv.Lat != 0 && tpv.Lon != 0 {
-			g.mu.Lock()
-			pos := Position{
-				Latitude:   tpv.Lat,
-				Longitude:  tpv.Lon,
-				Altitude:   tpv.Alt,
-				Timestamp:  tpv.Time,
-				FixQuality: fixQuality,
-				Satellites: g.satCount, // Use separate satellite count field
-			}
+	// Start watching
+	g.client.Watch()
 
-			g.position = pos
-			g.mu.Unlock()
+	return nil
+}
 
-			select {
-			case g.fixChan <- pos:
-			default:
-			}
-		}
-	})
+// handleTPV records a position report from gpsd. gpsd calls its filters from
+// its own goroutine, so the position is only touched under g.mu.
+func (g *GPSDClient) handleTPV(r interface{}) {
+	tpv, ok := r.(*gpsd.TPVReport)
+	if !ok {
+		return
+	}
 
-	// Also watch for satellite info
-	g.client.AddFilter("SKY", func(r interface{}) {
-		sky, ok := r.(*gpsd.SKYReport)
-		if !ok {
-			return
-		}
+	// Convert gpsd fix mode to our quality system
+	var fixQuality int
+	switch tpv.Mode {
+	case 0, 1: // No fix or invalid
+		fixQuality = 0
+	case 2: // 2D fix
+		fixQuality = 1
+	case 3: // 3D fix
+		fixQuality = 1
+	default:
+		fixQuality = 0
+	}
 
+	// Only process valid fixes
+	if fixQuality > 0 && tpv.Lat != 0 && tpv.Lon != 0 {
 		g.mu.Lock()
-		// Count only satellites used in the fix
-		usedSatellites := 0
-		for _, sat := range sky.Satellites {
-			if sat.Used {
-				usedSatellites++
-			}
+		now := time.Now()
+		pos := Position{
+			Latitude:       tpv.Lat,
+			Longitude:      tpv.Lon,
+			Altitude:       tpv.Alt,
+			Timestamp:      now,
+			FixQuality:     fixQuality,
+			Satellites:     g.satCount, // Use separate satellite count field
+			SatellitesSeen: g.satSeen,
+			UTC:            tpv.Time.UTC(),
 		}
-		g.satCount = usedSatellites
-		
-		// If we have a valid position, update it with new satellite count
-		if g.position.FixQuality > 0 {
-			g.position.Satellites = g.satCount
+
+		g.position = pos
+		if !tpv.Time.IsZero() {
+			g.clockOffsets = addClockSample(g.clockOffsets, now.Sub(tpv.Time))
 		}
 		g.mu.Unlock()
-	})
+		publishUpdate(g.updates, pos)
 
-	// Start watching
-	g.client.Watch()
+		select {
+		case g.fixChan <- pos:
+		default:
+		}
+	}
+}
 
-	return nil
+// handleSKY records the satellite counts from a gpsd sky report
+func (g *GPSDClient) handleSKY(r interface{}) {
+	sky, ok := r.(*gpsd.SKYReport)
+	if !ok {
+		return
+	}
+
+	g.mu.Lock()
+	g.satCount, g.satSeen = countSatellites(sky.Satellites)
+
+	// If we have a valid position, update it with new satellite count
+	if g.position.FixQuality > 0 {
+		g.position.Satellites = g.satCount
+		g.position.SatellitesSeen = g.satSeen
+	}
+	pos := g.position
+	if pos.FixQuality == 0 {
+		pos.Satellites, pos.SatellitesSeen = g.satCount, g.satSeen
+	}
+	g.mu.Unlock()
+	publishUpdate(g.updates, pos)
+}
+
+// countSatellites splits a SKY report's satellites into those used in the fix,
+// the count other GPS tools report, and all those seen
+func countSatellites(sats []gpsd.Satellite) (used, seen int) {
+	for _, sat := range sats {
+		if sat.Used {
+			used++
+		}
+	}
+	return used, len(sats)
 }
 
 func (g *GPSDClient) WaitForFix(timeout time.Duration) (*Position, error) {
@@ -584,6 +813,19 @@ func (g *GPSDClient) GetFixQualityString() string {
 	}
 }
 
+// GetClockOffset returns the estimated system clock offset from GPS time (system minus GPS)
+func (g *GPSDClient) GetClockOffset() (time.Duration, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return minClockOffset(g.clockOffsets)
+}
+
+// Updates returns a channel receiving the position whenever it or the
+// satellite counts change
+func (g *GPSDClient) Updates() <-chan Position {
+	return g.updates
+}
+
 func (g *GPSDClient) Close() error {
 	if g.client != nil {
 		g.client.Close()
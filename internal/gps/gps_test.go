@@ -1,9 +1,11 @@
 package gps
 
 import (
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/adrianmo/go-nmea"
 	"github.com/stratoberry/go-gpsd"
 )
 
@@ -160,3 +162,262 @@ func TestGPSDSatelliteCountUpdate(t *testing.T) {
 		t.Errorf("Expected latitude to be preserved as 33.349, got %f", gpsdClient.position.Latitude)
 	}
 }
+
+func TestCountSatellites(t *testing.T) {
+	sats := []gpsd.Satellite{{PRN: 1, Used: true}, {PRN: 2}, {PRN: 3, Used: true}, {PRN: 4}, {PRN: 5}}
+	used, seen := countSatellites(sats)
+	if used != 2 || seen != 5 {
+		t.Errorf("Expected 2 used and 5 seen, got %d used and %d seen", used, seen)
+	}
+}
+
+func TestNMEASatellitesSeenAcrossConstellations(t *testing.T) {
+	n := &NMEASerial{fixChan: make(chan Position, 1)}
+
+	for _, raw := range []string{
+		"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74",
+		"$GLGSV,1,1,04,65,23,045,30,66,58,310,35,72,12,100,28,88,05,200,20*60",
+		"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74", // Repeat must not double count
+	} {
+		sentence, err := nmea.Parse(raw)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", raw, err)
+		}
+		n.processGSV(sentence.(nmea.GSV))
+	}
+
+	if n.position.SatellitesSeen != 15 {
+		t.Errorf("Expected 15 satellites seen, got %d", n.position.SatellitesSeen)
+	}
+}
+
+func TestGPSDConcurrentReportsAndReads(t *testing.T) {
+	gpsdClient := &GPSDClient{fixChan: make(chan Position, 1)}
+
+	// gpsd delivers reports from its own goroutine while the collector polls
+	// the position; run with -race to check the locking
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			gpsdClient.handleTPV(&gpsd.TPVReport{Mode: 3, Lat: 33.349, Lon: -111.758, Time: time.Now()})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			gpsdClient.handleSKY(&gpsd.SKYReport{Satellites: []gpsd.Satellite{{Used: true}, {Used: true}, {}}})
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		gpsdClient.IsFixValid()
+		gpsdClient.GetFixQualityString()
+		gpsdClient.GetCurrentPosition()
+		gpsdClient.GetClockOffset()
+	}
+	wg.Wait()
+
+	pos, err := gpsdClient.GetCurrentPosition()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.Satellites != 2 || pos.SatellitesSeen != 3 {
+		t.Errorf("Expected 2 used of 3 seen satellites, got %d of %d", pos.Satellites, pos.SatellitesSeen)
+	}
+}
+
+func TestGPSDUpdates(t *testing.T) {
+	gpsdClient, err := NewGPSDClient("localhost", "2947")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates := gpsdClient.Updates()
+
+	// Satellite counts are reported before there is a fix
+	gpsdClient.handleSKY(&gpsd.SKYReport{Satellites: []gpsd.Satellite{{Used: true}, {}, {}}})
+	pos := <-updates
+	if pos.FixQuality != 0 || pos.Satellites != 1 || pos.SatellitesSeen != 3 {
+		t.Errorf("Update before fix: quality %d, %d used of %d seen, want 0, 1 of 3",
+			pos.FixQuality, pos.Satellites, pos.SatellitesSeen)
+	}
+
+	// An update not yet taken is replaced by the next, so the latest is kept
+	gpsdClient.handleSKY(&gpsd.SKYReport{Satellites: []gpsd.Satellite{{Used: true}, {Used: true}, {}, {}}})
+	gpsdClient.handleTPV(&gpsd.TPVReport{Mode: 3, Lat: 33.349, Lon: -111.758, Time: time.Now()})
+	pos = <-updates
+	if pos.FixQuality != 1 || pos.Latitude != 33.349 || pos.Satellites != 2 || pos.SatellitesSeen != 4 {
+		t.Errorf("Update after fix: quality %d at %f, %d used of %d seen, want 1 at 33.349, 2 of 4",
+			pos.FixQuality, pos.Latitude, pos.Satellites, pos.SatellitesSeen)
+	}
+	select {
+	case pos := <-updates:
+		t.Errorf("Unexpected stale update %+v", pos)
+	default:
+	}
+}
+
+func TestFixUTC(t *testing.T) {
+	reference := time.Date(2026, 10, 14, 23, 59, 59, 0, time.UTC)
+	tests := []struct {
+		t    nmea.Time
+		want time.Time
+	}{
+		{nmea.Time{Valid: true, Hour: 23, Minute: 59, Second: 58, Millisecond: 250}, time.Date(2026, 10, 14, 23, 59, 58, 250e6, time.UTC)},
+		{nmea.Time{Valid: true, Hour: 0, Minute: 0, Second: 1}, time.Date(2026, 10, 15, 0, 0, 1, 0, time.UTC)}, // Past midnight
+		{nmea.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := fixUTC(tt.t, reference); !got.Equal(tt.want) {
+			t.Errorf("fixUTC(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+
+	// A reference just after midnight dates a fix just before it to the day before
+	after := time.Date(2026, 10, 15, 0, 0, 1, 0, time.UTC)
+	if got, want := fixUTC(nmea.Time{Valid: true, Hour: 23, Minute: 59, Second: 59}, after), time.Date(2026, 10, 14, 23, 59, 59, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("fixUTC across midnight = %v, want %v", got, want)
+	}
+}
+
+func TestNMEAUTCFromReceiver(t *testing.T) {
+	n := &NMEASerial{fixChan: make(chan Position, 1)}
+
+	// The RMC dates the GGA that follows it, across midnight
+	for _, raw := range []string{
+		"$GPRMC,235959.50,A,3533.000,N,09737.000,W,0.0,0.0,141026,,,A*44",
+		"$GPGGA,000000.50,3533.000,N,09737.000,W,1,08,1.0,365.0,M,-26.0,M,,*57",
+	} {
+		sentence, err := nmea.Parse(raw)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", raw, err)
+		}
+		switch s := sentence.(type) {
+		case nmea.RMC:
+			n.processRMC(s, time.Now())
+		case nmea.GGA:
+			n.processGGA(s, time.Now())
+		}
+	}
+
+	pos, err := n.GetCurrentPosition()
+	if err != nil {
+		t.Fatalf("GetCurrentPosition failed: %v", err)
+	}
+	if want := time.Date(2026, 10, 15, 0, 0, 0, 500e6, time.UTC); !pos.UTC.Equal(want) {
+		t.Errorf("Expected fix UTC %v, got %v", want, pos.UTC)
+	}
+	// Receive time stays on the system clock for judging the fix's age
+	if age := time.Since(pos.Timestamp); age < 0 || age > time.Minute {
+		t.Errorf("Expected receive timestamp near now, got %v", pos.Timestamp)
+	}
+	if len(n.clockOffsets) != 2 {
+		t.Errorf("Expected a clock sample from each sentence, got %d", len(n.clockOffsets))
+	}
+}
+
+func TestBurstClock(t *testing.T) {
+	epoch := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	ms := func(n int) time.Time { return epoch.Add(time.Duration(n) * time.Millisecond) }
+
+	// 70 bytes plus CR LF at 9600 baud take 75ms to arrive
+	if got := lineTime(70, 9600); got != 75*time.Millisecond {
+		t.Fatalf("Expected 75ms line time, got %v", got)
+	}
+
+	b := burstClock{baud: 9600}
+	tests := []struct {
+		name   string
+		at     time.Time
+		length int
+		want   time.Time
+	}{
+		{"first line starts a burst", ms(95), 70, ms(20)},
+		{"following line joins it", ms(170), 70, ms(20)},
+		{"short pause within the burst", ms(300), 70, ms(20)},
+		{"next epoch starts a new burst", ms(1095), 70, ms(1020)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.arrived(tt.at, tt.length); !got.Equal(tt.want) {
+				t.Errorf("Expected burst start %v, got %v", tt.want.Sub(epoch), got.Sub(epoch))
+			}
+		})
+	}
+
+	// Without a known baud rate the line time is not counted
+	unknown := burstClock{}
+	if got := unknown.arrived(ms(95), 70); !got.Equal(ms(95)) {
+		t.Errorf("Expected burst start at read time, got %v", got.Sub(epoch))
+	}
+}
+
+func TestNormalizeSerialPort(t *testing.T) {
+	tests := []struct {
+		name, goos, want string
+		ok               bool
+	}{
+		{"/dev/ttyUSB0", "linux", "/dev/ttyUSB0", true},
+		{" /dev/cu.usbmodem1101 ", "darwin", "/dev/cu.usbmodem1101", true},
+		{"COM3", "linux", "", false},
+		{"ttyUSB0", "linux", "", false},
+		{"", "linux", "", false},
+		{"COM3", "windows", "COM3", true},
+		{"com12", "windows", "COM12", true},
+		{`\\.\COM12`, "windows", "COM12", true},
+		{"/dev/ttyUSB0", "windows", "", false},
+		{"COM0", "windows", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeSerialPort(tt.name, tt.goos)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("normalizeSerialPort(%q, %s) = %q, %v; want %q, ok %t", tt.name, tt.goos, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseBaudRate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+		ok    bool
+	}{
+		{"9600", 9600, true},
+		{" 115200 ", 115200, true},
+		{"auto", 0, true},
+		{"AUTO", 0, true},
+		{"0", 0, true},
+		{"-9600", 0, false},
+		{"fast", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBaudRate(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseBaudRate(%q) = %d, %v; want %d, ok %t", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCountNMEASentences(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"parsed types", "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47\r\n$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39\r\n", 2},
+		{"unsupported type", "$PUBX,00,081350.00,4717.113210,N,00833.915187,E,546.589,G3,2.1,2.0,0.007,77.52,0.007,,0.92,1.19,0.77,9,0,0*5F\r\n", 1},
+		{"bad checksum", "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48\r\n", 0},
+		{"partial line", "$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39", 0},
+		{"wrong baud garbage", "\x80\xf8x\x00\xfe$\x86\x1e\xe0\n\x98\xf8\x80\n", 0},
+	}
+
+	for _, tt := range tests {
+		if got := countNMEASentences([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: countNMEASentences = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gps
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"go.bug.st/serial"
+)
+
+// windowsPortPattern matches a Windows serial port name, with or without
+// the \\.\ device namespace prefix needed for COM10 and above
+var windowsPortPattern = regexp.MustCompile(`(?i)^(?:\\\\\.\\)?(COM[1-9][0-9]*)$`)
+
+// NormalizeSerialPort checks that name is a serial port name for this
+// platform and returns it in the form the serial library opens. Windows
+// takes COM port names, in any case and with or without the \\.\ prefix;
+// other platforms take device paths.
+func NormalizeSerialPort(name string) (string, error) {
+	return normalizeSerialPort(name, runtime.GOOS)
+}
+
+func normalizeSerialPort(name, goos string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("GPS port not specified")
+	}
+
+	match := windowsPortPattern.FindStringSubmatch(name)
+	if goos == "windows" {
+		if match == nil {
+			return "", fmt.Errorf("GPS port %q is not a Windows serial port: use a COM port such as COM3 (listed under Ports in Device Manager)", name)
+		}
+		return strings.ToUpper(match[1]), nil
+	}
+
+	if match != nil {
+		return "", fmt.Errorf("GPS port %q is a Windows port name: on %s use a device path such as /dev/ttyUSB0 or /dev/ttyACM0", name, goos)
+	}
+	if !strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("GPS port %q is not a device path: use a path such as /dev/ttyUSB0 or /dev/ttyACM0", name)
+	}
+	return name, nil
+}
+
+// openError explains a failure to open the GPS serial port, listing the
+// ports present when the named one does not exist
+func openError(portName string, err error) error {
+	var portErr *serial.PortError
+	notFound := errors.Is(err, fs.ErrNotExist) || (errors.As(err, &portErr) && portErr.Code() == serial.PortNotFound)
+	if !notFound {
+		return fmt.Errorf("failed to open GPS port %s: %w", portName, err)
+	}
+
+	ports, listErr := serial.GetPortsList()
+	switch {
+	case listErr != nil:
+		return fmt.Errorf("GPS port %s not found: %w", portName, err)
+	case len(ports) == 0:
+		return fmt.Errorf("GPS port %s not found and no serial ports are present; is the GPS receiver plugged in?", portName)
+	default:
+		return fmt.Errorf("GPS port %s not found (serial ports present: %s)", portName, strings.Join(ports, ", "))
+	}
+}
@@ -0,0 +1,76 @@
+// Package processor - Time alignment self-check between two stations
+package processor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AlignmentResult reports the residual timing offset between two captures of
+// a common reference signal. With a properly synchronized deployment the
+// reference arrives at both receivers at the same sample index, so any
+// correlation delay is timing error.
+type AlignmentResult struct {
+	Receiver1       ReceiverInfo `json:"receiver1"`
+	Receiver2       ReceiverInfo `json:"receiver2"`
+	OffsetSamples   int          `json:"offset_samples"`      // Correlation delay in samples
+	OffsetNs        float64      `json:"offset_ns"`           // Correlation delay in nanoseconds
+	ResolutionNs    float64      `json:"resolution_ns"`       // One sample period
+	StartDiffNs     float64      `json:"start_diff_ns"`       // Recorded start time difference (receiver 2 - receiver 1)
+	Confidence      float64      `json:"confidence"`          // Correlation confidence (0-1)
+	PeakToSidelobe  float64      `json:"peak_to_sidelobe"`    // Correlation peak-to-sidelobe ratio
+	FrequencyOffset float64      `json:"frequency_offset_hz"` // Receiver 2's carrier offset from receiver 1 (0 if not estimated)
+	ToleranceNs     float64      `json:"tolerance_ns"`        // Maximum acceptable offset
+	WithinTolerance bool         `json:"within_tolerance"`
+}
+
+// CheckTimeAlignment correlates two captures of a known common reference
+// signal and reports the residual timing offset between them. Unlike
+// ProcessFiles the receivers may be co-located.
+func (p *Processor) CheckTimeAlignment(filenames []string, tolerance time.Duration) (*AlignmentResult, error) {
+	if len(filenames) != 2 {
+		return nil, fmt.Errorf("time alignment check requires exactly 2 files, got %d", len(filenames))
+	}
+
+	if err := checkHeaders(filenames); err != nil {
+		return nil, err
+	}
+
+	receivers, err := p.loadReceivers(filenames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load receivers: %w", err)
+	}
+	r1, r2 := receivers[0], receivers[1]
+
+	if r1.Metadata.Frequency != r2.Metadata.Frequency {
+		return nil, fmt.Errorf("frequency mismatch: %d Hz vs %d Hz", r1.Metadata.Frequency, r2.Metadata.Frequency)
+	}
+	if r1.Metadata.SampleRate != r2.Metadata.SampleRate {
+		return nil, fmt.Errorf("sample rate mismatch: %d Hz vs %d Hz", r1.Metadata.SampleRate, r2.Metadata.SampleRate)
+	}
+
+	// Co-located receivers give no geometric bound, so use the default window
+	measurement, err := p.crossCorrelate(r1, r2, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cross-correlation failed: %w", err)
+	}
+
+	sampleRate := float64(r1.Metadata.SampleRate)
+	startDiff := r2.Metadata.CorrectedCollectionTime().Sub(r1.Metadata.CorrectedCollectionTime())
+	toleranceNs := float64(tolerance.Nanoseconds())
+
+	return &AlignmentResult{
+		Receiver1:       r1,
+		Receiver2:       r2,
+		OffsetSamples:   int(math.Round(measurement.TimeDiff * sampleRate / 1e9)),
+		OffsetNs:        measurement.TimeDiff,
+		ResolutionNs:    1e9 / sampleRate,
+		StartDiffNs:     float64(startDiff.Nanoseconds()),
+		Confidence:      measurement.Confidence,
+		PeakToSidelobe:  measurement.PeakToSidelobe,
+		FrequencyOffset: measurement.FrequencyOffset,
+		ToleranceNs:     toleranceNs,
+		WithinTolerance: math.Abs(measurement.TimeDiff) <= toleranceNs,
+	}, nil
+}
@@ -0,0 +1,103 @@
+// Package processor - Per-receiver calibration delays
+package processor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"argus-collector/internal/filewriter"
+)
+
+// stationSuffix matches the "_<unix time>" suffix, with any nanoseconds and
+// overwrite suffix, that the collector appends to the station's collection ID.
+// The submatches are the seconds and nanoseconds of the collection time.
+var stationSuffix = regexp.MustCompile(`_(\d{9,})(?:\.(\d{9}))?(?:_\d+)?$`)
+
+// LoadCalibration reads per-receiver calibration delays from a text file.
+// Each non-blank line holds a station name and its fixed cable/front-end
+// delay in nanoseconds; text after '#' is a comment:
+//
+//	# station   delay_ns
+//	argus-0     125.0
+//	station2    -40
+//
+// A station is matched by its receiver ID (e.g. R2) or by its filename
+// without extension and collection timestamp (e.g. argus-0 for
+// argus-0_1700000000.dat).
+func LoadCalibration(filename string) (map[string]float64, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open calibration file: %w", err)
+	}
+	defer file.Close()
+
+	delays := make(map[string]float64)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"<station> <delay_ns>\"", filename, lineNum)
+		}
+		delay, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid delay %q: %w", filename, lineNum, fields[1], err)
+		}
+		if _, dup := delays[fields[0]]; dup {
+			return nil, fmt.Errorf("%s:%d: station %s listed more than once", filename, lineNum, fields[0])
+		}
+		delays[fields[0]] = delay
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calibration file: %w", err)
+	}
+	return delays, nil
+}
+
+// StationName returns the station part of a collector output filename: the
+// base name without extension and collection timestamp
+func StationName(filename string) string {
+	base := filewriter.TrimExtension(filepath.Base(filename))
+	return stationSuffix.ReplaceAllString(base, "")
+}
+
+// FilenameTime returns the collection time embedded in a collector output
+// filename, including any nanoseconds, and whether the name carries one
+func FilenameTime(filename string) (time.Time, bool) {
+	base := filewriter.TrimExtension(filepath.Base(filename))
+	m := stationSuffix.FindStringSubmatch(base)
+	if m == nil {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var nsec int64
+	if m[2] != "" {
+		nsec, _ = strconv.ParseInt(m[2], 10, 64)
+	}
+	return time.Unix(sec, nsec), true
+}
+
+// calibrationDelay returns the configured delay in nanoseconds for a receiver,
+// preferring an entry for its receiver ID over one for its station name
+func (p *Processor) calibrationDelay(id, filename string) (float64, bool) {
+	if delay, ok := p.config.Calibration[id]; ok {
+		return delay, true
+	}
+	delay, ok := p.config.Calibration[StationName(filename)]
+	return delay, ok
+}
@@ -0,0 +1,48 @@
+// Package processor - Reporting the corrections applied to receivers
+package processor
+
+import (
+	"fmt"
+	"time"
+)
+
+// corrected reports whether any correction was applied to the receiver's
+// samples or timing
+func (r *ReceiverInfo) corrected() bool {
+	return r.GapPadding > 0 || r.CalibrationDelay != 0 || r.SwappedIQ || r.ClockOffset != 0
+}
+
+// displayCorrections prints each receiver's recorded sample rate and start
+// time next to the corrections applied before correlation, whenever one was
+// applied to any receiver (or always when verbose), so no correction feeding
+// the position estimate goes unreported. The same fields are exported with
+// each receiver.
+func (p *Processor) displayCorrections(receivers []ReceiverInfo, reference int) {
+	show := p.config.Verbose
+	for i := range receivers {
+		show = show || receivers[i].corrected()
+	}
+	if !show {
+		return
+	}
+
+	ref := receivers[reference].Metadata
+	fmt.Fprintf(p.out, "   🧾 Receiver corrections (start relative to %s, after clock correction):\n", receivers[reference].ID)
+	fmt.Fprintf(p.out, "      %-8s %10s %10s %13s %12s %13s %8s\n", "Receiver", "Rate MSps", "Start ms", "Clock off. ms", "Gap padding", "Cal. delay ns", "I/Q")
+	for _, r := range receivers {
+		start := "-"
+		if r.Metadata != nil && ref != nil {
+			offset := r.Metadata.CorrectedCollectionTime().Sub(ref.CorrectedCollectionTime())
+			start = fmt.Sprintf("%+.3f", float64(offset)/float64(time.Millisecond))
+		}
+		iq := "as read"
+		if r.SwappedIQ {
+			iq = "swapped"
+		}
+		fmt.Fprintf(p.out, "      %-8s %10.3f %10s %13.3f %12d %13.1f %8s\n", r.ID, float64(r.SampleRate)/1e6, start,
+			float64(r.ClockOffset)/float64(time.Millisecond), r.GapPadding, r.CalibrationDelay, iq)
+	}
+	fmt.Fprintf(p.out, "      Clock offsets are removed from collection times, calibration delays from\n")
+	fmt.Fprintf(p.out, "      arrival times; gap padding is silence restoring timing after dropped samples.\n")
+	fmt.Fprintf(p.out, "      Sample rates are as recorded: receivers are never resampled.\n")
+}
@@ -0,0 +1,280 @@
+// Package processor - Solving from externally measured time differences
+package processor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column headers that start the receiver and measurement tables of an
+// imported CSV file, as written by ExportCSV
+const (
+	receiverTableHeader    = "Receiver_ID"
+	measurementTableHeader = "Receiver1_ID"
+)
+
+// MeasurementSet is a set of receiver positions and time differences measured
+// outside Argus, ready for the positioning solver
+type MeasurementSet struct {
+	Receivers    []ReceiverInfo
+	Measurements []TDOAMeasurement // TimeDiff and Confidence set; distances are derived when solving
+	Frequency    float64           // Signal frequency in Hz, 0 if not given
+}
+
+// ReadMeasurementCSV reads receiver positions and time differences from a CSV
+// file. The file holds two tables, each introduced by its header row; columns
+// may appear in any order and unknown columns are ignored:
+//
+//	Receiver_ID,Latitude,Longitude,Altitude
+//	north,35.578,-97.621,365
+//	east,35.533,-97.566,370
+//	...
+//	Receiver1_ID,Receiver2_ID,Time_Diff_ns,Confidence
+//	north,east,-1234.5,0.9
+//
+// Altitude and Confidence are optional (default 0 m and 1.0), as is a
+// Collection_Time column of RFC 3339 capture times. Time_Diff_ns is
+// the arrival time at Receiver2 minus the arrival time at Receiver1, with any
+// fixed station delays already removed. A row starting with '#' is a comment
+// and ends the table before it; rows outside the two tables are ignored, and
+// a "# Frequency MHz" row sets the frequency, so a CSV written by the
+// processor can be read back.
+func ReadMeasurementCSV(filename string) (*MeasurementSet, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open measurement file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	set := &MeasurementSet{}
+	var table string           // Header of the table being read
+	var columns map[string]int // Column index by header name
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read measurement file: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		first := strings.TrimSpace(record[0])
+		if first == "" && len(record) == 1 {
+			continue
+		}
+		if strings.HasPrefix(first, "#") {
+			table = ""
+			if first == "# Frequency MHz" && len(record) > 1 {
+				if mhz, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64); err == nil {
+					set.Frequency = mhz * 1e6
+				}
+			}
+			continue
+		}
+
+		if first == receiverTableHeader || first == measurementTableHeader {
+			table = first
+			columns = make(map[string]int, len(record))
+			for i, name := range record {
+				columns[strings.TrimSpace(name)] = i
+			}
+			continue
+		}
+
+		switch table {
+		case receiverTableHeader:
+			r, err := parseReceiverRow(record, columns)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			set.Receivers = append(set.Receivers, r)
+		case measurementTableHeader:
+			m, err := parseMeasurementRow(record, columns)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			set.Measurements = append(set.Measurements, m)
+		}
+	}
+
+	return set, nil
+}
+
+// csvField returns the trimmed value of the named column, or "" if the row
+// has no such column
+func csvField(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// csvFloat parses the named column; a missing or empty optional column gives def
+func csvFloat(record []string, columns map[string]int, name string, required bool, def float64) (float64, error) {
+	value := csvField(record, columns, name)
+	if value == "" {
+		if required {
+			return 0, fmt.Errorf("missing %s", name)
+		}
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return v, nil
+}
+
+// parseReceiverRow parses one row of the receiver table
+func parseReceiverRow(record []string, columns map[string]int) (ReceiverInfo, error) {
+	r := ReceiverInfo{ID: csvField(record, columns, receiverTableHeader)}
+	if r.ID == "" {
+		return r, fmt.Errorf("missing %s", receiverTableHeader)
+	}
+
+	var err error
+	if r.Location.Latitude, err = csvFloat(record, columns, "Latitude", true, 0); err != nil {
+		return r, err
+	}
+	if r.Location.Longitude, err = csvFloat(record, columns, "Longitude", true, 0); err != nil {
+		return r, err
+	}
+	if r.Location.Altitude, err = csvFloat(record, columns, "Altitude", false, 0); err != nil {
+		return r, err
+	}
+	if r.CalibrationDelay, err = csvFloat(record, columns, "Calibration_Delay_ns", false, 0); err != nil {
+		return r, err
+	}
+	r.Filename = csvField(record, columns, "Filename")
+	if value := csvField(record, columns, "Collection_Time"); value != "" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return r, fmt.Errorf("invalid Collection_Time %q: use RFC 3339, e.g. 2025-08-01T14:30:22Z", value)
+		}
+		r.CollectionTime = &t
+	}
+	return r, nil
+}
+
+// parseMeasurementRow parses one row of the measurement table
+func parseMeasurementRow(record []string, columns map[string]int) (TDOAMeasurement, error) {
+	m := TDOAMeasurement{
+		Receiver1ID: csvField(record, columns, measurementTableHeader),
+		Receiver2ID: csvField(record, columns, "Receiver2_ID"),
+	}
+	if m.Receiver1ID == "" || m.Receiver2ID == "" {
+		return m, fmt.Errorf("missing receiver ID")
+	}
+
+	var err error
+	if m.TimeDiff, err = csvFloat(record, columns, "Time_Diff_ns", true, 0); err != nil {
+		return m, err
+	}
+	if m.Confidence, err = csvFloat(record, columns, "Confidence", false, 1); err != nil {
+		return m, err
+	}
+	if m.Confidence < 0 || m.Confidence > 1 {
+		return m, fmt.Errorf("confidence %.3f out of range 0-1", m.Confidence)
+	}
+	return m, nil
+}
+
+// ProcessCSV solves the transmitter location from receiver positions and time
+// differences read from a CSV file (see ReadMeasurementCSV), skipping file
+// loading and correlation
+func (p *Processor) ProcessCSV(filename string) (*Result, error) {
+	totalSteps := 2 // Load measurements, location calculation
+	if p.config.Algorithm == "heatmap" || p.config.Verbose {
+		totalSteps = 3
+	}
+	progress := p.newProgressTracker(totalSteps)
+
+	progress.StartStep("Loading measurements")
+	set, err := ReadMeasurementCSV(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validateMeasurementSet(set); err != nil {
+		return nil, fmt.Errorf("measurement validation failed: %w", err)
+	}
+
+	// Distances follow from the times at the configured propagation speed
+	for i := range set.Measurements {
+		set.Measurements[i].DistanceDiff = set.Measurements[i].TimeDiff * p.config.PropagationSpeed / 1e9
+	}
+
+	measurements := p.confidentMeasurements(set.Measurements)
+	fmt.Fprintf(p.out, "   📄 Read %d receivers and %d time differences\n", len(set.Receivers), len(set.Measurements))
+	progress.CompleteStep()
+
+	return p.solve(set.Receivers, measurements, commonReference(measurements), set.Frequency, progress)
+}
+
+// validateMeasurementSet checks that an imported set can be solved
+func (p *Processor) validateMeasurementSet(set *MeasurementSet) error {
+	if len(set.Receivers) < 3 {
+		return fmt.Errorf("need at least 3 receivers with a position, got %d", len(set.Receivers))
+	}
+	if len(set.Measurements) == 0 {
+		return fmt.Errorf("no time differences given")
+	}
+
+	known := make(map[string]bool, len(set.Receivers))
+	for _, r := range set.Receivers {
+		if known[r.ID] {
+			return fmt.Errorf("receiver %s listed more than once", r.ID)
+		}
+		known[r.ID] = true
+	}
+	for _, m := range set.Measurements {
+		for _, id := range []string{m.Receiver1ID, m.Receiver2ID} {
+			if !known[id] {
+				return fmt.Errorf("time difference %s↔%s refers to unknown receiver %s", m.Receiver1ID, m.Receiver2ID, id)
+			}
+		}
+		if m.Receiver1ID == m.Receiver2ID {
+			return fmt.Errorf("time difference between receiver %s and itself", m.Receiver1ID)
+		}
+	}
+	return nil
+}
+
+// confidentMeasurements returns the measurements meeting the confidence
+// threshold, or all of them with a warning if none do
+func (p *Processor) confidentMeasurements(all []TDOAMeasurement) []TDOAMeasurement {
+	var measurements []TDOAMeasurement
+	for _, m := range all {
+		if m.Confidence >= p.config.Confidence {
+			measurements = append(measurements, m)
+		}
+	}
+	if len(measurements) == 0 {
+		fmt.Fprintf(p.out, "⚠️  No TDOA measurements met confidence threshold of %.2f\n", p.config.Confidence)
+		fmt.Fprintf(p.out, "   📍 Using %d low-confidence measurements for approximate location\n", len(all))
+		return all
+	}
+	return measurements
+}
+
+// commonReference returns the receiver every measurement was taken against,
+// or "" if they do not share one
+func commonReference(measurements []TDOAMeasurement) string {
+	reference := measurements[0].Receiver1ID
+	for _, m := range measurements[1:] {
+		if m.Receiver1ID != reference {
+			return ""
+		}
+	}
+	return reference
+}
@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMeasurementCSVRoundTrip(t *testing.T) {
+	captured := time.Date(2025, 8, 1, 14, 30, 22, 0, time.UTC)
+	result := &Result{
+		Algorithm:         "basic",
+		ReferenceReceiver: "north",
+		Frequency:         433920000,
+		ProcessingTime:    captured.Add(time.Minute),
+		ReceiverLocations: []ReceiverInfo{
+			{ID: "north", Location: Location{Latitude: 35.578, Longitude: -97.621, Altitude: 365}, CalibrationDelay: 125, CollectionTime: &captured},
+			{ID: "east", Location: Location{Latitude: 35.533, Longitude: -97.566, Altitude: 370}},
+			{ID: "south", Location: Location{Latitude: 35.488, Longitude: -97.621, Altitude: 355.5}},
+		},
+		TDOAMeasurements: []TDOAMeasurement{
+			{Receiver1ID: "north", Receiver2ID: "east", TimeDiff: -1234.5, Confidence: 0.9},
+			{Receiver1ID: "north", Receiver2ID: "south", TimeDiff: 2500, Confidence: 0.75},
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "result.csv")
+	if err := result.ExportCSV(filename); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	set, err := ReadMeasurementCSV(filename)
+	if err != nil {
+		t.Fatalf("ReadMeasurementCSV failed: %v", err)
+	}
+
+	if set.Frequency != result.Frequency {
+		t.Errorf("frequency = %.0f Hz, want %.0f", set.Frequency, result.Frequency)
+	}
+	if len(set.Receivers) != len(result.ReceiverLocations) {
+		t.Fatalf("read %d receivers, want %d", len(set.Receivers), len(result.ReceiverLocations))
+	}
+	for i, want := range result.ReceiverLocations {
+		got := set.Receivers[i]
+		if got.ID != want.ID || got.Location != want.Location || got.CalibrationDelay != want.CalibrationDelay {
+			t.Errorf("receiver %d = %s %+v (%.1f ns), want %s %+v (%.1f ns)",
+				i, got.ID, got.Location, got.CalibrationDelay, want.ID, want.Location, want.CalibrationDelay)
+		}
+	}
+	if got := set.Receivers[0].CollectionTime; got == nil || !got.Equal(captured) {
+		t.Errorf("collection time = %v, want %v", got, captured)
+	}
+	if got := set.Receivers[1].CollectionTime; got != nil {
+		t.Errorf("receiver without a collection time read as %v", got)
+	}
+
+	if len(set.Measurements) != len(result.TDOAMeasurements) {
+		t.Fatalf("read %d measurements, want %d", len(set.Measurements), len(result.TDOAMeasurements))
+	}
+	for i, want := range result.TDOAMeasurements {
+		got := set.Measurements[i]
+		// The sign says which receiver the signal reached first, so it must survive
+		if got.Receiver1ID != want.Receiver1ID || got.Receiver2ID != want.Receiver2ID || got.TimeDiff != want.TimeDiff || got.Confidence != want.Confidence {
+			t.Errorf("measurement %d = %s↔%s %.1f ns (%.3f), want %s↔%s %.1f ns (%.3f)", i,
+				got.Receiver1ID, got.Receiver2ID, got.TimeDiff, got.Confidence,
+				want.Receiver1ID, want.Receiver2ID, want.TimeDiff, want.Confidence)
+		}
+	}
+
+	p, err := NewProcessor(&Config{MaxDistance: 100})
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+	if err := p.validateMeasurementSet(set); err != nil {
+		t.Errorf("exported result rejected: %v", err)
+	}
+}
+
+func TestReadMeasurementCSVErrors(t *testing.T) {
+	const receivers = "Receiver_ID,Latitude,Longitude\nnorth,35.578,-97.621\n"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"confidence above 1", receivers + "Receiver1_ID,Receiver2_ID,Time_Diff_ns,Confidence\nnorth,east,10,1.5\n", "out of range"},
+		{"negative confidence", receivers + "Receiver1_ID,Receiver2_ID,Time_Diff_ns,Confidence\nnorth,east,10,-0.1\n", "out of range"},
+		{"missing time difference", receivers + "Receiver1_ID,Receiver2_ID,Confidence\nnorth,east,0.5\n", "missing Time_Diff_ns"},
+		{"invalid time difference", receivers + "Receiver1_ID,Receiver2_ID,Time_Diff_ns\nnorth,east,soon\n", "invalid Time_Diff_ns"},
+		{"missing receiver ID", receivers + "Receiver1_ID,Receiver2_ID,Time_Diff_ns\nnorth,,10\n", "missing receiver ID"},
+		{"missing latitude", "Receiver_ID,Longitude\nnorth,-97.621\n", "missing Latitude"},
+		{"invalid collection time", "Receiver_ID,Latitude,Longitude,Collection_Time\nnorth,35.578,-97.621,yesterday\n", "invalid Collection_Time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "measurements.csv")
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadMeasurementCSV(filename)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMeasurementSet(t *testing.T) {
+	receivers := []ReceiverInfo{{ID: "north"}, {ID: "east"}, {ID: "south"}}
+	tests := []struct {
+		name         string
+		receivers    []ReceiverInfo
+		measurements []TDOAMeasurement
+		want         string // Expected error text; "" accepts the set
+	}{
+		{"valid", receivers, []TDOAMeasurement{{Receiver1ID: "north", Receiver2ID: "east"}}, ""},
+		{"unknown receiver", receivers, []TDOAMeasurement{{Receiver1ID: "north", Receiver2ID: "west"}}, "unknown receiver west"},
+		{"self-pair", receivers, []TDOAMeasurement{{Receiver1ID: "east", Receiver2ID: "east"}}, "and itself"},
+		{"duplicate receiver", append(receivers, ReceiverInfo{ID: "east"}), []TDOAMeasurement{{Receiver1ID: "north", Receiver2ID: "east"}}, "more than once"},
+		{"too few receivers", receivers[:2], []TDOAMeasurement{{Receiver1ID: "north", Receiver2ID: "east"}}, "at least 3 receivers"},
+		{"no measurements", receivers, nil, "no time differences"},
+	}
+
+	p, err := NewProcessor(&Config{MaxDistance: 100})
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.validateMeasurementSet(&MeasurementSet{Receivers: tt.receivers, Measurements: tt.measurements})
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("rejected: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package processor - Confidence ellipses of position estimates
+package processor
+
+import (
+	"math"
+)
+
+// ellipseConfidenceLevel is the probability that the transmitter lies inside
+// the reported error ellipse, assuming Gaussian measurement errors
+const ellipseConfidenceLevel = 0.95
+
+// ErrorEllipse is the confidence region of a position estimate. TDOA
+// uncertainty is rarely circular: it stretches along the direction the
+// station geometry constrains least, typically away from the receivers.
+type ErrorEllipse struct {
+	SemiMajor       float64 `json:"semi_major_m"`
+	SemiMinor       float64 `json:"semi_minor_m"`
+	Orientation     float64 `json:"orientation_deg"`   // Bearing of the major axis, degrees clockwise from north (0-180)
+	ConfidenceLevel float64 `json:"confidence_level"`  // Probability the transmitter lies inside
+	RangeError      float64 `json:"range_error_m"`     // Standard deviation assumed for each distance difference
+	Clamped         bool    `json:"clamped,omitempty"` // Semi-major axis limited to the maximum transmitter distance
+}
+
+// computeErrorEllipse propagates the measurement errors through the station
+// geometry at location: each measurement's distance difference changes
+// across the map along the difference of the unit vectors from its two
+// receivers, and the inverse of the resulting normal matrix, scaled by the
+// range error variance, is the covariance of the position. The range error
+// is estimated from the residuals, but not below the distance one sample
+// period spans. It returns nil when the measurements do not constrain both
+// directions, as with a single measurement.
+func (p *Processor) computeErrorEllipse(receivers []ReceiverInfo, measurements []TDOAMeasurement, location Location) *ErrorEllipse {
+	byID := make(map[string]ReceiverInfo, len(receivers))
+	for _, r := range receivers {
+		byID[r.ID] = r
+	}
+
+	// Unit vector from a receiver to location on the local east/north plane
+	metersPerDegLon := 111000.0 * math.Cos(location.Latitude*math.Pi/180) // Approximate, as in generateCirclePoints
+	unit := func(r Location) (float64, float64, bool) {
+		east := (location.Longitude - r.Longitude) * metersPerDegLon
+		north := (location.Latitude - r.Latitude) * 111000.0
+		d := math.Hypot(east, north)
+		if d == 0 {
+			return 0, 0, false
+		}
+		return east / d, north / d, true
+	}
+
+	var nEE, nEN, nNN, sumSquares float64
+	used := 0
+	for _, m := range measurements {
+		r1, ok1 := byID[m.Receiver1ID]
+		r2, ok2 := byID[m.Receiver2ID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		e1, n1, ok1 := unit(r1.Location)
+		e2, n2, ok2 := unit(r2.Location)
+		if !ok1 || !ok2 {
+			continue // Gradient undefined at a receiver
+		}
+		ge, gn := e2-e1, n2-n1
+		nEE += ge * ge
+		nEN += ge * gn
+		nNN += gn * gn
+		sumSquares += m.Residual * m.Residual
+		used++
+	}
+
+	det := nEE*nNN - nEN*nEN
+	if used < 2 || det <= 1e-9*(nEE+nNN)*(nEE+nNN) {
+		return nil
+	}
+
+	// Two of the degrees of freedom are spent on the position itself
+	dof := used - 2
+	if dof < 1 {
+		dof = used
+	}
+	rangeError := math.Sqrt(sumSquares / float64(dof))
+	if len(receivers) > 0 && receivers[0].Metadata != nil && receivers[0].Metadata.SampleRate > 0 {
+		rangeError = math.Max(rangeError, p.config.PropagationSpeed/float64(receivers[0].Metadata.SampleRate))
+	}
+
+	// Covariance = rangeError² · N⁻¹
+	variance := rangeError * rangeError
+	cEE := variance * nNN / det
+	cNN := variance * nEE / det
+	cEN := -variance * nEN / det
+
+	// Eigen decomposition of the 2x2 covariance
+	mean := (cEE + cNN) / 2
+	spread := math.Hypot((cEE-cNN)/2, cEN)
+	major := mean + spread
+	minor := math.Max(mean-spread, 0)
+	angle := math.Atan2(2*cEN, cEE-cNN) / 2 // Major axis, counterclockwise from east
+
+	scale := math.Sqrt(-2 * math.Log(1-ellipseConfidenceLevel)) // Chi-squared with two degrees of freedom
+	ellipse := &ErrorEllipse{
+		SemiMajor:       scale * math.Sqrt(major),
+		SemiMinor:       scale * math.Sqrt(minor),
+		Orientation:     math.Mod(90-angle*180/math.Pi+360, 180),
+		ConfidenceLevel: ellipseConfidenceLevel,
+		RangeError:      rangeError,
+	}
+	if limit := p.config.MaxDistance * 1000; limit > 0 && ellipse.SemiMajor > limit {
+		ellipse.SemiMajor = limit
+		ellipse.SemiMinor = math.Min(ellipse.SemiMinor, limit)
+		ellipse.Clamped = true
+	}
+	return ellipse
+}
+
+// generateEllipsePoints generates points around an error ellipse centered on
+// center, like generateCirclePoints does for a circle
+func generateEllipsePoints(center Location, ellipse *ErrorEllipse, numPoints int) []Location {
+	points := make([]Location, numPoints)
+
+	metersPerDegLon := 111000.0 * math.Cos(center.Latitude*math.Pi/180)
+	bearing := ellipse.Orientation * math.Pi / 180
+	// Unit vectors along the major and minor axes, as east/north components
+	majorE, majorN := math.Sin(bearing), math.Cos(bearing)
+	minorE, minorN := majorN, -majorE
+
+	for i := 0; i < numPoints; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(numPoints)
+		a := ellipse.SemiMajor * math.Cos(angle)
+		b := ellipse.SemiMinor * math.Sin(angle)
+
+		points[i] = Location{
+			Latitude:  center.Latitude + (a*majorN+b*minorN)/111000.0,
+			Longitude: center.Longitude + (a*majorE+b*minorE)/metersPerDegLon,
+			Altitude:  center.Altitude,
+		}
+	}
+
+	return points
+}
@@ -7,10 +7,66 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"time"
 )
 
+// Summary is a compact machine-readable digest of a Result for automation,
+// omitting the heatmap and per-pair correlation detail
+type Summary struct {
+	Location          Location       `json:"location"`
+	Confidence        float64        `json:"confidence"`
+	ErrorRadius       float64        `json:"error_radius_m"`
+	ErrorEllipse      *ErrorEllipse  `json:"error_ellipse,omitempty"`
+	Frequency         float64        `json:"frequency_hz"`
+	Algorithm         string         `json:"algorithm"`
+	ReferenceReceiver string         `json:"reference_receiver"`
+	ProcessingTime    time.Time      `json:"processing_time"`
+	CaptureTime       *time.Time     `json:"capture_time,omitempty"` // Collection time of the reference receiver's capture
+	Receivers         []ReceiverInfo `json:"receivers"`
+	FailedPairs       []PairFailure  `json:"failed_pairs,omitempty"` // Receiver pairs left out of the solve
+	Session           string         `json:"session,omitempty"`      // Session label when processing by time
+	OutputFile        string         `json:"output_file,omitempty"`  // Exported map file
+}
+
+// Summary returns the summary of the processing results
+func (r *Result) Summary() Summary {
+	return Summary{
+		Location:          r.Location,
+		Confidence:        r.Confidence,
+		ErrorRadius:       r.ErrorRadius,
+		ErrorEllipse:      r.ErrorEllipse,
+		Frequency:         r.Frequency,
+		Algorithm:         r.Algorithm,
+		ReferenceReceiver: r.ReferenceReceiver,
+		ProcessingTime:    r.ProcessingTime,
+		CaptureTime:       r.CaptureTime,
+		Receivers:         r.ReceiverLocations,
+		FailedPairs:       r.FailedPairs,
+	}
+}
+
 // ExportGeoJSON exports the TDOA results in GeoJSON format for web mapping
 func (r *Result) ExportGeoJSON(filename string) error {
+	// Write to file
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create GeoJSON file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.GeoJSON()); err != nil {
+		return fmt.Errorf("failed to encode GeoJSON: %w", err)
+	}
+
+	return nil
+}
+
+// GeoJSON returns the TDOA results as a GeoJSON feature collection: the
+// transmitter, its confidence area, the receivers, the measured baselines
+// and any hyperbolas and heatmap points
+func (r *Result) GeoJSON() map[string]interface{} {
 	// Create GeoJSON structure
 	geojson := map[string]interface{}{
 		"type":     "FeatureCollection",
@@ -18,6 +74,7 @@ func (r *Result) ExportGeoJSON(filename string) error {
 		"properties": map[string]interface{}{
 			"title":           "TDOA Transmitter Location Analysis",
 			"algorithm":       r.Algorithm,
+			"reference":       r.ReferenceReceiver,
 			"frequency_mhz":   r.Frequency / 1e6,
 			"confidence":      r.Confidence,
 			"error_radius_m":  r.ErrorRadius,
@@ -25,6 +82,16 @@ func (r *Result) ExportGeoJSON(filename string) error {
 		},
 	}
 
+	if len(r.FailedPairs) > 0 {
+		geojson["properties"].(map[string]interface{})["failed_pairs"] = r.FailedPairs
+	}
+	if r.ErrorEllipse != nil {
+		geojson["properties"].(map[string]interface{})["error_ellipse"] = r.ErrorEllipse
+	}
+	if r.CaptureTime != nil {
+		geojson["properties"].(map[string]interface{})["capture_time"] = formatCaptureTime(r.CaptureTime)
+	}
+
 	features := []map[string]interface{}{}
 
 	// Add estimated transmitter location as a point
@@ -44,9 +111,13 @@ func (r *Result) ExportGeoJSON(filename string) error {
 	}
 	features = append(features, transmitterFeature)
 
-	// Add confidence circle around transmitter location
-	confidenceCircle := generateCircleFeature(r.Location, r.ErrorRadius, "confidence_area")
-	features = append(features, confidenceCircle)
+	// Add confidence ellipse or circle around transmitter location
+	if r.ErrorEllipse != nil {
+		features = append(features, generateEllipseFeature(r.Location, r.ErrorEllipse, "confidence_area"))
+	} else {
+		confidenceCircle := generateCircleFeature(r.Location, r.ErrorRadius, "confidence_area")
+		features = append(features, confidenceCircle)
+	}
 
 	// Add receiver locations
 	for _, receiver := range r.ReceiverLocations {
@@ -57,12 +128,25 @@ func (r *Result) ExportGeoJSON(filename string) error {
 				"coordinates": []float64{receiver.Location.Longitude, receiver.Location.Latitude},
 			},
 			"properties": map[string]interface{}{
-				"name":     receiver.ID,
-				"type":     "receiver",
-				"filename": receiver.Filename,
-				"snr_db":   receiver.SNR,
+				"name":                 receiver.ID,
+				"type":                 "receiver",
+				"filename":             receiver.Filename,
+				"snr_db":               receiver.SNR,
+				"avg_power_db":         receiver.Power.Avg,
+				"min_power_db":         receiver.Power.Min,
+				"max_power_db":         receiver.Power.Max,
+				"calibration_delay_ns": receiver.CalibrationDelay,
+				"clock_offset_ns":      receiver.ClockOffset.Nanoseconds(),
+				"gap_padding_samples":  receiver.GapPadding,
+				"swapped_iq":           receiver.SwappedIQ,
 			},
 		}
+		if receiver.SampleRate > 0 {
+			receiverFeature["properties"].(map[string]interface{})["sample_rate_hz"] = receiver.SampleRate
+		}
+		if receiver.CollectionTime != nil {
+			receiverFeature["properties"].(map[string]interface{})["collection_time"] = formatCaptureTime(receiver.CollectionTime)
+		}
 		features = append(features, receiverFeature)
 	}
 
@@ -90,18 +174,40 @@ func (r *Result) ExportGeoJSON(filename string) error {
 					},
 				},
 				"properties": map[string]interface{}{
-					"name":             fmt.Sprintf("%s-%s TDOA", measurement.Receiver1ID, measurement.Receiver2ID),
-					"type":             "tdoa_baseline",
-					"time_diff_ns":     measurement.TimeDiff,
-					"distance_diff_m":  measurement.DistanceDiff,
-					"confidence":       measurement.Confidence,
-					"correlation_peak": measurement.CorrelationPeak,
+					"name":                fmt.Sprintf("%s-%s TDOA", measurement.Receiver1ID, measurement.Receiver2ID),
+					"type":                "tdoa_baseline",
+					"time_diff_ns":        measurement.TimeDiff,
+					"distance_diff_m":     measurement.DistanceDiff,
+					"confidence":          measurement.Confidence,
+					"correlation_peak":    measurement.CorrelationPeak,
+					"frequency_offset_hz": measurement.FrequencyOffset,
+					"residual_m":          measurement.Residual,
 				},
 			}
 			features = append(features, lineFeature)
 		}
 	}
 
+	// Add the hyperbola of each measurement if computed; they cross at the estimate
+	for _, h := range r.Hyperbolas {
+		coordinates := make([][]float64, len(h.Points))
+		for i, point := range h.Points {
+			coordinates[i] = []float64{point.Longitude, point.Latitude}
+		}
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "LineString",
+				"coordinates": coordinates,
+			},
+			"properties": map[string]interface{}{
+				"name":            fmt.Sprintf("%s-%s hyperbola", h.Receiver1ID, h.Receiver2ID),
+				"type":            "tdoa_hyperbola",
+				"distance_diff_m": h.DistanceDiff,
+			},
+		})
+	}
+
 	// Add heatmap points if available
 	if len(r.HeatmapPoints) > 0 {
 		for _, point := range r.HeatmapPoints {
@@ -123,21 +229,7 @@ func (r *Result) ExportGeoJSON(filename string) error {
 	}
 
 	geojson["features"] = features
-
-	// Write to file
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create GeoJSON file: %w", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(geojson); err != nil {
-		return fmt.Errorf("failed to encode GeoJSON: %w", err)
-	}
-
-	return nil
+	return geojson
 }
 
 // ExportKML exports the TDOA results in KML format for Google Earth
@@ -153,7 +245,7 @@ func (r *Result) ExportKML(filename string) error {
 <kml xmlns="http://www.opengis.net/kml/2.2">
   <Document>
     <name>TDOA Transmitter Location Analysis</name>
-    <description>Frequency: %.3f MHz, Algorithm: %s, Confidence: %.2f</description>
+    <description>Frequency: %.3f MHz, Algorithm: %s, Reference: %s, Confidence: %.2f%s</description>
     
     <!-- Styles -->
     <Style id="transmitterStyle">
@@ -194,7 +286,7 @@ func (r *Result) ExportKML(filename string) error {
         <width>1</width>
       </LineStyle>
     </Style>
-`, r.Frequency/1e6, r.Algorithm, r.Confidence)
+`, r.Frequency/1e6, r.Algorithm, r.ReferenceReceiver, r.Confidence, kmlCaptureTime(r.CaptureTime))
 
 	// Add estimated transmitter location
 	fmt.Fprintf(file, `
@@ -208,21 +300,31 @@ func (r *Result) ExportKML(filename string) error {
     </Placemark>
 `, r.Confidence, r.ErrorRadius, r.Algorithm, r.Location.Longitude, r.Location.Latitude, r.Location.Altitude)
 
-	// Add confidence circle
+	// Add confidence ellipse or circle
+	description := fmt.Sprintf("%.1f meter radius confidence area", r.ErrorRadius)
+	var areaPoints []Location
+	if e := r.ErrorEllipse; e != nil {
+		description = fmt.Sprintf("%.0f%% confidence ellipse: %.1f m by %.1f m semi-axes, major axis bearing %.0f°",
+			e.ConfidenceLevel*100, e.SemiMajor, e.SemiMinor, e.Orientation)
+		areaPoints = generateEllipsePoints(r.Location, e, 72)
+	} else {
+		areaPoints = generateCirclePoints(r.Location, r.ErrorRadius, 36)
+	}
+	// Repeat the first point to close the ring
+	areaPoints = append(areaPoints, areaPoints[0])
+
 	fmt.Fprintf(file, `
     <Placemark>
       <name>Confidence Area</name>
-      <description>%.1f meter radius confidence area</description>
+      <description>%s</description>
       <styleUrl>#confidenceStyle</styleUrl>
       <Polygon>
         <outerBoundaryIs>
           <LinearRing>
             <coordinates>
-`, r.ErrorRadius)
+`, description)
 
-	// Generate circle points
-	circlePoints := generateCirclePoints(r.Location, r.ErrorRadius, 36)
-	for _, point := range circlePoints {
+	for _, point := range areaPoints {
 		fmt.Fprintf(file, "%.8f,%.8f,%.1f ", point.Longitude, point.Latitude, point.Altitude)
 	}
 
@@ -239,13 +341,14 @@ func (r *Result) ExportKML(filename string) error {
 		fmt.Fprintf(file, `
     <Placemark>
       <name>%s</name>
-      <description>SNR: %.1f dB, File: %s</description>
+      <description>SNR: %.1f dB, Power: %.1f dBFS avg (%.1f to %.1f), File: %s%s</description>
       <styleUrl>#receiverStyle</styleUrl>
       <Point>
         <coordinates>%.8f,%.8f,%.1f</coordinates>
       </Point>
     </Placemark>
-`, receiver.ID, receiver.SNR, receiver.Filename, receiver.Location.Longitude, receiver.Location.Latitude, receiver.Location.Altitude)
+`, receiver.ID, receiver.SNR, receiver.Power.Avg, receiver.Power.Min, receiver.Power.Max, receiver.Filename, kmlCaptureTime(receiver.CollectionTime),
+			receiver.Location.Longitude, receiver.Location.Latitude, receiver.Location.Altitude)
 	}
 
 	// Add TDOA baseline measurements
@@ -265,7 +368,7 @@ func (r *Result) ExportKML(filename string) error {
 			fmt.Fprintf(file, `
     <Placemark>
       <name>%s-%s TDOA Baseline</name>
-      <description>Time Diff: %.1f ns, Distance Diff: %.1f m, Confidence: %.3f</description>
+      <description>Time Diff: %.1f ns, Distance Diff: %.1f m, Confidence: %.3f, Residual: %.1f m</description>
       <styleUrl>#baselineStyle</styleUrl>
       <LineString>
         <coordinates>
@@ -273,7 +376,7 @@ func (r *Result) ExportKML(filename string) error {
         </coordinates>
       </LineString>
     </Placemark>
-`, measurement.Receiver1ID, measurement.Receiver2ID, measurement.TimeDiff, measurement.DistanceDiff, measurement.Confidence,
+`, measurement.Receiver1ID, measurement.Receiver2ID, measurement.TimeDiff, measurement.DistanceDiff, measurement.Confidence, measurement.Residual,
 				r1.Location.Longitude, r1.Location.Latitude, r1.Location.Altitude,
 				r2.Location.Longitude, r2.Location.Latitude, r2.Location.Altitude)
 		}
@@ -302,16 +405,31 @@ func (r *Result) ExportCSV(filename string) error {
 	// Write metadata header
 	writer.Write([]string{"# TDOA Transmitter Location Analysis"})
 	writer.Write([]string{"# Processing Time", r.ProcessingTime.Format("2006-01-02 15:04:05")})
+	if r.CaptureTime != nil {
+		writer.Write([]string{"# Capture Time", formatCaptureTime(r.CaptureTime)})
+	}
 	writer.Write([]string{"# Algorithm", r.Algorithm})
+	writer.Write([]string{"# Reference Receiver", r.ReferenceReceiver})
 	writer.Write([]string{"# Frequency MHz", fmt.Sprintf("%.3f", r.Frequency/1e6)})
 	writer.Write([]string{"# Estimated Location", fmt.Sprintf("%.8f,%.8f", r.Location.Latitude, r.Location.Longitude)})
 	writer.Write([]string{"# Confidence", fmt.Sprintf("%.3f", r.Confidence)})
 	writer.Write([]string{"# Error Radius m", fmt.Sprintf("%.1f", r.ErrorRadius)})
+	writer.Write([]string{"# RMS Residual m", fmt.Sprintf("%.1f", r.RMSResidual)})
+	if e := r.ErrorEllipse; e != nil {
+		writer.Write([]string{"# Error Ellipse Confidence", fmt.Sprintf("%.2f", e.ConfidenceLevel)})
+		writer.Write([]string{"# Error Ellipse Semi-Major m", fmt.Sprintf("%.1f", e.SemiMajor)})
+		writer.Write([]string{"# Error Ellipse Semi-Minor m", fmt.Sprintf("%.1f", e.SemiMinor)})
+		writer.Write([]string{"# Error Ellipse Orientation deg", fmt.Sprintf("%.1f", e.Orientation)})
+	}
+	for _, failure := range r.FailedPairs {
+		writer.Write([]string{"# Failed Pair", failure.Receiver1ID + "-" + failure.Receiver2ID, failure.Reason})
+	}
 	writer.Write([]string{""}) // Empty line
 
 	// Write receiver information
 	writer.Write([]string{"# Receiver Stations"})
-	writer.Write([]string{"Receiver_ID", "Latitude", "Longitude", "Altitude", "SNR_dB", "Filename"})
+	writer.Write([]string{"Receiver_ID", "Latitude", "Longitude", "Altitude", "SNR_dB", "Avg_Power_dB", "Min_Power_dB", "Max_Power_dB", "Calibration_Delay_ns", "Filename", "Collection_Time",
+		"Sample_Rate_Hz", "Clock_Offset_ns", "Gap_Padding_Samples", "Swapped_IQ"})
 	for _, receiver := range r.ReceiverLocations {
 		writer.Write([]string{
 			receiver.ID,
@@ -319,14 +437,23 @@ func (r *Result) ExportCSV(filename string) error {
 			fmt.Sprintf("%.8f", receiver.Location.Longitude),
 			fmt.Sprintf("%.1f", receiver.Location.Altitude),
 			fmt.Sprintf("%.1f", receiver.SNR),
+			fmt.Sprintf("%.1f", receiver.Power.Avg),
+			fmt.Sprintf("%.1f", receiver.Power.Min),
+			fmt.Sprintf("%.1f", receiver.Power.Max),
+			fmt.Sprintf("%.1f", receiver.CalibrationDelay),
 			receiver.Filename,
+			formatCaptureTime(receiver.CollectionTime),
+			fmt.Sprintf("%d", receiver.SampleRate),
+			fmt.Sprintf("%d", receiver.ClockOffset.Nanoseconds()),
+			fmt.Sprintf("%d", receiver.GapPadding),
+			fmt.Sprintf("%t", receiver.SwappedIQ),
 		})
 	}
 	writer.Write([]string{""}) // Empty line
 
 	// Write TDOA measurements
 	writer.Write([]string{"# TDOA Measurements"})
-	writer.Write([]string{"Receiver1_ID", "Receiver2_ID", "Time_Diff_ns", "Distance_Diff_m", "Confidence", "Correlation_Peak"})
+	writer.Write([]string{"Receiver1_ID", "Receiver2_ID", "Time_Diff_ns", "Distance_Diff_m", "Confidence", "Correlation_Peak", "Residual_m", "Frequency_Offset_Hz"})
 	for _, measurement := range r.TDOAMeasurements {
 		writer.Write([]string{
 			measurement.Receiver1ID,
@@ -335,6 +462,8 @@ func (r *Result) ExportCSV(filename string) error {
 			fmt.Sprintf("%.1f", measurement.DistanceDiff),
 			fmt.Sprintf("%.3f", measurement.Confidence),
 			fmt.Sprintf("%.3f", measurement.CorrelationPeak),
+			fmt.Sprintf("%.1f", measurement.Residual),
+			fmt.Sprintf("%.1f", measurement.FrequencyOffset),
 		})
 	}
 
@@ -380,6 +509,50 @@ func generateCircleFeature(center Location, radius float64, featureType string)
 	}
 }
 
+// formatCaptureTime formats a capture time as RFC 3339 UTC with the
+// sub-second digits recorded, or "" if there is none
+func formatCaptureTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// kmlCaptureTime formats a capture time for appending to a KML description
+func kmlCaptureTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return ", Captured: " + formatCaptureTime(t)
+}
+
+// generateEllipseFeature creates a GeoJSON error ellipse feature
+func generateEllipseFeature(center Location, ellipse *ErrorEllipse, featureType string) map[string]interface{} {
+	points := generateEllipsePoints(center, ellipse, 72)
+
+	coordinates := make([][]float64, len(points)+1) // +1 to close the polygon
+	for i, point := range points {
+		coordinates[i] = []float64{point.Longitude, point.Latitude}
+	}
+	coordinates[len(points)] = []float64{points[0].Longitude, points[0].Latitude}
+
+	return map[string]interface{}{
+		"type": "Feature",
+		"geometry": map[string]interface{}{
+			"type":        "Polygon",
+			"coordinates": [][][]float64{coordinates},
+		},
+		"properties": map[string]interface{}{
+			"name":             "Confidence Ellipse",
+			"type":             featureType,
+			"semi_major_m":     ellipse.SemiMajor,
+			"semi_minor_m":     ellipse.SemiMinor,
+			"orientation_deg":  ellipse.Orientation,
+			"confidence_level": ellipse.ConfidenceLevel,
+		},
+	}
+}
+
 // generateCirclePoints generates points around a circle for a given center and radius
 func generateCirclePoints(center Location, radiusMeters float64, numPoints int) []Location {
 	points := make([]Location, numPoints)
@@ -0,0 +1,91 @@
+// Package processor - Carrier frequency offset estimation between receivers
+package processor
+
+import (
+	"math"
+
+	"argus-collector/internal/dsp"
+)
+
+// Frequency offset estimation constants
+const (
+	freqOffsetMaxFFT = 1 << 16 // Largest FFT used for the estimate
+	freqOffsetMinFFT = 1024    // Fewer samples than this give no estimate
+	freqOffsetMinSNR = 10.0    // Tone peak over mean bin power required to trust the estimate
+)
+
+// estimateFrequencyOffset estimates how far the carrier in samples2 sits above
+// the carrier in samples1, in Hz. Multiplying one stream by the conjugate of
+// the other cancels the common signal and leaves a tone at the frequency
+// difference of the receivers' local oscillators, found as the peak of its
+// FFT. ok is false when there are too few samples or no clear tone, e.g. for
+// signals without a carrier.
+func estimateFrequencyOffset(samples1, samples2 []complex64, sampleRate float64) (offset float64, ok bool) {
+	n := min(len(samples1), len(samples2))
+	size := freqOffsetMaxFFT
+	for size > n {
+		size >>= 1
+	}
+	if size < freqOffsetMinFFT {
+		return 0, false
+	}
+
+	// Remove each stream's mean so the RTL-SDR DC spike does not pull the peak to 0 Hz
+	mean1, mean2 := meanSample(samples1[:size]), meanSample(samples2[:size])
+
+	product := make([]complex128, size)
+	for i := range product {
+		s1 := complex128(samples1[i]) - mean1
+		s2 := complex128(samples2[i]) - mean2
+		window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size))
+		product[i] = s1 * complex(real(s2), -imag(s2)) * complex(window, 0)
+	}
+	dsp.FFT(product)
+
+	power := make([]float64, size)
+	peak, total := 0, 0.0
+	for i, v := range product {
+		power[i] = real(v)*real(v) + imag(v)*imag(v)
+		total += power[i]
+		if power[i] > power[peak] {
+			peak = i
+		}
+	}
+	if total == 0 || power[peak] < freqOffsetMinSNR*total/float64(size) {
+		return 0, false
+	}
+
+	// Parabolic interpolation between the neighbouring bins for sub-bin accuracy
+	left, right := power[(peak-1+size)%size], power[(peak+1)%size]
+	bin := float64(peak)
+	if denom := left - 2*power[peak] + right; denom != 0 {
+		bin += 0.5 * (left - right) / denom
+	}
+	if bin >= float64(size)/2 {
+		bin -= float64(size) // Upper half of the FFT holds negative frequencies
+	}
+
+	// The product tone is at f1 - f2
+	return -bin * sampleRate / float64(size), true
+}
+
+// shiftFrequency returns samples moved down in frequency by offset Hz, so a
+// stream whose carrier is offset Hz high lines up with the other receiver
+func shiftFrequency(samples []complex64, offset, sampleRate float64) []complex64 {
+	shifted := make([]complex64, len(samples))
+	step := -2 * math.Pi * offset / sampleRate
+	for i, s := range samples {
+		sin, cos := math.Sincos(step * float64(i))
+		shifted[i] = s * complex64(complex(cos, sin))
+	}
+	return shifted
+}
+
+// meanSample returns the average of samples
+func meanSample(samples []complex64) complex128 {
+	var sum complex128
+	for _, s := range samples {
+		sum += complex128(s)
+	}
+	return sum / complex(float64(len(samples)), 0)
+}
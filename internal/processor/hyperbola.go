@@ -0,0 +1,87 @@
+// Package processor - Hyperbolas of constant distance difference
+package processor
+
+import (
+	"math"
+)
+
+// hyperbolaPoints is the number of points each hyperbola is sampled at
+const hyperbolaPoints = 257
+
+// Hyperbola is the curve of locations whose distance difference between two
+// receivers matches a measurement; the transmitter lies on every measured
+// pair's hyperbola, so they cross at the estimate
+type Hyperbola struct {
+	Receiver1ID  string     `json:"receiver1_id"`
+	Receiver2ID  string     `json:"receiver2_id"`
+	DistanceDiff float64    `json:"distance_diff_m"` // Distance to Receiver2 minus distance to Receiver1
+	Points       []Location `json:"points"`
+}
+
+// computeHyperbolas returns the hyperbola of each measurement, sampled out to
+// the maximum transmitter distance from the midpoint of its receivers. A
+// measurement whose distance difference is not shorter than its baseline has
+// no hyperbola and is left out.
+func (p *Processor) computeHyperbolas(receivers []ReceiverInfo, measurements []TDOAMeasurement) []Hyperbola {
+	byID := make(map[string]ReceiverInfo, len(receivers))
+	for _, r := range receivers {
+		byID[r.ID] = r
+	}
+
+	var hyperbolas []Hyperbola
+	for _, m := range measurements {
+		r1, ok1 := byID[m.Receiver1ID]
+		r2, ok2 := byID[m.Receiver2ID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		points := hyperbolaCurve(r1.Location, r2.Location, m.DistanceDiff, p.config.MaxDistance*1000)
+		if points == nil {
+			continue
+		}
+		hyperbolas = append(hyperbolas, Hyperbola{
+			Receiver1ID:  m.Receiver1ID,
+			Receiver2ID:  m.Receiver2ID,
+			DistanceDiff: m.DistanceDiff,
+			Points:       points,
+		})
+	}
+	return hyperbolas
+}
+
+// hyperbolaCurve samples the branch of locations X with |X-r2| - |X-r1| =
+// distanceDiff, out to extent meters from the midpoint of r1 and r2. The
+// receivers are placed on a local flat east/north plane around the midpoint,
+// which holds over the tens of kilometers a search area spans. It returns nil
+// if there is no such curve.
+func hyperbolaCurve(r1, r2 Location, distanceDiff, extent float64) []Location {
+	midLat := (r1.Latitude + r2.Latitude) / 2
+	midLon := (r1.Longitude + r2.Longitude) / 2
+	metersPerDegLon := 111000.0 * math.Cos(midLat*math.Pi/180) // Approximate, as in generateCirclePoints
+
+	// Baseline from r1 to r2 in meters
+	east := (r2.Longitude - r1.Longitude) * metersPerDegLon
+	north := (r2.Latitude - r1.Latitude) * 111000.0
+	c := math.Hypot(east, north) / 2 // Focal distance
+	a := distanceDiff / 2            // Signed semi-major axis
+	if c == 0 || math.Abs(a) >= c {
+		return nil
+	}
+	b := math.Sqrt(c*c - a*a)
+	ux, uy := east/(2*c), north/(2*c) // Unit vector along the baseline towards r2
+
+	// x along the baseline, y across it: x = -a cosh t puts the branch on the
+	// side of the receiver the signal reached first
+	tMax := math.Asinh(extent / b)
+	points := make([]Location, hyperbolaPoints)
+	for i := range points {
+		t := tMax * (2*float64(i)/float64(hyperbolaPoints-1) - 1)
+		x := -a * math.Cosh(t)
+		y := b * math.Sinh(t)
+		points[i] = Location{
+			Latitude:  midLat + (x*uy+y*ux)/111000.0,
+			Longitude: midLon + (x*ux-y*uy)/metersPerDegLon,
+		}
+	}
+	return points
+}
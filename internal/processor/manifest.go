@@ -0,0 +1,184 @@
+// Package processor - Receiver manifest assigning IDs and positions to data files
+package processor
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"argus-collector/internal/filewriter"
+)
+
+// Column headers of a receiver manifest
+const (
+	manifestFileHeader        = "File"
+	manifestIDHeader          = "Receiver_ID"
+	manifestCalibrationHeader = "Calibration_ns"
+)
+
+// ManifestEntry assigns the data files of one station an explicit receiver ID
+// and optionally overrides the position and calibration delay they would
+// otherwise take from file metadata and --calibration
+type ManifestEntry struct {
+	File             string    // Data file path, or station name matching that station's file in every session
+	ID               string    // Receiver ID used in place of R1, R2, ...
+	Location         *Location // Position overriding the file's GPS metadata; nil keeps the metadata
+	CalibrationDelay *float64  // Calibration delay in ns overriding --calibration; nil keeps it
+}
+
+// LoadManifest reads a receiver manifest from a CSV file. The first row names
+// the columns, which may appear in any order; rows starting with '#' are
+// comments:
+//
+//	File,Receiver_ID,Latitude,Longitude,Altitude,Calibration_ns
+//	argus-0,north,35.578,-97.621,365,125
+//	argus-1,east,,,,
+//	data/station2_1700000000.dat,south,35.501,-97.600,,
+//
+// File is either a data file path, relative to the manifest's directory, or a
+// station name: the filename without extension and collection timestamp, as
+// for --calibration, which matches the station's file in every session. Latitude and Longitude are given together
+// or left empty to keep the file's GPS position; Altitude defaults to 0 m
+// when they are given. An empty Calibration_ns keeps the --calibration delay.
+func LoadManifest(filename string) ([]ManifestEntry, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var entries []ManifestEntry
+	var columns map[string]int // Column index by header name
+	ids := make(map[string]bool)
+	files := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if columns == nil {
+			columns = make(map[string]int, len(record))
+			for i, name := range record {
+				columns[strings.TrimSpace(name)] = i
+			}
+			for _, required := range []string{manifestFileHeader, manifestIDHeader} {
+				if _, ok := columns[required]; !ok {
+					return nil, fmt.Errorf("%s:%d: header has no %s column", filename, line, required)
+				}
+			}
+			continue
+		}
+
+		entry, err := parseManifestRow(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+		if isDataFileName(entry.File) && !filepath.IsAbs(entry.File) {
+			entry.File = filepath.Join(filepath.Dir(filename), entry.File)
+		}
+		if ids[entry.ID] {
+			return nil, fmt.Errorf("%s:%d: receiver ID %s listed more than once", filename, line, entry.ID)
+		}
+		if files[entry.File] {
+			return nil, fmt.Errorf("%s:%d: %s listed more than once", filename, line, entry.File)
+		}
+		ids[entry.ID] = true
+		files[entry.File] = true
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no receivers", filename)
+	}
+	return entries, nil
+}
+
+// parseManifestRow parses one receiver row of a manifest
+func parseManifestRow(record []string, columns map[string]int) (ManifestEntry, error) {
+	entry := ManifestEntry{
+		File: csvField(record, columns, manifestFileHeader),
+		ID:   csvField(record, columns, manifestIDHeader),
+	}
+	if entry.File == "" {
+		return entry, fmt.Errorf("missing %s", manifestFileHeader)
+	}
+	if entry.ID == "" {
+		return entry, fmt.Errorf("missing %s for %s", manifestIDHeader, entry.File)
+	}
+
+	hasLat := csvField(record, columns, "Latitude") != ""
+	hasLon := csvField(record, columns, "Longitude") != ""
+	if hasLat != hasLon {
+		return entry, fmt.Errorf("%s: Latitude and Longitude must be given together", entry.ID)
+	}
+	if hasLat {
+		var loc Location
+		var err error
+		if loc.Latitude, err = csvFloat(record, columns, "Latitude", true, 0); err != nil {
+			return entry, err
+		}
+		if loc.Longitude, err = csvFloat(record, columns, "Longitude", true, 0); err != nil {
+			return entry, err
+		}
+		if loc.Altitude, err = csvFloat(record, columns, "Altitude", false, 0); err != nil {
+			return entry, err
+		}
+		if loc.Latitude < -90 || loc.Latitude > 90 || loc.Longitude < -180 || loc.Longitude > 180 {
+			return entry, fmt.Errorf("%s: position %.6f, %.6f out of range", entry.ID, loc.Latitude, loc.Longitude)
+		}
+		entry.Location = &loc
+	}
+
+	if csvField(record, columns, manifestCalibrationHeader) != "" {
+		delay, err := csvFloat(record, columns, manifestCalibrationHeader, true, 0)
+		if err != nil {
+			return entry, err
+		}
+		entry.CalibrationDelay = &delay
+	}
+
+	return entry, nil
+}
+
+// isDataFileName reports whether a manifest File names a data file rather
+// than a station
+func isDataFileName(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), filewriter.CompressedExtension)
+	return filepath.Ext(name) == ".dat"
+}
+
+// manifestEntry returns the manifest entry for a data file: the entry naming
+// the file itself, or else the one naming its station. It returns nil if no
+// entry matches.
+func (p *Processor) manifestEntry(filename string) *ManifestEntry {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		abs = filename
+	}
+	for i := range p.config.Manifest {
+		if entryAbs, err := filepath.Abs(p.config.Manifest[i].File); err == nil && entryAbs == abs {
+			return &p.config.Manifest[i]
+		}
+	}
+
+	station := StationName(filename)
+	for i := range p.config.Manifest {
+		if p.config.Manifest[i].File == station {
+			return &p.config.Manifest[i]
+		}
+	}
+	return nil
+}
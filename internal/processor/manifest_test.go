@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"argus-collector/internal/filewriter"
+)
+
+// writeManifestTestFile writes a short capture for station at lat, lon to dir
+func writeManifestTestFile(t *testing.T, dir, station string, timestamp int64, lat, lon float64) string {
+	t.Helper()
+	samples := make([]complex64, 4096)
+	for i := range samples {
+		samples[i] = complex(float32(math.Cos(float64(i)*0.3)), float32(math.Sin(float64(i)*0.7)))
+	}
+	metadata := filewriter.Metadata{
+		Frequency:         433920000,
+		SampleRate:        2048000,
+		CollectionTime:    time.Unix(timestamp, 0),
+		GPSLocation:       filewriter.GPSLocation{Latitude: lat, Longitude: lon, Altitude: 300},
+		FileFormatVersion: filewriter.FormatVersion2,
+		CollectionID:      station,
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("%s_%d.dat", station, timestamp))
+	if err := filewriter.NewWriter().WriteFile(filename, metadata, samples); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return filename
+}
+
+// writeManifest writes content as a manifest in dir and loads it
+func writeManifest(t *testing.T, dir, content string) ([]ManifestEntry, error) {
+	t.Helper()
+	filename := filepath.Join(dir, "manifest.csv")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadManifest(filename)
+}
+
+func TestManifestOverridesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeManifestTestFile(t, dir, "argus-0", 1700000000, 35.578, -97.621),
+		writeManifestTestFile(t, dir, "argus-1", 1700000000, 35.533, -97.566),
+		writeManifestTestFile(t, dir, "argus-2", 1700000000, 35.488, -97.621),
+	}
+
+	// argus-0 by station name, overriding position and delay; argus-1 by
+	// path relative to the manifest, keeping both; argus-2 by station only
+	entries, err := writeManifest(t, dir, `# Receivers of the test deployment
+File,Receiver_ID,Latitude,Longitude,Altitude,Calibration_ns
+argus-0,north,36.100,-97.200,410,125
+`+filepath.Base(files[1])+`,east,,,,
+argus-2,south,,,,
+`)
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if entries[1].File != files[1] {
+		t.Errorf("relative file resolved to %s, want %s", entries[1].File, files[1])
+	}
+
+	p, err := NewProcessor(&Config{
+		MaxDistance: 100,
+		Manifest:    entries,
+		Calibration: map[string]float64{"north": 999, "east": 50},
+	})
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+	receivers, err := p.loadReceivers(files)
+	if err != nil {
+		t.Fatalf("loadReceivers failed: %v", err)
+	}
+
+	want := []struct {
+		id       string
+		location Location
+		delay    float64
+	}{
+		{"north", Location{Latitude: 36.1, Longitude: -97.2, Altitude: 410}, 125}, // Manifest over metadata and --calibration
+		{"east", Location{Latitude: 35.533, Longitude: -97.566, Altitude: 300}, 50},
+		{"south", Location{Latitude: 35.488, Longitude: -97.621, Altitude: 300}, 0},
+	}
+	if len(receivers) != len(want) {
+		t.Fatalf("loaded %d receivers, want %d", len(receivers), len(want))
+	}
+	for i, w := range want {
+		r := receivers[i]
+		if r.ID != w.id || r.Location != w.location || r.CalibrationDelay != w.delay {
+			t.Errorf("receiver %d = %s %+v (%.0f ns), want %s %+v (%.0f ns)",
+				i, r.ID, r.Location, r.CalibrationDelay, w.id, w.location, w.delay)
+		}
+	}
+}
+
+func TestManifestUnmatchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := writeManifestTestFile(t, dir, "argus-0", 1700000000, 35.578, -97.621)
+	second := writeManifestTestFile(t, dir, "argus-1", 1700000000, 35.533, -97.566)
+	repeat := writeManifestTestFile(t, dir, "argus-0", 1700000100, 35.578, -97.621)
+
+	entries, err := writeManifest(t, dir, "File,Receiver_ID\nargus-0,north\n")
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	p, err := NewProcessor(&Config{MaxDistance: 100, Manifest: entries})
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	if _, err := p.loadReceivers([]string{first, second}); err == nil || !strings.Contains(err.Error(), "not listed in the receiver manifest") {
+		t.Errorf("unlisted file: error = %v", err)
+	}
+	if _, err := p.loadReceivers([]string{first, repeat}); err == nil || !strings.Contains(err.Error(), "matches both") {
+		t.Errorf("station entry matching two files: error = %v", err)
+	}
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no ID column", "File,Latitude\nargus-0,35\n", "no Receiver_ID column"},
+		{"missing ID", "File,Receiver_ID\nargus-0,\n", "missing Receiver_ID"},
+		{"duplicate ID", "File,Receiver_ID\nargus-0,north\nargus-1,north\n", "listed more than once"},
+		{"duplicate file", "File,Receiver_ID\nargus-0,north\nargus-0,east\n", "listed more than once"},
+		{"latitude without longitude", "File,Receiver_ID,Latitude,Longitude\nargus-0,north,35.5,\n", "given together"},
+		{"position out of range", "File,Receiver_ID,Latitude,Longitude\nargus-0,north,95,-97\n", "out of range"},
+		{"invalid calibration", "File,Receiver_ID,Calibration_ns\nargus-0,north,late\n", "invalid Calibration_ns"},
+		{"no receivers", "File,Receiver_ID\n", "lists no receivers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := writeManifest(t, t.TempDir(), tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
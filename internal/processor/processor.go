@@ -2,29 +2,48 @@
 package processor
 
 import (
-	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
-	"unsafe"
 
+	"argus-collector/internal/datareader"
 	"argus-collector/internal/filewriter"
 )
 
 // Config holds the configuration for TDOA processing
 type Config struct {
-	Algorithm      string   // TDOA algorithm to use
-	Confidence     float64  // Minimum confidence threshold
-	MaxDistance    float64  // Maximum expected transmitter distance (km)
-	FrequencyRange []string // Frequency ranges to analyze
-	Verbose        bool     // Enable verbose logging
-	ParallelWorkers int     // Number of parallel workers (0 = auto-detect based on CPU cores)
+	Algorithm        string             // TDOA algorithm to use
+	Confidence       float64            // Minimum confidence threshold
+	MaxDistance      float64            // Maximum expected transmitter distance (km)
+	FrequencyRange   []string           // Frequency ranges to analyze
+	Verbose          bool               // Enable verbose logging
+	ParallelWorkers  int                // Number of parallel workers (0 = auto-detect based on CPU cores)
+	Reference        string             // Reference receiver ID (e.g. "R2") or 1-based index; empty selects highest SNR
+	PropagationSpeed float64            // Signal propagation speed (m/s) used to convert delays to distances; 0 = speed of light
+	Calibration      map[string]float64 // Per-receiver calibration delays (ns) keyed by receiver ID or station name
+	CorrStart        time.Duration      // Offset into each capture where correlation starts
+	CorrDuration     time.Duration      // Length of the correlated segment; 0 = to the end of the capture
+	Hyperbolas       bool               // Compute each measurement's hyperbola for map output
+	ErrorEllipse     bool               // Compute a confidence ellipse and draw it on maps instead of the error circle
+	HeatmapGridSize  int                // Heatmap points along each side of the grid; 0 = DefaultHeatmapGridSize
+	HeatmapExtent    float64            // Heatmap grid width in meters; 0 = HeatmapRadii times the error radius
+	HeatmapRadii     float64            // Heatmap grid width as a multiple of the error radius; 0 = DefaultHeatmapRadii
+	Envelope         bool               // Correlate sample magnitudes instead of complex samples
+	FreqCorrection   bool               // Estimate and remove each pair's carrier frequency offset before correlating
+	Manifest         []ManifestEntry    // Receiver IDs, positions and delays by file or station; every file must be listed when set
+	LowMemory        bool               // Load only the samples correlation uses instead of whole captures
+	SwapIQ           []string           // Receiver IDs or station names whose I and Q are exchanged on read, or "all"
+	MinPeakToNoise   float64            // Skip captures recorded as peaking less than this many dB above their noise floor; 0 = keep all
+	Output           io.Writer          // Where progress and diagnostics are printed; nil = standard output
 }
 
 // ReceiverPair represents a pair of receivers for parallel processing
@@ -43,6 +62,8 @@ type CorrelationResult struct {
 	Error       error
 	PairNum     int
 	PairID      string // For logging (e.g., "R1↔R2")
+	Receiver1ID string
+	Receiver2ID string
 }
 
 // ProgressTracker tracks progress of long-running operations
@@ -53,7 +74,22 @@ type ProgressTracker struct {
 	subProgress   float64
 	lastReported  time.Time
 	startTime     time.Time
+	stepStart     time.Time
 	verbose       bool
+	timings       []StepTiming
+	out           io.Writer // Where progress is printed
+}
+
+// Names of the processing steps that handle every loaded sample
+const (
+	StepLoad      = "Loading and validating data files"
+	StepCorrelate = "Performing cross-correlation analysis"
+)
+
+// StepTiming is the time one processing step took
+type StepTiming struct {
+	Name     string
+	Duration time.Duration
 }
 
 // NewProgressTracker creates a new progress tracker
@@ -64,6 +100,7 @@ func NewProgressTracker(totalSteps int, verbose bool) *ProgressTracker {
 		startTime:    time.Now(),
 		lastReported: time.Now(),
 		verbose:      verbose,
+		out:          os.Stdout,
 	}
 }
 
@@ -73,9 +110,10 @@ func (pt *ProgressTracker) StartStep(stepName string) {
 	pt.stepName = stepName
 	pt.subProgress = 0.0
 	pt.lastReported = time.Now()
+	pt.stepStart = pt.lastReported
 	
 	elapsed := time.Since(pt.startTime)
-	fmt.Printf("⏳ Step %d/%d: %s (elapsed: %v)\n", pt.currentStep, pt.totalSteps, stepName, elapsed.Truncate(time.Second))
+	fmt.Fprintf(pt.out, "⏳ Step %d/%d: %s (elapsed: %v)\n", pt.currentStep, pt.totalSteps, stepName, elapsed.Truncate(time.Second))
 }
 
 // UpdateSubProgress updates progress within the current step
@@ -93,10 +131,10 @@ func (pt *ProgressTracker) UpdateSubProgress(progress float64, details string) {
 		overallProgress := (float64(pt.currentStep-1) + progress) / float64(pt.totalSteps) * 100
 		
 		if details != "" {
-			fmt.Printf("   📊 %.1f%% complete (%.1f%% overall, %s) - %v elapsed\n", 
+			fmt.Fprintf(pt.out, "   📊 %.1f%% complete (%.1f%% overall, %s) - %v elapsed\n", 
 				progress*100, overallProgress, details, elapsed.Truncate(time.Second))
 		} else {
-			fmt.Printf("   📊 %.1f%% complete (%.1f%% overall) - %v elapsed\n", 
+			fmt.Fprintf(pt.out, "   📊 %.1f%% complete (%.1f%% overall) - %v elapsed\n", 
 				progress*100, overallProgress, elapsed.Truncate(time.Second))
 		}
 		
@@ -106,17 +144,23 @@ func (pt *ProgressTracker) UpdateSubProgress(progress float64, details string) {
 
 // CompleteStep marks the current step as complete
 func (pt *ProgressTracker) CompleteStep() {
+	pt.timings = append(pt.timings, StepTiming{Name: pt.stepName, Duration: time.Since(pt.stepStart)})
 	elapsed := time.Since(pt.startTime)
 	overallProgress := float64(pt.currentStep) / float64(pt.totalSteps) * 100
 	
-	fmt.Printf("✅ Step %d/%d complete: %s (%.1f%% overall, %v elapsed)\n", 
+	fmt.Fprintf(pt.out, "✅ Step %d/%d complete: %s (%.1f%% overall, %v elapsed)\n", 
 		pt.currentStep, pt.totalSteps, pt.stepName, overallProgress, elapsed.Truncate(time.Second))
 }
 
 // Finish completes all progress tracking
 func (pt *ProgressTracker) Finish() {
 	totalTime := time.Since(pt.startTime)
-	fmt.Printf("🎉 All processing complete! Total time: %v\n", totalTime.Truncate(time.Second))
+	fmt.Fprintf(pt.out, "🎉 All processing complete! Total time: %v\n", totalTime.Truncate(time.Second))
+}
+
+// Timings returns the time each completed step took, in order
+func (pt *ProgressTracker) Timings() []StepTiming {
+	return append([]StepTiming(nil), pt.timings...)
 }
 
 // Location represents a geographic coordinate
@@ -132,31 +176,93 @@ type ReceiverInfo struct {
 	Location Location             `json:"location"`
 	Filename string               `json:"filename"`
 	SNR      float64              `json:"snr"`
+	RMS      float64              `json:"rms"` // RMS amplitude before normalization
+	Power    PowerStats           `json:"power"`
 	Metadata *filewriter.Metadata `json:"-"`
 	Samples  []complex64          `json:"-"`
+
+	// SampleOffset is the position of Samples[0] on the capture timeline,
+	// non-zero when only a window of the capture was loaded
+	SampleOffset int `json:"-"`
+
+	// CalibrationDelay is the fixed cable/front-end delay (ns) removed from
+	// this receiver's arrival times
+	CalibrationDelay float64 `json:"calibration_delay_ns,omitempty"`
+
+	// SwappedIQ is true if I and Q were exchanged on read to correct the capture
+	SwappedIQ bool `json:"swapped_iq,omitempty"`
+
+	// CollectionTime is when the capture started, as recorded by the
+	// collector; nil for receivers imported without one
+	CollectionTime *time.Time `json:"collection_time,omitempty"`
+
+	// SampleRate is the capture's sample rate as recorded; receivers are
+	// never resampled, so it is also the rate the delays were measured at
+	SampleRate uint32 `json:"sample_rate_hz,omitempty"`
+
+	// ClockOffset is the station's measured system clock offset from GPS
+	// time, removed from its collection time when checking synchronization
+	ClockOffset time.Duration `json:"clock_offset_ns,omitempty"`
+
+	// GapPadding is the number of silent samples inserted where the device
+	// dropped samples, restoring the timing of the samples after each gap
+	GapPadding int `json:"gap_padding_samples,omitempty"`
 }
 
 // TDOAMeasurement represents a time difference measurement between two receivers
 type TDOAMeasurement struct {
 	Receiver1ID     string  `json:"receiver1_id"`
 	Receiver2ID     string  `json:"receiver2_id"`
-	TimeDiff        float64 `json:"time_diff_ns"`     // Time difference in nanoseconds
-	DistanceDiff    float64 `json:"distance_diff_m"`  // Distance difference in meters
-	Confidence      float64 `json:"confidence"`       // Measurement confidence (0-1)
-	CorrelationPeak float64 `json:"correlation_peak"` // Cross-correlation peak value
+	TimeDiff        float64 `json:"time_diff_ns"`        // Time difference in nanoseconds
+	DistanceDiff    float64 `json:"distance_diff_m"`     // Distance difference in meters
+	Confidence      float64 `json:"confidence"`          // Measurement confidence (0-1)
+	CorrelationPeak float64 `json:"correlation_peak"`    // Cross-correlation peak value
+	PeakToSidelobe  float64 `json:"peak_to_sidelobe"`    // Ratio of correlation peak to strongest sidelobe
+	OverlapSamples  int     `json:"overlap_samples"`     // Number of samples overlapping at the peak delay
+	FrequencyOffset float64 `json:"frequency_offset_hz"` // Receiver2's carrier offset from Receiver1, removed before correlating (0 if not estimated)
+	Residual        float64 `json:"residual_m"`          // Observed minus predicted DistanceDiff at the solved location
+}
+
+// PairFailure records a receiver pair that produced no measurement and why,
+// so a solve using fewer baselines than expected can be explained
+type PairFailure struct {
+	Receiver1ID string `json:"receiver1_id"`
+	Receiver2ID string `json:"receiver2_id"`
+	Reason      string `json:"reason"`
 }
 
+// ErrInsufficientSamples reports a receiver pair whose captures overlap by
+// too few samples to correlate, e.g. after a truncated capture
+var ErrInsufficientSamples = errors.New("insufficient samples for correlation")
+
+// minCorrelationSamples is the fewest samples a pair needs to be correlated
+const minCorrelationSamples = 1000
+
+// CentroidFallbackAlgorithm labels results whose location is a weighted
+// centroid of the receivers rather than a true TDOA solution
+const CentroidFallbackAlgorithm = "centroid-fallback"
+
+// centroidMaxConfidence caps the reported confidence of centroid fallback results
+const centroidMaxConfidence = 0.1
+
 // Result holds the complete TDOA processing results
 type Result struct {
 	Location          Location          `json:"location"`
 	Confidence        float64           `json:"confidence"`
 	ErrorRadius       float64           `json:"error_radius_m"`
 	Algorithm         string            `json:"algorithm"`
+	ReferenceReceiver string            `json:"reference_receiver"`
 	Frequency         float64           `json:"frequency_hz"`
 	ProcessingTime    time.Time         `json:"processing_time"`
+	CaptureTime       *time.Time        `json:"capture_time,omitempty"` // Collection time of the reference receiver's capture
 	ReceiverLocations []ReceiverInfo    `json:"receivers"`
 	TDOAMeasurements  []TDOAMeasurement `json:"tdoa_measurements"`
+	RMSResidual       float64           `json:"rms_residual_m"` // RMS of the measurement residuals
+	ErrorEllipse      *ErrorEllipse     `json:"error_ellipse,omitempty"`
 	HeatmapPoints     []HeatmapPoint    `json:"heatmap_points,omitempty"`
+	Hyperbolas        []Hyperbola       `json:"hyperbolas,omitempty"`
+	FailedPairs       []PairFailure     `json:"failed_pairs,omitempty"` // Pairs that produced no measurement
+	StepTimings       []StepTiming      `json:"-"`                      // Time each processing step took
 }
 
 // HeatmapPoint represents a point in the probability heatmap
@@ -168,8 +274,18 @@ type HeatmapPoint struct {
 // Processor handles TDOA signal processing
 type Processor struct {
 	config *Config
+	out    io.Writer // Config.Output, or standard output
 }
 
+// SpeedOfLight is the default propagation speed in m/s
+const SpeedOfLight = 299792458.0
+
+// Default heatmap grid: 20x20 points spanning twice the error radius
+const (
+	DefaultHeatmapGridSize = 20
+	DefaultHeatmapRadii    = 2.0
+)
+
 // NewProcessor creates a new TDOA processor with the given configuration
 func NewProcessor(config *Config) (*Processor, error) {
 	if config == nil {
@@ -185,12 +301,47 @@ func NewProcessor(config *Config) (*Processor, error) {
 		return nil, fmt.Errorf("max distance must be positive")
 	}
 
+	if config.PropagationSpeed < 0 {
+		return nil, fmt.Errorf("propagation speed must be positive")
+	}
+	if config.PropagationSpeed == 0 {
+		config.PropagationSpeed = SpeedOfLight
+	}
+
+	if config.CorrStart < 0 || config.CorrDuration < 0 {
+		return nil, fmt.Errorf("correlation segment start and duration must not be negative")
+	}
+
+	if config.HeatmapGridSize < 0 || config.HeatmapExtent < 0 || config.HeatmapRadii < 0 {
+		return nil, fmt.Errorf("heatmap grid size and extent must be positive")
+	}
+	if config.MinPeakToNoise < 0 {
+		return nil, fmt.Errorf("minimum peak-to-noise ratio must not be negative")
+	}
+	if config.HeatmapGridSize == 0 {
+		config.HeatmapGridSize = DefaultHeatmapGridSize
+	}
+	if config.HeatmapRadii == 0 {
+		config.HeatmapRadii = DefaultHeatmapRadii
+	}
+
 	// Set default algorithm if not specified
 	if config.Algorithm == "" {
 		config.Algorithm = "basic"
 	}
 
-	return &Processor{config: config}, nil
+	out := config.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	return &Processor{config: config, out: out}, nil
+}
+
+// newProgressTracker creates a progress tracker printing to the processor's output
+func (p *Processor) newProgressTracker(totalSteps int) *ProgressTracker {
+	pt := NewProgressTracker(totalSteps, p.config.Verbose)
+	pt.out = p.out
+	return pt
 }
 
 // ProcessFiles processes multiple argus data files to calculate transmitter location
@@ -205,10 +356,16 @@ func (p *Processor) ProcessFiles(filenames []string) (*Result, error) {
 		totalSteps = 5
 	}
 
-	progress := NewProgressTracker(totalSteps, p.config.Verbose)
+	// Catch captures of different signals from their headers before spending
+	// minutes loading samples
+	if err := checkHeaders(filenames); err != nil {
+		return nil, fmt.Errorf("receiver validation failed: %w", err)
+	}
+
+	progress := p.newProgressTracker(totalSteps)
 
 	// Step 1: Load and validate files
-	progress.StartStep("Loading and validating data files")
+	progress.StartStep(StepLoad)
 	receivers, err := p.loadReceiversWithProgress(filenames, progress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load receivers: %w", err)
@@ -218,25 +375,54 @@ func (p *Processor) ProcessFiles(filenames []string) (*Result, error) {
 	if err := p.validateReceivers(receivers); err != nil {
 		return nil, fmt.Errorf("receiver validation failed: %w", err)
 	}
+
+	// Choose the receiver every other station is correlated against
+	reference, err := p.selectReference(receivers)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(p.out, "   📡 Reference receiver: %s (SNR %.1f dB)\n", receivers[reference].ID, receivers[reference].SNR)
+	p.displayCorrections(receivers, reference)
 	progress.CompleteStep()
 
-	// Step 2: Cross-correlation analysis
-	progress.StartStep("Performing cross-correlation analysis")
-	measurements, err := p.performTDOAAnalysisWithProgress(receivers, progress)
+	// Step 2: Cross-correlation analysis, restricted to the selected segment if any
+	progress.StartStep(StepCorrelate)
+	correlated := receivers
+	if p.segmentSelected() {
+		if correlated, err = p.selectSegment(receivers); err != nil {
+			return nil, err
+		}
+	}
+	measurements, failures, err := p.performTDOAAnalysisWithProgress(correlated, reference, progress)
 	if err != nil {
 		return nil, fmt.Errorf("TDOA analysis failed: %w", err)
 	}
 	progress.CompleteStep()
 
-	// Step 3: Location calculation
+	result, err := p.solve(receivers, measurements, receivers[reference].ID, float64(receivers[0].Metadata.Frequency), progress)
+	if err != nil {
+		return nil, err
+	}
+	result.FailedPairs = failures
+	result.StepTimings = progress.Timings()
+	return result, nil
+}
+
+// solve locates the transmitter from the measurements between receivers and
+// assembles the result, completing the remaining steps of progress
+func (p *Processor) solve(receivers []ReceiverInfo, measurements []TDOAMeasurement, reference string, frequency float64, progress *ProgressTracker) (*Result, error) {
+	// Location calculation
 	progress.StartStep("Calculating transmitter location")
-	location, confidence, errorRadius, err := p.calculateLocationWithProgress(receivers, measurements, progress)
+	location, confidence, errorRadius, method, err := p.calculateLocationWithProgress(receivers, measurements, progress)
 	if err != nil {
 		return nil, fmt.Errorf("location calculation failed: %w", err)
 	}
 	progress.CompleteStep()
 
-	// Step 4: Generate heatmap if requested
+	// Residuals show which baselines disagree with the solution
+	rmsResidual := p.computeResiduals(receivers, measurements, *location)
+
+	// Generate heatmap if requested
 	var heatmapPoints []HeatmapPoint
 	if p.config.Algorithm == "heatmap" || p.config.Verbose {
 		progress.StartStep("Generating probability heatmap")
@@ -248,21 +434,59 @@ func (p *Processor) ProcessFiles(filenames []string) (*Result, error) {
 		Location:          *location,
 		Confidence:        confidence,
 		ErrorRadius:       errorRadius,
-		Algorithm:         p.config.Algorithm,
-		Frequency:         float64(receivers[0].Metadata.Frequency),
+		Algorithm:         method,
+		ReferenceReceiver: reference,
+		Frequency:         frequency,
 		ProcessingTime:    time.Now(),
+		CaptureTime:       referenceCaptureTime(receivers, reference),
 		ReceiverLocations: receivers,
 		TDOAMeasurements:  measurements,
+		RMSResidual:       rmsResidual,
 		HeatmapPoints:     heatmapPoints,
 	}
+	if p.config.Hyperbolas {
+		result.Hyperbolas = p.computeHyperbolas(receivers, measurements)
+	}
+	if p.config.ErrorEllipse {
+		result.ErrorEllipse = p.computeErrorEllipse(receivers, measurements, *location)
+	}
 
 	progress.Finish()
-	fmt.Printf("🎯 Final Result: %.6f°, %.6f° (±%.1fm, confidence: %.2f)\n",
+	fmt.Fprintf(p.out, "🎯 Final Result: %.6f°, %.6f° (±%.1fm, confidence: %.2f)\n",
 		location.Latitude, location.Longitude, errorRadius, confidence)
+	if method == CentroidFallbackAlgorithm {
+		fmt.Fprintf(p.out, "⚠️  WARNING: this location is a confidence-weighted centroid of the receivers, NOT a true TDOA fix\n")
+	}
+	if e := result.ErrorEllipse; e != nil {
+		fmt.Fprintf(p.out, "📐 %.0f%% error ellipse: %.1fm × %.1fm semi-axes, major axis bearing %.0f° (range error %.1fm)\n",
+			e.ConfidenceLevel*100, e.SemiMajor, e.SemiMinor, e.Orientation, e.RangeError)
+		if e.Clamped {
+			fmt.Fprintf(p.out, "⚠️  Error ellipse major axis limited to the %.0f km maximum distance: the geometry barely constrains it\n", p.config.MaxDistance)
+		}
+	} else if p.config.ErrorEllipse {
+		fmt.Fprintf(p.out, "⚠️  Error ellipse needs measurements from at least two independent baselines; mapping the error circle instead\n")
+	}
 
 	return result, nil
 }
 
+// collectionTime returns the UTC collection time recorded in metadata
+func collectionTime(metadata *filewriter.Metadata) *time.Time {
+	t := metadata.CollectionTime.UTC()
+	return &t
+}
+
+// referenceCaptureTime returns the collection time of the reference
+// receiver, dating the data a result was solved from
+func referenceCaptureTime(receivers []ReceiverInfo, reference string) *time.Time {
+	for _, r := range receivers {
+		if r.ID == reference {
+			return r.CollectionTime
+		}
+	}
+	return nil
+}
+
 // loadReceiversWithProgress loads data from all input files with progress reporting
 func (p *Processor) loadReceiversWithProgress(filenames []string, progress *ProgressTracker) ([]ReceiverInfo, error) {
 	return p.loadReceivers(filenames, progress)
@@ -270,7 +494,7 @@ func (p *Processor) loadReceiversWithProgress(filenames []string, progress *Prog
 
 // loadReceivers loads data from all input files and creates receiver information
 func (p *Processor) loadReceivers(filenames []string, progress ...*ProgressTracker) ([]ReceiverInfo, error) {
-	receivers := make([]ReceiverInfo, len(filenames))
+	receivers := make([]ReceiverInfo, 0, len(filenames))
 
 	// Get optional progress tracker
 	var pt *ProgressTracker
@@ -278,6 +502,8 @@ func (p *Processor) loadReceivers(filenames []string, progress ...*ProgressTrack
 		pt = progress[0]
 	}
 
+	assigned := make(map[*ManifestEntry]string) // File each manifest entry was used for
+
 	for i, filename := range filenames {
 		// Update progress
 		if pt != nil {
@@ -285,34 +511,120 @@ func (p *Processor) loadReceivers(filenames []string, progress ...*ProgressTrack
 			pt.UpdateSubProgress(fileProgress, fmt.Sprintf("file %d/%d", i+1, len(filenames)))
 		}
 
+		// The collector records whether a capture holds a signal; one that
+		// does not only adds noise to the correlation, so skip it unread
+		if p.config.MinPeakToNoise > 0 {
+			metadata, _, err := filewriter.ReadMetadata(filename)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read header of %s: %w", filepath.Base(filename), err)
+			}
+			if metadata.SignalMeasured && metadata.PeakToNoise < p.config.MinPeakToNoise {
+				fmt.Fprintf(p.out, "   ⚠️  Skipping %s: no signal stands out, peaks only %.1f dB above its noise floor\n",
+					filepath.Base(filename), metadata.PeakToNoise)
+				continue
+			}
+		}
+
 		// Get file size for progress estimation
 		if fileInfo, err := os.Stat(filename); err == nil {
 			sizeMB := float64(fileInfo.Size()) / (1024 * 1024)
 			if pt == nil { // Only print if no progress tracker (backward compatibility)
-				fmt.Printf("   📁 Loading %s (%.1f MB) (%d/%d)...\n",
+				fmt.Fprintf(p.out, "   📁 Loading %s (%.1f MB) (%d/%d)...\n",
 					filepath.Base(filename), sizeMB, i+1, len(filenames))
 			}
 		} else {
 			if pt == nil {
-				fmt.Printf("   📁 Loading %s (%d/%d)...\n", filepath.Base(filename), i+1, len(filenames))
+				fmt.Fprintf(p.out, "   📁 Loading %s (%d/%d)...\n", filepath.Base(filename), i+1, len(filenames))
 			}
 		}
 
-		// Use progress-aware file reading for large files
-		metadata, samples, err := p.readFileWithProgress(filename)
+		// Use progress-aware file reading for large files, or read just the
+		// correlation window when memory is short
+		var (
+			metadata *filewriter.Metadata
+			samples  []complex64
+			gaps     []filewriter.Gap
+			offset   int
+			err      error
+		)
+		if p.config.LowMemory {
+			metadata, samples, gaps, offset, err = p.readCorrelationWindow(filename)
+		} else {
+			metadata, samples, err = p.readFileWithProgress(filename)
+			if err == nil {
+				gaps = metadata.Gaps
+			}
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
 		}
 
 		if pt == nil {
-			fmt.Printf("      ✅ Loaded %d samples\n", len(samples))
+			fmt.Fprintf(p.out, "      ✅ Loaded %d samples\n", len(samples))
+		}
+
+		// A manifest names every receiver, so an unlisted file is a mistake
+		// rather than a receiver to number automatically
+		var entry *ManifestEntry
+		if len(p.config.Manifest) > 0 {
+			entry = p.manifestEntry(filename)
+			if entry == nil {
+				return nil, fmt.Errorf("%s is not listed in the receiver manifest", filepath.Base(filename))
+			}
+			if other, ok := assigned[entry]; ok {
+				return nil, fmt.Errorf("manifest entry %s (%s) matches both %s and %s",
+					entry.ID, entry.File, filepath.Base(other), filepath.Base(filename))
+			}
+			assigned[entry] = filename
+		}
+
+		// A file collected without GPS has only a placeholder position,
+		// unless the manifest supplies one
+		if metadata.NoPosition && (entry == nil || entry.Location == nil) {
+			fmt.Fprintf(p.out, "   ⚠️  Skipping %s: collected without GPS, no receiver position\n", filepath.Base(filename))
+			continue
+		}
+
+		// The capture still overlaps the others up to where the device was lost
+		if metadata.DeviceLost {
+			fmt.Fprintf(p.out, "   ⚠️  %s ended early: device disconnected during collection\n", filepath.Base(filename))
+		}
+
+		// Repeated data from a USB or driver fault looks like signal and
+		// would pull the correlation peak
+		var stuck filewriter.StuckDetector
+		stuck.Add(samples)
+		stuck.Finish()
+		if stuck.RunCount > 0 {
+			fmt.Fprintf(p.out, "   ⚠️  %s: %.2f%% of samples are stuck in %d run(s) of identical values (longest %d), likely a USB or driver fault\n",
+				filepath.Base(filename), stuck.Fraction()*100, stuck.RunCount, stuck.Longest)
 		}
 
 		// Calculate basic signal metrics
 		snr := p.calculateSNR(samples)
+		power := calculatePower(samples)
+
+		// Put all receivers on a common amplitude scale so differing gains
+		// (or AGC on one station) don't bias the correlation search
+		rms := normalizeRMS(samples)
+
+		// Restore the timing of samples after an overrun by standing silence
+		// in for what the device dropped
+		padding := 0
+		if len(metadata.Gaps) > 0 {
+			var missing time.Duration
+			for _, gap := range metadata.Gaps {
+				missing += gap.Duration
+			}
+			loaded := len(samples)
+			samples = fillGaps(samples, gaps, metadata.SampleRate)
+			padding = len(samples) - loaded
+			fmt.Fprintf(p.out, "   ⚠️  %s has %d gap(s) totalling %v from dropped samples, padded with silence\n",
+				filepath.Base(filename), len(metadata.Gaps), missing)
+		}
 
-		receivers[i] = ReceiverInfo{
-			ID: fmt.Sprintf("R%d", i+1),
+		receivers = append(receivers, ReceiverInfo{
+			ID: fmt.Sprintf("R%d", len(receivers)+1),
 			Location: Location{
 				Latitude:  metadata.GPSLocation.Latitude,
 				Longitude: metadata.GPSLocation.Longitude,
@@ -320,14 +632,49 @@ func (p *Processor) loadReceivers(filenames []string, progress ...*ProgressTrack
 			},
 			Filename: filename,
 			SNR:      snr,
+			RMS:      rms,
+			Power:    power,
 			Metadata: metadata,
 			Samples:  samples,
+
+			SampleOffset:   offset,
+			CollectionTime: collectionTime(metadata),
+			SampleRate:     metadata.SampleRate,
+			ClockOffset:    metadata.ClockOffset,
+			GapPadding:     padding,
+		})
+		receiver := &receivers[len(receivers)-1]
+
+		// Manifest IDs and positions take precedence over file metadata
+		if entry != nil {
+			receiver.ID = entry.ID
+			if entry.Location != nil {
+				receiver.Location = *entry.Location
+			}
 		}
 
 		if p.config.Verbose && pt == nil {
-			fmt.Printf("   %s: %.6f°, %.6f° (SNR: %.1f dB, %d samples)\n",
-				receivers[i].ID, receivers[i].Location.Latitude, receivers[i].Location.Longitude,
-				snr, len(samples))
+			fmt.Fprintf(p.out, "   %s: %.6f°, %.6f° (SNR: %.1f dB, power %.1f dBFS avg, %.1f to %.1f, RMS: %.4f, %d samples)\n",
+				receiver.ID, receiver.Location.Latitude, receiver.Location.Longitude,
+				snr, power.Avg, power.Min, power.Max, rms, len(samples))
+		}
+
+		p.correctIQ(receiver)
+
+		if entry != nil && entry.CalibrationDelay != nil {
+			receiver.CalibrationDelay = *entry.CalibrationDelay
+			if p.config.Verbose {
+				fmt.Fprintf(p.out, "   %s: calibration delay %.1f ns (manifest)\n", receiver.ID, receiver.CalibrationDelay)
+			}
+		} else if len(p.config.Calibration) > 0 {
+			if delay, ok := p.calibrationDelay(receiver.ID, filename); ok {
+				receiver.CalibrationDelay = delay
+				if p.config.Verbose {
+					fmt.Fprintf(p.out, "   %s: calibration delay %.1f ns\n", receiver.ID, delay)
+				}
+			} else {
+				fmt.Fprintf(p.out, "   ⚠️  No calibration delay for %s (%s), assuming 0 ns\n", receiver.ID, StationName(filename))
+			}
 		}
 	}
 
@@ -342,7 +689,7 @@ func (p *Processor) loadReceivers(filenames []string, progress ...*ProgressTrack
 // validateReceivers ensures all receivers have compatible parameters for TDOA
 func (p *Processor) validateReceivers(receivers []ReceiverInfo) error {
 	if len(receivers) < 3 {
-		return fmt.Errorf("need at least 3 receivers")
+		return fmt.Errorf("need at least 3 receivers with a position, got %d", len(receivers))
 	}
 
 	// Check that all receivers have the same frequency and sample rate
@@ -371,19 +718,148 @@ func (p *Processor) validateReceivers(receivers []ReceiverInfo) error {
 		}
 	}
 
-	// Check time synchronization (collection times should be very close)
-	refTime := receivers[0].Metadata.CollectionTime
+	// Check time synchronization (collection times should be very close).
+	// Use GPS-corrected times so a measured system clock offset is taken into account.
+	refTime := receivers[0].Metadata.CorrectedCollectionTime()
 	for i, receiver := range receivers[1:] {
-		timeDiff := receiver.Metadata.CollectionTime.Sub(refTime)
+		timeDiff := receiver.Metadata.CorrectedCollectionTime().Sub(refTime)
 		if math.Abs(timeDiff.Seconds()) > 1.0 { // More than 1 second difference
-			return fmt.Errorf("time sync issue: receiver %d collection time differs by %.1f seconds",
-				i+2, timeDiff.Seconds())
+			return timeOffsetError(i+2, timeDiff)
 		}
 	}
 
 	return nil
 }
 
+// roundOffsetTolerance is how close to a whole number of seconds a collection
+// time difference must be to be taken for a clock error rather than jitter
+const roundOffsetTolerance = 50 * time.Millisecond
+
+// timeOffsetError explains a collection time difference too large to process.
+// Stations that start together differ by a fraction of a second at most, so a
+// difference of a whole number of quarter hours points to a station clock
+// kept in local time, one of a whole number of synced start periods to files
+// from different collections, and one of a whole number of seconds to a clock
+// set by hand or running on GPS time, which is ahead of UTC by the leap seconds.
+func timeOffsetError(receiver int, offset time.Duration) error {
+	abs := offset
+	if abs < 0 {
+		abs = -abs
+	}
+	nearest := func(unit time.Duration) bool {
+		rest := abs % unit
+		return rest <= roundOffsetTolerance || unit-rest <= roundOffsetTolerance
+	}
+
+	switch {
+	case abs >= 15*time.Minute-roundOffsetTolerance && nearest(15*time.Minute):
+		return fmt.Errorf("likely clock or time zone misconfiguration: receiver %d collection time differs by %v, "+
+			"a whole number of quarter hours; check that station's clock runs on UTC rather than local time "+
+			"(on Linux, timedatectl set-local-rtc 0) and is synchronized with NTP or GPS",
+			receiver, offset.Round(time.Second))
+	case abs >= 100*time.Second-roundOffsetTolerance && nearest(100*time.Second):
+		return fmt.Errorf("likely files from different collections: receiver %d collection time differs by %v, "+
+			"a whole number of the 100 second periods --synced-start aligns to; check the files belong to one "+
+			"collection, or start the stations together with --start-time", receiver, offset.Round(time.Second))
+	case nearest(time.Second):
+		return fmt.Errorf("likely clock misconfiguration: receiver %d collection time differs by %v, "+
+			"a whole number of seconds; check that station's clock is synchronized with NTP or GPS and "+
+			"not set by hand or to GPS time, which is ahead of UTC by the leap seconds",
+			receiver, offset.Round(time.Second))
+	default:
+		return fmt.Errorf("time sync issue: receiver %d collection time differs by %.1f seconds, "+
+			"more than start jitter allows; start the stations together with --start-time, or with "+
+			"--synced-start on clocks synchronized with NTP or GPS", receiver, offset.Seconds())
+	}
+}
+
+// checkHeaders reads only the header of each file and reports files whose
+// frequency or sample rate differs from the others, grouping the files by
+// setting so a mixed-up capture is easy to spot
+func checkHeaders(filenames []string) error {
+	type setting struct {
+		frequency  uint64
+		sampleRate uint32
+	}
+	var settings []setting
+	groups := make(map[setting][]string)
+	for _, filename := range filenames {
+		metadata, _, err := filewriter.ReadMetadata(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read header of %s: %w", filepath.Base(filename), err)
+		}
+		key := setting{metadata.Frequency, metadata.SampleRate}
+		if _, seen := groups[key]; !seen {
+			settings = append(settings, key)
+		}
+		groups[key] = append(groups[key], filepath.Base(filename))
+	}
+	if len(settings) <= 1 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("input files do not share one frequency and sample rate:")
+	for _, key := range settings {
+		fmt.Fprintf(&b, "\n  %.3f MHz at %.3f MSps: %s",
+			float64(key.frequency)/1e6, float64(key.sampleRate)/1e6, strings.Join(groups[key], ", "))
+	}
+	return errors.New(b.String())
+}
+
+// powerBlockSamples is the length of the blocks whose power PowerStats
+// ranges over: long enough to average out sample noise, short enough to
+// resolve a burst
+const powerBlockSamples = 1024
+
+// powerFloorDB stands in for the power of silent samples, which has no
+// finite value in dB
+const powerFloorDB = -200.0
+
+// PowerStats summarizes the signal level of a receiver's samples in dB
+// relative to full scale, before amplitude normalization
+type PowerStats struct {
+	Avg float64 `json:"avg_db"` // Mean power of all samples
+	Min float64 `json:"min_db"` // Power of the quietest block of powerBlockSamples
+	Max float64 `json:"max_db"` // Power of the loudest block of powerBlockSamples
+}
+
+// calculatePower measures the mean power of the samples and the range of
+// their power over whole blocks of powerBlockSamples. A capture shorter than
+// one block is its own only block.
+func calculatePower(samples []complex64) PowerStats {
+	if len(samples) == 0 {
+		return PowerStats{Avg: powerFloorDB, Min: powerFloorDB, Max: powerFloorDB}
+	}
+
+	var total, block float64
+	minBlock, maxBlock := math.Inf(1), math.Inf(-1)
+	for i, sample := range samples {
+		block += float64(real(sample))*float64(real(sample)) + float64(imag(sample))*float64(imag(sample))
+		if (i+1)%powerBlockSamples == 0 {
+			minBlock = math.Min(minBlock, block/powerBlockSamples)
+			maxBlock = math.Max(maxBlock, block/powerBlockSamples)
+			total += block
+			block = 0
+		}
+	}
+	total += block
+	avg := total / float64(len(samples))
+	if len(samples) < powerBlockSamples {
+		minBlock, maxBlock = avg, avg
+	}
+
+	return PowerStats{Avg: powerDB(avg), Min: powerDB(minBlock), Max: powerDB(maxBlock)}
+}
+
+// powerDB converts a mean sample power to dB full scale
+func powerDB(power float64) float64 {
+	if power <= 0 {
+		return powerFloorDB
+	}
+	return math.Max(10*math.Log10(power), powerFloorDB)
+}
+
 // calculateSNR estimates the signal-to-noise ratio of the samples
 func (p *Processor) calculateSNR(samples []complex64) float64 {
 	if len(samples) == 0 {
@@ -420,6 +896,54 @@ func (p *Processor) calculateSNR(samples []complex64) float64 {
 	return 0.0
 }
 
+// normalizeRMS scales samples in place to unit RMS amplitude and returns the
+// original RMS. Silent captures are left unchanged.
+func normalizeRMS(samples []complex64) float64 {
+	if len(samples) == 0 {
+		return 0.0
+	}
+
+	var totalPower float64
+	for _, sample := range samples {
+		totalPower += float64(real(sample))*float64(real(sample)) + float64(imag(sample))*float64(imag(sample))
+	}
+	rms := math.Sqrt(totalPower / float64(len(samples)))
+	if rms == 0 {
+		return 0.0
+	}
+
+	scale := float32(1 / rms)
+	for i, sample := range samples {
+		samples[i] = complex(real(sample)*scale, imag(sample)*scale)
+	}
+	return rms
+}
+
+// fillGaps returns samples with zeros inserted for each gap, so every sample
+// sits at its true time from the start of the capture. Gaps must be in order.
+func fillGaps(samples []complex64, gaps []filewriter.Gap, sampleRate uint32) []complex64 {
+	total := int64(len(samples))
+	for _, gap := range gaps {
+		total += gap.Samples(sampleRate)
+	}
+
+	filled := make([]complex64, 0, total)
+	next := int64(0) // First sample of samples not yet copied
+	for _, gap := range gaps {
+		end := gap.SampleIndex
+		if end < next {
+			end = next
+		}
+		if end > int64(len(samples)) {
+			end = int64(len(samples))
+		}
+		filled = append(filled, samples[next:end]...)
+		filled = append(filled, make([]complex64, gap.Samples(sampleRate))...)
+		next = end
+	}
+	return append(filled, samples[next:]...)
+}
+
 // distanceBetweenLocations calculates the distance between two GPS coordinates in meters
 func (p *Processor) distanceBetweenLocations(loc1, loc2 Location) float64 {
 	const R = 6371000 // Earth radius in meters
@@ -437,24 +961,57 @@ func (p *Processor) distanceBetweenLocations(loc1, loc2 Location) float64 {
 	return R * c
 }
 
+// selectReference returns the index of the receiver used as the TDOA reference.
+// An explicit Config.Reference is matched against receiver IDs and then 1-based
+// indices; otherwise the receiver with the highest SNR is chosen, since
+// correlating against the strongest signal gives the most reliable delays.
+func (p *Processor) selectReference(receivers []ReceiverInfo) (int, error) {
+	if len(receivers) == 0 {
+		return 0, fmt.Errorf("no receivers available for reference selection")
+	}
+
+	if p.config.Reference != "" {
+		for i, receiver := range receivers {
+			if strings.EqualFold(receiver.ID, p.config.Reference) {
+				return i, nil
+			}
+		}
+		if index, err := strconv.Atoi(p.config.Reference); err == nil && index >= 1 && index <= len(receivers) {
+			return index - 1, nil
+		}
+		return 0, fmt.Errorf("unknown reference receiver %q (use an ID R1-R%d or an index 1-%d)",
+			p.config.Reference, len(receivers), len(receivers))
+	}
+
+	best := 0
+	for i, receiver := range receivers {
+		if receiver.SNR > receivers[best].SNR {
+			best = i
+		}
+	}
+	return best, nil
+}
+
 // performTDOAAnalysisWithProgress performs cross-correlation analysis with progress reporting
-func (p *Processor) performTDOAAnalysisWithProgress(receivers []ReceiverInfo, progress *ProgressTracker) ([]TDOAMeasurement, error) {
-	return p.performTDOAAnalysis(receivers, progress)
+func (p *Processor) performTDOAAnalysisWithProgress(receivers []ReceiverInfo, reference int, progress *ProgressTracker) ([]TDOAMeasurement, []PairFailure, error) {
+	return p.performTDOAAnalysis(receivers, reference, progress)
 }
 
-// performTDOAAnalysis performs cross-correlation analysis between all receiver pairs using parallel processing
-func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*ProgressTracker) ([]TDOAMeasurement, error) {
+// performTDOAAnalysis correlates every receiver against the reference receiver
+// using parallel processing. Pairs that could not be correlated are returned
+// as failures rather than measurements.
+func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, reference int, progress ...*ProgressTracker) ([]TDOAMeasurement, []PairFailure, error) {
 	// Get optional progress tracker
 	var pt *ProgressTracker
 	if len(progress) > 0 {
 		pt = progress[0]
 	}
 
-	// Calculate total number of pairs
-	totalPairs := len(receivers) * (len(receivers) - 1) / 2
+	// Each non-reference receiver forms one pair with the reference
+	totalPairs := len(receivers) - 1
 	
 	if totalPairs == 0 {
-		return nil, fmt.Errorf("insufficient receivers for TDOA analysis")
+		return nil, nil, fmt.Errorf("insufficient receivers for TDOA analysis")
 	}
 
 	// Determine number of workers
@@ -469,7 +1026,7 @@ func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*P
 	}
 
 	if pt == nil && p.config.Verbose {
-		fmt.Printf("   🧵 Using %d parallel workers for %d receiver pairs\n", numWorkers, totalPairs)
+		fmt.Fprintf(p.out, "   🧵 Using %d parallel workers for %d receiver pairs\n", numWorkers, totalPairs)
 	}
 
 	// Create channels for work distribution
@@ -485,21 +1042,22 @@ func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*P
 		go p.correlationWorker(workChan, resultsChan, &wg)
 	}
 
-	// Generate all receiver pairs and send to work channel
+	// Pair the reference with every other receiver and send to work channel
 	pairCount := 0
-	for i := 0; i < len(receivers); i++ {
-		for j := i + 1; j < len(receivers); j++ {
-			pairCount++
-			pair := ReceiverPair{
-				Index1:  i,
-				Index2:  j,
-				R1:      receivers[i],
-				R2:      receivers[j],
-				PairNum: pairCount,
-				Total:   totalPairs,
-			}
-			workChan <- pair
+	for j := 0; j < len(receivers); j++ {
+		if j == reference {
+			continue
+		}
+		pairCount++
+		pair := ReceiverPair{
+			Index1:  reference,
+			Index2:  j,
+			R1:      receivers[reference],
+			R2:      receivers[j],
+			PairNum: pairCount,
+			Total:   totalPairs,
 		}
+		workChan <- pair
 	}
 	close(workChan) // No more work
 
@@ -512,6 +1070,7 @@ func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*P
 	// Collect results and track progress
 	var measurements []TDOAMeasurement
 	var allMeasurements []TDOAMeasurement
+	failed := make([]*PairFailure, totalPairs) // By pair number, so failures are listed in pair order
 	completedCount := 0
 	
 	for result := range resultsChan {
@@ -525,8 +1084,10 @@ func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*P
 
 		// Handle result
 		if result.Error != nil {
-			if p.config.Verbose && pt == nil {
-				fmt.Printf("⚠️  Cross-correlation failed for %s: %v\n", result.PairID, result.Error)
+			failed[result.PairNum-1] = &PairFailure{
+				Receiver1ID: result.Receiver1ID,
+				Receiver2ID: result.Receiver2ID,
+				Reason:      result.Error.Error(),
 			}
 			continue
 		}
@@ -538,13 +1099,13 @@ func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*P
 		if result.Measurement.Confidence >= p.config.Confidence {
 			measurements = append(measurements, *result.Measurement)
 			if pt == nil && p.config.Verbose {
-				fmt.Printf("      ✅ %s: Δt=%.1fns, Δd=%.1fm, confidence=%.3f\n",
+				fmt.Fprintf(p.out, "      ✅ %s: Δt=%.1fns, Δd=%.1fm, confidence=%.3f\n",
 					result.PairID, result.Measurement.TimeDiff, 
 					result.Measurement.DistanceDiff, result.Measurement.Confidence)
 			}
 		} else {
 			if pt == nil && p.config.Verbose {
-				fmt.Printf("      ⚠️  %s: Low confidence: %.3f (threshold: %.3f) - included in output\n",
+				fmt.Fprintf(p.out, "      ⚠️  %s: Low confidence: %.3f (threshold: %.3f) - included in output\n",
 					result.PairID, result.Measurement.Confidence, p.config.Confidence)
 			}
 		}
@@ -555,18 +1116,29 @@ func (p *Processor) performTDOAAnalysis(receivers []ReceiverInfo, progress ...*P
 		pt.UpdateSubProgress(1.0, fmt.Sprintf("completed %d correlations", totalPairs))
 	}
 
+	// A missing baseline weakens the solve, so say which and why even when
+	// not verbose
+	var failures []PairFailure
+	for _, failure := range failed {
+		if failure == nil {
+			continue
+		}
+		failures = append(failures, *failure)
+		fmt.Fprintf(p.out, "   ⚠️  %s↔%s not measured: %s\n", failure.Receiver1ID, failure.Receiver2ID, failure.Reason)
+	}
+
 	// If no high-confidence measurements, use all measurements but warn user
 	if len(measurements) == 0 {
-		fmt.Printf("⚠️  No TDOA measurements met confidence threshold of %.2f\n", p.config.Confidence)
+		fmt.Fprintf(p.out, "⚠️  No TDOA measurements met confidence threshold of %.2f\n", p.config.Confidence)
 		if len(allMeasurements) > 0 {
-			fmt.Printf("   📍 Using %d low-confidence measurements for approximate location\n", len(allMeasurements))
+			fmt.Fprintf(p.out, "   📍 Using %d low-confidence measurements for approximate location\n", len(allMeasurements))
 			measurements = allMeasurements
 		} else {
-			return nil, fmt.Errorf("no valid TDOA measurements could be calculated")
+			return nil, failures, fmt.Errorf("no valid TDOA measurements could be calculated (%d of %d pairs failed)", len(failures), totalPairs)
 		}
 	}
 
-	return measurements, nil
+	return measurements, failures, nil
 }
 
 // correlationWorker is a worker goroutine that processes receiver pairs from the work channel
@@ -578,7 +1150,7 @@ func (p *Processor) correlationWorker(workChan <-chan ReceiverPair, resultsChan
 		pairID := fmt.Sprintf("%s↔%s", pair.R1.ID, pair.R2.ID)
 		
 		// Perform correlation
-		measurement, err := p.crossCorrelate(pair.R1, pair.R2)
+		measurement, err := p.crossCorrelate(pair.R1, pair.R2, p.pairSearchWindow(pair.R1, pair.R2))
 		
 		// Send result
 		result := CorrelationResult{
@@ -586,53 +1158,105 @@ func (p *Processor) correlationWorker(workChan <-chan ReceiverPair, resultsChan
 			Error:       err,
 			PairNum:     pair.PairNum,
 			PairID:      pairID,
+			Receiver1ID: pair.R1.ID,
+			Receiver2ID: pair.R2.ID,
 		}
 		
 		resultsChan <- result
 	}
 }
 
-// crossCorrelate performs cross-correlation between two receiver signals using multi-resolution search
-func (p *Processor) crossCorrelate(r1, r2 ReceiverInfo) (*TDOAMeasurement, error) {
+// searchWindowMargin is the slack in samples added to the geometric search
+// window, covering sample quantization and small timing errors
+const searchWindowMargin = 8
+
+// pairSearchWindow returns the largest correlation delay in samples that the
+// geometry of two receivers allows: the signal cannot arrive earlier at one
+// than the time it takes to travel the baseline, plus any difference in their
+// calibration delays
+func (p *Processor) pairSearchWindow(r1, r2 ReceiverInfo) int {
+	baseline := p.distanceBetweenLocations(r1.Location, r2.Location)
+	maxDelayNs := baseline/p.config.PropagationSpeed*1e9 + math.Abs(r2.CalibrationDelay-r1.CalibrationDelay)
+	return int(math.Ceil(maxDelayNs*float64(r1.Metadata.SampleRate)/1e9)) + searchWindowMargin
+}
+
+// crossCorrelate performs cross-correlation between two receiver signals using
+// multi-resolution search. maxDelay limits the search to delays of at most that
+// many samples; 0 searches the default window of 10% of the correlation length.
+func (p *Processor) crossCorrelate(r1, r2 ReceiverInfo, maxDelay int) (*TDOAMeasurement, error) {
 	// Ensure we have enough samples
 	minLen := len(r1.Samples)
 	if len(r2.Samples) < minLen {
 		minLen = len(r2.Samples)
 	}
 
-	if minLen < 1000 {
-		return nil, fmt.Errorf("insufficient samples for correlation")
+	if minLen < minCorrelationSamples {
+		return nil, fmt.Errorf("%w: %d samples, at least %d needed", ErrInsufficientSamples, minLen, minCorrelationSamples)
 	}
 
-	// Use first 50000 samples for correlation (increased from 10000 for better accuracy)
+	// Use first maxCorrelationSamples samples for correlation (increased from
+	// 10000 for better accuracy), or all of an explicitly sized segment
 	corrLen := minLen
-	if corrLen > 50000 {
-		corrLen = 50000
+	if corrLen > maxCorrelationSamples && p.config.CorrDuration == 0 {
+		corrLen = maxCorrelationSamples
 	}
 
 	samples1 := r1.Samples[:corrLen]
 	samples2 := r2.Samples[:corrLen]
+	sampleRate := float64(r1.Metadata.SampleRate)
+
+	// Line up the receivers' carriers so the offset does not rotate the
+	// correlation product; magnitudes are unaffected by it
+	frequencyOffset := 0.0
+	if p.config.FreqCorrection && !p.config.Envelope {
+		if offset, ok := estimateFrequencyOffset(samples1, samples2, sampleRate); ok {
+			frequencyOffset = offset
+			samples2 = shiftFrequency(samples2, offset, sampleRate)
+			if p.config.Verbose {
+				fmt.Fprintf(p.out, "         📻 Frequency offset %s→%s: %+.1f Hz, corrected\n", r1.ID, r2.ID, offset)
+			}
+		} else if p.config.Verbose {
+			fmt.Fprintf(p.out, "         📻 Frequency offset %s→%s: no carrier found, not corrected\n", r1.ID, r2.ID)
+		}
+	}
+
+	// Search within 10% of signal length, or less where geometry rules out larger delays
+	window := corrLen / 10
+	if maxDelay > 0 && maxDelay < window {
+		window = maxDelay
+	}
 
 	if p.config.Verbose {
-		fmt.Printf("         🔍 Multi-resolution correlation search (%d samples)...\n", corrLen)
+		mode := "complex"
+		if p.config.Envelope {
+			mode = "envelope"
+		}
+		fmt.Fprintf(p.out, "         🔍 Multi-resolution %s correlation search (%d samples, delays up to ±%d)...\n", mode, corrLen, window)
 	}
 
 	// Perform multi-resolution search for optimal performance
-	bestDelay, maxCorr, err := p.multiResolutionCorrelation(samples1, samples2)
+	bestDelay, maxCorr, err := p.multiResolutionCorrelation(samples1, samples2, window)
 	if err != nil {
 		return nil, fmt.Errorf("correlation failed: %w", err)
 	}
 
 	// Convert sample delay to time delay
-	sampleRate := float64(r1.Metadata.SampleRate)
 	timeDiffNs := float64(bestDelay) * 1e9 / sampleRate
 
-	// Convert time delay to distance difference (speed of light)
-	const speedOfLight = 299792458.0 // m/s
-	distanceDiffM := timeDiffNs * speedOfLight / 1e9
+	// Remove the fixed station delays so only the propagation difference remains
+	timeDiffNs -= r2.CalibrationDelay - r1.CalibrationDelay
+
+	// Convert time delay to distance difference at the propagation speed
+	distanceDiffM := timeDiffNs * p.config.PropagationSpeed / 1e9
 
-	// Calculate confidence based on correlation peak strength
-	confidence := math.Min(math.Abs(maxCorr), 1.0)
+	// Calculate confidence from peak strength, peak-to-sidelobe ratio and overlap length
+	overlap := corrLen - absInt(bestDelay)
+	sidelobe := p.sidelobeLevel(samples1, samples2, bestDelay)
+	confidence, psr := correlationConfidence(maxCorr, sidelobe, overlap)
+
+	if p.config.Verbose {
+		fmt.Fprintf(p.out, "         📐 Peak-to-sidelobe ratio: %.2f, overlap: %d samples, confidence: %.3f\n", psr, overlap, confidence)
+	}
 
 	return &TDOAMeasurement{
 		Receiver1ID:     r1.ID,
@@ -641,13 +1265,83 @@ func (p *Processor) crossCorrelate(r1, r2 ReceiverInfo) (*TDOAMeasurement, error
 		DistanceDiff:    distanceDiffM,
 		Confidence:      confidence,
 		CorrelationPeak: maxCorr,
+		PeakToSidelobe:  psr,
+		OverlapSamples:  overlap,
+		FrequencyOffset: frequencyOffset,
 	}, nil
 }
 
+// sidelobeLevel estimates the strongest correlation away from the main peak by
+// probing delays across the search window, excluding the main lobe
+func (p *Processor) sidelobeLevel(samples1, samples2 []complex64, peakDelay int) float64 {
+	const decimation = 4
+	const probes = 32
+
+	maxSearchDelay := len(samples1) / 10
+	exclusion := max(maxSearchDelay/8, 8*decimation)
+
+	decimated1 := p.decimateSamples(samples1, decimation)
+	decimated2 := p.decimateSamples(samples2, decimation)
+
+	sidelobe := 0.0
+	step := max(1, 2*maxSearchDelay/probes)
+	for delay := -maxSearchDelay; delay <= maxSearchDelay; delay += step {
+		if absInt(delay-peakDelay) <= exclusion {
+			continue
+		}
+		corr := math.Abs(p.calculateCorrelation(decimated1, decimated2, delay/decimation))
+		if corr > sidelobe {
+			sidelobe = corr
+		}
+	}
+
+	return sidelobe
+}
+
+// Confidence model constants
+const (
+	maxPeakToSidelobe     = 100.0 // Cap on the reported peak-to-sidelobe ratio
+	peakSignificanceSigma = 6.0   // Peak height, in noise standard deviations, treated as fully significant
+)
+
+// correlationConfidence returns a bounded 0-1 confidence and the peak-to-sidelobe ratio.
+// Three factors are multiplied:
+//   - the normalized peak height
+//   - 1 - 1/PSR, which is 0 when the peak is no higher than the sidelobes
+//   - the peak's significance against the 1/sqrt(N) correlation noise floor of
+//     the overlap, so short overlaps cannot produce high confidence by chance
+func correlationConfidence(peak, sidelobe float64, overlap int) (float64, float64) {
+	if overlap <= 0 {
+		return 0, 0
+	}
+
+	peak = math.Min(math.Abs(peak), 1.0)
+
+	psr := maxPeakToSidelobe
+	if sidelobe > 0 {
+		psr = math.Min(peak/sidelobe, maxPeakToSidelobe)
+	}
+	psrFactor := 0.0
+	if psr > 1 {
+		psrFactor = 1 - 1/psr
+	}
+
+	significance := peak * math.Sqrt(float64(overlap)) / peakSignificanceSigma
+	significanceFactor := math.Min(significance, 1.0)
+
+	return peak * psrFactor * significanceFactor, psr
+}
+
+// absInt returns the absolute value of an integer
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 // multiResolutionCorrelation performs coarse-to-fine correlation search for optimal performance
-func (p *Processor) multiResolutionCorrelation(samples1, samples2 []complex64) (int, float64, error) {
-	maxSearchDelay := len(samples1) / 10 // Search within 10% of signal length
-	
+func (p *Processor) multiResolutionCorrelation(samples1, samples2 []complex64, maxSearchDelay int) (int, float64, error) {
 	// Stage 1: Coarse search with heavily decimated samples (8x decimation)
 	decimationFactor1 := 8
 	coarseDelay, coarseCorr, err := p.coarseCorrelationSearch(samples1, samples2, decimationFactor1, maxSearchDelay)
@@ -656,30 +1350,30 @@ func (p *Processor) multiResolutionCorrelation(samples1, samples2 []complex64) (
 	}
 
 	if p.config.Verbose {
-		fmt.Printf("         📊 Coarse search: delay=%d, corr=%.4f\n", coarseDelay, coarseCorr)
+		fmt.Fprintf(p.out, "         📊 Coarse search: delay=%d, corr=%.4f\n", coarseDelay, coarseCorr)
 	}
 
 	// Stage 2: Medium resolution search around coarse result (2x decimation)
 	decimationFactor2 := 2
 	searchRange2 := decimationFactor1 * 4 // Search ±32 samples around coarse result
-	mediumDelay, mediumCorr, err := p.refinedCorrelationSearch(samples1, samples2, decimationFactor2, coarseDelay, searchRange2)
+	mediumDelay, mediumCorr, err := p.refinedCorrelationSearch(samples1, samples2, decimationFactor2, coarseDelay, searchRange2, maxSearchDelay)
 	if err != nil {
 		return 0, 0, fmt.Errorf("medium search failed: %w", err)
 	}
 
 	if p.config.Verbose {
-		fmt.Printf("         📊 Medium search: delay=%d, corr=%.4f\n", mediumDelay, mediumCorr)
+		fmt.Fprintf(p.out, "         📊 Medium search: delay=%d, corr=%.4f\n", mediumDelay, mediumCorr)
 	}
 
 	// Stage 3: Fine search at full resolution around medium result
 	searchRange3 := decimationFactor2 * 4 // Search ±8 samples around medium result  
-	fineDelay, fineCorr, err := p.refinedCorrelationSearch(samples1, samples2, 1, mediumDelay, searchRange3)
+	fineDelay, fineCorr, err := p.refinedCorrelationSearch(samples1, samples2, 1, mediumDelay, searchRange3, maxSearchDelay)
 	if err != nil {
 		return 0, 0, fmt.Errorf("fine search failed: %w", err)
 	}
 
 	if p.config.Verbose {
-		fmt.Printf("         📊 Fine search: delay=%d, corr=%.4f\n", fineDelay, fineCorr)
+		fmt.Fprintf(p.out, "         📊 Fine search: delay=%d, corr=%.4f\n", fineDelay, fineCorr)
 	}
 
 	return fineDelay, fineCorr, nil
@@ -718,15 +1412,16 @@ func (p *Processor) coarseCorrelationSearch(samples1, samples2 []complex64, deci
 	}
 
 	if p.config.Verbose {
-		fmt.Printf("         🔎 Coarse: %d correlations at %dx decimation\n", searchCount, decimationFactor)
+		fmt.Fprintf(p.out, "         🔎 Coarse: %d correlations at %dx decimation\n", searchCount, decimationFactor)
 	}
 
 	// Convert back to original sample delay
 	return bestDelay * decimationFactor, maxCorr, nil
 }
 
-// refinedCorrelationSearch performs refined search around a candidate delay
-func (p *Processor) refinedCorrelationSearch(samples1, samples2 []complex64, decimationFactor, centerDelay, searchRange int) (int, float64, error) {
+// refinedCorrelationSearch performs refined search around a candidate delay,
+// never beyond ±maxDelay samples
+func (p *Processor) refinedCorrelationSearch(samples1, samples2 []complex64, decimationFactor, centerDelay, searchRange, maxDelay int) (int, float64, error) {
 	// Decimate samples if needed
 	var searchSamples1, searchSamples2 []complex64
 	if decimationFactor > 1 {
@@ -734,6 +1429,7 @@ func (p *Processor) refinedCorrelationSearch(samples1, samples2 []complex64, dec
 		searchSamples2 = p.decimateSamples(samples2, decimationFactor)
 		centerDelay = centerDelay / decimationFactor
 		searchRange = searchRange / decimationFactor
+		maxDelay = maxDelay / decimationFactor
 	} else {
 		searchSamples1 = samples1
 		searchSamples2 = samples2
@@ -748,7 +1444,7 @@ func (p *Processor) refinedCorrelationSearch(samples1, samples2 []complex64, dec
 	searchCount := 0
 
 	// Search around the center delay
-	for delay := centerDelay - searchRange; delay <= centerDelay + searchRange; delay++ {
+	for delay := max(centerDelay-searchRange, -maxDelay); delay <= min(centerDelay+searchRange, maxDelay); delay++ {
 		corr := p.calculateCorrelation(searchSamples1, searchSamples2, delay)
 		if math.Abs(corr) > math.Abs(maxCorr) {
 			maxCorr = corr
@@ -764,7 +1460,7 @@ func (p *Processor) refinedCorrelationSearch(samples1, samples2 []complex64, dec
 		} else if decimationFactor == 1 {
 			resolution = "fine"
 		}
-		fmt.Printf("         🎯 %s: %d correlations (range ±%d)\n", resolution, searchCount, searchRange)
+		fmt.Fprintf(p.out, "         🎯 %s: %d correlations (range ±%d)\n", resolution, searchCount, searchRange)
 	}
 
 	// Convert back to original sample delay if decimated
@@ -798,7 +1494,10 @@ func max(a, b int) int {
 	return b
 }
 
-// calculateCorrelation calculates normalized cross-correlation at a specific delay
+// calculateCorrelation calculates normalized cross-correlation at a specific delay.
+// With Config.Envelope the magnitudes |sample| are correlated instead, which
+// finds the timing despite a frequency offset between the receivers at the
+// cost of a broader, less precise peak.
 func (p *Processor) calculateCorrelation(sig1, sig2 []complex64, delay int) float64 {
 	if len(sig1) == 0 || len(sig2) == 0 {
 		return 0.0
@@ -836,11 +1535,17 @@ func (p *Processor) calculateCorrelation(sig1, sig2 []complex64, delay int) floa
 	for i := 0; i < overlapLen; i++ {
 		s1 := complex128(sig1[start1+i])
 		s2 := complex128(sig2[start2+i])
+		if p.config.Envelope {
+			// Magnitudes carry no phase, so a carrier frequency offset between
+			// the receivers cannot rotate the product and cancel the sum
+			s1 = complex(math.Hypot(real(s1), imag(s1)), 0)
+			s2 = complex(math.Hypot(real(s2), imag(s2)), 0)
+		}
 
 		sum1 += s1
 		sum2 += s2
-		sum1Sq += s1 * s1
-		sum2Sq += s2 * s2
+		sum1Sq += s1 * complex(real(s1), -imag(s1)) // |s1|^2
+		sum2Sq += s2 * complex(real(s2), -imag(s2)) // |s2|^2
 		sumProduct += s1 * complex(real(s2), -imag(s2)) // Complex conjugate
 	}
 
@@ -865,14 +1570,15 @@ func (p *Processor) calculateCorrelation(sig1, sig2 []complex64, delay int) floa
 }
 
 // calculateLocationWithProgress calculates transmitter location with progress reporting
-func (p *Processor) calculateLocationWithProgress(receivers []ReceiverInfo, measurements []TDOAMeasurement, progress *ProgressTracker) (*Location, float64, float64, error) {
+func (p *Processor) calculateLocationWithProgress(receivers []ReceiverInfo, measurements []TDOAMeasurement, progress *ProgressTracker) (*Location, float64, float64, string, error) {
 	return p.calculateLocation(receivers, measurements, progress)
 }
 
-// calculateLocation calculates transmitter location using TDOA measurements
-func (p *Processor) calculateLocation(receivers []ReceiverInfo, measurements []TDOAMeasurement, progress ...*ProgressTracker) (*Location, float64, float64, error) {
+// calculateLocation calculates transmitter location using TDOA measurements,
+// returning the location, confidence, error radius and the method used
+func (p *Processor) calculateLocation(receivers []ReceiverInfo, measurements []TDOAMeasurement, progress ...*ProgressTracker) (*Location, float64, float64, string, error) {
 	if len(measurements) == 0 {
-		return nil, 0, 0, fmt.Errorf("no TDOA measurements available")
+		return nil, 0, 0, "", fmt.Errorf("no TDOA measurements available")
 	}
 
 	// Get optional progress tracker
@@ -885,10 +1591,10 @@ func (p *Processor) calculateLocation(receivers []ReceiverInfo, measurements []T
 		pt.UpdateSubProgress(0.1, "validating measurements")
 	}
 
-	// Warn if we have fewer than optimal measurements
-	if len(measurements) < 3 {
+	// Warn if some reference pairs were dropped
+	if len(measurements) < len(receivers)-1 {
 		if pt == nil {
-			fmt.Printf("⚠️  Only %d TDOA measurements available (optimal: 3+) - accuracy may be limited\n", len(measurements))
+			fmt.Fprintf(p.out, "⚠️  Only %d of %d TDOA measurements available - accuracy may be limited\n", len(measurements), len(receivers)-1)
 		}
 	}
 
@@ -896,28 +1602,46 @@ func (p *Processor) calculateLocation(receivers []ReceiverInfo, measurements []T
 		pt.UpdateSubProgress(0.3, "calculating centroid")
 	}
 
-	// For this implementation, use a simplified least-squares approach
-	// In practice, this would use more sophisticated algorithms like Newton-Raphson
+	// TODO: Implement proper hyperbolic positioning algorithm. Until then the
+	// location is a centroid of the receivers, weighted by the confidence of
+	// the measurements each receiver takes part in, and is labeled as such.
+	weights := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, m := range measurements {
+		weights[m.Receiver1ID] += m.Confidence
+		weights[m.Receiver2ID] += m.Confidence
+		counts[m.Receiver1ID]++
+		counts[m.Receiver2ID]++
+	}
 
-	// Start with centroid of receivers as initial guess
-	var sumLat, sumLon float64
+	var sumLat, sumLon, sumWeight float64
 	for _, r := range receivers {
-		sumLat += r.Location.Latitude
-		sumLon += r.Location.Longitude
+		if counts[r.ID] == 0 {
+			continue
+		}
+		w := weights[r.ID] / float64(counts[r.ID])
+		sumLat += w * r.Location.Latitude
+		sumLon += w * r.Location.Longitude
+		sumWeight += w
+	}
+
+	// Fall back to the plain centroid if no measurement carries any confidence
+	if sumWeight == 0 {
+		sumLat, sumLon = 0, 0
+		for _, r := range receivers {
+			sumLat += r.Location.Latitude
+			sumLon += r.Location.Longitude
+		}
+		sumWeight = float64(len(receivers))
 	}
 
-	initialLat := sumLat / float64(len(receivers))
-	initialLon := sumLon / float64(len(receivers))
-
 	if pt != nil {
 		pt.UpdateSubProgress(0.6, "estimating location")
 	}
 
-	// For now, return the centroid with estimated error
-	// TODO: Implement proper hyperbolic positioning algorithm
 	location := &Location{
-		Latitude:  initialLat,
-		Longitude: initialLon,
+		Latitude:  sumLat / sumWeight,
+		Longitude: sumLon / sumWeight,
 		Altitude:  0.0, // Ground level assumed
 	}
 
@@ -925,12 +1649,14 @@ func (p *Processor) calculateLocation(receivers []ReceiverInfo, measurements []T
 		pt.UpdateSubProgress(0.8, "calculating confidence")
 	}
 
-	// Calculate average confidence from measurements
+	// Calculate average confidence from measurements, capped because the
+	// centroid does not use the time differences at all
 	var avgConfidence float64
 	for _, m := range measurements {
 		avgConfidence += m.Confidence
 	}
 	avgConfidence /= float64(len(measurements))
+	avgConfidence = math.Min(avgConfidence, centroidMaxConfidence)
 
 	// Estimate error radius based on geometry and confidence
 	errorRadius := p.estimateErrorRadius(receivers, measurements, avgConfidence)
@@ -939,7 +1665,32 @@ func (p *Processor) calculateLocation(receivers []ReceiverInfo, measurements []T
 		pt.UpdateSubProgress(1.0, fmt.Sprintf("location: %.6f°, %.6f°", location.Latitude, location.Longitude))
 	}
 
-	return location, avgConfidence, errorRadius, nil
+	return location, avgConfidence, errorRadius, CentroidFallbackAlgorithm, nil
+}
+
+// computeResiduals sets each measurement's Residual to its observed distance
+// difference minus the one predicted for a transmitter at location, and
+// returns the RMS residual. DistanceDiff is the distance to Receiver2 minus
+// the distance to Receiver1, as a positive correlation delay means the
+// signal reached Receiver2 later.
+func (p *Processor) computeResiduals(receivers []ReceiverInfo, measurements []TDOAMeasurement, location Location) float64 {
+	byID := make(map[string]ReceiverInfo, len(receivers))
+	for _, r := range receivers {
+		byID[r.ID] = r
+	}
+
+	var sumSquares float64
+	for i := range measurements {
+		m := &measurements[i]
+		predicted := p.distanceBetweenLocations(location, byID[m.Receiver2ID].Location) -
+			p.distanceBetweenLocations(location, byID[m.Receiver1ID].Location)
+		m.Residual = m.DistanceDiff - predicted
+		sumSquares += m.Residual * m.Residual
+	}
+	if len(measurements) == 0 {
+		return 0
+	}
+	return math.Sqrt(sumSquares / float64(len(measurements)))
 }
 
 // estimateErrorRadius estimates the positioning error radius
@@ -994,9 +1745,14 @@ func (p *Processor) generateHeatmap(receivers []ReceiverInfo, measurements []TDO
 		pt = progress[0]
 	}
 
-	// Generate grid of points around the center location
-	gridSize := 20                                  // 20x20 grid
-	stepSize := errorRadius * 2 / float64(gridSize) // Grid step in meters
+	// Generate a grid of points around the center location, one at the
+	// center of each cell of a square extent meters wide
+	gridSize := p.config.HeatmapGridSize
+	extent := p.config.HeatmapExtent
+	if extent == 0 {
+		extent = p.config.HeatmapRadii * errorRadius
+	}
+	stepSize := extent / float64(gridSize) // Grid step in meters
 	totalPoints := gridSize * gridSize
 	processedPoints := 0
 
@@ -1015,8 +1771,8 @@ func (p *Processor) generateHeatmap(receivers []ReceiverInfo, measurements []TDO
 			}
 
 			// Calculate offset from center
-			offsetX := (float64(i) - float64(gridSize)/2) * stepSize
-			offsetY := (float64(j) - float64(gridSize)/2) * stepSize
+			offsetX := (float64(i) - float64(gridSize-1)/2) * stepSize
+			offsetY := (float64(j) - float64(gridSize-1)/2) * stepSize
 
 			// Convert meter offsets to lat/lon offsets (approximate)
 			latOffset := offsetY / 111000.0 // Approximate meters per degree latitude
@@ -1048,332 +1804,6 @@ func (p *Processor) generateHeatmap(receivers []ReceiverInfo, measurements []TDO
 	return points
 }
 
-// OptimizedFileReader provides optimized file I/O for argus data files
-type OptimizedFileReader struct {
-	filename string
-	file     *os.File
-	mmap     []byte
-	size     int64
-}
-
-// NewOptimizedFileReader creates a new optimized file reader
-func NewOptimizedFileReader(filename string) (*OptimizedFileReader, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-
-	stat, err := file.Stat()
-	if err != nil {
-		file.Close()
-		return nil, fmt.Errorf("failed to stat file: %w", err)
-	}
-
-	size := stat.Size()
-
-	// Use memory mapping for files larger than 50MB
-	var mmap []byte
-	if size > 50*1024*1024 {
-		// Memory map the entire file for large files
-		mmap, err = syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE)
-		if err != nil {
-			file.Close()
-			return nil, fmt.Errorf("failed to memory map file: %w", err)
-		}
-	}
-
-	return &OptimizedFileReader{
-		filename: filename,
-		file:     file,
-		mmap:     mmap,
-		size:     size,
-	}, nil
-}
-
-// Close closes the file reader and cleans up resources
-func (r *OptimizedFileReader) Close() error {
-	var err error
-	if r.mmap != nil {
-		if unmapErr := syscall.Munmap(r.mmap); unmapErr != nil {
-			err = fmt.Errorf("failed to unmap memory: %w", unmapErr)
-		}
-	}
-	if r.file != nil {
-		if closeErr := r.file.Close(); closeErr != nil {
-			if err != nil {
-				err = fmt.Errorf("multiple errors: %v, %v", err, closeErr)
-			} else {
-				err = closeErr
-			}
-		}
-	}
-	return err
-}
-
-// ReadFile reads an entire argus data file using optimized I/O
-func (r *OptimizedFileReader) ReadFile() (*filewriter.Metadata, []complex64, error) {
-	if r.mmap != nil {
-		return r.readFromMemoryMap()
-	}
-	return r.readWithBufferedIO()
-}
-
-// readFromMemoryMap reads data using memory mapping for maximum performance
-func (r *OptimizedFileReader) readFromMemoryMap() (*filewriter.Metadata, []complex64, error) {
-	data := r.mmap
-	offset := 0
-
-	// Read magic header
-	if len(data) < 5 {
-		return nil, nil, fmt.Errorf("file too small")
-	}
-	if string(data[0:5]) != "ARGUS" {
-		return nil, nil, fmt.Errorf("invalid file format")
-	}
-	offset += 5
-
-	var metadata filewriter.Metadata
-
-	// Read metadata fields using unsafe pointer arithmetic for speed
-	if len(data) < offset+2 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading version")
-	}
-	metadata.FileFormatVersion = *(*uint16)(unsafe.Pointer(&data[offset]))
-	offset += 2
-
-	if len(data) < offset+8 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading frequency")
-	}
-	metadata.Frequency = *(*uint64)(unsafe.Pointer(&data[offset]))
-	offset += 8
-
-	if len(data) < offset+4 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading sample rate")
-	}
-	metadata.SampleRate = *(*uint32)(unsafe.Pointer(&data[offset]))
-	offset += 4
-
-	// Read collection timestamp
-	if len(data) < offset+12 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading collection time")
-	}
-	collectionTimeUnix := *(*int64)(unsafe.Pointer(&data[offset]))
-	offset += 8
-	collectionTimeNano := *(*int32)(unsafe.Pointer(&data[offset]))
-	offset += 4
-	metadata.CollectionTime = time.Unix(collectionTimeUnix, int64(collectionTimeNano))
-
-	// Read GPS location
-	if len(data) < offset+24 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading GPS location")
-	}
-	metadata.GPSLocation.Latitude = *(*float64)(unsafe.Pointer(&data[offset]))
-	offset += 8
-	metadata.GPSLocation.Longitude = *(*float64)(unsafe.Pointer(&data[offset]))
-	offset += 8
-	metadata.GPSLocation.Altitude = *(*float64)(unsafe.Pointer(&data[offset]))
-	offset += 8
-
-	// Read GPS timestamp
-	if len(data) < offset+12 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading GPS timestamp")
-	}
-	gpsTimeUnix := *(*int64)(unsafe.Pointer(&data[offset]))
-	offset += 8
-	gpsTimeNano := *(*int32)(unsafe.Pointer(&data[offset]))
-	offset += 4
-	metadata.GPSTimestamp = time.Unix(gpsTimeUnix, int64(gpsTimeNano))
-
-	// Read device info
-	if len(data) < offset+1 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading device info length")
-	}
-	deviceInfoLen := data[offset]
-	offset += 1
-	if len(data) < offset+int(deviceInfoLen) {
-		return nil, nil, fmt.Errorf("unexpected EOF reading device info")
-	}
-	metadata.DeviceInfo = string(data[offset : offset+int(deviceInfoLen)])
-	offset += int(deviceInfoLen)
-
-	// Read collection ID
-	if len(data) < offset+1 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading collection ID length")
-	}
-	collectionIDLen := data[offset]
-	offset += 1
-	if len(data) < offset+int(collectionIDLen) {
-		return nil, nil, fmt.Errorf("unexpected EOF reading collection ID")
-	}
-	metadata.CollectionID = string(data[offset : offset+int(collectionIDLen)])
-	offset += int(collectionIDLen)
-
-	// Read sample count
-	if len(data) < offset+4 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading sample count")
-	}
-	sampleCount := *(*uint32)(unsafe.Pointer(&data[offset]))
-	offset += 4
-
-	fmt.Printf("      📊 Memory-mapped file, reading %d samples...\n", sampleCount)
-
-	// Read samples directly from memory map for maximum speed
-	if len(data) < offset+int(sampleCount)*8 {
-		return nil, nil, fmt.Errorf("unexpected EOF reading samples")
-	}
-
-	samples := make([]complex64, sampleCount)
-	sampleBytes := data[offset : offset+int(sampleCount)*8]
-
-	// Convert bytes directly to complex64 slice using unsafe operations
-	// This is much faster than reading individual float32 values
-	floatPtr := (*float32)(unsafe.Pointer(&sampleBytes[0]))
-	floatSlice := (*[1 << 30]float32)(unsafe.Pointer(floatPtr))[:sampleCount*2:sampleCount*2]
-
-	for i := uint32(0); i < sampleCount; i++ {
-		real := floatSlice[i*2]
-		imag := floatSlice[i*2+1]
-		samples[i] = complex(real, imag)
-	}
-
-	fmt.Printf("      ✅ Memory-mapped read complete\n")
-
-	return &metadata, samples, nil
-}
-
-// readWithBufferedIO reads data using optimized buffered I/O for smaller files
-func (r *OptimizedFileReader) readWithBufferedIO() (*filewriter.Metadata, []complex64, error) {
-	// Use larger buffer for better performance
-	const bufferSize = 64 * 1024 // 64KB buffer
-	buffer := make([]byte, bufferSize)
-
-	// Read header first
-	if _, err := r.file.Read(buffer[:5]); err != nil {
-		return nil, nil, fmt.Errorf("failed to read magic: %w", err)
-	}
-	if string(buffer[:5]) != "ARGUS" {
-		return nil, nil, fmt.Errorf("invalid file format")
-	}
-
-	var metadata filewriter.Metadata
-
-	// Read metadata using binary.Read for compatibility
-	if err := binary.Read(r.file, binary.LittleEndian, &metadata.FileFormatVersion); err != nil {
-		return nil, nil, err
-	}
-	if err := binary.Read(r.file, binary.LittleEndian, &metadata.Frequency); err != nil {
-		return nil, nil, err
-	}
-	if err := binary.Read(r.file, binary.LittleEndian, &metadata.SampleRate); err != nil {
-		return nil, nil, err
-	}
-
-	var collectionTimeUnix int64
-	var collectionTimeNano int32
-	if err := binary.Read(r.file, binary.LittleEndian, &collectionTimeUnix); err != nil {
-		return nil, nil, err
-	}
-	if err := binary.Read(r.file, binary.LittleEndian, &collectionTimeNano); err != nil {
-		return nil, nil, err
-	}
-	metadata.CollectionTime = time.Unix(collectionTimeUnix, int64(collectionTimeNano))
-
-	if err := binary.Read(r.file, binary.LittleEndian, &metadata.GPSLocation.Latitude); err != nil {
-		return nil, nil, err
-	}
-	if err := binary.Read(r.file, binary.LittleEndian, &metadata.GPSLocation.Longitude); err != nil {
-		return nil, nil, err
-	}
-	if err := binary.Read(r.file, binary.LittleEndian, &metadata.GPSLocation.Altitude); err != nil {
-		return nil, nil, err
-	}
-
-	var gpsTimeUnix int64
-	var gpsTimeNano int32
-	if err := binary.Read(r.file, binary.LittleEndian, &gpsTimeUnix); err != nil {
-		return nil, nil, err
-	}
-	if err := binary.Read(r.file, binary.LittleEndian, &gpsTimeNano); err != nil {
-		return nil, nil, err
-	}
-	metadata.GPSTimestamp = time.Unix(gpsTimeUnix, int64(gpsTimeNano))
-
-	var deviceInfoLen uint8
-	if err := binary.Read(r.file, binary.LittleEndian, &deviceInfoLen); err != nil {
-		return nil, nil, err
-	}
-	deviceInfoBytes := make([]byte, deviceInfoLen)
-	if _, err := r.file.Read(deviceInfoBytes); err != nil {
-		return nil, nil, err
-	}
-	metadata.DeviceInfo = string(deviceInfoBytes)
-
-	var collectionIDLen uint8
-	if err := binary.Read(r.file, binary.LittleEndian, &collectionIDLen); err != nil {
-		return nil, nil, err
-	}
-	collectionIDBytes := make([]byte, collectionIDLen)
-	if _, err := r.file.Read(collectionIDBytes); err != nil {
-		return nil, nil, err
-	}
-	metadata.CollectionID = string(collectionIDBytes)
-
-	var sampleCount uint32
-	if err := binary.Read(r.file, binary.LittleEndian, &sampleCount); err != nil {
-		return nil, nil, err
-	}
-
-	fmt.Printf("      📊 Buffered read, processing %d samples...\n", sampleCount)
-
-	// Read samples in larger chunks for better performance
-	samples := make([]complex64, sampleCount)
-	const samplesPerChunk = 4096 // Read 4096 samples at a time
-	chunkBuffer := make([]byte, samplesPerChunk*8) // 8 bytes per complex64
-
-	var samplesRead uint32
-	lastProgress := -1
-
-	for samplesRead < sampleCount {
-		// Calculate how many samples to read in this chunk
-		samplesToRead := samplesPerChunk
-		if samplesRead+uint32(samplesToRead) > sampleCount {
-			samplesToRead = int(sampleCount - samplesRead)
-		}
-
-		chunkBytes := samplesToRead * 8
-		n, err := r.file.Read(chunkBuffer[:chunkBytes])
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read sample chunk: %w", err)
-		}
-		if n != chunkBytes {
-			return nil, nil, fmt.Errorf("incomplete read: expected %d bytes, got %d", chunkBytes, n)
-		}
-
-		// Convert bytes to complex64 using unsafe operations for speed
-		floatPtr := (*float32)(unsafe.Pointer(&chunkBuffer[0]))
-		floatSlice := (*[1 << 20]float32)(unsafe.Pointer(floatPtr))[:samplesToRead*2:samplesToRead*2]
-
-		for i := 0; i < samplesToRead; i++ {
-			real := floatSlice[i*2]
-			imag := floatSlice[i*2+1]
-			samples[samplesRead+uint32(i)] = complex(real, imag)
-		}
-
-		samplesRead += uint32(samplesToRead)
-
-		// Show progress for large files
-		progress := int((float64(samplesRead) / float64(sampleCount)) * 100)
-		if progress != lastProgress && progress%20 == 0 {
-			fmt.Printf("         Progress: %d%%\n", progress)
-			lastProgress = progress
-		}
-	}
-
-	fmt.Printf("         Progress: 100%%\n")
-
-	return &metadata, samples, nil
-}
-
 // readFileWithProgress reads an argus data file with optimized I/O and progress reporting
 func (p *Processor) readFileWithProgress(filename string) (*filewriter.Metadata, []complex64, error) {
 	// Get file size for strategy selection
@@ -1389,14 +1819,28 @@ func (p *Processor) readFileWithProgress(filename string) (*filewriter.Metadata,
 	}
 
 	// Use optimized reader for larger files
-	reader, err := NewOptimizedFileReader(filename)
+	reader, err := datareader.NewReader(filename)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create optimized reader: %w", err)
 	}
 	defer reader.Close()
+	reader.SetDecodeWorkers(p.config.ParallelWorkers)
 
 	sizeMB := float64(fileSize) / (1024 * 1024)
-	fmt.Printf("      📁 Using optimized I/O for %.1f MB file\n", sizeMB)
+	fmt.Fprintf(p.out, "      📁 Using optimized I/O for %.1f MB file\n", sizeMB)
+	if reader.MemoryMapped() {
+		fmt.Fprintf(p.out, "      📊 Memory-mapped file, reading %d samples...\n", reader.SampleCount())
+	} else if err := reader.MmapError(); err != nil {
+		fmt.Fprintf(p.out, "      📊 Memory mapping unavailable (%v), buffered read of %d samples...\n", err, reader.SampleCount())
+	} else {
+		fmt.Fprintf(p.out, "      📊 Buffered read, processing %d samples...\n", reader.SampleCount())
+	}
+
+	metadata, samples, err := reader.ReadFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Fprintf(p.out, "      ✅ Read complete\n")
 
-	return reader.ReadFile()
+	return metadata, samples, nil
 }
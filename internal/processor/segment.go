@@ -0,0 +1,55 @@
+// Package processor - Restricting correlation to a segment of the captures
+package processor
+
+import (
+	"fmt"
+	"math"
+)
+
+// minSegmentSamples is the shortest segment cross-correlation can use
+const minSegmentSamples = 1000
+
+// segmentSelected reports whether correlation is restricted to a segment
+func (p *Processor) segmentSelected() bool {
+	return p.config.CorrStart > 0 || p.config.CorrDuration > 0
+}
+
+// selectSegment returns copies of the receivers holding only the samples of
+// the correlation segment. The captures start together, so the segment is
+// taken at the same sample offset in each and covers the same stretch of
+// time at every receiver; delays measured within it are the delays between
+// the full captures.
+func (p *Processor) selectSegment(receivers []ReceiverInfo) ([]ReceiverInfo, error) {
+	sampleRate := float64(receivers[0].Metadata.SampleRate)
+	start := int(math.Round(p.config.CorrStart.Seconds() * sampleRate))
+
+	segmented := make([]ReceiverInfo, len(receivers))
+	for i, r := range receivers {
+		// A receiver loaded in low-memory mode holds only its window
+		first := start - r.SampleOffset
+		if first < 0 || first >= len(r.Samples) {
+			return nil, fmt.Errorf("correlation segment starts at %v but receiver %s holds only %.3fs of samples",
+				p.config.CorrStart, r.ID, float64(r.SampleOffset+len(r.Samples))/sampleRate)
+		}
+
+		end := len(r.Samples)
+		if p.config.CorrDuration > 0 {
+			end = min(end, first+int(math.Round(p.config.CorrDuration.Seconds()*sampleRate)))
+		}
+		if end-first < minSegmentSamples {
+			return nil, fmt.Errorf("correlation segment holds only %d samples of receiver %s, need at least %d",
+				end-first, r.ID, minSegmentSamples)
+		}
+
+		r.Samples = r.Samples[first:end]
+		r.SampleOffset = start
+		segmented[i] = r
+	}
+
+	if p.config.Verbose {
+		refTime := receivers[0].Metadata.CorrectedCollectionTime().Add(p.config.CorrStart)
+		fmt.Fprintf(p.out, "   ✂️  Correlating segment from %.3fs (%s UTC), %d samples\n",
+			p.config.CorrStart.Seconds(), refTime.UTC().Format("15:04:05.000"), len(segmented[0].Samples))
+	}
+	return segmented, nil
+}
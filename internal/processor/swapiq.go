@@ -0,0 +1,38 @@
+// Package processor - Correcting captures with swapped I and Q
+package processor
+
+import (
+	"fmt"
+
+	"argus-collector/internal/filewriter"
+)
+
+// swapIQAll selects every receiver in Config.SwapIQ
+const swapIQAll = "all"
+
+// swapsIQ reports whether the receiver's I and Q are to be exchanged,
+// matching Config.SwapIQ entries against its receiver ID and station name
+// as calibration delays are matched
+func (p *Processor) swapsIQ(id, filename string) bool {
+	station := StationName(filename)
+	for _, name := range p.config.SwapIQ {
+		if name == swapIQAll || name == id || name == station {
+			return true
+		}
+	}
+	return false
+}
+
+// correctIQ exchanges I and Q of the receiver's samples if configured.
+// Swapped I and Q conjugate a capture, so correlating it against a correct
+// one pairs a signal with its mirror image.
+func (p *Processor) correctIQ(receiver *ReceiverInfo) {
+	if !p.swapsIQ(receiver.ID, receiver.Filename) {
+		return
+	}
+	filewriter.SwapIQ(receiver.Samples)
+	receiver.SwappedIQ = true
+	if p.config.Verbose {
+		fmt.Fprintf(p.out, "   %s: I and Q swapped on read\n", receiver.ID)
+	}
+}
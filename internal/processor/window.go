@@ -0,0 +1,117 @@
+// Package processor - Loading only the correlation window of each capture
+package processor
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"argus-collector/internal/datareader"
+	"argus-collector/internal/filewriter"
+)
+
+// maxCorrelationSamples is the most samples correlated when no segment
+// length is given
+const maxCorrelationSamples = 50000
+
+// correlationWindow returns the first sample and number of samples of the
+// capture timeline that correlation uses; count is -1 for the rest of the
+// capture
+func (p *Processor) correlationWindow(sampleRate uint32) (start, count int64) {
+	if !p.segmentSelected() {
+		return 0, maxCorrelationSamples
+	}
+	rate := float64(sampleRate)
+	start = int64(math.Round(p.config.CorrStart.Seconds() * rate))
+	if p.config.CorrDuration == 0 {
+		return start, -1
+	}
+	return start, int64(math.Round(p.config.CorrDuration.Seconds() * rate))
+}
+
+// readCorrelationWindow reads only the samples of filename that correlation
+// uses rather than the whole capture. It returns the samples as recorded,
+// the gaps within them indexed from the first sample, and the position of
+// the first sample on the capture timeline (which counts the samples lost
+// in gaps, as fillGaps restores them).
+func (p *Processor) readCorrelationWindow(filename string) (*filewriter.Metadata, []complex64, []filewriter.Gap, int, error) {
+	reader, err := datareader.NewReader(filename)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	defer reader.Close()
+	reader.SetDecodeWorkers(p.config.ParallelWorkers)
+
+	metadata := reader.Metadata()
+	start, count := p.correlationWindow(metadata.SampleRate)
+	fileStart, gaps := windowGaps(metadata.Gaps, metadata.SampleRate, start)
+
+	recorded := int64(reader.SampleCount())
+	if fileStart > recorded {
+		// The window starts after the capture ends; report where it ends
+		start += recorded - fileStart
+		fileStart = recorded
+		gaps = nil
+	}
+	if count < 0 || fileStart+count > recorded {
+		count = recorded - fileStart
+	}
+
+	// Gaps after the window would only pad its end
+	for i, gap := range gaps {
+		if gap.SampleIndex > count {
+			gaps = gaps[:i]
+			break
+		}
+	}
+
+	samples := make([]complex64, count)
+	n, err := reader.ReadSamples(fileStart, samples)
+	if err != nil && err != io.EOF {
+		return nil, nil, nil, 0, err
+	}
+	if int64(n) < count {
+		return nil, nil, nil, 0, fmt.Errorf("%w: expected %d samples from sample %d, got %d",
+			filewriter.ErrTruncated, count, fileStart, n)
+	}
+
+	fmt.Fprintf(p.out, "      📊 Low-memory read of %d of %d samples\n", count, recorded)
+	return metadata, samples, gaps, int(start), nil
+}
+
+// windowGaps maps the capture timeline position start onto the samples as
+// recorded. It returns the recorded sample the window starts at and the
+// gaps from there on, indexed from that sample; when start falls inside a
+// gap the rest of that gap leads the window.
+func windowGaps(gaps []filewriter.Gap, sampleRate uint32, start int64) (int64, []filewriter.Gap) {
+	var shift int64 // Samples lost in gaps before the current one
+	fileStart := int64(-1)
+	var rest []filewriter.Gap
+	for _, gap := range gaps {
+		length := gap.Samples(sampleRate)
+		if fileStart < 0 {
+			gapStart := gap.SampleIndex + shift
+			switch {
+			case start < gapStart:
+				fileStart = start - shift
+			case start < gapStart+length:
+				// Keep the part of the gap after start
+				fileStart = gap.SampleIndex
+				lead := gapStart + length - start
+				rest = append(rest, filewriter.Gap{
+					Duration: time.Duration(math.Round(float64(lead) * 1e9 / float64(sampleRate))),
+				})
+				continue
+			default:
+				shift += length
+				continue
+			}
+		}
+		rest = append(rest, filewriter.Gap{SampleIndex: gap.SampleIndex - fileStart, Duration: gap.Duration})
+	}
+	if fileStart < 0 {
+		fileStart = start - shift
+	}
+	return fileStart, rest
+}
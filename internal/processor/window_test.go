@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"argus-collector/internal/filewriter"
+)
+
+// At 1 kHz a millisecond of gap is one sample. The capture records 100
+// samples, losing 10 after recorded sample 40 and 5 after recorded sample 70:
+//
+//	timeline  0-39  40-49  50-79  80-84  85-114
+//	recorded  0-39  (gap)  40-69  (gap)  70-99
+const testWindowRate = 1000
+
+var testWindowGaps = []filewriter.Gap{
+	{SampleIndex: 40, Duration: 10 * time.Millisecond},
+	{SampleIndex: 70, Duration: 5 * time.Millisecond},
+}
+
+func TestWindowGaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		gaps      []filewriter.Gap
+		start     int64
+		fileStart int64
+		rest      []filewriter.Gap
+	}{
+		{"no gaps", nil, 25, 25, nil},
+		{"capture start", testWindowGaps, 0, 0, testWindowGaps},
+		{"before the first gap", testWindowGaps, 20, 20, []filewriter.Gap{
+			{SampleIndex: 20, Duration: 10 * time.Millisecond},
+			{SampleIndex: 50, Duration: 5 * time.Millisecond},
+		}},
+		{"last sample before a gap", testWindowGaps, 39, 39, []filewriter.Gap{
+			{SampleIndex: 1, Duration: 10 * time.Millisecond},
+			{SampleIndex: 31, Duration: 5 * time.Millisecond},
+		}},
+		{"first sample of a gap", testWindowGaps, 40, 40, []filewriter.Gap{
+			{SampleIndex: 0, Duration: 10 * time.Millisecond},
+			{SampleIndex: 30, Duration: 5 * time.Millisecond},
+		}},
+		{"inside the first gap", testWindowGaps, 45, 40, []filewriter.Gap{
+			{SampleIndex: 0, Duration: 5 * time.Millisecond},
+			{SampleIndex: 30, Duration: 5 * time.Millisecond},
+		}},
+		{"last sample of a gap", testWindowGaps, 49, 40, []filewriter.Gap{
+			{SampleIndex: 0, Duration: 1 * time.Millisecond},
+			{SampleIndex: 30, Duration: 5 * time.Millisecond},
+		}},
+		{"just after the first gap", testWindowGaps, 50, 40, []filewriter.Gap{
+			{SampleIndex: 30, Duration: 5 * time.Millisecond},
+		}},
+		{"inside the second gap", testWindowGaps, 82, 70, []filewriter.Gap{
+			{SampleIndex: 0, Duration: 3 * time.Millisecond},
+		}},
+		{"after every gap", testWindowGaps, 90, 75, nil},
+		{"past the end of the capture", testWindowGaps, 200, 185, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileStart, rest := windowGaps(tt.gaps, testWindowRate, tt.start)
+			if fileStart != tt.fileStart {
+				t.Errorf("recorded start = %d, want %d", fileStart, tt.fileStart)
+			}
+			if !slices.Equal(rest, tt.rest) {
+				t.Errorf("gaps = %v, want %v", rest, tt.rest)
+			}
+		})
+	}
+}
+
+func TestReadCorrelationWindow(t *testing.T) {
+	// Each recorded sample holds its recorded index
+	recorded := make([]complex64, 100)
+	for i := range recorded {
+		recorded[i] = complex(float32(i), 0)
+	}
+	filename := filepath.Join(t.TempDir(), "window.dat")
+	metadata := filewriter.Metadata{
+		Frequency:         433920000,
+		SampleRate:        testWindowRate,
+		CollectionTime:    time.Unix(1700000000, 0),
+		FileFormatVersion: filewriter.FormatVersion2,
+		CollectionID:      "window",
+		Gaps:              testWindowGaps,
+	}
+	if err := filewriter.NewWriter().WriteFile(filename, metadata, recorded); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		corrStart time.Duration
+		corrLen   time.Duration
+		first     int // Recorded index of the first sample read
+		count     int
+		start     int // Timeline position of the first sample
+		gaps      []filewriter.Gap
+	}{
+		{"before a gap", 10 * time.Millisecond, 40 * time.Millisecond, 10, 40, 10, []filewriter.Gap{
+			{SampleIndex: 30, Duration: 10 * time.Millisecond},
+		}},
+		{"inside a gap", 45 * time.Millisecond, 20 * time.Millisecond, 40, 20, 45, []filewriter.Gap{
+			{SampleIndex: 0, Duration: 5 * time.Millisecond},
+		}},
+		{"after the gaps", 90 * time.Millisecond, 10 * time.Millisecond, 75, 10, 90, nil},
+		{"running past the end", 90 * time.Millisecond, 50 * time.Millisecond, 75, 25, 90, nil},
+		{"to the end", 50 * time.Millisecond, 0, 40, 60, 50, []filewriter.Gap{
+			{SampleIndex: 30, Duration: 5 * time.Millisecond},
+		}},
+		{"starting past the end", 200 * time.Millisecond, 0, 100, 0, 115, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProcessor(&Config{MaxDistance: 100, CorrStart: tt.corrStart, CorrDuration: tt.corrLen})
+			if err != nil {
+				t.Fatalf("NewProcessor failed: %v", err)
+			}
+			_, samples, gaps, start, err := p.readCorrelationWindow(filename)
+			if err != nil {
+				t.Fatalf("readCorrelationWindow failed: %v", err)
+			}
+			if !slices.Equal(samples, recorded[tt.first:tt.first+tt.count]) {
+				t.Errorf("read %d samples from %v, want %d from recorded sample %d", len(samples), samples[:min(len(samples), 1)], tt.count, tt.first)
+			}
+			if start != tt.start {
+				t.Errorf("timeline start = %d, want %d", start, tt.start)
+			}
+			if !slices.Equal(gaps, tt.gaps) {
+				t.Errorf("gaps = %v, want %v", gaps, tt.gaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package rtlsdr
+
+import (
+	"fmt"
+	"math"
+)
+
+// Software AGC tuning
+const (
+	DefaultAGCSmoothing = 0.3 // Weight of the newest chunk in the smoothed power estimate
+	agcDeadband         = 0.2 // Relative power error that starts a gain adjustment
+	agcSettleBand       = 0.1 // Relative power error at which an adjustment is complete
+	agcMinChange        = 0.1 // Smallest gain change in dB the tuner can make
+)
+
+// agcLoop is the software AGC control law. It keeps an exponential moving
+// average of the chunk power so a single loud or quiet chunk does not move the
+// gain, and uses a deadband with hysteresis: adjustment starts only when the
+// smoothed power is more than agcDeadband from the target and continues until
+// it is within agcSettleBand, so the gain settles instead of hunting around
+// the edge of the deadband.
+type agcLoop struct {
+	targetPower float64 // Target RMS power (0.0 to 1.0)
+	gainStep    float64 // Gain change in dB for a 100% power error
+	minGain     float64 // Minimum allowed gain in dB
+	maxGain     float64 // Maximum allowed gain in dB
+	smoothing   float64 // Weight of the newest chunk, 1 = no smoothing
+
+	power     float64 // Smoothed power estimate at the current gain, 0 before the first chunk
+	adjusting bool    // Power left the deadband and has not yet settled
+}
+
+// newAGCLoop returns the AGC control law with the default RTL-SDR settings
+func newAGCLoop() agcLoop {
+	return agcLoop{
+		targetPower: 0.7,  // Target 70% of full scale
+		gainStep:    3.0,  // 3 dB steps
+		minGain:     0.0,  // Minimum RTL-SDR gain
+		maxGain:     49.6, // Maximum RTL-SDR gain
+		smoothing:   DefaultAGCSmoothing,
+	}
+}
+
+// reset forgets the power history at the start of a capture
+func (a *agcLoop) reset() {
+	a.power = 0
+	a.adjusting = false
+}
+
+// update records the RMS power of a chunk read at gain and returns the gain
+// to use next, which equals gain when no adjustment is needed
+func (a *agcLoop) update(power, gain float64) float64 {
+	if a.power == 0 {
+		a.power = power
+	} else {
+		a.power += a.smoothing * (power - a.power)
+	}
+
+	relError := (a.targetPower - a.power) / a.targetPower
+	if a.adjusting {
+		a.adjusting = math.Abs(relError) >= agcSettleBand
+	} else {
+		a.adjusting = math.Abs(relError) > agcDeadband
+	}
+	if !a.adjusting {
+		return gain
+	}
+
+	// Proportional step, limited to two steps per chunk
+	adjustment := math.Max(-2*a.gainStep, math.Min(2*a.gainStep, a.gainStep*relError))
+	newGain := math.Max(a.minGain, math.Min(a.maxGain, gain+adjustment))
+	if math.Abs(newGain-gain) < agcMinChange {
+		return gain
+	}
+
+	// The estimate was measured at the old gain; rescale it to the new one so
+	// later chunks are not compared against power the change already removed
+	a.power *= math.Pow(10, (newGain-gain)/20)
+	return newGain
+}
+
+// SetAGCSmoothing sets the weight (0 < alpha <= 1) of each new chunk in the
+// software AGC's smoothed power estimate. Smaller values react more slowly
+// but ignore short bursts; 1 reacts to every chunk on its own.
+func (d *Device) SetAGCSmoothing(alpha float64) error {
+	if alpha <= 0 || alpha > 1 {
+		return fmt.Errorf("invalid AGC smoothing %g (must be greater than 0 and at most 1)", alpha)
+	}
+	d.agc.smoothing = alpha
+	return nil
+}
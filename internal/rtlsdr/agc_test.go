@@ -0,0 +1,111 @@
+package rtlsdr
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// agcChunk is the number of samples in each simulated read
+const agcChunk = 4096
+
+// simulateAGC runs loop against a tuner receiving noise whose amplitude at
+// 0 dB gain is given per chunk by amplitude. It returns the gain in effect
+// for each chunk.
+func simulateAGC(loop *agcLoop, chunks int, amplitude func(chunk int) float64) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]complex64, agcChunk)
+	d := &Device{}
+
+	gains := make([]float64, chunks)
+	gain := (loop.minGain + loop.maxGain) / 2
+	for i := range gains {
+		gains[i] = gain
+		scale := amplitude(i) * math.Pow(10, gain/20) / math.Sqrt2
+		for j := range samples {
+			samples[j] = complex(float32(rng.NormFloat64()*scale), float32(rng.NormFloat64()*scale))
+		}
+		gain = loop.update(d.calculateSignalPower(samples), gain)
+	}
+	return gains
+}
+
+// gainChanges counts the retunes in gains
+func gainChanges(gains []float64) int {
+	changes := 0
+	for i := 1; i < len(gains); i++ {
+		if gains[i] != gains[i-1] {
+			changes++
+		}
+	}
+	return changes
+}
+
+// fluctuating returns a per-chunk amplitude varying ±40% around base, as a
+// fading or bursty signal would
+func fluctuating(base float64) func(int) float64 {
+	rng := rand.New(rand.NewSource(2))
+	return func(int) float64 {
+		return base * (1 + 0.8*(rng.Float64()-0.5))
+	}
+}
+
+func TestAGCSmoothingReducesHunting(t *testing.T) {
+	// About 25 dB of gain brings this input to the target
+	base := 0.7 / math.Pow(10, 25.0/20)
+
+	smoothed := newAGCLoop()
+	smoothedGains := simulateAGC(&smoothed, 300, fluctuating(base))
+
+	unsmoothed := newAGCLoop()
+	unsmoothed.smoothing = 1
+	unsmoothedGains := simulateAGC(&unsmoothed, 300, fluctuating(base))
+
+	// Once converged, the smoothed loop should rarely retune
+	settled, hunting := gainChanges(smoothedGains[100:]), gainChanges(unsmoothedGains[100:])
+	if settled >= hunting {
+		t.Errorf("Smoothed AGC retuned %d times after converging, unsmoothed %d; smoothing should reduce hunting", settled, hunting)
+	}
+	if settled > 10 {
+		t.Errorf("Smoothed AGC retuned %d times in 200 chunks after converging", settled)
+	}
+
+	final := smoothedGains[len(smoothedGains)-1]
+	if math.Abs(final-25) > 2 {
+		t.Errorf("Smoothed AGC settled at %.1f dB, want about 25 dB", final)
+	}
+}
+
+func TestAGCFollowsLevelChange(t *testing.T) {
+	// The input drops by 12 dB halfway through
+	base := 0.7 / math.Pow(10, 20.0/20)
+	level := fluctuating(base)
+	amplitude := func(chunk int) float64 {
+		if chunk >= 150 {
+			return level(chunk) / math.Pow(10, 12.0/20)
+		}
+		return level(chunk)
+	}
+
+	loop := newAGCLoop()
+	gains := simulateAGC(&loop, 300, amplitude)
+
+	if g := gains[149]; math.Abs(g-20) > 2 {
+		t.Errorf("Gain before the level change %.1f dB, want about 20 dB", g)
+	}
+	if g := gains[len(gains)-1]; math.Abs(g-32) > 2 {
+		t.Errorf("Gain after the level change %.1f dB, want about 32 dB", g)
+	}
+}
+
+func TestSetAGCSmoothing(t *testing.T) {
+	d := &Device{}
+	for _, alpha := range []float64{0, -0.1, 1.5} {
+		if err := d.SetAGCSmoothing(alpha); err == nil {
+			t.Errorf("Expected error for smoothing %g", alpha)
+		}
+	}
+	if err := d.SetAGCSmoothing(1); err != nil || d.agc.smoothing != 1 {
+		t.Errorf("Smoothing 1: got %g, %v", d.agc.smoothing, err)
+	}
+}
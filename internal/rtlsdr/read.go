@@ -0,0 +1,71 @@
+package rtlsdr
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default device read settings
+const (
+	DefaultReadChunkBytes = 262144          // Bytes requested per ReadSync call, 64 ms at 2.048 MSps
+	DefaultReadTimeout    = 2 * time.Second // Longest a ReadSync call may take before the device is taken to be stalled
+)
+
+// readSettings controls how samples are read from the device
+type readSettings struct {
+	chunkBytes int           // Bytes requested per read; two per sample
+	timeout    time.Duration // Longest a read may take before the capture ends as overrun
+	swapIQ     bool          // Exchange I and Q of each sample as it is converted
+}
+
+// newReadSettings returns the default read settings
+func newReadSettings() readSettings {
+	return readSettings{
+		chunkBytes: DefaultReadChunkBytes,
+		timeout:    DefaultReadTimeout,
+	}
+}
+
+// ValidateReadChunkBytes checks that n bytes can be requested per read: each
+// sample is an I and a Q byte, so reads must cover whole samples
+func ValidateReadChunkBytes(n int) error {
+	if n <= 0 || n%2 != 0 {
+		return fmt.Errorf("invalid read chunk size %d bytes: must be a positive multiple of 2", n)
+	}
+	return nil
+}
+
+// SetReadChunkBytes sets the number of bytes requested per device read.
+// Larger reads cost fewer calls; smaller ones hand samples to AGC and the
+// sample sink more often and bound the latency the overrun check tolerates.
+func (d *Device) SetReadChunkBytes(n int) error {
+	if err := ValidateReadChunkBytes(n); err != nil {
+		return err
+	}
+	d.read.chunkBytes = n
+	return nil
+}
+
+// SetReadTimeout sets how long a single device read may take before the
+// capture is ended as a buffer overrun, keeping the samples read so far
+func (d *Device) SetReadTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("invalid read timeout %v: must be positive", timeout)
+	}
+	d.read.timeout = timeout
+	return nil
+}
+
+// SetSwapIQ exchanges the I and Q of every sample read, for hardware or
+// drivers that deliver Q first. Swapped IQ mirrors the spectrum about the
+// center frequency and flips the sign of phase-derived measurements.
+func (d *Device) SetSwapIQ(swap bool) {
+	d.read.swapIQ = swap
+}
+
+// swapIQ exchanges the real and imaginary parts of samples in place
+func swapIQ(samples []complex64) {
+	for i, s := range samples {
+		samples[i] = complex(imag(s), real(s))
+	}
+}
@@ -0,0 +1,51 @@
+package rtlsdr
+
+// ringBuffer holds the most recent samples written to it, overwriting the
+// oldest once full. It keeps pre-trigger context while waiting for a trigger.
+type ringBuffer struct {
+	buf  []complex64
+	next int  // Index the next sample is written to
+	full bool // Whether buf has wrapped at least once
+}
+
+// newRingBuffer creates a ring buffer holding up to size samples
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]complex64, size)}
+}
+
+// Write appends samples, discarding the oldest when capacity is exceeded
+func (r *ringBuffer) Write(samples []complex64) {
+	if len(r.buf) == 0 {
+		return
+	}
+	// Only the newest len(buf) samples can survive
+	if len(samples) > len(r.buf) {
+		samples = samples[len(samples)-len(r.buf):]
+	}
+	for len(samples) > 0 {
+		n := copy(r.buf[r.next:], samples)
+		samples = samples[n:]
+		r.next += n
+		if r.next == len(r.buf) {
+			r.next = 0
+			r.full = true
+		}
+	}
+}
+
+// Len returns the number of samples held
+func (r *ringBuffer) Len() int {
+	if r.full {
+		return len(r.buf)
+	}
+	return r.next
+}
+
+// Samples returns a copy of the held samples, oldest first
+func (r *ringBuffer) Samples() []complex64 {
+	out := make([]complex64, 0, r.Len())
+	if r.full {
+		out = append(out, r.buf[r.next:]...)
+	}
+	return append(out, r.buf[:r.next]...)
+}
@@ -0,0 +1,35 @@
+package rtlsdr
+
+import "testing"
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(4)
+
+	r.Write([]complex64{1, 2})
+	if got := r.Samples(); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("before wrapping: got %v", got)
+	}
+
+	// Wrapping keeps only the newest samples, oldest first
+	r.Write([]complex64{3, 4, 5})
+	want := []complex64{2, 3, 4, 5}
+	got := r.Samples()
+	if len(got) != len(want) {
+		t.Fatalf("after wrapping: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("after wrapping: got %v, want %v", got, want)
+		}
+	}
+
+	// A write larger than the buffer keeps its tail
+	r.Write([]complex64{6, 7, 8, 9, 10, 11})
+	want = []complex64{8, 9, 10, 11}
+	got = r.Samples()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("oversized write: got %v, want %v", got, want)
+		}
+	}
+}
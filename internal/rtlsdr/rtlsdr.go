@@ -7,7 +7,9 @@ package rtlsdr
 import (
 	"context"
 	"fmt"
-	"math"
+	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/jpoirier/gortlsdr"
@@ -21,23 +23,30 @@ type Device struct {
 	gain       int             // Current gain in tenths of dB
 	gainMode   string          // Current gain mode: "auto" or "manual"
 	biasTee    bool            // Bias tee enabled state
+	tunerType  string          // Tuner chip, e.g. "R820T"
 	
 	// Software AGC state
 	agcEnabled     bool        // Software AGC enabled
-	agcTargetPower float64     // Target signal power for AGC (0.0 to 1.0)
-	agcGainStep    float64     // Gain adjustment step size in dB
-	agcMaxGain     float64     // Maximum allowed gain in dB
-	agcMinGain     float64     // Minimum allowed gain in dB
+	agc            agcLoop     // AGC control law and its smoothed power estimate
 	agcFinalGain   float64     // Final AGC gain (for summary reporting)
+
+	read readSettings // Read chunk size and stall timeout
 	
 	// Logging control
 	verbose        bool        // Enable verbose logging
+	out            io.Writer   // Receives warnings, progress and AGC messages
+
+	sink     SampleSink      // Receives samples as they are read, nil if unused
+	monitor  PowerMonitor    // Receives the power of each chunk as it is read, nil if unused
+	progress captureProgress // Progress of the running capture, for status displays
 }
 
 // IQSample represents a collected set of IQ samples with timestamp
 type IQSample struct {
-	Timestamp time.Time   // Time when collection started
-	Data      []complex64 // IQ sample data (I=real, Q=imaginary)
+	Timestamp  time.Time   // Time when collection started
+	Data       []complex64 // IQ sample data (I=real, Q=imaginary)
+	DeviceLost bool        // True if the device disconnected and Data ends early
+	Gaps       []Gap       // Samples missing from Data, in order
 }
 
 // NewDevice creates a new RTL-SDR device instance
@@ -62,10 +71,10 @@ func NewDevice(deviceIndex int) (*Device, error) {
 
 	return &Device{
 		dev:            dev,
-		agcTargetPower: 0.7,   // Target 70% of full scale
-		agcGainStep:    3.0,   // 3 dB steps
-		agcMaxGain:     49.6,  // Maximum RTL-SDR gain
-		agcMinGain:     0.0,   // Minimum RTL-SDR gain
+		tunerType:      tunerName(dev.GetTunerType()),
+		agc:            newAGCLoop(),
+		read:           newReadSettings(),
+		out:            os.Stdout,
 	}, nil
 }
 
@@ -95,10 +104,10 @@ func NewDeviceBySerial(serialNumber string) (*Device, error) {
 			}
 			return &Device{
 				dev:            dev,
-				agcTargetPower: 0.7,   // Target 70% of full scale
-				agcGainStep:    3.0,   // 3 dB steps
-				agcMaxGain:     49.6,  // Maximum RTL-SDR gain
-				agcMinGain:     0.0,   // Minimum RTL-SDR gain
+				tunerType:      tunerName(dev.GetTunerType()),
+				agc:            newAGCLoop(),
+				read:           newReadSettings(),
+				out:            os.Stdout,
 			}, nil
 		}
 	}
@@ -115,6 +124,14 @@ func ListDevices() ([]DeviceInfo, error) {
 
 	devices := make([]DeviceInfo, 0, count)
 	for i := 0; i < count; i++ {
+		// The tuner is only reported by an open device. A device already in
+		// use by another process cannot be opened, so its tuner is unknown.
+		tuner := "Unknown"
+		if dev, err := rtlsdr.Open(i); err == nil {
+			tuner = tunerName(dev.GetTunerType())
+			dev.Close()
+		}
+
 		// Get device USB strings
 		manufacturer, product, serial, err := rtlsdr.GetDeviceUsbStrings(i)
 		if err != nil {
@@ -126,6 +143,7 @@ func ListDevices() ([]DeviceInfo, error) {
 				Manufacturer: "Unknown",
 				Product:      "Unknown",
 				SerialNumber: "Unknown",
+				TunerType:    tuner,
 			})
 			continue
 		}
@@ -136,6 +154,7 @@ func ListDevices() ([]DeviceInfo, error) {
 			Manufacturer: manufacturer,
 			Product:      product,
 			SerialNumber: serial,
+			TunerType:    tuner,
 		})
 	}
 
@@ -149,6 +168,17 @@ type DeviceInfo struct {
 	Manufacturer string // USB manufacturer string
 	Product      string // USB product string
 	SerialNumber string // USB serial number string
+	TunerType    string // Tuner chip, e.g. "R820T" or "E4000"
+}
+
+// tunerName shortens a librtlsdr tuner constant such as RTLSDR_TUNER_R820T
+// to the chip name
+func tunerName(tunerType string) string {
+	name := strings.TrimPrefix(tunerType, "RTLSDR_TUNER_")
+	if name == "UNKNOWN" {
+		return "Unknown"
+	}
+	return name
 }
 
 // SetFrequency sets the center frequency of the RTL-SDR device
@@ -166,18 +196,15 @@ func (d *Device) SetFrequency(freq uint32) error {
 func (d *Device) SetSampleRate(rate uint32) error {
 	// Try the requested rate first
 	if err := d.dev.SetSampleRate(int(rate)); err != nil {
-		// If the requested rate fails, try to find a valid rate
-		validRate, fallbackErr := d.findValidSampleRate(rate)
-		if fallbackErr != nil {
-			return fmt.Errorf("failed to set sample rate to %d Hz and no valid fallback found: %w", rate, err)
-		}
+		// If the requested rate fails, fall back to the nearest standard rate
+		validRate := NearestSampleRate(rate)
 
 		// Try the valid rate
 		if err := d.dev.SetSampleRate(int(validRate)); err != nil {
 			return fmt.Errorf("failed to set sample rate to %d Hz (tried fallback %d Hz): %w", rate, validRate, err)
 		}
 
-		fmt.Printf("Warning: Requested sample rate %d Hz not supported, using %d Hz instead\n", rate, validRate)
+		fmt.Fprintf(d.out, "Warning: Requested sample rate %d Hz not supported, using %d Hz instead\n", rate, validRate)
 		d.sampleRate = validRate
 		return nil
 	}
@@ -186,47 +213,6 @@ func (d *Device) SetSampleRate(rate uint32) error {
 	return nil
 }
 
-// findValidSampleRate finds a valid sample rate close to the requested rate
-func (d *Device) findValidSampleRate(requestedRate uint32) (uint32, error) {
-	// Common RTL-SDR supported sample rates (in Hz)
-	validRates := []uint32{
-		250000,  // 250 kHz
-		1024000, // 1.024 MHz
-		1536000, // 1.536 MHz
-		1792000, // 1.792 MHz
-		1920000, // 1.92 MHz
-		2048000, // 2.048 MHz
-		2160000, // 2.16 MHz
-		2560000, // 2.56 MHz
-		2880000, // 2.88 MHz
-		3200000, // 3.2 MHz (maximum for most devices)
-	}
-
-	// Find the closest valid rate
-	var bestRate uint32
-	var minDiff uint32 = ^uint32(0) // Max uint32
-
-	for _, rate := range validRates {
-		var diff uint32
-		if rate > requestedRate {
-			diff = rate - requestedRate
-		} else {
-			diff = requestedRate - rate
-		}
-
-		if diff < minDiff {
-			minDiff = diff
-			bestRate = rate
-		}
-	}
-
-	if bestRate == 0 {
-		return 0, fmt.Errorf("no valid sample rate found")
-	}
-
-	return bestRate, nil
-}
-
 // GetTunerGains returns the list of supported tuner gains in tenths of dB
 func (d *Device) GetTunerGains() ([]int, error) {
 	gains, err := d.dev.GetTunerGains()
@@ -253,13 +239,22 @@ func (d *Device) GetTunerGainsFloat() ([]float64, error) {
 // SetGain sets the tuner gain of the RTL-SDR device
 // gain: gain in dB (decibels)
 func (d *Device) SetGain(gain float64) error {
+	if err := d.setTunerGain(gain); err != nil {
+		return err
+	}
+	d.gainMode = "manual"
+	return nil
+}
+
+// setTunerGain changes the tuner gain without touching the gain mode, so
+// AGC adjustments leave the device in "auto"
+func (d *Device) setTunerGain(gain float64) error {
 	// Convert gain from dB to tenths of dB (RTL-SDR API requirement)
 	gainTenthsDB := int(gain * 10)
 	if err := d.dev.SetTunerGain(gainTenthsDB); err != nil {
 		return fmt.Errorf("failed to set gain to %.1f dB: %w", gain, err)
 	}
 	d.gain = gainTenthsDB
-	d.gainMode = "manual"
 	return nil
 }
 
@@ -273,16 +268,16 @@ func (d *Device) SetGainMode(mode string) error {
 			return fmt.Errorf("failed to enable manual gain control for software AGC: %w", err)
 		}
 		// Set initial gain to middle of the range for AGC starting point
-		initialGain := (d.agcMaxGain + d.agcMinGain) / 2
-		if err := d.SetGain(initialGain); err != nil {
+		initialGain := (d.agc.maxGain + d.agc.minGain) / 2
+		if err := d.setTunerGain(initialGain); err != nil {
 			return fmt.Errorf("failed to set initial AGC gain: %w", err)
 		}
 		d.gainMode = "auto"
 		d.agcEnabled = true
 		d.agcFinalGain = initialGain // Initialize final gain tracking
 		if d.verbose {
-			fmt.Printf("Software AGC enabled (target: %.1f%%, range: %.1f-%.1f dB, step: %.1f dB)\n", 
-				d.agcTargetPower*100, d.agcMinGain, d.agcMaxGain, d.agcGainStep)
+			fmt.Fprintf(d.out, "Software AGC enabled (target: %.1f%%, range: %.1f-%.1f dB, step: %.1f dB, smoothing: %.2f)\n", 
+				d.agc.targetPower*100, d.agc.minGain, d.agc.maxGain, d.agc.gainStep, d.agc.smoothing)
 		}
 	case "manual":
 		// Disable software AGC and enable manual gain control
@@ -322,33 +317,26 @@ func (d *Device) SetVerbose(verbose bool) {
 	d.verbose = verbose
 }
 
+// SetOutput sets where the device writes warnings, progress and AGC messages
+func (d *Device) SetOutput(w io.Writer) {
+	d.out = w
+}
+
 // GetFinalAGCGain returns the final gain value determined by AGC
 func (d *Device) GetFinalAGCGain() float64 {
 	return d.agcFinalGain
 }
 
-// ReportAGCResult reports the final AGC result (only when AGC was used)
-func (d *Device) ReportAGCResult() {
-	if d.agcEnabled && d.gainMode == "auto" {
-		fmt.Printf("AGC converged to %.1f dB gain\n", d.agcFinalGain)
-	}
+// AGCActive reports whether software AGC is controlling the gain
+func (d *Device) AGCActive() bool {
+	return d.agcEnabled && d.gainMode == "auto"
 }
 
-// calculateSignalPower calculates the RMS power of IQ samples
-func (d *Device) calculateSignalPower(samples []complex64) float64 {
-	if len(samples) == 0 {
-		return 0.0
-	}
-	
-	var sumSquares float64
-	for _, sample := range samples {
-		// Calculate magnitude squared (power)
-		magnitude := real(sample)*real(sample) + imag(sample)*imag(sample)
-		sumSquares += float64(magnitude)
+// ReportAGCResult reports the final AGC result (only when AGC was used)
+func (d *Device) ReportAGCResult() {
+	if d.AGCActive() {
+		fmt.Fprintf(d.out, "AGC converged to %.1f dB gain\n", d.agcFinalGain)
 	}
-	
-	// Return RMS power (sqrt of mean of squares)
-	return math.Sqrt(sumSquares / float64(len(samples)))
 }
 
 // adjustGainAGC performs automatic gain control based on signal power
@@ -357,55 +345,22 @@ func (d *Device) adjustGainAGC(samples []complex64) error {
 		return nil
 	}
 	
-	// Calculate current signal power
+	// Feed the chunk power to the control law, which smooths it and holds
+	// the gain while the smoothed power stays near the target
 	currentPower := d.calculateSignalPower(samples)
-	
-	// Calculate power error (how far we are from target)
-	powerError := d.agcTargetPower - currentPower
-	
-	// Only adjust if error is significant (>10% of target)
-	if math.Abs(powerError) < d.agcTargetPower*0.1 {
+	currentGain := d.GetGain()
+	newGain := d.agc.update(currentPower, currentGain)
+	if newGain == currentGain {
 		return nil
 	}
 	
-	// Calculate gain adjustment needed
-	// More power error = larger gain change
-	var gainAdjustment float64
-	if powerError > 0 {
-		// Signal too weak, increase gain
-		gainAdjustment = d.agcGainStep * (powerError / d.agcTargetPower)
-		if gainAdjustment > d.agcGainStep*2 {
-			gainAdjustment = d.agcGainStep * 2 // Limit maximum adjustment
-		}
-	} else {
-		// Signal too strong, decrease gain
-		gainAdjustment = d.agcGainStep * (powerError / d.agcTargetPower)
-		if gainAdjustment < -d.agcGainStep*2 {
-			gainAdjustment = -d.agcGainStep * 2 // Limit maximum adjustment
-		}
+	if err := d.setTunerGain(newGain); err != nil {
+		return fmt.Errorf("AGC gain adjustment failed: %w", err)
 	}
-	
-	// Calculate new gain value
-	currentGain := d.GetGain()
-	newGain := currentGain + gainAdjustment
-	
-	// Clamp to valid range
-	if newGain > d.agcMaxGain {
-		newGain = d.agcMaxGain
-	} else if newGain < d.agcMinGain {
-		newGain = d.agcMinGain
-	}
-	
-	// Only adjust if change is significant
-	if math.Abs(newGain-currentGain) > 0.5 {
-		if err := d.SetGain(newGain); err != nil {
-			return fmt.Errorf("AGC gain adjustment failed: %w", err)
-		}
-		d.agcFinalGain = newGain // Track final gain for summary
-		if d.verbose {
-			fmt.Printf("AGC: Power=%.3f (target=%.3f), Gain: %.1f→%.1f dB\n", 
-				currentPower, d.agcTargetPower, currentGain, newGain)
-		}
+	d.agcFinalGain = newGain // Track final gain for summary
+	if d.verbose {
+		fmt.Fprintf(d.out, "AGC: Power=%.3f (smoothed=%.3f, target=%.3f), Gain: %.1f→%.1f dB\n", 
+			currentPower, d.agc.power, d.agc.targetPower, currentGain, newGain)
 	}
 	
 	return nil
@@ -436,49 +391,214 @@ func (d *Device) GetDeviceInfo() (string, error) {
 	}
 
 	gainInfo := fmt.Sprintf("%.1f dB (%s)", float64(d.gain)/10, d.gainMode)
+	if d.AGCActive() {
+		// Report the gain AGC settled on rather than a mid-adjustment value
+		gainInfo = fmt.Sprintf("%.1f dB (auto), agc: active", d.agcFinalGain)
+	}
 
-	return fmt.Sprintf("%s (freq: %d Hz, rate: %d Hz, gain: %s, bias-tee: %s)",
-		name, d.frequency, d.sampleRate, gainInfo, biasStatus), nil
+	return fmt.Sprintf("%s (tuner: %s, freq: %d Hz, rate: %d Hz, gain: %s, bias-tee: %s)",
+		name, d.tunerType, d.frequency, d.sampleRate, gainInfo, biasStatus), nil
+}
+
+// TunerType returns the tuner chip of the open device, e.g. "R820T"
+func (d *Device) TunerType() string {
+	return d.tunerType
 }
 
 // StartCollection collects IQ samples from RTL-SDR for specified duration
 // duration: how long to collect samples
 // samplesChan: channel to send collected samples to
 func (d *Device) StartCollection(duration time.Duration, samplesChan chan<- IQSample) error {
-	// Create context with timeout to ensure collection stops
-	ctx, cancel := context.WithTimeout(context.Background(), duration)
-	defer cancel()
+	return d.StartCollectionWithContext(context.Background(), duration, samplesChan)
+}
+
+// StartCollectionWithContext collects like StartCollection, but stops early
+// when ctx is cancelled and sends the samples read so far
+func (d *Device) StartCollectionWithContext(ctx context.Context, duration time.Duration, samplesChan chan<- IQSample) error {
 	// Reset RTL-SDR buffer to ensure clean start
 	if err := d.dev.ResetBuffer(); err != nil {
 		return fmt.Errorf("failed to reset buffer: %w", err)
 	}
+	return d.collect(ctx, duration, samplesChan, nil)
+}
+
+// StartCollectionWithPretrigger streams samples into a ring buffer holding the
+// last pretrigger of data until trigger, then collects for duration. The sent
+// IQSample begins with the buffered pre-trigger samples and its Timestamp is
+// the time of the first of them. Cancelling ctx before the trigger discards
+// the buffer; after it, the samples read so far are sent.
+func (d *Device) StartCollectionWithPretrigger(ctx context.Context, trigger time.Time, pretrigger, duration time.Duration, samplesChan chan<- IQSample) error {
+	if err := d.dev.ResetBuffer(); err != nil {
+		return fmt.Errorf("failed to reset buffer: %w", err)
+	}
+
+	ring := newRingBuffer(int(float64(d.sampleRate) * pretrigger.Seconds()))
+	buffer := make([]uint8, d.read.chunkBytes)
+	var converted []complex64
+	for time.Now().Before(trigger) {
+		if ctx.Err() != nil {
+			return fmt.Errorf("pre-trigger buffering cancelled: %w", ctx.Err())
+		}
+		nRead, err := d.dev.ReadSync(buffer, len(buffer))
+		if err != nil {
+			if isDeviceGone(err) {
+				return fmt.Errorf("%w during pre-trigger buffering: %v", ErrDeviceGone, err)
+			}
+			return fmt.Errorf("failed to read pre-trigger samples: %w", err)
+		}
+		converted = appendU8Samples(converted[:0], buffer[:nRead], d.read.swapIQ)
+		ring.Write(converted)
+	}
+
+	// Continue without resetting the buffer so no samples are lost at the trigger
+	return d.collect(ctx, duration, samplesChan, ring.Samples())
+}
+
+// SetSampleSink sets a function that receives the samples of each capture as
+// they are read, e.g. to stream them to disk. A sink error stops further
+// calls for that capture; the capture itself continues. nil removes the sink.
+func (d *Device) SetSampleSink(sink SampleSink) {
+	d.sink = sink
+}
+
+// MeasurePower tunes to freq, reads dwell worth of samples and returns the
+// RMS amplitude within bandwidth/2 of freq (see BandPower), for surveying a
+// band. The first read after retuning is discarded while the tuner settles.
+func (d *Device) MeasurePower(freq uint32, dwell time.Duration, bandwidth float64) (float64, error) {
+	samples, err := d.CaptureSamples(freq, dwell)
+	if err != nil {
+		return 0, err
+	}
+	return BandPower(samples, d.sampleRate, bandwidth), nil
+}
+
+// CaptureSamples tunes to freq and returns dwell worth of samples, for short
+// diagnostic captures at the current gain. The first read after retuning or
+// changing gain is discarded while the tuner settles.
+func (d *Device) CaptureSamples(freq uint32, dwell time.Duration) ([]complex64, error) {
+	if err := d.SetFrequency(freq); err != nil {
+		return nil, err
+	}
+	if err := d.dev.ResetBuffer(); err != nil {
+		return nil, fmt.Errorf("failed to reset buffer: %w", err)
+	}
+
+	n, err := TotalSamples(d.sampleRate, dwell)
+	if err != nil {
+		return nil, err
+	}
+	// USB bulk transfers are made in multiples of 512 bytes
+	size := (int(n)*2 + 511) / 512 * 512
+	buffer := make([]uint8, max(size, measureSettleBytes))
+
+	if _, err := d.dev.ReadSync(buffer[:measureSettleBytes], measureSettleBytes); err != nil {
+		return nil, fmt.Errorf("failed to read samples at %d Hz: %w", freq, err)
+	}
+	nRead, err := d.dev.ReadSync(buffer[:size], size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read samples at %d Hz: %w", freq, err)
+	}
+	if nRead == 0 {
+		return nil, fmt.Errorf("no samples read at %d Hz", freq)
+	}
+
+	return appendU8Samples(make([]complex64, 0, nRead/2), buffer[:nRead], d.read.swapIQ), nil
+}
+
+// measureSettleBytes is how much data CaptureSamples discards after retuning
+const measureSettleBytes = 16384
+
+// appendU8Samples converts unsigned 8-bit IQ pairs (I,Q,I,Q...) as provided by
+// the RTL-SDR to complex64 samples in [-1.0, 1.0] and appends them to dst.
+// swap takes the first byte of each pair as Q.
+func appendU8Samples(dst []complex64, raw []uint8, swap bool) []complex64 {
+	for i := 0; i+1 < len(raw); i += 2 {
+		i_val := (float32(raw[i]) - 127.5) / 127.5
+		q_val := (float32(raw[i+1]) - 127.5) / 127.5
+		if swap {
+			i_val, q_val = q_val, i_val
+		}
+		dst = append(dst, complex(i_val, q_val))
+	}
+	return dst
+}
+
+// collect reads duration worth of samples and sends them, preceded by any
+// pre-trigger samples, on samplesChan. If parent is cancelled first, or the
+// device disconnects after some samples were read, the samples read so far
+// are sent.
+func (d *Device) collect(parent context.Context, duration time.Duration, samplesChan chan<- IQSample, pre []complex64) error {
+	// Create context with timeout to ensure collection stops
+	ctx, cancel := context.WithTimeout(parent, duration)
+	defer cancel()
 
 	// Calculate total samples needed (2 bytes per complex sample)
-	totalSamples := int(d.sampleRate * uint32(duration.Seconds()))
-	chunkSize := 262144 // 256KB chunks for memory efficiency
+	total, err := TotalSamples(d.sampleRate, duration)
+	if err != nil {
+		return err
+	}
+	totalSamples := int(total)
+	chunkSize := d.read.chunkBytes
 	if chunkSize > totalSamples*2 {
 		chunkSize = totalSamples * 2
 	}
 
-	// Pre-allocate slice for all samples
-	allSamples := make([]complex64, 0, totalSamples)
+	// Pre-allocate slice for all samples, starting with the pre-trigger context
+	allSamples := make([]complex64, 0, len(pre)+totalSamples)
+	allSamples = append(allSamples, pre...)
 	buffer := make([]uint8, chunkSize)
 
-	startTime := time.Now()
+	// Record progress and hand samples to the sink as they arrive, dropping
+	// the sink on failure
+	sink := d.sink
+	d.progress.reset()
+	d.agc.reset()
+	feed := func(samples []complex64) {
+		if len(samples) == 0 {
+			return
+		}
+		power, gain := d.calculateSignalPower(samples), d.GetGain()
+		d.progress.add(len(samples), power, gain)
+		if d.monitor != nil {
+			d.monitor(ChunkPower{Time: time.Now(), Samples: len(samples), Power: power, Gain: gain})
+		}
+		if sink == nil {
+			return
+		}
+		if err := sink(samples); err != nil {
+			fmt.Fprintf(d.out, "Warning: sample sink failed, continuing in memory only: %v\n", err)
+			sink = nil
+		}
+	}
+	feed(pre)
+
+	// The saved data starts with the oldest pre-trigger sample
+	preDuration := time.Duration(float64(len(pre)) / float64(d.sampleRate) * float64(time.Second))
+	startTime := time.Now().Add(-preDuration)
 	totalRead := 0
 
 	// Read samples in chunks to manage memory usage
 	zeroReadCount := 0
+	deviceLost := false
 	maxZeroReads := 3                  // Allow up to 3 consecutive zero reads before giving up
-	maxReadInterval := 2 * time.Second // If ReadSync takes longer than 2 seconds, likely hung
+	maxReadInterval := d.read.timeout // If ReadSync takes longer than this, likely hung
+
+	// The device samples at a fixed rate whether or not it is read, so wall
+	// clock time running ahead of the samples received means samples were
+	// dropped. Lags within a couple of chunks are ordinary read latency.
+	var gaps []Gap
+	var missing time.Duration // Total length of the gaps recorded so far
+	readStart := time.Now()
+	chunkDuration := time.Duration(float64(chunkSize/2) / float64(d.sampleRate) * float64(time.Second))
+	gapThreshold := 2 * chunkDuration
 
 readLoop:
 	for totalRead < totalSamples*2 {
 		// Check if context has been cancelled (timeout reached)
 		select {
 		case <-ctx.Done():
-			// Context cancelled, stop collection
-			break
+			// Duration reached or collection cancelled, stop collection
+			break readLoop
 		default:
 		}
 
@@ -510,24 +630,34 @@ readLoop:
 			err = result.err
 		case <-time.After(maxReadInterval):
 			// ReadSync is taking too long - likely buffer overrun
-			fmt.Printf("Warning: RTL-SDR ReadSync timeout after %v (likely buffer overrun), collected %d/%d samples\n",
-				maxReadInterval, len(allSamples), totalSamples)
+			fmt.Fprintf(d.out, "Warning: RTL-SDR ReadSync timeout after %v (likely buffer overrun, see rtlsdr.read_timeout), collected %d/%d samples\n",
+				maxReadInterval, len(allSamples)-len(pre), totalSamples)
 			break readLoop // Exit loop to send collected samples
 		case <-ctx.Done():
-			// Collection duration expired
+			// Collection duration expired or cancelled
 			break readLoop // Exit loop to send collected samples
 		}
 
 		if err != nil {
-			return fmt.Errorf("failed to read samples: %w", err)
+			if !isDeviceGone(err) {
+				return fmt.Errorf("failed to read samples: %w", err)
+			}
+			if len(allSamples) == len(pre) {
+				return fmt.Errorf("%w before any samples were read: %v", ErrDeviceGone, err)
+			}
+			// Keep what was read before the device went away
+			fmt.Fprintf(d.out, "Warning: RTL-SDR read failed (%v), device disconnected? Collected %d/%d samples\n",
+				err, len(allSamples)-len(pre), totalSamples)
+			deviceLost = true
+			break readLoop
 		}
 
 		if nRead == 0 {
 			zeroReadCount++
 			if zeroReadCount >= maxZeroReads {
 				// RTL-SDR buffer likely overrun - exit gracefully with collected samples
-				fmt.Printf("Warning: RTL-SDR stopped providing data (likely buffer overrun), collected %d/%d samples\n",
-					len(allSamples), totalSamples)
+				fmt.Fprintf(d.out, "Warning: RTL-SDR stopped providing data (likely buffer overrun), collected %d/%d samples\n",
+					len(allSamples)-len(pre), totalSamples)
 				break
 			}
 			continue
@@ -537,28 +667,29 @@ readLoop:
 		zeroReadCount = 0
 
 		// Report progress every 2 seconds worth of data
-		if len(allSamples) > 0 && len(allSamples)%(int(d.sampleRate)*2) == 0 {
-			fmt.Printf("Progress: collected %d/%d samples (%.1f seconds)\n",
-				len(allSamples), totalSamples, float64(len(allSamples))/float64(d.sampleRate))
+		if collected := len(allSamples) - len(pre); collected > 0 && collected%(int(d.sampleRate)*2) == 0 {
+			fmt.Fprintf(d.out, "Progress: collected %d/%d samples (%.1f seconds)\n",
+				collected, totalSamples, float64(collected)/float64(d.sampleRate))
 		}
 
 		// Convert raw bytes to complex64 samples and store chunk for AGC
-		// RTL-SDR provides unsigned 8-bit IQ pairs (I,Q,I,Q...)
 		chunkStart := len(allSamples)
-		for i := 0; i < nRead; i += 2 {
-			if i+1 < nRead {
-				// Convert unsigned 8-bit to signed float [-1.0, 1.0]
-				i_val := (float32(buffer[i]) - 127.5) / 127.5
-				q_val := (float32(buffer[i+1]) - 127.5) / 127.5
-				allSamples = append(allSamples, complex(i_val, q_val))
-			}
+		allSamples = appendU8Samples(allSamples, buffer[:nRead], d.read.swapIQ)
+		feed(allSamples[chunkStart:])
+
+		received := time.Duration(float64(len(allSamples)-len(pre)) / float64(d.sampleRate) * float64(time.Second))
+		if lag := time.Since(readStart) - received - missing; lag > gapThreshold {
+			gaps = append(gaps, Gap{SampleIndex: int64(chunkStart), Duration: lag})
+			missing += lag
+			fmt.Fprintf(d.out, "Warning: RTL-SDR dropped about %v of samples before sample %d (likely buffer overrun), gap recorded in metadata\n",
+				lag.Round(time.Millisecond), chunkStart)
 		}
 
 		// Perform AGC adjustment based on this chunk of samples
 		if d.agcEnabled && len(allSamples) > chunkStart {
 			chunkSamples := allSamples[chunkStart:]
 			if err := d.adjustGainAGC(chunkSamples); err != nil {
-				fmt.Printf("AGC adjustment error: %v\n", err)
+				fmt.Fprintf(d.out, "AGC adjustment error: %v\n", err)
 			}
 		}
 
@@ -568,8 +699,10 @@ readLoop:
 	// Send collected samples through channel
 	select {
 	case samplesChan <- IQSample{
-		Timestamp: startTime,
-		Data:      allSamples,
+		Timestamp:  startTime,
+		Data:       allSamples,
+		DeviceLost: deviceLost,
+		Gaps:       gaps,
 	}:
 	default:
 		return fmt.Errorf("samples channel is full")
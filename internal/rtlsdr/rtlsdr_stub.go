@@ -5,7 +5,12 @@
 package rtlsdr
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
 	"time"
 )
 
@@ -19,17 +24,52 @@ type Device struct {
 	
 	// Software AGC state (stub)
 	agcEnabled     bool    // Software AGC enabled (stub)
-	agcTargetPower float64 // Target signal power (stub)
+	agc            agcLoop // AGC settings (stub)
 	agcFinalGain   float64 // Final AGC gain (stub)
+
+	read readSettings // Read chunk size and stall timeout (stub: chunk size only)
 	
 	// Logging control (stub)
 	verbose        bool    // Enable verbose logging (stub)
+	out            io.Writer // Receives warnings and AGC messages
+
+	sink     SampleSink      // Receives samples as they are produced, nil if unused
+	monitor  PowerMonitor    // Receives the power of each chunk as it is produced, nil if unused
+	progress captureProgress // Progress of the running capture, for status displays
+
+	synthetic *SyntheticSignal // Generated in place of the constant test pattern, nil if unused
+
+	disconnectAfter time.Duration // Capture time after which a simulated unplug ends the capture, 0 if never
+
+	overrunAt     time.Duration // Capture time at which a simulated overrun drops samples
+	overrunLength time.Duration // Length of the samples dropped by the simulated overrun, 0 if none
+}
+
+// SyntheticSignal describes a deterministic test signal for the stub to
+// generate instead of its constant test pattern. The waveform depends only on
+// Kind, Seed and the sample's time from the capture start, so stub devices
+// given the same signal with different offsets see the same transmission
+// arriving at different times, as receivers at different distances would.
+type SyntheticSignal struct {
+	Kind      string        // "noise" (default, band-limited pseudo-random) or "chirp" (repeating linear sweep)
+	Seed      uint64        // Selects the noise waveform; equal seeds give equal waveforms
+	Offset    time.Duration // Arrival delay of the signal at this device
+	Amplitude float64       // Peak amplitude, 0 = 0.5
 }
 
+// Synthetic signal shape parameters
+const (
+	noiseKnotSpacing = 128                   // Samples between random noise values; sets the bandwidth
+	chirpPeriod      = 10 * time.Millisecond // Length of one chirp sweep
+	chirpSpan        = 1.0 / 32              // Chirp sweep width as a fraction of the sample rate
+)
+
 // IQSample represents a stub IQ sample structure (matches real implementation)
 type IQSample struct {
-	Timestamp time.Time   // Time when collection would have started
-	Data      []complex64 // Empty sample data
+	Timestamp  time.Time   // Time when collection would have started
+	Data       []complex64 // Empty sample data
+	DeviceLost bool        // True if the simulated device disconnected and Data ends early
+	Gaps       []Gap       // Samples missing from Data, in order
 }
 
 // NewDevice creates a stub RTL-SDR device for testing
@@ -40,7 +80,9 @@ func NewDevice(deviceIndex int) (*Device, error) {
 		gain:           207,       // Default gain (20.7 dB in tenths)
 		gainMode:       "manual",  // Default to manual gain
 		biasTee:        false,     // Default bias tee off
-		agcTargetPower: 0.7,       // Target 70% of full scale
+		agc:            newAGCLoop(),
+		read:           newReadSettings(),
+		out:            os.Stdout,
 		agcFinalGain:   20.7,      // Default final gain
 	}, nil
 }
@@ -53,7 +95,9 @@ func NewDeviceBySerial(serialNumber string) (*Device, error) {
 		gain:           207,       // Default gain (20.7 dB in tenths)
 		gainMode:       "manual",  // Default to manual gain
 		biasTee:        false,     // Default bias tee off
-		agcTargetPower: 0.7,       // Target 70% of full scale
+		agc:            newAGCLoop(),
+		read:           newReadSettings(),
+		out:            os.Stdout,
 		agcFinalGain:   20.7,      // Default final gain
 	}, nil
 }
@@ -67,6 +111,7 @@ func ListDevices() ([]DeviceInfo, error) {
 			Manufacturer: "Stub Corp",
 			Product:      "RTL-SDR Stub",
 			SerialNumber: "00000001",
+			TunerType:    "R820T",
 		},
 		{
 			Index:        1,
@@ -74,6 +119,7 @@ func ListDevices() ([]DeviceInfo, error) {
 			Manufacturer: "Stub Corp",
 			Product:      "RTL-SDR Stub",
 			SerialNumber: "00000002",
+			TunerType:    "R820T",
 		},
 	}, nil
 }
@@ -85,6 +131,7 @@ type DeviceInfo struct {
 	Manufacturer string // USB manufacturer string
 	Product      string // USB product string
 	SerialNumber string // USB serial number string
+	TunerType    string // Tuner chip, e.g. "R820T" or "E4000"
 }
 
 // SetFrequency stub method - stores frequency setting
@@ -96,27 +143,9 @@ func (d *Device) SetFrequency(freq uint32) error {
 // SetSampleRate stub method - stores sample rate setting with validation
 func (d *Device) SetSampleRate(rate uint32) error {
 	// Simulate the same validation as real implementation
-	validRates := []uint32{
-		250000, 1024000, 1536000, 1792000, 1920000,
-		2048000, 2160000, 2560000, 2880000, 3200000,
-	}
-
-	// Check if requested rate is valid
-	isValid := false
-	for _, validRate := range validRates {
-		if rate == validRate {
-			isValid = true
-			break
-		}
-	}
-
-	if !isValid {
-		// Find closest valid rate
-		bestRate, err := d.findValidSampleRate(rate)
-		if err != nil {
-			return fmt.Errorf("failed to set sample rate to %d Hz: %w", rate, err)
-		}
-		fmt.Printf("Warning: Requested sample rate %d Hz not supported, using %d Hz instead\n", rate, bestRate)
+	if !slices.Contains(supportedSampleRates, rate) {
+		bestRate := NearestSampleRate(rate)
+		fmt.Fprintf(d.out, "Warning: Requested sample rate %d Hz not supported, using %d Hz instead\n", rate, bestRate)
 		d.sampleRate = bestRate
 	} else {
 		d.sampleRate = rate
@@ -125,37 +154,6 @@ func (d *Device) SetSampleRate(rate uint32) error {
 	return nil
 }
 
-// findValidSampleRate finds a valid sample rate close to the requested rate (stub version)
-func (d *Device) findValidSampleRate(requestedRate uint32) (uint32, error) {
-	validRates := []uint32{
-		250000, 1024000, 1536000, 1792000, 1920000,
-		2048000, 2160000, 2560000, 2880000, 3200000,
-	}
-
-	var bestRate uint32
-	var minDiff uint32 = ^uint32(0)
-
-	for _, rate := range validRates {
-		var diff uint32
-		if rate > requestedRate {
-			diff = rate - requestedRate
-		} else {
-			diff = requestedRate - rate
-		}
-
-		if diff < minDiff {
-			minDiff = diff
-			bestRate = rate
-		}
-	}
-
-	if bestRate == 0 {
-		return 0, fmt.Errorf("no valid sample rate found")
-	}
-
-	return bestRate, nil
-}
-
 // GetTunerGains stub method - returns typical RTL-SDR gains in tenths of dB
 func (d *Device) GetTunerGains() ([]int, error) {
 	// Typical RTL-SDR gains in tenths of dB
@@ -191,7 +189,7 @@ func (d *Device) SetGainMode(mode string) error {
 		d.agcEnabled = true
 		d.agcFinalGain = 24.8 // Simulate AGC final gain for stub
 		if d.verbose {
-			fmt.Printf("Software AGC enabled (stub mode - target: %.1f%%, simulating gain adjustments)\n", d.agcTargetPower*100)
+			fmt.Fprintf(d.out, "Software AGC enabled (stub mode - target: %.1f%%, simulating gain adjustments)\n", d.agc.targetPower*100)
 		}
 		return nil
 	case "manual":
@@ -227,15 +225,25 @@ func (d *Device) SetVerbose(verbose bool) {
 	d.verbose = verbose
 }
 
+// SetOutput sets where the device writes warnings and AGC messages
+func (d *Device) SetOutput(w io.Writer) {
+	d.out = w
+}
+
 // GetFinalAGCGain stub method - returns the final gain value determined by AGC
 func (d *Device) GetFinalAGCGain() float64 {
 	return d.agcFinalGain
 }
 
+// AGCActive stub method - reports whether software AGC is controlling the gain
+func (d *Device) AGCActive() bool {
+	return d.agcEnabled && d.gainMode == "auto"
+}
+
 // ReportAGCResult stub method - reports the final AGC result
 func (d *Device) ReportAGCResult() {
-	if d.agcEnabled && d.gainMode == "auto" {
-		fmt.Printf("AGC converged to %.1f dB gain\n", d.agcFinalGain)
+	if d.AGCActive() {
+		fmt.Fprintf(d.out, "AGC converged to %.1f dB gain\n", d.agcFinalGain)
 	}
 }
 
@@ -253,33 +261,102 @@ func (d *Device) GetDeviceInfo() (string, error) {
 	}
 
 	gainInfo := fmt.Sprintf("%.1f dB (%s)", float64(d.gain)/10, d.gainMode)
+	if d.AGCActive() {
+		gainInfo = fmt.Sprintf("%.1f dB (auto), agc: active", d.agcFinalGain)
+	}
 
-	return fmt.Sprintf("RTL-SDR Stub Device (freq: %d Hz, rate: %d Hz, gain: %s, bias-tee: %s)",
-		d.frequency, d.sampleRate, gainInfo, biasStatus), nil
+	return fmt.Sprintf("RTL-SDR Stub Device (tuner: %s, freq: %d Hz, rate: %d Hz, gain: %s, bias-tee: %s)",
+		d.TunerType(), d.frequency, d.sampleRate, gainInfo, biasStatus), nil
+}
+
+// TunerType stub method - reports the most common tuner
+func (d *Device) TunerType() string {
+	return "R820T"
 }
 
 // StartCollection stub method - simulates collection for testing with proper timeout handling
 func (d *Device) StartCollection(duration time.Duration, samplesChan chan<- IQSample) error {
+	return d.StartCollectionWithContext(context.Background(), duration, samplesChan)
+}
+
+// StartCollectionWithContext stub method - simulates collection, sending
+// samples only for the time elapsed if ctx is cancelled early or a simulated
+// disconnect ends the capture
+func (d *Device) StartCollectionWithContext(ctx context.Context, duration time.Duration, samplesChan chan<- IQSample) error {
 	startTime := time.Now()
 
 	// Generate fake sample data for testing
-	totalSamples := int(d.sampleRate * uint32(duration.Seconds()))
-	fakeSamples := make([]complex64, totalSamples)
-
-	// Fill with simple test pattern
-	for i := range fakeSamples {
-		fakeSamples[i] = complex(0.1, 0.1) // Simple test signal
+	totalSamples, err := TotalSamples(d.sampleRate, duration)
+	if err != nil {
+		return err
 	}
 
 	// Simulate the real hardware behavior: collect for the duration, then send data
 	// This matches how the real RTL-SDR works - it collects samples over time
-	time.Sleep(duration)
+	d.progress.reset()
+	pattern := make([]complex64, 1024)
+	d.fillSamples(pattern, 0, complex(0.1, 0.1))
+	power := d.calculateSignalPower(pattern)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	done := time.After(duration)
+	var unplugged <-chan time.Time // Never fires unless a disconnect is simulated
+	if d.disconnectAfter > 0 {
+		unplugged = time.After(d.disconnectAfter)
+	}
+	deviceLost := false
+wait:
+	for {
+		select {
+		case <-ticker.C:
+			// Report the samples the hardware would have read by now
+			read := min(totalSamples, int64(float64(d.sampleRate)*time.Since(startTime).Seconds()))
+			d.progress.add(int(read-d.progress.samples.Load()), power, d.GetGain())
+		case <-done:
+			break wait
+		case <-ctx.Done():
+			// Interrupted: keep what the hardware would have read so far
+			totalSamples = min(totalSamples, int64(float64(d.sampleRate)*time.Since(startTime).Seconds()))
+			break wait
+		case <-unplugged:
+			// Unplugged: keep what was read before the device went away
+			totalSamples = min(totalSamples, int64(float64(d.sampleRate)*d.disconnectAfter.Seconds()))
+			if totalSamples == 0 {
+				return fmt.Errorf("%w before any samples were read", ErrDeviceGone)
+			}
+			fmt.Fprintf(d.out, "Warning: RTL-SDR read failed (simulated disconnect), device disconnected? Collected %d samples\n", totalSamples)
+			deviceLost = true
+			break wait
+		}
+	}
+	d.progress.add(int(totalSamples-d.progress.samples.Load()), power, d.GetGain())
+
+	// An overrun loses the samples the device took while it was not read, so
+	// the capture holds fewer samples than its duration and those after the
+	// gap come from later than their index implies
+	var gaps []Gap
+	gapStart := int64(float64(d.sampleRate) * d.overrunAt.Seconds())
+	dropped := int64(float64(d.sampleRate) * d.overrunLength.Seconds())
+	if dropped > 0 && gapStart < totalSamples-dropped {
+		gaps = append(gaps, Gap{SampleIndex: gapStart, Duration: d.overrunLength})
+		fmt.Fprintf(d.out, "Warning: RTL-SDR dropped about %v of samples before sample %d (simulated overrun), gap recorded in metadata\n",
+			d.overrunLength, gapStart)
+	} else {
+		gapStart, dropped = totalSamples, 0
+	}
+
+	fakeSamples := make([]complex64, totalSamples-dropped)
+	d.fillSamples(fakeSamples[:gapStart], 0, complex(0.1, 0.1)) // Simple test signal
+	d.fillSamples(fakeSamples[gapStart:], gapStart+dropped, complex(0.1, 0.1))
+	d.feedSink(fakeSamples, startTime)
 
 	// Send the fake samples after collection completes (like real hardware)
 	select {
 	case samplesChan <- IQSample{
-		Timestamp: startTime,
-		Data:      fakeSamples,
+		Timestamp:  startTime,
+		Data:       fakeSamples,
+		DeviceLost: deviceLost,
+		Gaps:       gaps,
 	}:
 		return nil
 	default:
@@ -287,6 +364,190 @@ func (d *Device) StartCollection(duration time.Duration, samplesChan chan<- IQSa
 	}
 }
 
+// SetSampleSink stub method - sets the function receiving capture samples
+func (d *Device) SetSampleSink(sink SampleSink) {
+	d.sink = sink
+}
+
+// feedSink stub helper - passes samples to the sink and their power to the
+// power monitor in read-sized chunks, as if read from start on
+func (d *Device) feedSink(samples []complex64, start time.Time) {
+	chunk := d.read.chunkBytes / 2 // Samples per real ReadSync call
+	sink := d.sink
+	var read int
+	for len(samples) > 0 && (sink != nil || d.monitor != nil) {
+		n := min(chunk, len(samples))
+		read += n
+		if d.monitor != nil {
+			d.monitor(ChunkPower{
+				Time:    start.Add(time.Duration(float64(read) / float64(d.sampleRate) * float64(time.Second))),
+				Samples: n,
+				Power:   d.calculateSignalPower(samples[:n]),
+				Gain:    d.GetGain(),
+			})
+		}
+		if sink != nil {
+			if err := sink(samples[:n]); err != nil {
+				fmt.Fprintf(d.out, "Warning: sample sink failed, continuing in memory only: %v\n", err)
+				sink = nil
+			}
+		}
+		samples = samples[n:]
+	}
+}
+
+// MeasurePower stub method - tunes and returns the power of the fake test pattern within bandwidth/2 of freq
+func (d *Device) MeasurePower(freq uint32, dwell time.Duration, bandwidth float64) (float64, error) {
+	samples, err := d.CaptureSamples(freq, dwell)
+	if err != nil {
+		return 0, err
+	}
+	return BandPower(samples, d.sampleRate, bandwidth), nil
+}
+
+// CaptureSamples stub method - tunes and returns dwell worth of the fake test pattern
+func (d *Device) CaptureSamples(freq uint32, dwell time.Duration) ([]complex64, error) {
+	if err := d.SetFrequency(freq); err != nil {
+		return nil, err
+	}
+	n, err := TotalSamples(d.sampleRate, dwell)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]complex64, n)
+	d.fillSamples(samples, 0, complex(0.1, 0.1))
+	return samples, nil
+}
+
+// StartCollectionWithPretrigger stub method - waits for trigger, then returns
+// fake pre-trigger samples for the time waited (up to pretrigger) followed by
+// the fake capture
+func (d *Device) StartCollectionWithPretrigger(ctx context.Context, trigger time.Time, pretrigger, duration time.Duration, samplesChan chan<- IQSample) error {
+	waited := max(time.Until(trigger), 0)
+	select {
+	case <-time.After(waited):
+	case <-ctx.Done():
+		return fmt.Errorf("pre-trigger buffering cancelled: %w", ctx.Err())
+	}
+
+	pre := make([]complex64, int(float64(d.sampleRate)*min(waited, pretrigger).Seconds()))
+	d.fillSamples(pre, -int64(len(pre)), complex(0.05, 0.05)) // Weaker than the triggered capture
+	d.feedSink(pre, time.Now().Add(-time.Duration(float64(len(pre))/float64(d.sampleRate)*float64(time.Second))))
+
+	captured := make(chan IQSample, 1)
+	if err := d.StartCollectionWithContext(ctx, duration, captured); err != nil {
+		return err
+	}
+	sample := <-captured
+
+	preDuration := time.Duration(float64(len(pre)) / float64(d.sampleRate) * float64(time.Second))
+	for i := range sample.Gaps {
+		sample.Gaps[i].SampleIndex += int64(len(pre))
+	}
+	select {
+	case samplesChan <- IQSample{
+		Timestamp:  sample.Timestamp.Add(-preDuration),
+		Data:       append(pre, sample.Data...),
+		DeviceLost: sample.DeviceLost,
+		Gaps:       sample.Gaps,
+	}:
+		return nil
+	default:
+		return fmt.Errorf("samples channel is full")
+	}
+}
+
+// SetDisconnectAfter stub-only method - simulates the device being unplugged
+// once a capture has run for after, as a hardware read error would end it;
+// 0 disables the simulation
+func (d *Device) SetDisconnectAfter(after time.Duration) {
+	d.disconnectAfter = after
+}
+
+// SetOverrun stub-only method - simulates a buffer overrun that drops length
+// worth of samples once a capture has run for at; a zero length disables the
+// simulation
+func (d *Device) SetOverrun(at, length time.Duration) {
+	d.overrunAt = at
+	d.overrunLength = length
+}
+
+// SetSyntheticSignal stub-only method - generates sig in place of the
+// constant test pattern, or restores the pattern when sig is nil
+func (d *Device) SetSyntheticSignal(sig *SyntheticSignal) {
+	d.synthetic = sig
+}
+
+// fillSamples stub helper - fills samples with the synthetic signal, the
+// first sample being start samples from the capture start, or with pattern
+// when no synthetic signal is set
+func (d *Device) fillSamples(samples []complex64, start int64, pattern complex64) {
+	if d.read.swapIQ {
+		defer swapIQ(samples)
+	}
+
+	sig := d.synthetic
+	if sig == nil {
+		for i := range samples {
+			samples[i] = pattern
+		}
+		return
+	}
+
+	amplitude := sig.Amplitude
+	if amplitude == 0 {
+		amplitude = 0.5
+	}
+	rate := float64(d.sampleRate)
+	offset := int64(math.Round(sig.Offset.Seconds() * rate))
+	period := int64(chirpPeriod.Seconds() * rate)
+	sweep := chirpSpan / float64(period) // Frequency change per sample, in cycles per sample
+
+	for i := range samples {
+		n := start + int64(i) - offset
+		switch sig.Kind {
+		case "chirp":
+			// Linear sweep from -span/2 to +span/2 of the sample rate
+			m := float64(((n % period) + period) % period)
+			phase := 2 * math.Pi * (-chirpSpan/2*m + sweep/2*m*m)
+			samples[i] = complex64(complex(amplitude*math.Cos(phase), amplitude*math.Sin(phase)))
+		default:
+			// Interpolating between pseudo-random values at every knot keeps
+			// the noise band-limited, so it still correlates when decimated
+			k := floorDiv(n, noiseKnotSpacing)
+			frac := float64(n-k*noiseKnotSpacing) / noiseKnotSpacing
+			a, b := noiseValue(sig.Seed, k), noiseValue(sig.Seed, k+1)
+			samples[i] = complex64(complex(amplitude, 0) * (a + complex(frac, 0)*(b-a)))
+		}
+	}
+}
+
+// noiseValue stub helper - a pseudo-random complex value in the unit square
+// for knot k, the same for every call with the same seed
+func noiseValue(seed uint64, k int64) complex128 {
+	h := splitmix64(seed ^ splitmix64(uint64(k)))
+	re := float64(h>>32)/float64(1<<31) - 1
+	im := float64(uint32(h))/float64(1<<31) - 1
+	return complex(re, im)
+}
+
+// splitmix64 stub helper - mixes x into a well-distributed 64-bit hash
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// floorDiv stub helper - integer division rounding toward negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
 // Close stub method - no-op for stub implementation
 func (d *Device) Close() error {
 	return nil
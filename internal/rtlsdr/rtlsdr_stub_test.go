@@ -0,0 +1,120 @@
+//go:build !rtlsdr
+
+package rtlsdr
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSyntheticSignalOffset(t *testing.T) {
+	for _, kind := range []string{"noise", "chirp"} {
+		early, _ := NewDevice(0)
+		late, _ := NewDevice(1)
+		early.SetSyntheticSignal(&SyntheticSignal{Kind: kind, Seed: 7})
+		// 10 samples at the default 2.048 MSps
+		late.SetSyntheticSignal(&SyntheticSignal{Kind: kind, Seed: 7, Offset: 10 * time.Second / 2048000})
+
+		a := make([]complex64, 1000)
+		b := make([]complex64, 1000)
+		early.fillSamples(a, 0, 0)
+		late.fillSamples(b, 0, 0)
+
+		// The late device sees the same waveform 10 samples later
+		for i := 10; i < len(b); i++ {
+			if a[i-10] != b[i] {
+				t.Fatalf("%s: sample %d of delayed signal is %v, want %v", kind, i, b[i], a[i-10])
+			}
+		}
+		if a[0] == a[1] {
+			t.Errorf("%s: signal is constant", kind)
+		}
+	}
+
+	// Without a synthetic signal the constant pattern is used
+	d, _ := NewDevice(0)
+	s := make([]complex64, 4)
+	d.fillSamples(s, 0, complex(0.1, 0.1))
+	if s[3] != complex(0.1, 0.1) {
+		t.Errorf("pattern: got %v", s[3])
+	}
+}
+
+func TestStubSnapsSampleRate(t *testing.T) {
+	d, _ := NewDevice(0)
+	if err := d.SetSampleRate(2400000); err != nil || d.sampleRate != 2560000 {
+		t.Errorf("SetSampleRate(2400000): got %d Hz, %v; want the nearest standard rate 2560000 Hz", d.sampleRate, err)
+	}
+	if err := d.SetSampleRate(2048000); err != nil || d.sampleRate != 2048000 {
+		t.Errorf("SetSampleRate(2048000): got %d Hz, %v", d.sampleRate, err)
+	}
+}
+
+func TestReadChunkBytes(t *testing.T) {
+	d, _ := NewDevice(0)
+	for _, n := range []int{0, -2, 1001} {
+		if err := d.SetReadChunkBytes(n); err == nil {
+			t.Errorf("SetReadChunkBytes(%d) accepted", n)
+		}
+	}
+	if err := d.SetReadChunkBytes(1000); err != nil {
+		t.Fatalf("SetReadChunkBytes(1000): %v", err)
+	}
+
+	// The sink receives samples a read chunk at a time
+	var sizes []int
+	d.SetSampleSink(func(samples []complex64) error {
+		sizes = append(sizes, len(samples))
+		return nil
+	})
+	d.feedSink(make([]complex64, 1200), time.Now())
+	if len(sizes) != 3 || sizes[0] != 500 || sizes[2] != 200 {
+		t.Errorf("sink chunks %v, want [500 500 200]", sizes)
+	}
+}
+
+func TestSwapIQ(t *testing.T) {
+	plain, _ := NewDevice(0)
+	swapped, _ := NewDevice(0)
+	plain.SetSyntheticSignal(&SyntheticSignal{Kind: "chirp"})
+	swapped.SetSyntheticSignal(&SyntheticSignal{Kind: "chirp"})
+	swapped.SetSwapIQ(true)
+
+	a := make([]complex64, 100)
+	b := make([]complex64, 100)
+	plain.fillSamples(a, 0, 0)
+	swapped.fillSamples(b, 0, 0)
+	for i := range a {
+		if real(b[i]) != imag(a[i]) || imag(b[i]) != real(a[i]) {
+			t.Fatalf("sample %d: swapped %v, plain %v", i, b[i], a[i])
+		}
+	}
+}
+
+func TestPowerMonitor(t *testing.T) {
+	d, _ := NewDevice(0)
+	var chunks []ChunkPower
+	d.SetPowerMonitor(func(p ChunkPower) {
+		chunks = append(chunks, p)
+	})
+
+	// The monitor sees every chunk even without a sample sink
+	samples := make([]complex64, 300000)
+	d.fillSamples(samples, 0, complex(0.3, 0.4))
+	start := time.Now()
+	d.feedSink(samples, start)
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	last := chunks[2]
+	if last.Samples != 300000-2*DefaultReadChunkBytes/2 {
+		t.Errorf("last chunk has %d samples", last.Samples)
+	}
+	if math.Abs(last.Power-0.5) > 1e-6 || last.Gain != d.GetGain() {
+		t.Errorf("last chunk power %v at %v dB, want 0.5 at %v dB", last.Power, last.Gain, d.GetGain())
+	}
+	if want := start.Add(300000 * time.Second / 2048000); !last.Time.Equal(want) {
+		t.Errorf("last chunk read at %v, want %v", last.Time, want)
+	}
+}
@@ -0,0 +1,241 @@
+package rtlsdr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"argus-collector/internal/dsp"
+)
+
+// ErrDeviceGone reports that the RTL-SDR stopped answering mid-capture,
+// usually because it was unplugged
+var ErrDeviceGone = errors.New("RTL-SDR device disconnected")
+
+// deviceGoneMessages are the libusb error texts a read fails with once the
+// device has been unplugged
+var deviceGoneMessages = []string{"no such device", "input/output error", "pipe error"}
+
+// isDeviceGone reports whether a read error means the device has gone away
+// rather than a transient failure
+func isDeviceGone(err error) bool {
+	if errors.Is(err, ErrDeviceGone) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range deviceGoneMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gap is a stretch of a capture the device sampled but never delivered, for
+// example after a USB buffer overrun. Data holds nothing for it, so the
+// samples from SampleIndex on are Duration later than a contiguous stream
+// would place them.
+type Gap struct {
+	SampleIndex int64         // Index in Data of the first sample after the gap
+	Duration    time.Duration // Estimated length of the missing stretch
+}
+
+// SampleSink receives each chunk of samples as it is read during a capture,
+// in order and starting with any pre-trigger samples
+type SampleSink func(samples []complex64) error
+
+// ChunkPower is the received power of one chunk of samples as read
+type ChunkPower struct {
+	Time    time.Time // When the chunk was read, the time of its last sample
+	Samples int       // Samples in the chunk
+	Power   float64   // RMS amplitude of the chunk, 1.0 = full scale
+	Gain    float64   // Tuner gain in dB when the chunk was read
+}
+
+// PowerMonitor receives the power of each chunk of samples as it is read
+// during a capture, in order and starting with any pre-trigger samples
+type PowerMonitor func(ChunkPower)
+
+// SetPowerMonitor sets a function that receives the power of each chunk of
+// samples as it is read, whether or not AGC is enabled. It is called on the
+// capture goroutine, so it should return quickly. nil removes the monitor.
+func (d *Device) SetPowerMonitor(monitor PowerMonitor) {
+	d.monitor = monitor
+}
+
+// CaptureProgress is a snapshot of a running capture
+type CaptureProgress struct {
+	Samples int64   // Samples read so far, including any pre-trigger
+	Power   float64 // RMS power of the most recent samples
+	Gain    float64 // Tuner gain in dB when the most recent samples were read
+}
+
+// captureProgress tracks a running capture for status displays. It is
+// written by the capture and may be read concurrently.
+type captureProgress struct {
+	samples atomic.Int64  // Samples read so far, including any pre-trigger
+	power   atomic.Uint64 // Float64 bits of the RMS power of the latest chunk
+	gain    atomic.Uint64 // Float64 bits of the gain when the latest chunk was read
+}
+
+// reset clears the progress at the start of a capture
+func (p *captureProgress) reset() {
+	p.samples.Store(0)
+	p.power.Store(0)
+}
+
+// add records a chunk of n samples read at gain with the given RMS power
+func (p *captureProgress) add(n int, power, gain float64) {
+	p.samples.Add(int64(n))
+	p.power.Store(math.Float64bits(power))
+	p.gain.Store(math.Float64bits(gain))
+}
+
+// Progress returns the progress of the current, or last, capture. It is safe
+// to call while a capture runs.
+func (d *Device) Progress() CaptureProgress {
+	return CaptureProgress{
+		Samples: d.progress.samples.Load(),
+		Power:   math.Float64frombits(d.progress.power.Load()),
+		Gain:    math.Float64frombits(d.progress.gain.Load()),
+	}
+}
+
+// supportedSampleRates are the standard RTL-SDR sample rates in Hz, in
+// ascending order. A requested rate the device refuses is replaced by the
+// nearest of these.
+var supportedSampleRates = []uint32{
+	250000,  // 250 kHz
+	1024000, // 1.024 MHz
+	1536000, // 1.536 MHz
+	1792000, // 1.792 MHz
+	1920000, // 1.92 MHz
+	2048000, // 2.048 MHz
+	2160000, // 2.16 MHz
+	2560000, // 2.56 MHz
+	2880000, // 2.88 MHz
+	3200000, // 3.2 MHz (maximum for most devices)
+}
+
+// SupportedSampleRates returns the standard RTL-SDR sample rates in Hz, in
+// ascending order
+func SupportedSampleRates() []uint32 {
+	return slices.Clone(supportedSampleRates)
+}
+
+// NearestSampleRate returns the standard sample rate closest to rate, which is
+// rate itself if it is one of them
+func NearestSampleRate(rate uint32) uint32 {
+	best := supportedSampleRates[0]
+	for _, r := range supportedSampleRates[1:] {
+		if absDiff(r, rate) < absDiff(best, rate) {
+			best = r
+		}
+	}
+	return best
+}
+
+// absDiff returns the distance between two sample rates
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// NearestGainStep returns the index in gains, a tuner's supported gains in
+// tenths of dB, of the one closest to gain in dB; the tuner rounds a
+// requested gain to it. It returns -1 if gains is empty.
+func NearestGainStep(gains []int, gain float64) int {
+	best := -1
+	for i, g := range gains {
+		if best < 0 || math.Abs(float64(g)/10-gain) < math.Abs(float64(gains[best])/10-gain) {
+			best = i
+		}
+	}
+	return best
+}
+
+// MaxCaptureSamples is the largest number of samples one capture may hold; the
+// data file header stores the sample count as a uint32
+const MaxCaptureSamples = math.MaxUint32
+
+// TotalSamples returns the number of samples in a capture of duration at
+// sampleRate, including fractional seconds. It returns an error if the capture
+// would hold no samples or more than MaxCaptureSamples.
+func TotalSamples(sampleRate uint32, duration time.Duration) (int64, error) {
+	samples := math.Round(float64(sampleRate) * duration.Seconds())
+	if samples < 1 {
+		return 0, fmt.Errorf("capture of %v at %d Hz contains no samples", duration, sampleRate)
+	}
+	if samples > MaxCaptureSamples {
+		return 0, fmt.Errorf("capture of %v at %d Hz needs %.0f samples, more than the %d a data file can hold",
+			duration, sampleRate, samples, int64(MaxCaptureSamples))
+	}
+	return int64(samples), nil
+}
+
+// calculateSignalPower calculates the RMS power of IQ samples
+func (d *Device) calculateSignalPower(samples []complex64) float64 {
+	return SignalPower(samples)
+}
+
+// SignalPower returns the RMS amplitude of IQ samples, the power measure
+// the AGC and power log use, with 1 at full scale
+func SignalPower(samples []complex64) float64 {
+	if len(samples) == 0 {
+		return 0.0
+	}
+
+	var sumSquares float64
+	for _, sample := range samples {
+		// Calculate magnitude squared (power)
+		magnitude := real(sample)*real(sample) + imag(sample)*imag(sample)
+		sumSquares += float64(magnitude)
+	}
+
+	// Return RMS power (sqrt of mean of squares)
+	return math.Sqrt(sumSquares / float64(len(samples)))
+}
+
+// BandPower returns the RMS amplitude of the part of IQ samples within
+// bandwidth/2 of the tuned center, with 1 at full scale like SignalPower. It
+// sums the FFT bins in the band, averaged over segments long enough to give
+// the band about 8 bins where the samples allow. A bandwidth of 0 or more
+// than the sample rate measures the whole band.
+func BandPower(samples []complex64, sampleRate uint32, bandwidth float64) float64 {
+	if bandwidth <= 0 || bandwidth >= float64(sampleRate) {
+		return SignalPower(samples)
+	}
+
+	size := 1
+	for size*2 <= len(samples) && float64(size) < 8*float64(sampleRate)/bandwidth {
+		size *= 2
+	}
+	half := min(int(bandwidth/2/float64(sampleRate)*float64(size)), (size-1)/2) // Bins each side of DC
+
+	segment := make([]complex128, size)
+	var sum float64
+	segments := 0
+	for start := 0; start+size <= len(samples); start += size {
+		for i, v := range samples[start : start+size] {
+			segment[i] = complex128(v)
+		}
+		dsp.FFT(segment)
+		for k := -half; k <= half; k++ {
+			bin := segment[(k+size)%size]
+			sum += real(bin)*real(bin) + imag(bin)*imag(bin)
+		}
+		segments++
+	}
+	if segments == 0 {
+		return 0.0
+	}
+
+	// By Parseval's theorem a segment's mean square is its bin power over size²
+	return math.Sqrt(sum / float64(size) / float64(size) / float64(segments))
+}
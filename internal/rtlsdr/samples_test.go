@@ -0,0 +1,107 @@
+package rtlsdr
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTotalSamples(t *testing.T) {
+	// Fractional seconds are not truncated
+	if n, err := TotalSamples(2048000, 250*time.Millisecond); err != nil || n != 512000 {
+		t.Errorf("250ms at 2.048 MSps: got %d, %v", n, err)
+	}
+
+	// The largest captures still fit a file
+	if n, err := TotalSamples(3200000, 1342*time.Second); err != nil || n != 4294400000 {
+		t.Errorf("1342s at 3.2 MSps: got %d, %v", n, err)
+	}
+
+	// 1800s at 2.4 MSps is 4.32e9 samples; a uint32 product wraps to
+	// 25032704, which would silently cut the capture short
+	if n, err := TotalSamples(2400000, 1800*time.Second); err == nil {
+		t.Errorf("1800s at 2.4 MSps: got %d samples, expected an error", n)
+	}
+
+	if _, err := TotalSamples(3200000, time.Hour); err == nil {
+		t.Error("expected error for a capture exceeding the file sample limit")
+	}
+	if _, err := TotalSamples(2048000, 0); err == nil {
+		t.Error("expected error for a zero-length capture")
+	}
+}
+
+func TestNearestSampleRate(t *testing.T) {
+	tests := []struct {
+		rate, want uint32
+	}{
+		{2048000, 2048000}, // Standard rates are kept
+		{2400000, 2560000},
+		{2000000, 2048000},
+		{100000, 250000},
+		{10000000, 3200000},
+	}
+	for _, tt := range tests {
+		if got := NearestSampleRate(tt.rate); got != tt.want {
+			t.Errorf("NearestSampleRate(%d) = %d, want %d", tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestNearestGainStep(t *testing.T) {
+	gains := []int{0, 9, 14, 27, 37, 77, 87, 125, 144, 157, 166, 197, 207, 229, 254, 280, 297, 328, 338, 364, 372, 386, 402, 421, 434, 439, 445, 480, 496}
+	tests := []struct {
+		gain float64
+		want int
+	}{
+		{20.7, 12}, // Supported gains map to themselves
+		{0, 0},
+		{49.6, 28},
+		{20, 11}, // Rounded to the nearest supported gain
+		{60, 28}, // Above the ladder
+		{-5, 0},  // Below the ladder
+	}
+	for _, tt := range tests {
+		if got := NearestGainStep(gains, tt.gain); got != tt.want {
+			t.Errorf("NearestGainStep(%.1f) = %d, want %d", tt.gain, got, tt.want)
+		}
+	}
+	if got := NearestGainStep(nil, 20); got != -1 {
+		t.Errorf("NearestGainStep with no gains = %d, want -1", got)
+	}
+}
+
+func TestBandPower(t *testing.T) {
+	const rate = 2400000
+	tone := func(samples []complex64, freq, amplitude float64) {
+		for i := range samples {
+			phase := 2 * math.Pi * freq * float64(i) / rate
+			samples[i] += complex(float32(amplitude*math.Cos(phase)), float32(amplitude*math.Sin(phase)))
+		}
+	}
+
+	tests := []struct {
+		name      string
+		tones     [][2]float64 // Frequency offset (Hz) and amplitude of each tone
+		bandwidth float64
+		want      float64
+		tolerance float64
+	}{
+		{"tone at center", [][2]float64{{0, 0.5}}, 25000, 0.5, 0.01},
+		{"tone within band", [][2]float64{{5000, 0.5}}, 25000, 0.5, 0.05},
+		{"tone outside band", [][2]float64{{200000, 0.5}}, 25000, 0, 0.01},
+		{"neighbour excluded", [][2]float64{{0, 0.1}, {100000, 0.5}}, 25000, 0.1, 0.01},
+		{"whole band", [][2]float64{{0, 0.1}, {100000, 0.5}}, 0, math.Sqrt(0.26), 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([]complex64, rate/20) // 50ms
+			for _, tn := range tt.tones {
+				tone(samples, tn[0], tn[1])
+			}
+			if got := BandPower(samples, rate, tt.bandwidth); math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("Expected band power %.3f, got %.3f", tt.want, got)
+			}
+		})
+	}
+}
@@ -5,7 +5,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -16,10 +21,14 @@ import (
 
 	"argus-collector/internal/collector"
 	"argus-collector/internal/config"
+	"argus-collector/internal/console"
+	"argus-collector/internal/filewriter"
+	"argus-collector/internal/gps"
 	"argus-collector/internal/rtlsdr"
 	"argus-collector/internal/version"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -42,14 +51,38 @@ var (
 	device          string  // RTL-SDR device selection (serial number or index)
 	gain            float64 // Manual gain setting in dB
 	gainMode        string  // Gain mode: auto or manual
+	agcSmoothing    float64 // Weight of each new chunk in the AGC power average
 	biasTeeFlag     bool    // Enable bias tee for external LNA power
+	swapIQ          bool    // Exchange I and Q of each sample as it is captured
 	showVersion     bool    // Show version information
 	sampleRate      uint32  // Sample rate in Hz
 	freqCorrection  int     // Frequency correction in PPM
+	lnaGain         float64 // External LNA gain in dB (metadata only)
+	antenna         string  // Antenna description (metadata only)
 	collectionID    string  // Collection identifier for filename
 	filePrefix      string  // Prefix for output filenames
-	gpsBaudRate     int     // GPS serial port baud rate
+	filenameTmpl    string  // Output filename template with placeholders
+	gpsBaud         string  // GPS serial port baud rate, or "auto"
 	gpsTimeout      string  // GPS fix timeout duration
+	clockThreshold  string  // Maximum acceptable system clock offset from GPS time
+	fixWatch        string  // How long to keep reporting satellites after the fix
+	sampleFormat    string  // Sample storage format: complex64, int16 or uint8
+	sidecarJSON     bool    // Write metadata sidecar JSON alongside the .dat file
+	dryRun          bool    // Print the resolved collection plan without collecting
+	requireFix      bool    // Abort collection if the GPS fix is lost mid-capture
+	noGPS           bool    // Skip GPS entirely and record no position
+	demDir          string  // Directory of SRTM tiles for looking up the manual altitude
+	altRequired     bool    // Refuse manual mode without a known altitude
+	tui             bool    // Show a live status screen instead of scrolling output
+	quiet           bool    // Plain output without banners or emoji
+	pretrigger      string  // Duration of data to keep from before the start time
+	maxRuntime      string  // Absolute cap on the wait for one capture
+	timeoutFactor   float64 // Capture timeout as a multiple of the duration
+	syncInterval    string  // Interval between syncs of a capture streamed to disk
+	powerLog        string  // CSV file logging the power of each chunk read
+	repeat          int     // Number of captures to take
+	overwrite       bool    // Replace existing output files
+	noOverwrite     bool    // Fail instead of renaming when an output file exists
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -61,13 +94,13 @@ and GPS positioning data for Time Difference of Arrival (TDOA) analysis.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Handle version flag
 		if showVersion {
-			fmt.Println(version.GetVersionInfo("Argus Collector"))
+			fmt.Fprintln(out, version.GetVersionInfo("Argus Collector"))
 			return
 		}
 
 		if err := runCollector(cmd); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
 		}
 	},
 }
@@ -81,24 +114,51 @@ product, and serial number information. Use this to identify devices for
 configuration with serial numbers.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := listDevices(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
 		}
 	},
 }
 
+// gainsCmd represents the gains command to list a device's supported tuner gains
+var gainsCmd = &cobra.Command{
+	Use:   "gains",
+	Short: "List supported tuner gains for an RTL-SDR device",
+	Long: `Open the selected RTL-SDR device and list the tuner gain values it supports.
+Manual gain settings are snapped to one of these values by the tuner, so use
+--gain to see which supported value a requested gain maps to.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := listGains(cmd); err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
+		}
+	},
+}
+
+var sampleRatesCmd = &cobra.Command{
+	Use:   "sample-rates",
+	Short: "List the standard RTL-SDR sample rates",
+	Long: `List the standard RTL-SDR sample rates. A requested rate the device refuses
+is replaced by the nearest of these, so use --sample-rate to see which rate a
+requested value would fall back to. No device is opened.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		listSampleRates(cmd)
+	},
+}
+
 // init initializes the CLI flags and configuration
 func init() {
 	// Initialize configuration when cobra starts
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initOutput)
 
 	// Persistent flags available to all commands
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "./config.yaml", "config file (default is ./config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&showVersion, "version", false, "show version information")
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "plain output without banners, emoji or symbols, for logs and scripts")
 
 	// Command-specific flags
-	rootCmd.Flags().Float64VarP(&frequency, "frequency", "f", 433.92e6, "frequency to monitor (Hz)")
+	rootCmd.Flags().VarP(newFrequencyValue(433.92e6, &frequency), "frequency", "f", "frequency to monitor, in Hz or with a k, M or G suffix (e.g. 433.92M)")
 	rootCmd.Flags().StringVarP(&duration, "duration", "d", "60s", "collection duration")
 	rootCmd.Flags().StringVarP(&output, "output", "o", "./data", "output directory")
 	rootCmd.Flags().BoolVar(&syncedStart, "synced-start", true, "enable delayed/synchronized start time (true|false)")
@@ -106,7 +166,7 @@ func init() {
 
 	// GPS configuration options
 	rootCmd.Flags().StringVar(&gpsMode, "gps-mode", "nmea", "GPS mode: nmea, gpsd, or manual")
-	rootCmd.Flags().StringVarP(&gpsPort, "gps-port", "p", "/dev/ttyUSB0", "GPS serial port (for NMEA mode)")
+	rootCmd.Flags().StringVarP(&gpsPort, "gps-port", "p", config.DefaultGPSPort(), "GPS serial port (for NMEA mode, e.g. /dev/ttyUSB0 or COM3)")
 	rootCmd.Flags().StringVar(&gpsdHost, "gpsd-host", "localhost", "GPSD host address (for gpsd mode)")
 	rootCmd.Flags().StringVar(&gpsdPort, "gpsd-port", "2947", "GPSD port (for gpsd mode)")
 
@@ -114,23 +174,79 @@ func init() {
 	rootCmd.Flags().Float64Var(&latitude, "latitude", 0.0, "manual latitude in decimal degrees (for manual mode)")
 	rootCmd.Flags().Float64Var(&longitude, "longitude", 0.0, "manual longitude in decimal degrees (for manual mode)")
 	rootCmd.Flags().Float64Var(&altitude, "altitude", 0.0, "manual altitude in meters (for manual mode)")
+	rootCmd.Flags().StringVar(&demDir, "dem-dir", "", "directory of SRTM .hgt tiles to look up the ground elevation when no manual altitude is given")
+	rootCmd.Flags().BoolVar(&altRequired, "altitude-required", false, "refuse manual mode unless the altitude is given or found in the DEM")
+	rootCmd.Flags().BoolVar(&noGPS, "no-gps", false, "skip GPS entirely and mark the file as having no position (bench testing)")
 
 	// RTL-SDR device selection and gain control
 	rootCmd.Flags().StringVarP(&device, "device", "D", "", "RTL-SDR device selection (serial number or index)")
 	rootCmd.Flags().Float64VarP(&gain, "gain", "g", 10.0, "manual gain setting in dB (used when gain-mode is manual)")
-	rootCmd.Flags().StringVar(&gainMode, "gain-mode", "manual", "gain control mode: auto (AGC) or manual")
+	rootCmd.Flags().StringVar(&gainMode, "gain-mode", "manual", "gain control mode: auto (AGC), manual, or max (highest gain the tuner supports)")
+	rootCmd.Flags().Float64Var(&agcSmoothing, "agc-smoothing", rtlsdr.DefaultAGCSmoothing, "weight (0-1] of each new chunk in the AGC power average; lower settles more, 1 disables smoothing")
 	rootCmd.Flags().BoolVar(&biasTeeFlag, "bias-tee", false, "enable bias tee for powering external LNAs")
+	rootCmd.Flags().BoolVar(&swapIQ, "swap-iq", false, "exchange I and Q of each sample, for hardware or drivers that deliver Q first")
 	
 	// Add missing flags for complete configuration coverage
 	rootCmd.Flags().Uint32Var(&sampleRate, "sample-rate", 0, "sample rate in Hz")
 	rootCmd.Flags().IntVar(&freqCorrection, "frequency-correction", 0, "frequency correction in PPM")
+	rootCmd.Flags().Float64Var(&lnaGain, "lna-gain", 0, "external LNA gain in dB, recorded in file metadata only")
+	rootCmd.Flags().StringVar(&antenna, "antenna", "", "antenna description, recorded in file metadata only")
 	rootCmd.Flags().StringVar(&collectionID, "collection-id", "", "collection identifier for filename")
 	rootCmd.Flags().StringVar(&filePrefix, "file-prefix", "", "prefix for output filenames")
-	rootCmd.Flags().IntVar(&gpsBaudRate, "gps-baud", 0, "GPS serial port baud rate (for NMEA mode)")
+	rootCmd.Flags().StringVar(&filenameTmpl, "filename-template", "", "output filename template, e.g. {prefix}_{freq_mhz}MHz_{datetime} (placeholders: {prefix} {id} {device} {freq} {freq_mhz} {epoch} {datetime} {date} {seq})")
+	rootCmd.Flags().StringVar(&sampleFormat, "sample-format", "complex64", "sample storage format: complex64, int16 or uint8 (raw RTL-SDR bytes, lossless)")
+	rootCmd.Flags().BoolVar(&sidecarJSON, "sidecar-json", false, "also write metadata as <collection-id>.json next to the .dat file")
+	rootCmd.Flags().StringVar(&gpsBaud, "gps-baud", "", "GPS serial port baud rate (for NMEA mode), or 'auto' to detect it")
 	rootCmd.Flags().StringVar(&gpsTimeout, "gps-timeout", "", "GPS fix timeout duration")
+	rootCmd.Flags().StringVar(&clockThreshold, "clock-offset-threshold", "", "warn if system clock differs from GPS time by more than this (e.g. 50ms)")
+	rootCmd.Flags().StringVar(&fixWatch, "gps-watch", "", "keep showing the fix and satellite count for this long after the fix is acquired (e.g. 2m)")
+	rootCmd.Flags().BoolVar(&requireFix, "require-fix-throughout", false, "abort collection if the GPS fix is lost or goes stale during capture")
+	rootCmd.Flags().StringVar(&pretrigger, "pretrigger", "", "also save this much data from before the start time (e.g. 500ms)")
+	rootCmd.Flags().StringVar(&maxRuntime, "max-runtime", "", "hard cap on the time one capture may take before it is abandoned as hung (e.g. 15m)")
+	rootCmd.Flags().Float64Var(&timeoutFactor, "timeout-factor", 3.2, "abandon a capture taking longer than this multiple of the duration")
+	rootCmd.Flags().StringVar(&syncInterval, "sync-interval", "", "stream samples to disk during capture, syncing this often, so a crash keeps the data (e.g. 5s)")
+	rootCmd.Flags().StringVar(&powerLog, "power-log", "", "append the time, signal power and gain of each chunk read to this CSV file (works with or without AGC)")
+	rootCmd.Flags().IntVar(&repeat, "repeat", 1, "number of captures; with synced start each re-aligns to the next shared sync point")
+	rootCmd.Flags().BoolVar(&overwrite, "overwrite", false, "replace an existing output file with the same name")
+	rootCmd.Flags().BoolVar(&noOverwrite, "no-overwrite", false, "fail if the output file exists (default: add a numeric suffix)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the resolved collection plan and exit without collecting")
+	rootCmd.Flags().BoolVar(&gainSweep, "gain-sweep", false, "capture briefly at each supported gain, report power, clipping and SNR, recommend a gain and exit")
+	rootCmd.Flags().DurationVar(&gainSweepDwell, "gain-sweep-dwell", time.Second, "capture length at each gain of --gain-sweep")
+	rootCmd.Flags().BoolVar(&tui, "tui", false, "show a live status screen (GPS, progress, gain, signal power) when output is a terminal")
 
 	// Add subcommands
 	rootCmd.AddCommand(devicesCmd)
+	rootCmd.AddCommand(gainsCmd)
+	rootCmd.AddCommand(multiCmd)
+	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(sampleRatesCmd)
+
+	gainsCmd.Flags().StringVarP(&device, "device", "D", "", "RTL-SDR device selection (serial number or index)")
+	gainsCmd.Flags().Float64VarP(&gain, "gain", "g", 10.0, "mark the supported gain nearest to this value in dB")
+	sampleRatesCmd.Flags().Uint32Var(&sampleRate, "sample-rate", 0, "mark the standard rate nearest to this value in Hz")
+
+	// multi shares the collection flags; device selection and repeats are per-command
+	rootCmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		switch flag.Name {
+		case "device", "repeat", "dry-run", "gain-sweep", "gain-sweep-dwell":
+		default:
+			multiCmd.Flags().AddFlag(flag)
+		}
+	})
+	multiCmd.Flags().StringSliceVar(&multiDevices, "devices", nil, "RTL-SDR devices to collect from, comma separated serial numbers or indices")
+	scanCmd.Flags().StringVarP(&device, "device", "D", "", "RTL-SDR device selection (serial number or index)")
+	scanCmd.Flags().Float64VarP(&gain, "gain", "g", 10.0, "manual gain setting in dB")
+	scanCmd.Flags().Uint32Var(&sampleRate, "sample-rate", 0, "sample rate in Hz")
+	scanCmd.Flags().BoolVar(&biasTeeFlag, "bias-tee", false, "enable bias tee for powering external LNAs")
+	scanCmd.Flags().Var(newFrequencyValue(0, &scanStart), "start", "first frequency to measure, in Hz or with a k, M or G suffix (e.g. 162.3M)")
+	scanCmd.Flags().Var(newFrequencyValue(0, &scanEnd), "end", "last frequency to measure, in Hz or with a k, M or G suffix")
+	scanCmd.Flags().Var(newFrequencyValue(100e3, &scanStep), "step", "frequency step, in Hz or with a k, M or G suffix (e.g. 25k)")
+	scanCmd.Flags().DurationVar(&scanDwell, "dwell", 50*time.Millisecond, "measurement time at each frequency")
+	scanCmd.Flags().IntVar(&scanTop, "top", 10, "number of strongest frequencies to list (0 = all)")
+	scanCmd.Flags().StringVar(&scanCSV, "csv", "", "also write every measurement, strongest first, to this CSV file")
+	scanCmd.MarkFlagRequired("start")
+	scanCmd.MarkFlagRequired("end")
+	multiCmd.Flags().BoolVar(&multiProcess, "process", false, "run TDOA processing on the collected files (requires at least 3 devices)")
 
 	// Bind command line flags to viper configuration keys
 	viper.BindPFlag("rtlsdr.frequency", rootCmd.Flags().Lookup("frequency"))
@@ -151,6 +267,25 @@ func init() {
 	viper.BindPFlag("rtlsdr.gain", rootCmd.Flags().Lookup("gain"))
 	viper.BindPFlag("rtlsdr.gain_mode", rootCmd.Flags().Lookup("gain-mode"))
 	viper.BindPFlag("rtlsdr.bias_tee", rootCmd.Flags().Lookup("bias-tee"))
+	viper.BindPFlag("rtlsdr.swap_iq", rootCmd.Flags().Lookup("swap-iq"))
+}
+
+// envPrefix is the prefix for configuration environment variables
+const envPrefix = "ARGUS"
+
+// Output streams for people, with decoration stripped when --quiet is given
+var (
+	out    io.Writer = os.Stdout
+	errOut io.Writer = os.Stderr
+)
+
+// initOutput strips decoration from the output when --quiet is given
+func initOutput() {
+	if quiet {
+		out = console.NewWriter(os.Stdout)
+		errOut = console.NewWriter(os.Stderr)
+	}
+	log.SetOutput(errOut)
 }
 
 // initConfig reads in config file and ENV variables if set
@@ -165,107 +300,91 @@ func initConfig() {
 		viper.AddConfigPath(".")
 	}
 
-	// Read in environment variables that match
+	// Read in environment variables that match, e.g. ARGUS_RTLSDR_FREQUENCY
+	// for rtlsdr.frequency
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
-	// If a config file is found, read it in
-	if err := viper.ReadInConfig(); err == nil {
+	// If a config file is found, read it in. A missing file is not an error so
+	// stations can be configured entirely from environment variables and flags.
+	err := viper.ReadInConfig()
+	var notFound viper.ConfigFileNotFoundError
+	switch {
+	case err == nil:
 		configPath, _ := filepath.Abs(viper.ConfigFileUsed())
-		fmt.Printf("Reading configuration file: %s\n", configPath)
+		fmt.Fprintf(out, "Reading configuration file: %s\n", configPath)
+	case errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist):
+		// No config file, use environment variables, flags and defaults
+	default:
+		fmt.Fprintf(errOut, "Warning: failed to read configuration file: %v\n", err)
 	}
 }
 
 // runCollector is the main application logic
 func runCollector(cmd *cobra.Command) error {
-	// Load default configuration
-	cfg := config.DefaultConfig()
+	cfg, err := resolveConfig(cmd)
+	if err != nil {
+		return err
+	}
 
-	// Apply configuration with proper precedence: defaults < config file < command line
-	applyConfiguration(cfg, cmd)
+	if dryRun {
+		return printCollectionPlan(cfg)
+	}
+	if gainSweep {
+		return runGainSweep(cfg)
+	}
 
-	// Handle device selection with proper precedence
-	handleDeviceSelection(cfg, cmd)
+	// Display startup information
+	fmt.Fprintf(out, "Argus Collector %s starting...\n", version.GetFullVersion())
 
-	// Handle backward compatibility for GPS disable flag
-	if cfg.GPS.Disable {
-		// Backward compatibility: config file has disable: true
-		cfg.GPS.Mode = "manual"
-		if cfg.GPS.ManualLatitude == 0.0 && cfg.GPS.ManualLongitude == 0.0 {
-			cfg.GPS.ManualLatitude = viper.GetFloat64("gps.manual_latitude")
-			cfg.GPS.ManualLongitude = viper.GetFloat64("gps.manual_longitude")
-			cfg.GPS.ManualAltitude = viper.GetFloat64("gps.manual_altitude")
+	// Report which start timing mode applies; an exact start time takes precedence
+	if cfg.Collection.StartTime > 0 {
+		if cfg.Collection.SyncedStart {
+			fmt.Fprintf(out, "Start: using exact start time %d, ignoring synced-start\n", cfg.Collection.StartTime)
+		} else {
+			fmt.Fprintf(out, "Start: using exact start time %d\n", cfg.Collection.StartTime)
 		}
 	}
 
-	// Validate duration format
-	if cfg.Collection.Duration == 0 {
-		return fmt.Errorf("invalid duration: must be greater than 0")
-	}
-
-	// Validate GPS configuration
 	switch cfg.GPS.Mode {
 	case "manual":
-		// Validate manual coordinates
-		if cfg.GPS.ManualLatitude < -90 || cfg.GPS.ManualLatitude > 90 {
-			return fmt.Errorf("invalid latitude: %.8f (must be between -90 and 90 degrees)", cfg.GPS.ManualLatitude)
-		}
-		if cfg.GPS.ManualLongitude < -180 || cfg.GPS.ManualLongitude > 180 {
-			return fmt.Errorf("invalid longitude: %.8f (must be between -180 and 180 degrees)", cfg.GPS.ManualLongitude)
-		}
-		// Check if coordinates are set to default values (0,0) which likely means they weren't configured
-		if cfg.GPS.ManualLatitude == 0.0 && cfg.GPS.ManualLongitude == 0.0 {
-			return fmt.Errorf("manual coordinates not specified: set manual_latitude and manual_longitude in config file or use --latitude and --longitude flags")
+		if cfg.GPS.NoPosition {
+			fmt.Fprintf(out, "GPS: DISABLED (no position recorded, system time only)\n")
+			break
 		}
+		fmt.Fprintf(out, "GPS: MANUAL MODE (using fixed coordinates)\n")
+		fmt.Fprintf(out, "Location: %.8f°, %.8f° (%.1f m)\n",
+			cfg.GPS.ManualLatitude, cfg.GPS.ManualLongitude, cfg.GPS.ManualAltitude)
 	case "nmea":
-		// Validate NMEA serial port configuration
-		if cfg.GPS.Port == "" {
-			return fmt.Errorf("GPS port not specified for NMEA mode")
-		}
+		fmt.Fprintf(out, "GPS: NMEA MODE (serial port %s)\n", cfg.GPS.Port)
 	case "gpsd":
-		// Validate gpsd configuration
-		if cfg.GPS.GPSDHost == "" {
-			return fmt.Errorf("GPSD host not specified for gpsd mode")
-		}
-		if cfg.GPS.GPSDPort == "" {
-			return fmt.Errorf("GPSD port not specified for gpsd mode")
-		}
-	default:
-		return fmt.Errorf("invalid GPS mode: %s (must be 'nmea', 'gpsd', or 'manual')", cfg.GPS.Mode)
+		fmt.Fprintf(out, "GPS: GPSD MODE (%s:%s)\n", cfg.GPS.GPSDHost, cfg.GPS.GPSDPort)
 	}
 
-	// Display startup information
-	fmt.Printf("Argus Collector %s starting...\n", version.GetFullVersion())
-
-	switch cfg.GPS.Mode {
-	case "manual":
-		fmt.Printf("GPS: MANUAL MODE (using fixed coordinates)\n")
-		fmt.Printf("Location: %.8f°, %.8f° (%.1f m)\n",
-			cfg.GPS.ManualLatitude, cfg.GPS.ManualLongitude, cfg.GPS.ManualAltitude)
-	case "nmea":
-		fmt.Printf("GPS: NMEA MODE (serial port %s)\n", cfg.GPS.Port)
-	case "gpsd":
-		fmt.Printf("GPS: GPSD MODE (%s:%s)\n", cfg.GPS.GPSDHost, cfg.GPS.GPSDPort)
+	// Output goes through the status view, which holds it while the screen is
+	// drawn. The screen needs a terminal; otherwise keep the line output.
+	stdout, stderr := out, errOut
+	var view *statusView
+	if tui {
+		if isTerminal(os.Stdout) {
+			view = newStatusView(os.Stdout, out, errOut)
+			defer view.close()
+			stdout, stderr = view.Stdout(), view.Stderr()
+			log.SetOutput(stderr)
+		} else {
+			fmt.Fprintf(out, "Output is not a terminal, ignoring --tui\n")
+		}
 	}
 
 	// Set up signal handling for graceful shutdown EARLY
 	// This ensures Ctrl-C works even during initialization
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	// Create a context that can be cancelled by signal
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := interruptContext(stdout)
 	defer cancel()
 
-	// Handle interrupt signals in a separate goroutine
-	go func() {
-		<-sigChan
-		fmt.Printf("\nReceived interrupt signal, shutting down...\n")
-		cancel()   // Cancel the context to stop all operations
-		os.Exit(1) // Force exit if graceful shutdown takes too long
-	}()
-
 	// Create and initialize collector
 	c := collector.NewCollector(cfg)
+	c.SetOutput(stdout)
 
 	// Check for cancellation before initialization
 	select {
@@ -285,6 +404,10 @@ func runCollector(cmd *cobra.Command) error {
 		c.SetRTLSDRVerbose(true)
 	}
 
+	if view != nil {
+		view.start(c)
+	}
+
 	// Check for cancellation before GPS fix
 	select {
 	case <-ctx.Done():
@@ -304,18 +427,225 @@ func runCollector(cmd *cobra.Command) error {
 	default:
 	}
 
-	// Perform signal collection
-	if err := c.CollectWithContext(ctx); err != nil {
-		return fmt.Errorf("collection failed: %w", err)
+	// Perform signal collection. Each repeated capture recomputes its start,
+	// so with synced start every capture waits for the next shared sync point.
+	for i := 1; i <= cfg.Collection.Repeat; i++ {
+		if cfg.Collection.Repeat > 1 {
+			fmt.Fprintf(stdout, "\nCapture %d of %d\n", i, cfg.Collection.Repeat)
+		}
+		if err := c.CollectWithContext(ctx); err != nil {
+			return fmt.Errorf("collection %d of %d failed: %w", i, cfg.Collection.Repeat, err)
+		}
 	}
 
 	// Report final AGC result if AGC was used
 	c.ReportAGCResult()
 	
-	fmt.Printf("Collection completed successfully.\n")
+	fmt.Fprintf(stdout, "Collection completed successfully.\n")
 	return nil
 }
 
+// defaultManualAltitude fills in a manual altitude that was not configured,
+// from the DEM if one is set. Without one the altitude stays at 0 m, which is
+// refused with altitude_required and warned about otherwise.
+func defaultManualAltitude(cfg *config.Config) error {
+	if cfg.GPS.DEMDir != "" {
+		elevation, err := gps.LookupElevation(cfg.GPS.DEMDir, cfg.GPS.ManualLatitude, cfg.GPS.ManualLongitude)
+		if err != nil {
+			return fmt.Errorf("failed to look up manual altitude: %w", err)
+		}
+		cfg.GPS.ManualAltitude = elevation
+		fmt.Fprintf(out, "Manual altitude: %.1f m ground elevation from DEM\n", elevation)
+		return nil
+	}
+	if cfg.GPS.AltitudeRequired {
+		return fmt.Errorf("manual altitude not specified: set manual_altitude in config file, use --altitude, or look it up with --dem-dir")
+	}
+	fmt.Fprintf(out, "Warning: manual altitude not specified, recording 0 m (sea level); set it with --altitude or --dem-dir\n")
+	return nil
+}
+
+// resolveConfig builds the collection configuration from defaults, the
+// config file and command line flags, and validates it
+func resolveConfig(cmd *cobra.Command) (*config.Config, error) {
+	// Load default configuration
+	cfg := config.DefaultConfig()
+
+	// Apply configuration with proper precedence: defaults < config file < command line
+	applyConfiguration(cfg, cmd)
+
+	// Handle device selection with proper precedence
+	handleDeviceSelection(cfg, cmd)
+
+	// Handle backward compatibility for GPS disable flag
+	if cfg.GPS.Disable {
+		// Backward compatibility: config file has disable: true
+		cfg.GPS.Mode = "manual"
+		if cfg.GPS.ManualLatitude == 0.0 && cfg.GPS.ManualLongitude == 0.0 {
+			cfg.GPS.ManualLatitude = viper.GetFloat64("gps.manual_latitude")
+			cfg.GPS.ManualLongitude = viper.GetFloat64("gps.manual_longitude")
+			cfg.GPS.ManualAltitude = viper.GetFloat64("gps.manual_altitude")
+		}
+	}
+
+	// Without GPS the file gets a placeholder position that its metadata
+	// marks as absent, so it is never mistaken for a fix at (0,0)
+	if cfg.GPS.NoPosition {
+		cfg.GPS.Mode = "manual"
+		cfg.GPS.ManualLatitude = 0
+		cfg.GPS.ManualLongitude = 0
+		cfg.GPS.ManualAltitude = 0
+	}
+
+	// Validate duration format
+	if cfg.Collection.Duration == 0 {
+		return nil, fmt.Errorf("invalid duration: must be greater than 0")
+	}
+	if cfg.Collection.Pretrigger < 0 {
+		return nil, fmt.Errorf("invalid pretrigger in --pretrigger or collection.pretrigger: use a duration with a unit (e.g. 500ms), or 0 for none")
+	}
+	if _, err := rtlsdr.TotalSamples(cfg.RTLSDR.SampleRate, cfg.Collection.Duration+cfg.Collection.Pretrigger); err != nil {
+		return nil, fmt.Errorf("invalid duration: %w", err)
+	}
+	if cfg.RTLSDR.Frequency <= 0 || cfg.RTLSDR.Frequency > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid frequency in --frequency or rtlsdr.frequency: use Hz (e.g. 433920000) or a k, M or G suffix (e.g. 433.92M), up to %.3f GHz", float64(math.MaxUint32)/1e9)
+	}
+	if nearest := rtlsdr.NearestSampleRate(cfg.RTLSDR.SampleRate); nearest != cfg.RTLSDR.SampleRate {
+		fmt.Fprintf(out, "Warning: sample rate %d Hz is not a standard RTL-SDR rate; if the device refuses it, %d Hz is used instead (see 'argus-collector sample-rates')\n",
+			cfg.RTLSDR.SampleRate, nearest)
+	}
+	if cfg.Collection.TimeoutFactor < 1 {
+		return nil, fmt.Errorf("invalid timeout factor %.2f: must be at least 1", cfg.Collection.TimeoutFactor)
+	}
+	if cfg.Collection.MaxRuntime < 0 {
+		return nil, fmt.Errorf("invalid max runtime in --max-runtime or collection.max_runtime: use a duration with a unit (e.g. 15m), or 0 for none")
+	}
+	if cfg.Collection.MaxRuntime > 0 && cfg.Collection.MaxRuntime < cfg.Collection.Duration+cfg.Collection.Pretrigger {
+		return nil, fmt.Errorf("max runtime %v is shorter than the capture (%v), which could never complete",
+			cfg.Collection.MaxRuntime, cfg.Collection.Duration+cfg.Collection.Pretrigger)
+	}
+	switch cfg.RTLSDR.GainMode {
+	case "auto", "manual", "max":
+	default:
+		return nil, fmt.Errorf("invalid gain mode %q: must be auto, manual or max", cfg.RTLSDR.GainMode)
+	}
+	if cfg.RTLSDR.AGCSmoothing <= 0 || cfg.RTLSDR.AGCSmoothing > 1 {
+		return nil, fmt.Errorf("invalid AGC smoothing %g: must be greater than 0 and at most 1", cfg.RTLSDR.AGCSmoothing)
+	}
+	if err := rtlsdr.ValidateReadChunkBytes(cfg.RTLSDR.ReadChunkBytes); err != nil {
+		return nil, fmt.Errorf("rtlsdr.read_chunk_bytes: %w", err)
+	}
+	if cfg.RTLSDR.ReadTimeout <= 0 {
+		return nil, fmt.Errorf("invalid rtlsdr.read_timeout %v: must be positive", cfg.RTLSDR.ReadTimeout)
+	}
+	if cfg.Collection.SyncInterval < 0 {
+		return nil, fmt.Errorf("invalid sync interval in --sync-interval or collection.sync_interval: use a duration with a unit (e.g. 5s), or 0 to write the file after the capture")
+	}
+	if cfg.Collection.Repeat < 1 {
+		return nil, fmt.Errorf("invalid repeat count %d: must be at least 1", cfg.Collection.Repeat)
+	}
+	if cfg.Collection.Repeat > 1 && cfg.Collection.StartTime > 0 {
+		return nil, fmt.Errorf("--repeat cannot be combined with --start-time: only the first capture could start at that time")
+	}
+	if overwrite && noOverwrite {
+		return nil, fmt.Errorf("--overwrite and --no-overwrite cannot be combined")
+	}
+	switch cfg.Collection.Overwrite {
+	case "suffix", "error", "overwrite":
+	default:
+		return nil, fmt.Errorf("invalid overwrite policy: %s (must be 'suffix', 'error', or 'overwrite')", cfg.Collection.Overwrite)
+	}
+	if cfg.Collection.FilenameTemplate != "" {
+		if err := collector.ValidateFilenameTemplate(cfg.Collection.FilenameTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate GPS configuration
+	switch cfg.GPS.Mode {
+	case "manual":
+		// Validate manual coordinates
+		if cfg.GPS.ManualLatitude < -90 || cfg.GPS.ManualLatitude > 90 {
+			return nil, fmt.Errorf("invalid latitude: %.8f (must be between -90 and 90 degrees)", cfg.GPS.ManualLatitude)
+		}
+		if cfg.GPS.ManualLongitude < -180 || cfg.GPS.ManualLongitude > 180 {
+			return nil, fmt.Errorf("invalid longitude: %.8f (must be between -180 and 180 degrees)", cfg.GPS.ManualLongitude)
+		}
+		// Check if coordinates are set to default values (0,0) which likely means they weren't configured
+		if cfg.GPS.ManualLatitude == 0.0 && cfg.GPS.ManualLongitude == 0.0 && !cfg.GPS.NoPosition {
+			return nil, fmt.Errorf("manual coordinates not specified: set manual_latitude and manual_longitude in config file or use --latitude and --longitude flags")
+		}
+		if !cfg.GPS.NoPosition && !viper.IsSet("gps.manual_altitude") {
+			if err := defaultManualAltitude(cfg); err != nil {
+				return nil, err
+			}
+		}
+	case "nmea":
+		// Validate NMEA serial port configuration
+		port, err := gps.NormalizeSerialPort(cfg.GPS.Port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GPS port for NMEA mode: %w", err)
+		}
+		cfg.GPS.Port = port
+		if cfg.GPS.BaudRate < 0 {
+			return nil, fmt.Errorf("invalid GPS baud rate in --gps-baud or gps.baud_rate: use a positive number such as 9600, or 'auto' to detect it")
+		}
+	case "gpsd":
+		// Validate gpsd configuration
+		if cfg.GPS.GPSDHost == "" {
+			return nil, fmt.Errorf("GPSD host not specified for gpsd mode")
+		}
+		if cfg.GPS.GPSDPort == "" {
+			return nil, fmt.Errorf("GPSD port not specified for gpsd mode")
+		}
+	default:
+		return nil, fmt.Errorf("invalid GPS mode: %s (must be 'nmea', 'gpsd', or 'manual')", cfg.GPS.Mode)
+	}
+
+	// Validate start timing before initialization so a stale start time fails fast
+	if cfg.Collection.StartTime > 0 {
+		if err := collector.CheckStartTime(time.Unix(cfg.Collection.StartTime, 0), time.Now()); err != nil {
+			return nil, fmt.Errorf("invalid start time: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// shutdownGrace is how long a graceful shutdown may take after an interrupt
+// before the process is killed. It leaves time for the collector to save the
+// partial capture.
+const shutdownGrace = collector.FlushTimeout + 5*time.Second
+
+// interruptContext returns a context that is cancelled on SIGINT or SIGTERM,
+// reporting the signals on w. Cancellation lets an in-progress capture be
+// saved; a second signal, or shutdownGrace passing, exits immediately.
+func interruptContext(w io.Writer) (context.Context, context.CancelFunc) {
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Create a context that can be cancelled by signal
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Handle interrupt signals in a separate goroutine
+	go func() {
+		<-sigChan
+		fmt.Fprintf(w, "\nReceived interrupt signal, shutting down (interrupt again to exit immediately)...\n")
+		cancel() // Cancel the context to stop all operations
+
+		// Force exit if graceful shutdown hangs
+		select {
+		case <-sigChan:
+			fmt.Fprintf(w, "\nReceived second interrupt signal, exiting\n")
+		case <-time.After(shutdownGrace):
+			fmt.Fprintf(w, "\nShutdown did not complete within %v, exiting\n", shutdownGrace)
+		}
+		console.Exit(1)
+	}()
+
+	return ctx, cancel
+}
+
 // applyConfiguration applies configuration with proper precedence: defaults < config file < command line
 func applyConfiguration(cfg *config.Config, cmd *cobra.Command) {
 	// Apply config file values (overrides defaults)
@@ -325,11 +655,31 @@ func applyConfiguration(cfg *config.Config, cmd *cobra.Command) {
 	applyCommandLineFlags(cfg, cmd)
 }
 
+// baudRateSetting parses a GPS baud rate setting, returning 0 for "auto" and
+// -1, rejected when the configuration is validated, for an invalid one
+func baudRateSetting(value string) int {
+	baud, err := gps.ParseBaudRate(value)
+	if err != nil {
+		return -1
+	}
+	return baud
+}
+
+// durationSetting parses a configured duration, returning -1, rejected when
+// the configuration is validated, for an invalid one
+func durationSetting(value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return -1
+	}
+	return d
+}
+
 // applyConfigFileValues applies configuration file values to override defaults
 func applyConfigFileValues(cfg *config.Config) {
 	// RTL-SDR configuration
 	if viper.IsSet("rtlsdr.frequency") {
-		cfg.RTLSDR.Frequency = viper.GetFloat64("rtlsdr.frequency")
+		cfg.RTLSDR.Frequency = frequencySetting(viper.GetString("rtlsdr.frequency"))
 	}
 	if viper.IsSet("rtlsdr.sample_rate") {
 		cfg.RTLSDR.SampleRate = uint32(viper.GetInt("rtlsdr.sample_rate"))
@@ -340,6 +690,15 @@ func applyConfigFileValues(cfg *config.Config) {
 	if viper.IsSet("rtlsdr.gain_mode") {
 		cfg.RTLSDR.GainMode = viper.GetString("rtlsdr.gain_mode")
 	}
+	if viper.IsSet("rtlsdr.agc_smoothing") {
+		cfg.RTLSDR.AGCSmoothing = viper.GetFloat64("rtlsdr.agc_smoothing")
+	}
+	if viper.IsSet("rtlsdr.read_chunk_bytes") {
+		cfg.RTLSDR.ReadChunkBytes = viper.GetInt("rtlsdr.read_chunk_bytes")
+	}
+	if viper.IsSet("rtlsdr.read_timeout") {
+		cfg.RTLSDR.ReadTimeout = viper.GetDuration("rtlsdr.read_timeout")
+	}
 	if viper.IsSet("rtlsdr.device_index") {
 		cfg.RTLSDR.DeviceIndex = viper.GetInt("rtlsdr.device_index")
 	}
@@ -349,9 +708,18 @@ func applyConfigFileValues(cfg *config.Config) {
 	if viper.IsSet("rtlsdr.bias_tee") {
 		cfg.RTLSDR.BiasTee = viper.GetBool("rtlsdr.bias_tee")
 	}
+	if viper.IsSet("rtlsdr.swap_iq") {
+		cfg.RTLSDR.SwapIQ = viper.GetBool("rtlsdr.swap_iq")
+	}
 	if viper.IsSet("rtlsdr.frequency_correction") {
 		cfg.RTLSDR.FrequencyCorrection = viper.GetInt("rtlsdr.frequency_correction")
 	}
+	if viper.IsSet("rtlsdr.lna_gain") {
+		cfg.RTLSDR.LNAGain = viper.GetFloat64("rtlsdr.lna_gain")
+	}
+	if viper.IsSet("rtlsdr.antenna") {
+		cfg.RTLSDR.Antenna = viper.GetString("rtlsdr.antenna")
+	}
 
 	// GPS configuration
 	if viper.IsSet("gps.mode") {
@@ -361,7 +729,7 @@ func applyConfigFileValues(cfg *config.Config) {
 		cfg.GPS.Port = viper.GetString("gps.port")
 	}
 	if viper.IsSet("gps.baud_rate") {
-		cfg.GPS.BaudRate = viper.GetInt("gps.baud_rate")
+		cfg.GPS.BaudRate = baudRateSetting(viper.GetString("gps.baud_rate"))
 	}
 	if viper.IsSet("gps.gpsd_host") {
 		cfg.GPS.GPSDHost = viper.GetString("gps.gpsd_host")
@@ -372,9 +740,27 @@ func applyConfigFileValues(cfg *config.Config) {
 	if viper.IsSet("gps.timeout") {
 		cfg.GPS.Timeout = viper.GetDuration("gps.timeout")
 	}
+	if viper.IsSet("gps.clock_offset_threshold") {
+		cfg.GPS.ClockOffsetThreshold = viper.GetDuration("gps.clock_offset_threshold")
+	}
+	if viper.IsSet("gps.fix_watch") {
+		cfg.GPS.FixWatch = viper.GetDuration("gps.fix_watch")
+	}
+	if viper.IsSet("gps.require_fix_throughout") {
+		cfg.GPS.RequireFixThroughout = viper.GetBool("gps.require_fix_throughout")
+	}
 	if viper.IsSet("gps.disable") {
 		cfg.GPS.Disable = viper.GetBool("gps.disable")
 	}
+	if viper.IsSet("gps.no_position") {
+		cfg.GPS.NoPosition = viper.GetBool("gps.no_position")
+	}
+	if viper.IsSet("gps.dem_dir") {
+		cfg.GPS.DEMDir = viper.GetString("gps.dem_dir")
+	}
+	if viper.IsSet("gps.altitude_required") {
+		cfg.GPS.AltitudeRequired = viper.GetBool("gps.altitude_required")
+	}
 	if viper.IsSet("gps.manual_latitude") {
 		cfg.GPS.ManualLatitude = viper.GetFloat64("gps.manual_latitude")
 	}
@@ -400,12 +786,42 @@ func applyConfigFileValues(cfg *config.Config) {
 	if viper.IsSet("collection.collection_id") {
 		cfg.Collection.CollectionID = viper.GetString("collection.collection_id")
 	}
+	if viper.IsSet("collection.filename_template") {
+		cfg.Collection.FilenameTemplate = viper.GetString("collection.filename_template")
+	}
 	if viper.IsSet("collection.synced_start") {
 		cfg.Collection.SyncedStart = viper.GetBool("collection.synced_start")
 	}
 	if viper.IsSet("collection.start_time") {
 		cfg.Collection.StartTime = viper.GetInt64("collection.start_time")
 	}
+	if viper.IsSet("collection.sample_format") {
+		cfg.Collection.SampleFormat = viper.GetString("collection.sample_format")
+	}
+	if viper.IsSet("collection.sidecar_json") {
+		cfg.Collection.SidecarJSON = viper.GetBool("collection.sidecar_json")
+	}
+	if viper.IsSet("collection.repeat") {
+		cfg.Collection.Repeat = viper.GetInt("collection.repeat")
+	}
+	if viper.IsSet("collection.overwrite") {
+		cfg.Collection.Overwrite = viper.GetString("collection.overwrite")
+	}
+	if viper.IsSet("collection.pretrigger") {
+		cfg.Collection.Pretrigger = durationSetting(viper.GetString("collection.pretrigger"))
+	}
+	if viper.IsSet("collection.max_runtime") {
+		cfg.Collection.MaxRuntime = durationSetting(viper.GetString("collection.max_runtime"))
+	}
+	if viper.IsSet("collection.timeout_factor") {
+		cfg.Collection.TimeoutFactor = viper.GetFloat64("collection.timeout_factor")
+	}
+	if viper.IsSet("collection.sync_interval") {
+		cfg.Collection.SyncInterval = durationSetting(viper.GetString("collection.sync_interval"))
+	}
+	if viper.IsSet("collection.power_log") {
+		cfg.Collection.PowerLog = viper.GetString("collection.power_log")
+	}
 
 	// Logging configuration
 	if viper.IsSet("logging.level") {
@@ -431,12 +847,24 @@ func applyCommandLineFlags(cfg *config.Config, cmd *cobra.Command) {
 	if cmd.Flags().Changed("gain-mode") {
 		cfg.RTLSDR.GainMode = gainMode
 	}
+	if cmd.Flags().Changed("agc-smoothing") {
+		cfg.RTLSDR.AGCSmoothing = agcSmoothing
+	}
 	if cmd.Flags().Changed("bias-tee") {
 		cfg.RTLSDR.BiasTee = biasTeeFlag
 	}
+	if cmd.Flags().Changed("swap-iq") {
+		cfg.RTLSDR.SwapIQ = swapIQ
+	}
 	if cmd.Flags().Changed("frequency-correction") {
 		cfg.RTLSDR.FrequencyCorrection = freqCorrection
 	}
+	if cmd.Flags().Changed("lna-gain") {
+		cfg.RTLSDR.LNAGain = lnaGain
+	}
+	if cmd.Flags().Changed("antenna") {
+		cfg.RTLSDR.Antenna = antenna
+	}
 
 	// GPS flags
 	if cmd.Flags().Changed("gps-mode") {
@@ -446,13 +874,26 @@ func applyCommandLineFlags(cfg *config.Config, cmd *cobra.Command) {
 		cfg.GPS.Port = gpsPort
 	}
 	if cmd.Flags().Changed("gps-baud") {
-		cfg.GPS.BaudRate = gpsBaudRate
+		cfg.GPS.BaudRate = baudRateSetting(gpsBaud)
 	}
 	if cmd.Flags().Changed("gps-timeout") {
 		if timeout, err := time.ParseDuration(gpsTimeout); err == nil {
 			cfg.GPS.Timeout = timeout
 		}
 	}
+	if cmd.Flags().Changed("clock-offset-threshold") {
+		if threshold, err := time.ParseDuration(clockThreshold); err == nil {
+			cfg.GPS.ClockOffsetThreshold = threshold
+		}
+	}
+	if cmd.Flags().Changed("gps-watch") {
+		if watch, err := time.ParseDuration(fixWatch); err == nil {
+			cfg.GPS.FixWatch = watch
+		}
+	}
+	if cmd.Flags().Changed("require-fix-throughout") {
+		cfg.GPS.RequireFixThroughout = requireFix
+	}
 	if cmd.Flags().Changed("gpsd-host") {
 		cfg.GPS.GPSDHost = gpsdHost
 	}
@@ -468,6 +909,15 @@ func applyCommandLineFlags(cfg *config.Config, cmd *cobra.Command) {
 	if cmd.Flags().Changed("altitude") {
 		cfg.GPS.ManualAltitude = altitude
 	}
+	if cmd.Flags().Changed("no-gps") {
+		cfg.GPS.NoPosition = noGPS
+	}
+	if cmd.Flags().Changed("dem-dir") {
+		cfg.GPS.DEMDir = demDir
+	}
+	if cmd.Flags().Changed("altitude-required") {
+		cfg.GPS.AltitudeRequired = altRequired
+	}
 
 	// Collection flags
 	if cmd.Flags().Changed("duration") {
@@ -481,9 +931,42 @@ func applyCommandLineFlags(cfg *config.Config, cmd *cobra.Command) {
 	if cmd.Flags().Changed("file-prefix") {
 		cfg.Collection.FilePrefix = filePrefix
 	}
+	if cmd.Flags().Changed("sample-format") {
+		cfg.Collection.SampleFormat = sampleFormat
+	}
+	if cmd.Flags().Changed("sidecar-json") {
+		cfg.Collection.SidecarJSON = sidecarJSON
+	}
+	if cmd.Flags().Changed("repeat") {
+		cfg.Collection.Repeat = repeat
+	}
+	if cmd.Flags().Changed("overwrite") && overwrite {
+		cfg.Collection.Overwrite = "overwrite"
+	}
+	if cmd.Flags().Changed("no-overwrite") && noOverwrite {
+		cfg.Collection.Overwrite = "error"
+	}
+	if cmd.Flags().Changed("pretrigger") {
+		cfg.Collection.Pretrigger = durationSetting(pretrigger)
+	}
+	if cmd.Flags().Changed("max-runtime") {
+		cfg.Collection.MaxRuntime = durationSetting(maxRuntime)
+	}
+	if cmd.Flags().Changed("timeout-factor") {
+		cfg.Collection.TimeoutFactor = timeoutFactor
+	}
+	if cmd.Flags().Changed("sync-interval") {
+		cfg.Collection.SyncInterval = durationSetting(syncInterval)
+	}
+	if cmd.Flags().Changed("power-log") {
+		cfg.Collection.PowerLog = powerLog
+	}
 	if cmd.Flags().Changed("collection-id") {
 		cfg.Collection.CollectionID = collectionID
 	}
+	if cmd.Flags().Changed("filename-template") {
+		cfg.Collection.FilenameTemplate = filenameTmpl
+	}
 	if cmd.Flags().Changed("synced-start") {
 		cfg.Collection.SyncedStart = syncedStart
 	}
@@ -501,39 +984,183 @@ func applyCommandLineFlags(cfg *config.Config, cmd *cobra.Command) {
 func handleDeviceSelection(cfg *config.Config, cmd *cobra.Command) {
 	if cmd.Flags().Changed("device") {
 		// Device flag explicitly set - override config file values
-		deviceSelection := device
+		selectDevice(cfg, device)
+	}
+}
 
-		// Treat as serial number if it contains non-digit characters or is longer than reasonable for an index
-		// Also treat leading zeros as indication of serial number (e.g., "00000001")
-		isSerial := false
-		if len(deviceSelection) > 2 || strings.HasPrefix(deviceSelection, "0") && len(deviceSelection) > 1 {
-			isSerial = true
-		} else {
-			// Check if it contains non-digit characters
-			for _, r := range deviceSelection {
-				if r < '0' || r > '9' {
-					isSerial = true
-					break
-				}
+// selectDevice points cfg at the RTL-SDR given by a serial number or index
+func selectDevice(cfg *config.Config, deviceSelection string) {
+	// Treat as serial number if it contains non-digit characters or is longer than reasonable for an index
+	// Also treat leading zeros as indication of serial number (e.g., "00000001")
+	isSerial := false
+	if len(deviceSelection) > 2 || strings.HasPrefix(deviceSelection, "0") && len(deviceSelection) > 1 {
+		isSerial = true
+	} else {
+		// Check if it contains non-digit characters
+		for _, r := range deviceSelection {
+			if r < '0' || r > '9' {
+				isSerial = true
+				break
 			}
 		}
+	}
 
-		if isSerial {
-			// It's a serial number
-			cfg.RTLSDR.SerialNumber = deviceSelection
-			cfg.RTLSDR.DeviceIndex = -1 // Set to -1 to indicate serial number should be used
+	if isSerial {
+		// It's a serial number
+		cfg.RTLSDR.SerialNumber = deviceSelection
+		cfg.RTLSDR.DeviceIndex = -1 // Set to -1 to indicate serial number should be used
+	} else {
+		// Try to parse as device index
+		if deviceIndex, err := strconv.Atoi(deviceSelection); err == nil {
+			cfg.RTLSDR.DeviceIndex = deviceIndex
+			cfg.RTLSDR.SerialNumber = "" // Clear serial number when using index
 		} else {
-			// Try to parse as device index
-			if deviceIndex, err := strconv.Atoi(deviceSelection); err == nil {
-				cfg.RTLSDR.DeviceIndex = deviceIndex
-				cfg.RTLSDR.SerialNumber = "" // Clear serial number when using index
-			} else {
-				// Fallback to treating as serial number
-				cfg.RTLSDR.SerialNumber = deviceSelection
-				cfg.RTLSDR.DeviceIndex = -1
+			// Fallback to treating as serial number
+			cfg.RTLSDR.SerialNumber = deviceSelection
+			cfg.RTLSDR.DeviceIndex = -1
+		}
+	}
+}
+
+// printCollectionPlan prints the resolved configuration, start time, selected
+// device and estimated output size without opening the RTL-SDR or GPS
+func printCollectionPlan(cfg *config.Config) error {
+	format, err := filewriter.ParseSampleFormat(cfg.Collection.SampleFormat)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "DRY RUN: resolved collection plan (no data will be collected)\n\n")
+
+	fmt.Fprintf(out, "RTL-SDR:\n")
+	fmt.Fprintf(out, "  Frequency:            %.6f MHz\n", cfg.RTLSDR.Frequency/1e6)
+	if nearest := rtlsdr.NearestSampleRate(cfg.RTLSDR.SampleRate); nearest != cfg.RTLSDR.SampleRate {
+		fmt.Fprintf(out, "  Sample Rate:          %d Hz (not standard; %d Hz if refused)\n", cfg.RTLSDR.SampleRate, nearest)
+	} else {
+		fmt.Fprintf(out, "  Sample Rate:          %d Hz\n", cfg.RTLSDR.SampleRate)
+	}
+	if cfg.RTLSDR.GainMode == "auto" {
+		fmt.Fprintf(out, "  Gain:                 auto (software AGC, smoothing %.2f)\n", cfg.RTLSDR.AGCSmoothing)
+	} else if cfg.RTLSDR.GainMode == "max" {
+		fmt.Fprintf(out, "  Gain:                 max (highest gain the tuner supports)\n")
+	} else {
+		fmt.Fprintf(out, "  Gain:                 %.1f dB (manual)\n", cfg.RTLSDR.Gain)
+	}
+	fmt.Fprintf(out, "  Bias Tee:             %t\n", cfg.RTLSDR.BiasTee)
+	if cfg.RTLSDR.LNAGain != 0 {
+		fmt.Fprintf(out, "  LNA Gain:             %.1f dB\n", cfg.RTLSDR.LNAGain)
+	}
+	if cfg.RTLSDR.Antenna != "" {
+		fmt.Fprintf(out, "  Antenna:              %s\n", cfg.RTLSDR.Antenna)
+	}
+	fmt.Fprintf(out, "  Frequency Correction: %d PPM\n", cfg.RTLSDR.FrequencyCorrection)
+	fmt.Fprintf(out, "  Read Chunk:           %d bytes (%.1f ms), timeout %v\n", cfg.RTLSDR.ReadChunkBytes,
+		float64(cfg.RTLSDR.ReadChunkBytes/2)/float64(cfg.RTLSDR.SampleRate)*1000, cfg.RTLSDR.ReadTimeout)
+	if cfg.RTLSDR.SwapIQ {
+		fmt.Fprintf(out, "  Swap IQ:              enabled (Q taken as the first byte of each sample)\n")
+	}
+
+	// Enumerate devices only; the selected device is not configured
+	fmt.Fprintf(out, "  Device:               ")
+	devices, err := rtlsdr.ListDevices()
+	if err != nil {
+		fmt.Fprintf(out, "unable to enumerate devices: %v\n", err)
+	} else {
+		var selected *rtlsdr.DeviceInfo
+		for i := range devices {
+			if cfg.RTLSDR.SerialNumber != "" && devices[i].SerialNumber == cfg.RTLSDR.SerialNumber ||
+				cfg.RTLSDR.SerialNumber == "" && devices[i].Index == cfg.RTLSDR.DeviceIndex {
+				selected = &devices[i]
+				break
 			}
 		}
+		switch {
+		case selected != nil:
+			fmt.Fprintf(out, "%d: %s (serial %s, tuner %s)\n", selected.Index, selected.Name, selected.SerialNumber, selected.TunerType)
+		case cfg.RTLSDR.SerialNumber != "":
+			fmt.Fprintf(out, "WARNING: no device with serial %s found\n", cfg.RTLSDR.SerialNumber)
+		default:
+			fmt.Fprintf(out, "WARNING: no device at index %d found\n", cfg.RTLSDR.DeviceIndex)
+		}
 	}
+
+	fmt.Fprintf(out, "\nGPS:\n")
+	switch cfg.GPS.Mode {
+	case "manual":
+		if cfg.GPS.NoPosition {
+			fmt.Fprintf(out, "  Mode:                 none (no position recorded)\n")
+			break
+		}
+		fmt.Fprintf(out, "  Mode:                 manual (%.8f°, %.8f°, %.1f m)\n",
+			cfg.GPS.ManualLatitude, cfg.GPS.ManualLongitude, cfg.GPS.ManualAltitude)
+	case "nmea":
+		if cfg.GPS.BaudRate == 0 {
+			fmt.Fprintf(out, "  Mode:                 nmea (%s, baud rate detected from %v)\n", cfg.GPS.Port, gps.AutoBaudRates)
+		} else {
+			fmt.Fprintf(out, "  Mode:                 nmea (%s @ %d baud)\n", cfg.GPS.Port, cfg.GPS.BaudRate)
+		}
+	case "gpsd":
+		fmt.Fprintf(out, "  Mode:                 gpsd (%s:%s)\n", cfg.GPS.GPSDHost, cfg.GPS.GPSDPort)
+	}
+	if cfg.GPS.Mode != "manual" {
+		fmt.Fprintf(out, "  Fix Timeout:          %v\n", cfg.GPS.Timeout)
+		fmt.Fprintf(out, "  Require Fix:          %t\n", cfg.GPS.RequireFixThroughout)
+		if cfg.GPS.FixWatch > 0 {
+			fmt.Fprintf(out, "  Watch After Fix:      %v\n", cfg.GPS.FixWatch)
+		}
+	}
+
+	fmt.Fprintf(out, "\nCollection:\n")
+	fmt.Fprintf(out, "  Duration:             %v\n", cfg.Collection.Duration)
+	if cfg.Collection.Pretrigger > 0 {
+		fmt.Fprintf(out, "  Pre-trigger:          %v\n", cfg.Collection.Pretrigger)
+	}
+	if cfg.Collection.Repeat > 1 {
+		fmt.Fprintf(out, "  Repeat:               %d captures\n", cfg.Collection.Repeat)
+	}
+	switch {
+	case cfg.Collection.StartTime > 0:
+		fmt.Fprintf(out, "  Start:                exact start time %s\n", time.Unix(cfg.Collection.StartTime, 0).Format("2006-01-02 15:04:05"))
+	case cfg.Collection.SyncedStart:
+		fmt.Fprintf(out, "  Start:                synchronized, next slot %s\n", collector.SyncedStartTime(time.Now()).Format("2006-01-02 15:04:05"))
+	default:
+		fmt.Fprintf(out, "  Start:                immediate\n")
+	}
+	fmt.Fprintf(out, "  Output Directory:     %s\n", cfg.Collection.OutputDir)
+	if cfg.Collection.CollectionID != "" {
+		fmt.Fprintf(out, "  Collection ID:        %s\n", cfg.Collection.CollectionID)
+	} else {
+		fmt.Fprintf(out, "  File Prefix:          %s\n", cfg.Collection.FilePrefix)
+	}
+	if cfg.Collection.FilenameTemplate != "" {
+		fmt.Fprintf(out, "  Filename Template:    %s\n", cfg.Collection.FilenameTemplate)
+	}
+	fmt.Fprintf(out, "  Sample Format:        %s\n", format)
+	fmt.Fprintf(out, "  Sidecar JSON:         %t\n", cfg.Collection.SidecarJSON)
+	fmt.Fprintf(out, "  Existing Files:       %s\n", cfg.Collection.Overwrite)
+	fmt.Fprintf(out, "  Capture Timeout:      %v\n", collector.CaptureTimeout(cfg.Collection, cfg.Collection.Pretrigger))
+	if cfg.Collection.SyncInterval > 0 {
+		fmt.Fprintf(out, "  Disk Sync:            every %v while capturing\n", cfg.Collection.SyncInterval)
+	} else {
+		fmt.Fprintf(out, "  Disk Sync:            at end of capture\n")
+	}
+	if cfg.Collection.PowerLog != "" {
+		fmt.Fprintf(out, "  Power Log:            %s (one row per %.1f ms chunk)\n", cfg.Collection.PowerLog,
+			float64(cfg.RTLSDR.ReadChunkBytes/2)/float64(cfg.RTLSDR.SampleRate)*1000)
+	}
+
+	// Estimate the output size from the sample count and a representative header
+	samples := int64(float64(cfg.RTLSDR.SampleRate) * (cfg.Collection.Duration + cfg.Collection.Pretrigger).Seconds())
+	header := filewriter.HeaderSize(&filewriter.Metadata{
+		FileFormatVersion: filewriter.CurrentFormatVersion,
+		SampleFormat:      format,
+		CollectionID:      cfg.Collection.CollectionID,
+	})
+	size := header + samples*int64(format.Size())
+	fmt.Fprintf(out, "  Estimated Samples:    %d\n", samples)
+	fmt.Fprintf(out, "  Estimated File Size:  %.1f MB\n", float64(size)/(1024*1024))
+
+	return nil
 }
 
 // listDevices lists all available RTL-SDR devices with their information
@@ -543,33 +1170,125 @@ func listDevices() error {
 		return fmt.Errorf("failed to list RTL-SDR devices: %w", err)
 	}
 
-	fmt.Printf("Available RTL-SDR Devices:\n")
-	fmt.Printf("=============================\n\n")
+	fmt.Fprintf(out, "Available RTL-SDR Devices:\n")
+	fmt.Fprintf(out, "=============================\n\n")
 
 	for _, device := range devices {
-		fmt.Printf("Device %d:\n", device.Index)
-		fmt.Printf("  Name:         %s\n", device.Name)
-		fmt.Printf("  Manufacturer: %s\n", device.Manufacturer)
-		fmt.Printf("  Product:      %s\n", device.Product)
-		fmt.Printf("  Serial:       %s\n", device.SerialNumber)
-		fmt.Printf("\n")
-	}
-
-	fmt.Printf("Configuration Examples:\n")
-	fmt.Printf("======================\n")
-	fmt.Printf("# Use device by index (traditional method)\n")
-	fmt.Printf("rtlsdr:\n")
-	fmt.Printf("  device_index: 0\n\n")
-	fmt.Printf("# Use device by serial number (recommended)\n")
-	fmt.Printf("rtlsdr:\n")
-	fmt.Printf("  serial_number: \"00000001\"\n\n")
+		fmt.Fprintf(out, "Device %d:\n", device.Index)
+		fmt.Fprintf(out, "  Name:         %s\n", device.Name)
+		fmt.Fprintf(out, "  Manufacturer: %s\n", device.Manufacturer)
+		fmt.Fprintf(out, "  Product:      %s\n", device.Product)
+		fmt.Fprintf(out, "  Serial:       %s\n", device.SerialNumber)
+		fmt.Fprintf(out, "  Tuner:        %s\n", device.TunerType)
+		fmt.Fprintf(out, "\n")
+	}
+
+	fmt.Fprintf(out, "Configuration Examples:\n")
+	fmt.Fprintf(out, "======================\n")
+	fmt.Fprintf(out, "# Use device by index (traditional method)\n")
+	fmt.Fprintf(out, "rtlsdr:\n")
+	fmt.Fprintf(out, "  device_index: 0\n\n")
+	fmt.Fprintf(out, "# Use device by serial number (recommended)\n")
+	fmt.Fprintf(out, "rtlsdr:\n")
+	fmt.Fprintf(out, "  serial_number: \"00000001\"\n\n")
+
+	return nil
+}
+
+// openDevice opens the RTL-SDR selected in cfg by serial number or index
+func openDevice(cfg *config.Config) (*rtlsdr.Device, error) {
+	if cfg.RTLSDR.SerialNumber != "" {
+		dev, err := rtlsdr.NewDeviceBySerial(cfg.RTLSDR.SerialNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open RTL-SDR by serial %s: %w", cfg.RTLSDR.SerialNumber, err)
+		}
+		dev.SetOutput(out)
+		return dev, nil
+	}
+	dev, err := rtlsdr.NewDevice(cfg.RTLSDR.DeviceIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RTL-SDR by index %d: %w", cfg.RTLSDR.DeviceIndex, err)
+	}
+	dev.SetOutput(out)
+	return dev, nil
+}
+
+// listGains opens the selected RTL-SDR device and prints its supported tuner gains
+func listGains(cmd *cobra.Command) error {
+	cfg := config.DefaultConfig()
+	applyConfiguration(cfg, cmd)
+	handleDeviceSelection(cfg, cmd)
+
+	dev, err := openDevice(cfg)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+
+	gains, err := dev.GetTunerGainsFloat()
+	if err != nil {
+		return err
+	}
+	if len(gains) == 0 {
+		return fmt.Errorf("device reported no supported tuner gains")
+	}
+
+	// Only mark a nearest value when a gain was explicitly requested
+	nearest := -1
+	if cmd.Flags().Changed("gain") {
+		nearest = 0
+		for i, g := range gains {
+			if math.Abs(g-gain) < math.Abs(gains[nearest]-gain) {
+				nearest = i
+			}
+		}
+	}
+
+	fmt.Fprintf(out, "Supported Tuner Gains (%d values):\n", len(gains))
+	fmt.Fprintf(out, "=============================\n\n")
+
+	for i, g := range gains {
+		if i == nearest {
+			fmt.Fprintf(out, "  %5.1f dB  <- nearest to requested %.1f dB\n", g, gain)
+		} else {
+			fmt.Fprintf(out, "  %5.1f dB\n", g)
+		}
+	}
+	fmt.Fprintf(out, "\n")
 
 	return nil
 }
 
+// listSampleRates prints the standard sample rates, marking the one nearest to
+// --sample-rate when it is given
+func listSampleRates(cmd *cobra.Command) {
+	rates := rtlsdr.SupportedSampleRates()
+
+	nearest := uint32(0)
+	if cmd.Flags().Changed("sample-rate") {
+		nearest = rtlsdr.NearestSampleRate(sampleRate)
+	}
+
+	fmt.Fprintf(out, "Standard RTL-SDR Sample Rates (%d values):\n", len(rates))
+	fmt.Fprintf(out, "======================================\n\n")
+
+	for _, rate := range rates {
+		switch {
+		case rate == nearest && rate == sampleRate:
+			fmt.Fprintf(out, "  %7d Hz  <- requested rate\n", rate)
+		case rate == nearest:
+			fmt.Fprintf(out, "  %7d Hz  <- nearest to requested %d Hz\n", rate, sampleRate)
+		default:
+			fmt.Fprintf(out, "  %7d Hz\n", rate)
+		}
+	}
+	fmt.Fprintf(out, "\n")
+}
+
 // main is the entry point of the application
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		console.Exit(1)
 	}
+	console.Flush()
 }
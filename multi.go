@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"argus-collector/internal/collector"
+	"argus-collector/internal/config"
+	"argus-collector/internal/console"
+	"argus-collector/internal/processor"
+	"argus-collector/internal/version"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Multi-device command flag variables
+var (
+	multiDevices []string // RTL-SDR devices to collect from (serial numbers or indices)
+	multiProcess bool     // Run TDOA processing on the collected files
+)
+
+// multiStartLead is how far ahead an immediate (non-synced) multi-device
+// start is scheduled so every device goroutine is waiting before it arrives
+const multiStartLead = 2 * time.Second
+
+// multiCmd represents the multi command to collect from several RTL-SDRs at once
+var multiCmd = &cobra.Command{
+	Use:   "multi",
+	Short: "Collect simultaneously from several RTL-SDR devices",
+	Long: `Collect from several RTL-SDR devices attached to this host at the same time.
+Each device runs in its own goroutine with a shared start time and writes its
+own data file. The GPS receiver is opened once and shared by all devices.
+
+All collection flags of the main command apply to every device, except
+--device (use --devices) and --repeat.`,
+	Example: `  argus-collector multi --devices 0,1,2 --frequency 162.4e6 --duration 10s
+  argus-collector multi --devices 00000001,00000002,00000003 --process`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMulti(cmd); err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
+		}
+	},
+}
+
+// devicePowerLog names a device's own power log after the configured one,
+// e.g. power.csv becomes power-0.csv for device 0
+func devicePowerLog(filename, device string) string {
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(filename, ext), device, ext)
+}
+
+// runMulti collects from every device in --devices with a common start time
+// and optionally processes the resulting files
+func runMulti(cmd *cobra.Command) error {
+	if len(multiDevices) < 2 {
+		return fmt.Errorf("--devices must list at least 2 RTL-SDR devices, got %d", len(multiDevices))
+	}
+	seen := make(map[string]bool)
+	for _, sel := range multiDevices {
+		if seen[sel] {
+			return fmt.Errorf("device %s listed more than once", sel)
+		}
+		seen[sel] = true
+	}
+
+	cfg, err := resolveConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if template := cfg.Collection.FilenameTemplate; template != "" && !strings.Contains(template, "{device}") &&
+		!(strings.Contains(template, "{id}") && cfg.Collection.CollectionID != "") {
+		return fmt.Errorf("filename template %q must contain {device}, or {id} with a collection ID, to keep the devices' files apart", template)
+	}
+
+	fmt.Fprintf(out, "Argus Collector %s starting (%d devices)...\n", version.GetFullVersion(), len(multiDevices))
+
+	ctx, cancel := interruptContext(out)
+	defer cancel()
+
+	// Each device gets its own copy of the configuration. Distinct device
+	// identifiers keep the output filenames apart.
+	configs := make([]*config.Config, len(multiDevices))
+	collectors := make([]*collector.Collector, len(multiDevices))
+	for i, sel := range multiDevices {
+		devCfg := *cfg
+		selectDevice(&devCfg, sel)
+		if devCfg.Collection.CollectionID != "" {
+			devCfg.Collection.CollectionID = fmt.Sprintf("%s-%s", cfg.Collection.CollectionID, sel)
+		}
+		if devCfg.Collection.PowerLog != "" {
+			devCfg.Collection.PowerLog = devicePowerLog(cfg.Collection.PowerLog, sel)
+		}
+		configs[i] = &devCfg
+
+		c := collector.NewCollector(&devCfg)
+		c.SetOutput(out)
+		if i > 0 {
+			// Only the first collector opens the GPS receiver
+			c.ShareGPS(collectors[0])
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("cancelled during setup")
+		default:
+		}
+
+		fmt.Fprintf(out, "Initializing device %s...\n", sel)
+		if err := c.Initialize(); err != nil {
+			return fmt.Errorf("failed to initialize collector for device %s: %w", sel, err)
+		}
+		defer c.Close()
+
+		if viper.GetBool("verbose") {
+			c.SetGPSDebug(true)
+			c.SetRTLSDRVerbose(true)
+		}
+		collectors[i] = c
+	}
+
+	// The devices share one receiver, so one fix covers them all
+	if err := collectors[0].WaitForGPSFixWithContext(ctx); err != nil {
+		return fmt.Errorf("GPS initialization failed: %w", err)
+	}
+
+	// Resolve the start time once so every device waits for the same instant
+	start := cfg.Collection.StartTime
+	if start == 0 {
+		if cfg.Collection.SyncedStart {
+			start = collector.SyncedStartTime(time.Now()).Unix()
+		} else {
+			// Unix truncates, so round up by a second to keep the full lead
+			start = time.Now().Add(multiStartLead + time.Second).Unix()
+		}
+	}
+	for _, devCfg := range configs {
+		devCfg.Collection.StartTime = start
+	}
+	fmt.Fprintf(out, "Shared start time: %s (epoch %d)\n", time.Unix(start, 0).Format("15:04:05"), start)
+
+	errs := make([]error, len(collectors))
+	var wg sync.WaitGroup
+	for i, c := range collectors {
+		wg.Add(1)
+		go func(i int, c *collector.Collector) {
+			defer wg.Done()
+			errs[i] = c.CollectWithContext(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var files []string
+	failed := 0
+	fmt.Fprintf(out, "\nMulti-device collection results:\n")
+	for i, c := range collectors {
+		if errs[i] != nil {
+			failed++
+			fmt.Fprintf(out, "  Device %s: FAILED: %v\n", multiDevices[i], errs[i])
+			continue
+		}
+		c.ReportAGCResult()
+		files = append(files, c.LastFile())
+		fmt.Fprintf(out, "  Device %s: %s\n", multiDevices[i], c.LastFile())
+	}
+	if failed > 0 {
+		return fmt.Errorf("collection failed on %d of %d devices", failed, len(collectors))
+	}
+
+	if multiProcess {
+		return processMultiFiles(files, cfg.Collection.OutputDir)
+	}
+
+	fmt.Fprintf(out, "Collection completed successfully.\n")
+	return nil
+}
+
+// processMultiFiles runs basic TDOA processing on the files from one multi
+// collection and writes the result as KML next to them
+func processMultiFiles(files []string, outputDir string) error {
+	if len(files) < 3 {
+		fmt.Fprintf(out, "Skipping processing: TDOA requires at least 3 files, got %d\n", len(files))
+		return nil
+	}
+
+	fmt.Fprintf(out, "\nProcessing %d files...\n", len(files))
+	proc, err := processor.NewProcessor(&processor.Config{
+		Algorithm:   "basic",
+		Confidence:  0.5,
+		MaxDistance: 50.0,
+		Verbose:     viper.GetBool("verbose"),
+		Output:      out,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create processor: %w", err)
+	}
+
+	result, err := proc.ProcessFiles(files)
+	if err != nil {
+		return fmt.Errorf("processing failed: %w", err)
+	}
+
+	outputFile := filepath.Join(outputDir, fmt.Sprintf("tdoa_%s_%.0fHz.kml",
+		result.ProcessingTime.Format("20060102_150405"), result.Frequency))
+	if err := result.ExportKML(outputFile); err != nil {
+		return fmt.Errorf("failed to export results: %w", err)
+	}
+
+	fmt.Fprintf(out, "Estimated location: %.6f, %.6f (confidence %.2f, error radius %.0f m)\n",
+		result.Location.Latitude, result.Location.Longitude, result.Confidence, result.ErrorRadius)
+	fmt.Fprintf(out, "Results saved to: %s\n", outputFile)
+	return nil
+}
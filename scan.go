@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"argus-collector/internal/config"
+	"argus-collector/internal/console"
+
+	"github.com/spf13/cobra"
+)
+
+// Scan command flag variables
+var (
+	scanStart float64       // First frequency to measure (Hz)
+	scanEnd   float64       // Last frequency to measure (Hz)
+	scanStep  float64       // Frequency step (Hz)
+	scanDwell time.Duration // Measurement time at each frequency
+	scanTop   int           // Number of strongest frequencies to list
+	scanCSV   string        // File to write every measurement to as CSV
+)
+
+// maxScanSteps bounds the number of frequencies one scan may measure
+const maxScanSteps = 100000
+
+// scanCmd represents the scan command to survey a frequency range for signals
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Sweep a frequency range and rank frequencies by received power",
+	Long: `Tune the selected RTL-SDR across a frequency range, take a short power
+measurement at each step and list the frequencies ranked by received power.
+Each measurement covers a band one step wide centred on the frequency, so the
+step sets the resolution. Use it to find the exact frequency of a signal
+before a TDOA collection.
+
+Gain is always manual during a scan so measurements are comparable.`,
+	Example: `  argus-collector scan --start 162.3M --end 162.6M --step 25k
+  argus-collector scan --start 430M --end 440M --step 100k --csv survey.csv`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runScan(cmd); err != nil {
+			fmt.Fprintf(errOut, "Error: %v\n", err)
+			console.Exit(1)
+		}
+	},
+}
+
+// scanPoint is one power measurement of a scan
+type scanPoint struct {
+	Frequency uint32
+	PowerDBFS float64 // RMS amplitude within one step of the frequency, in dB relative to full scale
+}
+
+// runScan measures power at each frequency in the requested range and prints
+// the results ranked by power
+func runScan(cmd *cobra.Command) error {
+	if scanStep <= 0 {
+		return fmt.Errorf("--step must be positive")
+	}
+	if scanEnd < scanStart {
+		return fmt.Errorf("--end (%.0f Hz) must not be below --start (%.0f Hz)", scanEnd, scanStart)
+	}
+	if scanStart <= 0 || scanEnd > math.MaxUint32 {
+		return fmt.Errorf("scan range %.0f-%.0f Hz is outside the tunable range", scanStart, scanEnd)
+	}
+	steps := int(math.Floor((scanEnd-scanStart)/scanStep+1e-9)) + 1
+	if steps > maxScanSteps {
+		return fmt.Errorf("scan would measure %d frequencies, more than %d; use a larger --step", steps, maxScanSteps)
+	}
+
+	cfg := config.DefaultConfig()
+	applyConfiguration(cfg, cmd)
+	handleDeviceSelection(cfg, cmd)
+
+	dev, err := openDevice(cfg)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+
+	if err := dev.SetSampleRate(cfg.RTLSDR.SampleRate); err != nil {
+		return err
+	}
+	if err := dev.SetGainMode("manual"); err != nil {
+		return err
+	}
+	if err := dev.SetGain(cfg.RTLSDR.Gain); err != nil {
+		return err
+	}
+	if err := dev.SetBiasTee(cfg.RTLSDR.BiasTee); err != nil {
+		return err
+	}
+
+	ctx, cancel := interruptContext(out)
+	defer cancel()
+
+	fmt.Fprintf(out, "Scanning %.3f-%.3f MHz in %.1f kHz steps (%d frequencies, %v each, gain %.1f dB)\n",
+		scanStart/1e6, scanEnd/1e6, scanStep/1e3, steps, scanDwell, cfg.RTLSDR.Gain)
+
+	points := make([]scanPoint, 0, steps)
+sweep:
+	for i := 0; i < steps; i++ {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(out, "Scan interrupted after %d of %d frequencies\n", len(points), steps)
+			break sweep
+		default:
+		}
+
+		freq := uint32(math.Round(scanStart + float64(i)*scanStep))
+		power, err := dev.MeasurePower(freq, scanDwell, scanStep)
+		if err != nil {
+			return err
+		}
+		points = append(points, scanPoint{Frequency: freq, PowerDBFS: 20 * math.Log10(math.Max(power, 1e-12))})
+		if verbose {
+			fmt.Fprintf(out, "  %.4f MHz: %.1f dBFS\n", float64(freq)/1e6, points[len(points)-1].PowerDBFS)
+		}
+	}
+	if len(points) == 0 {
+		return fmt.Errorf("no frequencies measured")
+	}
+
+	// The median is a robust estimate of the noise floor across the band
+	floor := medianPower(points)
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].PowerDBFS > points[j].PowerDBFS
+	})
+
+	if scanCSV != "" {
+		if err := writeScanCSV(scanCSV, points, floor); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Scan results saved to: %s\n", scanCSV)
+	}
+
+	shown := points
+	if scanTop > 0 && scanTop < len(shown) {
+		shown = shown[:scanTop]
+	}
+	fmt.Fprintf(out, "\nStrongest frequencies (noise floor %.1f dBFS):\n", floor)
+	fmt.Fprintf(out, "  Rank  Frequency (MHz)   Power (dBFS)  Above Floor (dB)\n")
+	for i, p := range shown {
+		fmt.Fprintf(out, "  %4d  %15.4f  %13.1f  %16.1f\n", i+1, float64(p.Frequency)/1e6, p.PowerDBFS, p.PowerDBFS-floor)
+	}
+	return nil
+}
+
+// medianPower returns the median power of the scan points
+func medianPower(points []scanPoint) float64 {
+	powers := make([]float64, len(points))
+	for i, p := range points {
+		powers[i] = p.PowerDBFS
+	}
+	sort.Float64s(powers)
+	mid := len(powers) / 2
+	if len(powers)%2 == 0 {
+		return (powers[mid-1] + powers[mid]) / 2
+	}
+	return powers[mid]
+}
+
+// writeScanCSV writes the ranked scan points to filename as CSV
+func writeScanCSV(filename string, points []scanPoint, floor float64) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"rank", "frequency_hz", "power_dbfs", "above_floor_db"})
+	for i, p := range points {
+		writer.Write([]string{
+			fmt.Sprintf("%d", i+1),
+			fmt.Sprintf("%d", p.Frequency),
+			fmt.Sprintf("%.2f", p.PowerDBFS),
+			fmt.Sprintf("%.2f", p.PowerDBFS-floor),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"argus-collector/internal/collector"
+	"argus-collector/internal/console"
+	"argus-collector/internal/version"
+)
+
+// Status view layout
+const (
+	statusRefresh  = 250 * time.Millisecond // Interval between redraws
+	statusLogLines = 10                     // Recent output lines shown below the status
+	statusLineMax  = 100                    // Longer output lines are truncated on screen
+	statusBarWidth = 40                     // Width of the progress and power bars
+)
+
+// statusView draws a live status screen for a collector. Output written
+// through the view while it runs is held and its most recent lines are shown
+// below the status; when it stops, the screen is cleared and the held output
+// is written out so the usual log remains. Before the view starts and after
+// it stops, output passes straight through.
+type statusView struct {
+	collector *collector.Collector
+	screen    io.Writer // Terminal the status is drawn on
+	stdout    *viewOutput
+	stderr    *viewOutput
+
+	mu      sync.Mutex
+	running bool
+	lines   []heldLine // Output held while the view runs
+
+	stop    chan struct{}
+	drawing sync.WaitGroup
+	closing sync.Once
+}
+
+// heldLine is a line of output held while the view runs
+type heldLine struct {
+	dst  io.Writer // Where the line was headed
+	text string
+}
+
+// viewOutput is an output stream passing through a status view
+type viewOutput struct {
+	view    *statusView
+	dst     io.Writer
+	partial []byte // Unfinished last line held while the view runs
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// newStatusView creates a status view drawing on screen, whose Stdout and
+// Stderr pass output on to stdout and stderr until it is started
+func newStatusView(screen, stdout, stderr io.Writer) *statusView {
+	v := &statusView{screen: screen, stop: make(chan struct{})}
+	v.stdout = &viewOutput{view: v, dst: stdout}
+	v.stderr = &viewOutput{view: v, dst: stderr}
+	return v
+}
+
+// Stdout returns the writer for normal output
+func (v *statusView) Stdout() io.Writer { return v.stdout }
+
+// Stderr returns the writer for error and log output
+func (v *statusView) Stderr() io.Writer { return v.stderr }
+
+// start draws the status of c, holding the output, until close is called.
+// The held output is also written if the program exits through console.Exit.
+func (v *statusView) start(c *collector.Collector) {
+	v.mu.Lock()
+	v.collector = c
+	v.running = true
+	v.mu.Unlock()
+	console.OnFlush(v.close)
+
+	v.drawing.Add(1)
+	go v.run()
+}
+
+// Write holds p while the view runs and passes it on otherwise
+func (o *viewOutput) Write(p []byte) (int, error) {
+	v := o.view
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.running {
+		return o.dst.Write(p)
+	}
+	o.partial = append(o.partial, p...)
+	for {
+		i := bytes.IndexByte(o.partial, '\n')
+		if i < 0 {
+			break
+		}
+		v.lines = append(v.lines, heldLine{dst: o.dst, text: strings.TrimSuffix(string(o.partial[:i]), "\r")})
+		o.partial = o.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// run redraws the status until the view is stopped
+func (v *statusView) run() {
+	defer v.drawing.Done()
+
+	ticker := time.NewTicker(statusRefresh)
+	defer ticker.Stop()
+
+	v.draw()
+	for {
+		select {
+		case <-ticker.C:
+			v.draw()
+		case <-v.stop:
+			return
+		}
+	}
+}
+
+// close stops the view and writes the output held while it ran. Only the
+// first call has any effect.
+func (v *statusView) close() {
+	v.closing.Do(func() {
+		v.mu.Lock()
+		running := v.running
+		v.mu.Unlock()
+		if !running {
+			return
+		}
+
+		close(v.stop)
+		v.drawing.Wait()
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.running = false
+
+		// Clear the status screen and leave the plain log in its place
+		fmt.Fprint(v.screen, "\x1b[H\x1b[J")
+		for _, line := range v.lines {
+			fmt.Fprintln(line.dst, line.text)
+		}
+		v.lines = nil
+		for _, o := range []*viewOutput{v.stdout, v.stderr} {
+			o.dst.Write(o.partial)
+			o.partial = nil
+		}
+	})
+}
+
+// draw renders one frame of the status screen
+func (v *statusView) draw() {
+	status := v.collector.Status()
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[J") // Cursor home, clear screen
+
+	fmt.Fprintf(&b, "Argus Collector %s%*s\n", version.GetFullVersion(), 30, time.Now().Format("15:04:05"))
+	b.WriteString(strings.Repeat("─", 60) + "\n")
+	fmt.Fprintf(&b, "Status:    %s\n", status.Phase)
+
+	switch {
+	case status.Position == nil && status.FixQuality == "":
+		fmt.Fprintf(&b, "GPS:       none (no position recorded)\n")
+	case status.Position == nil:
+		fmt.Fprintf(&b, "GPS:       %s, waiting for position\n", status.FixQuality)
+	case status.FixQuality == "":
+		fmt.Fprintf(&b, "GPS:       manual position\n")
+	default:
+		pos := status.Position
+		satellites := fmt.Sprintf("%d satellites", pos.Satellites)
+		if pos.SatellitesSeen > 0 {
+			satellites = fmt.Sprintf("%d used, %d seen", pos.Satellites, pos.SatellitesSeen)
+		}
+		fmt.Fprintf(&b, "GPS:       %s, %s\n", status.FixQuality, satellites)
+	}
+	if pos := status.Position; pos != nil {
+		fmt.Fprintf(&b, "Position:  %.6f°, %.6f°, %.1f m\n", pos.Latitude, pos.Longitude, pos.Altitude)
+	}
+
+	capture := status.Capture
+	if status.Expected > 0 {
+		fraction := math.Min(float64(capture.Samples)/float64(status.Expected), 1)
+		fmt.Fprintf(&b, "Capture:   [%s] %3.0f%%  %d / %d samples\n",
+			bar(fraction), fraction*100, capture.Samples, status.Expected)
+	} else {
+		fmt.Fprintf(&b, "Capture:   -\n")
+	}
+
+	agc := ""
+	if status.AGCActive {
+		agc = ", AGC active"
+	}
+	if capture.Samples > 0 {
+		fmt.Fprintf(&b, "Gain:      %.1f dB (%s%s)\n", capture.Gain, status.GainMode, agc)
+	} else {
+		fmt.Fprintf(&b, "Gain:      - (%s%s)\n", status.GainMode, agc)
+	}
+
+	if capture.Power > 0 {
+		dbfs := 20 * math.Log10(capture.Power)
+		// Show the power on a -60 to 0 dBFS scale
+		fmt.Fprintf(&b, "Signal:    [%s] %.1f dBFS\n", bar((dbfs+60)/60), dbfs)
+	} else {
+		fmt.Fprintf(&b, "Signal:    -\n")
+	}
+
+	b.WriteString(strings.Repeat("─", 60) + "\n")
+	v.mu.Lock()
+	recent := v.lines[max(0, len(v.lines)-statusLogLines):]
+	for _, held := range recent {
+		line := held.text
+		if runes := []rune(line); len(runes) > statusLineMax {
+			line = string(runes[:statusLineMax])
+		}
+		b.WriteString(line + "\n")
+	}
+	v.mu.Unlock()
+
+	fmt.Fprint(v.screen, b.String())
+}
+
+// bar renders fraction (clamped to 0-1) as a fixed-width bar
+func bar(fraction float64) string {
+	filled := int(math.Round(math.Max(0, math.Min(fraction, 1)) * statusBarWidth))
+	return strings.Repeat("█", filled) + strings.Repeat("░", statusBarWidth-filled)
+}